@@ -10,6 +10,7 @@ type StatisticsData struct {
 	Value     int64     `json:"value,omitempty" db:"value"`         // 统计值
 	ItemID    int64     `json:"item_id,omitempty" db:"item_id"`     // 关联项目ID
 	ItemType  string    `json:"item_type,omitempty" db:"item_type"` // 关联项目类型
+	ItemKey   string    `json:"item_key,omitempty" db:"-"`          // 关联项目的字符串标识，如站点构建器的站点、页面ID和来源域名，不存储在statistics_data表
 	TenantID  int64     `json:"tenant_id,omitempty" db:"tenant_id"` // 租户ID，多租户支持
 	CreatedAt time.Time `json:"created_at,omitempty" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at,omitempty" db:"updated_at"`
@@ -0,0 +1,105 @@
+package collab
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// redisChannel 跨服务转发协作事件的Redis频道
+const redisChannel = "builder:collab"
+
+// Bus 协作事件总线，编辑数据的服务发布事件，持有WebSocket连接的服务订阅后推送给客户端
+type Bus interface {
+	Publish(event Event) error
+	// Subscribe 订阅事件，处理函数可能在总线内部的goroutine中执行
+	Subscribe(handler func(Event))
+}
+
+// localBus 进程内事件总线
+type localBus struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+// NewLocalBus 创建进程内事件总线
+func NewLocalBus() Bus {
+	return &localBus{}
+}
+
+func (b *localBus) Publish(event Event) error {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *localBus) Subscribe(handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// redisBus 基于Redis发布订阅的事件总线，本服务发布的事件同样经由Redis送达订阅者
+type redisBus struct {
+	client *redis.Client
+	local  localBus
+	once   sync.Once
+}
+
+// NewRedisBus 创建Redis事件总线
+func NewRedisBus(client *redis.Client) Bus {
+	return &redisBus{client: client}
+}
+
+func (b *redisBus) Publish(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(context.Background(), redisChannel, data).Err()
+}
+
+func (b *redisBus) Subscribe(handler func(Event)) {
+	b.local.Subscribe(handler)
+	b.once.Do(func() {
+		go b.receive()
+	})
+}
+
+// receive 接收Redis频道中的事件并分发给本地订阅者，连接断开时由客户端自动重连
+func (b *redisBus) receive() {
+	pubsub := b.client.Subscribe(context.Background(), redisChannel)
+	for message := range pubsub.Channel() {
+		var event Event
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			log.Printf("解析协作事件失败: %v", err)
+			continue
+		}
+		b.local.Publish(event)
+	}
+}
+
+// Open 根据环境变量创建事件总线，设置COLLAB_REDIS_ADDR时使用Redis，
+// 返回的shared表示事件是否在服务之间共享
+func Open() (bus Bus, shared bool) {
+	addr := os.Getenv("COLLAB_REDIS_ADDR")
+	if addr == "" {
+		return NewLocalBus(), false
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("COLLAB_REDIS_PASSWORD"),
+	})
+	return NewRedisBus(client), true
+}
@@ -0,0 +1,53 @@
+package collab
+
+import "time"
+
+// 协作事件类型
+const (
+	EventPageCreated         = "page.created"
+	EventPageUpdated         = "page.updated"
+	EventPageDeleted         = "page.deleted"
+	EventPagesReordered      = "pages.reordered"
+	EventSectionCreated      = "section.created"
+	EventSectionUpdated      = "section.updated"
+	EventSectionDeleted      = "section.deleted"
+	EventSectionsReordered   = "sections.reordered"
+	EventComponentCreated    = "component.created"
+	EventComponentUpdated    = "component.updated"
+	EventComponentDeleted    = "component.deleted"
+	EventComponentsReordered = "components.reordered"
+	// 全局区块的变更，全局区块中组件的变更使用component.*事件且PageID为空
+	EventGlobalSectionCreated    = "globalSection.created"
+	EventGlobalSectionUpdated    = "globalSection.updated"
+	EventGlobalSectionDeleted    = "globalSection.deleted"
+	EventGlobalSectionsReordered = "globalSections.reordered"
+	// EventSectionDetached 页面区块解除对全局区块的引用，Data为复制后的区块
+	EventSectionDetached = "section.detached"
+
+	// EventWelcome 连接建立后发送给客户端，Data为本连接的clientId
+	EventWelcome = "welcome"
+	// EventPresence 站点编辑者在线状态变化，Data为当前所有编辑者
+	EventPresence = "presence"
+)
+
+// Event 推送给编辑器客户端的协作事件
+type Event struct {
+	Type        string      `json:"type"`
+	SiteID      string      `json:"siteId"`
+	PageID      string      `json:"pageId,omitempty"`
+	SectionID   string      `json:"sectionId,omitempty"`
+	ComponentID string      `json:"componentId,omitempty"`
+	Revision    int         `json:"revision,omitempty"` // 变更后的修订号
+	UserID      string      `json:"userId,omitempty"`   // 做出修改的用户
+	Data        interface{} `json:"data,omitempty"`     // 变更后的内容，删除时为空
+	Time        time.Time   `json:"time"`
+}
+
+// Presence 编辑者的在线状态，PageID和SectionID为正在编辑的位置
+type Presence struct {
+	ClientID  string    `json:"clientId"`
+	UserID    string    `json:"userId"`
+	PageID    string    `json:"pageId,omitempty"`
+	SectionID string    `json:"sectionId,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,179 @@
+package collab
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/websocket"
+)
+
+// sendBuffer 每个连接待发送事件的缓冲数量，客户端处理不过来时断开连接，由客户端重连后重新加载
+const sendBuffer = 64
+
+// clientMessage 客户端发送的消息
+type clientMessage struct {
+	Type      string `json:"type"` // presence
+	PageID    string `json:"pageId"`
+	SectionID string `json:"sectionId"`
+}
+
+// client 一个编辑器连接
+type client struct {
+	siteID   string
+	conn     *websocket.Conn
+	send     chan Event
+	presence Presence
+	closed   bool
+}
+
+// Hub 按站点管理编辑器的WebSocket连接，把事件总线上的事件推送给站点的所有连接，
+// 并维护编辑者的在线状态。在线状态只在本进程内有效
+type Hub struct {
+	mu    sync.Mutex
+	rooms map[string]map[*client]struct{}
+}
+
+// NewHub 创建连接中心并订阅事件总线
+func NewHub(bus Bus) *Hub {
+	h := &Hub{rooms: map[string]map[*client]struct{}{}}
+	bus.Subscribe(h.Broadcast)
+	return h
+}
+
+// Broadcast 把事件推送给站点的所有连接
+func (h *Hub) Broadcast(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.broadcastLocked(event)
+}
+
+func (h *Hub) broadcastLocked(event Event) {
+	for c := range h.rooms[event.SiteID] {
+		select {
+		case c.send <- event:
+		default:
+			// 客户端积压过多，断开后由读取循环清理
+			h.closeLocked(c)
+		}
+	}
+}
+
+// Serve 处理一个编辑器连接，阻塞直到连接断开
+func (h *Hub) Serve(conn *websocket.Conn, siteID string, userID string) {
+	c := &client{
+		siteID: siteID,
+		conn:   conn,
+		send:   make(chan Event, sendBuffer),
+		presence: Presence{
+			ClientID:  uuid.NewString(),
+			UserID:    userID,
+			UpdatedAt: time.Now(),
+		},
+	}
+	c.send <- Event{Type: EventWelcome, SiteID: siteID, UserID: userID, Data: c.presence.ClientID, Time: time.Now()}
+
+	go c.writeLoop()
+	h.join(c)
+	defer h.leave(c)
+
+	for {
+		var message clientMessage
+		if err := websocket.JSON.Receive(conn, &message); err != nil {
+			return
+		}
+		if message.Type == "presence" {
+			h.updatePresence(c, message.PageID, message.SectionID)
+		}
+	}
+}
+
+// writeLoop 依次发送事件，发送失败时关闭连接使读取循环退出
+func (c *client) writeLoop() {
+	for event := range c.send {
+		if err := websocket.JSON.Send(c.conn, event); err != nil {
+			c.conn.Close()
+			return
+		}
+	}
+	c.conn.Close()
+}
+
+func (h *Hub) join(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	room, ok := h.rooms[c.siteID]
+	if !ok {
+		room = map[*client]struct{}{}
+		h.rooms[c.siteID] = room
+	}
+	room[c] = struct{}{}
+	h.broadcastPresenceLocked(c.siteID)
+}
+
+func (h *Hub) leave(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closeLocked(c)
+	h.broadcastPresenceLocked(c.siteID)
+}
+
+// closeLocked 将连接移出站点并停止发送，可重复调用
+func (h *Hub) closeLocked(c *client) {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.send)
+
+	room := h.rooms[c.siteID]
+	delete(room, c)
+	if len(room) == 0 {
+		delete(h.rooms, c.siteID)
+	}
+}
+
+func (h *Hub) updatePresence(c *client, pageID string, sectionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	c.presence.PageID = pageID
+	c.presence.SectionID = sectionID
+	c.presence.UpdatedAt = time.Now()
+	h.broadcastPresenceLocked(c.siteID)
+}
+
+// Presence 获取站点当前的编辑者
+func (h *Hub) Presence(siteID string) []Presence {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.presenceLocked(siteID)
+}
+
+func (h *Hub) presenceLocked(siteID string) []Presence {
+	presence := []Presence{}
+	for c := range h.rooms[siteID] {
+		presence = append(presence, c.presence)
+	}
+	sort.Slice(presence, func(i, j int) bool {
+		return presence[i].ClientID < presence[j].ClientID
+	})
+	return presence
+}
+
+func (h *Hub) broadcastPresenceLocked(siteID string) {
+	h.broadcastLocked(Event{
+		Type:   EventPresence,
+		SiteID: siteID,
+		Data:   h.presenceLocked(siteID),
+		Time:   time.Now(),
+	})
+}
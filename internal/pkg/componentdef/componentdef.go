@@ -0,0 +1,497 @@
+// Package componentdef 定义站点构建器的内置组件类型，包括默认值和设置、内容的Schema，
+// 组件服务写入组件和站点服务导入站点时按这里的定义校验
+package componentdef
+
+import (
+	"errors"
+	"sort"
+	"wz-backend-go/internal/pkg/forms"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/pkg/responsive"
+	"wz-backend-go/models"
+)
+
+// 组件样式为CSS属性到值的映射，desktop、tablet、mobile键为按设备的覆盖，这里只校验结构，具体属性在渲染时过滤
+var componentStyleSchema = &jsonschema.Schema{Type: jsonschema.TypeObject, Title: "样式"}
+
+// 通用的设置项
+var (
+	textAlignSchema = jsonschema.Enum("对齐方式", "left", "center", "right", "justify")
+	colorSchema     = jsonschema.String("文字颜色").WithFormat(jsonschema.FormatColor)
+	imageListSchema = jsonschema.Array("图片", jsonschema.Object(map[string]*jsonschema.Schema{
+		"src":     jsonschema.String("图片地址").WithFormat(jsonschema.FormatURI),
+		"alt":     jsonschema.String("替代文本").WithMaxLength(200),
+		"caption": jsonschema.String("说明").WithMaxLength(200),
+	}, "src"))
+	// 数据组件的详情链接模板，{id}等占位符在渲染时替换为数据项的字段
+	dataLinkSchema = jsonschema.String("详情链接").WithMaxLength(200).WithPattern(`^(/|https?://)[^\s"'<>]*$`)
+)
+
+// componentCategories 内置组件的分类和定义
+var componentCategories = []models.ComponentCategory{
+	{
+		ID:   "basic",
+		Name: "基础组件",
+		Components: []models.ComponentDefinition{
+			{
+				Type:        "text",
+				Name:        "文本",
+				Icon:        "text",
+				Description: "文本内容块",
+				DefaultSettings: map[string]interface{}{
+					"textAlign": "left",
+					"fontSize":  "16px",
+				},
+				DefaultContent: map[string]interface{}{
+					"text": "请输入文本内容",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"textAlign": textAlignSchema,
+					"fontSize":  jsonschema.String("字号").WithFormat(jsonschema.FormatLength),
+					"color":     colorSchema,
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"text": jsonschema.String("文本").WithMaxLength(5000),
+				}, "text"),
+			},
+			{
+				Type:        "heading",
+				Name:        "标题",
+				Icon:        "heading",
+				Description: "标题文本",
+				DefaultSettings: map[string]interface{}{
+					"level":     "h2",
+					"textAlign": "left",
+				},
+				DefaultContent: map[string]interface{}{
+					"text": "标题",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"level":     jsonschema.Enum("标题级别", "h1", "h2", "h3", "h4", "h5", "h6"),
+					"textAlign": textAlignSchema,
+					"color":     colorSchema,
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"text": jsonschema.String("标题文本").WithMaxLength(200),
+				}, "text"),
+			},
+			{
+				Type:        "button",
+				Name:        "按钮",
+				Icon:        "button",
+				Description: "可点击的按钮",
+				DefaultSettings: map[string]interface{}{
+					"style":   "filled",
+					"size":    "medium",
+					"rounded": true,
+				},
+				DefaultContent: map[string]interface{}{
+					"text": "按钮",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"style":   jsonschema.Enum("样式", "filled", "outlined", "text"),
+					"size":    jsonschema.Enum("尺寸", "small", "medium", "large"),
+					"rounded": jsonschema.Boolean("圆角"),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"text": jsonschema.String("按钮文字").WithMaxLength(50),
+					"link": jsonschema.String("链接").WithFormat(jsonschema.FormatURI),
+				}, "text"),
+			},
+			{
+				Type:        "divider",
+				Name:        "分隔线",
+				Icon:        "divider",
+				Description: "水平分隔线",
+				DefaultSettings: map[string]interface{}{
+					"style": "solid",
+					"width": "100%",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"style": jsonschema.Enum("线型", "solid", "dashed", "dotted", "double"),
+					"width": jsonschema.String("宽度").WithFormat(jsonschema.FormatLength),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{}),
+			},
+		},
+	},
+	{
+		ID:   "layout",
+		Name: "布局组件",
+		Components: []models.ComponentDefinition{
+			{
+				Type:        "container",
+				Name:        "容器",
+				Icon:        "container",
+				Description: "内容容器",
+				DefaultSettings: map[string]interface{}{
+					"width":  "100%",
+					"height": "auto",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"width":  jsonschema.String("宽度").WithFormat(jsonschema.FormatLength),
+					"height": jsonschema.String("高度").WithFormat(jsonschema.FormatLength),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"text": jsonschema.String("内容"),
+				}),
+			},
+			{
+				Type:        "row",
+				Name:        "行",
+				Icon:        "row",
+				Description: "水平行",
+				DefaultSettings: map[string]interface{}{
+					"gutter": 16,
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"gutter": jsonschema.Integer("间距", 0, 100),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"text": jsonschema.String("内容"),
+				}),
+			},
+			{
+				Type:        "column",
+				Name:        "列",
+				Icon:        "column",
+				Description: "垂直列",
+				DefaultSettings: map[string]interface{}{
+					"span": 12,
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"span": jsonschema.Integer("栅格宽度", 1, 24),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"text": jsonschema.String("内容"),
+				}),
+			},
+			{
+				Type:        "card",
+				Name:        "卡片",
+				Icon:        "card",
+				Description: "卡片容器",
+				DefaultSettings: map[string]interface{}{
+					"shadow":  "medium",
+					"padding": 16,
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"shadow":  jsonschema.Enum("阴影", "none", "small", "medium", "large"),
+					"padding": jsonschema.Integer("内边距", 0, 200),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"title": jsonschema.String("标题").WithMaxLength(200),
+					"text":  jsonschema.String("内容"),
+				}),
+			},
+		},
+	},
+	{
+		ID:   "media",
+		Name: "媒体组件",
+		Components: []models.ComponentDefinition{
+			{
+				Type:        "image",
+				Name:        "图片",
+				Icon:        "image",
+				Description: "图片展示",
+				DefaultSettings: map[string]interface{}{
+					"width":     "100%",
+					"height":    "auto",
+					"objectFit": "cover",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"width":     jsonschema.String("宽度").WithFormat(jsonschema.FormatLength),
+					"height":    jsonschema.String("高度").WithFormat(jsonschema.FormatLength),
+					"objectFit": jsonschema.Enum("填充方式", "cover", "contain", "fill", "none", "scale-down"),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"src":     jsonschema.String("图片地址").WithFormat(jsonschema.FormatURI),
+					"alt":     jsonschema.String("替代文本").WithMaxLength(200),
+					"mediaId": jsonschema.String("媒体库文件").WithMaxLength(64),
+				}, "src"),
+			},
+			{
+				Type:        "video",
+				Name:        "视频",
+				Icon:        "video",
+				Description: "视频播放器",
+				DefaultSettings: map[string]interface{}{
+					"autoplay": false,
+					"controls": true,
+					"loop":     false,
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"autoplay": jsonschema.Boolean("自动播放"),
+					"controls": jsonschema.Boolean("显示控制条"),
+					"loop":     jsonschema.Boolean("循环播放"),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"src":    jsonschema.String("视频地址").WithFormat(jsonschema.FormatURI),
+					"poster": jsonschema.String("封面图").WithFormat(jsonschema.FormatURI),
+				}, "src"),
+			},
+			{
+				Type:        "carousel",
+				Name:        "轮播图",
+				Icon:        "carousel",
+				Description: "图片轮播",
+				DefaultSettings: map[string]interface{}{
+					"autoplay":   true,
+					"interval":   3000,
+					"indicators": true,
+					"arrows":     true,
+				},
+				DefaultContent: map[string]interface{}{
+					"images": []interface{}{},
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"autoplay":   jsonschema.Boolean("自动播放"),
+					"interval":   jsonschema.Integer("切换间隔（毫秒）", 1000, 60000),
+					"indicators": jsonschema.Boolean("显示指示器"),
+					"arrows":     jsonschema.Boolean("显示箭头"),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"images": imageListSchema,
+				}),
+			},
+			{
+				Type:        "gallery",
+				Name:        "图库",
+				Icon:        "gallery",
+				Description: "网格排列的图片集",
+				DefaultSettings: map[string]interface{}{
+					"columns": 3,
+					"gap":     "8px",
+				},
+				DefaultContent: map[string]interface{}{
+					"images": []interface{}{},
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"columns": jsonschema.Integer("列数", 1, 6),
+					"gap":     jsonschema.String("间距").WithFormat(jsonschema.FormatLength),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"images": imageListSchema,
+				}),
+			},
+			{
+				Type:        "map",
+				Name:        "地图",
+				Icon:        "map",
+				Description: "嵌入地图及地址",
+				DefaultSettings: map[string]interface{}{
+					"zoom": 15,
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"zoom": jsonschema.Integer("缩放级别", 1, 20),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"embedUrl": jsonschema.String("嵌入地址").WithFormat(jsonschema.FormatURI),
+					"address":  jsonschema.String("地址").WithMaxLength(200),
+				}),
+			},
+		},
+	},
+	{
+		ID:   "form",
+		Name: "表单组件",
+		Components: []models.ComponentDefinition{
+			{
+				Type:        "form",
+				Name:        "表单",
+				Icon:        "form",
+				Description: "收集访客的联系方式和需求",
+				DefaultSettings: map[string]interface{}{
+					"submitText":     "提交",
+					"successMessage": "提交成功，我们会尽快与您联系",
+				},
+				DefaultContent: map[string]interface{}{
+					"title": "联系我们",
+					"fields": []interface{}{
+						map[string]interface{}{"name": "name", "label": "姓名", "type": forms.FieldText, "required": true, "maxLength": 50},
+						map[string]interface{}{"name": "email", "label": "邮箱", "type": forms.FieldEmail, "required": true},
+						map[string]interface{}{"name": "phone", "label": "电话", "type": forms.FieldPhone},
+						map[string]interface{}{"name": "message", "label": "留言", "type": forms.FieldText, "multiline": true, "maxLength": 1000},
+					},
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"submitText":     jsonschema.String("提交按钮文字").WithMaxLength(20),
+					"successMessage": jsonschema.String("提交成功提示").WithMaxLength(200),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"title":       jsonschema.String("表单标题").WithMaxLength(100),
+					"description": jsonschema.String("说明").WithMaxLength(500),
+					"fields":      forms.FieldsSchema(),
+				}, "fields"),
+			},
+		},
+	},
+	{
+		ID:   "data",
+		Name: "数据组件",
+		Components: []models.ComponentDefinition{
+			{
+				Type:        "product-grid",
+				Name:        "商品列表",
+				Icon:        "product-grid",
+				Description: "按分类、关键词和价格展示商城中的商品",
+				DefaultSettings: map[string]interface{}{
+					"sort":       "latest",
+					"order":      "desc",
+					"limit":      12,
+					"columns":    4,
+					"showPrice":  true,
+					"pagination": true,
+				},
+				DefaultContent: map[string]interface{}{
+					"title":     "热门商品",
+					"emptyText": "暂无商品",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"companyId":  jsonschema.Integer("商家ID", 0, 1e15),
+					"category":   jsonschema.String("商品分类").WithMaxLength(50),
+					"keyword":    jsonschema.String("关键词").WithMaxLength(50),
+					"priceMin":   jsonschema.Number("最低价格", 0, 1e9),
+					"priceMax":   jsonschema.Number("最高价格", 0, 1e9),
+					"sort":       jsonschema.Enum("排序", "latest", "price", "sales"),
+					"order":      jsonschema.Enum("排序方向", "asc", "desc"),
+					"limit":      jsonschema.Integer("每页数量", 1, 48),
+					"columns":    jsonschema.Integer("列数", 1, 6),
+					"currency":   jsonschema.String("货币符号").WithMaxLength(5),
+					"showPrice":  jsonschema.Boolean("显示价格"),
+					"pagination": jsonschema.Boolean("分页"),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"title":           jsonschema.String("标题").WithMaxLength(100),
+					"emptyText":       jsonschema.String("没有商品时的提示").WithMaxLength(200),
+					"unavailableText": jsonschema.String("数据无法加载时的提示").WithMaxLength(200),
+					"linkPattern":     dataLinkSchema,
+				}),
+			},
+			{
+				Type:        "post-list",
+				Name:        "最新文章",
+				Icon:        "post-list",
+				Description: "展示内容社区中最新发布的文章",
+				DefaultSettings: map[string]interface{}{
+					"limit":       5,
+					"showDate":    true,
+					"showExcerpt": true,
+					"pagination":  false,
+				},
+				DefaultContent: map[string]interface{}{
+					"title":     "最新文章",
+					"emptyText": "暂无文章",
+				},
+				SettingsSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"categoryId":  jsonschema.Integer("分类ID", 0, 1e15),
+					"userId":      jsonschema.Integer("作者ID", 0, 1e15),
+					"limit":       jsonschema.Integer("每页数量", 1, 50),
+					"showDate":    jsonschema.Boolean("显示日期"),
+					"showExcerpt": jsonschema.Boolean("显示摘要"),
+					"pagination":  jsonschema.Boolean("分页"),
+				}),
+				ContentSchema: jsonschema.Object(map[string]*jsonschema.Schema{
+					"title":           jsonschema.String("标题").WithMaxLength(100),
+					"emptyText":       jsonschema.String("没有文章时的提示").WithMaxLength(200),
+					"unavailableText": jsonschema.String("数据无法加载时的提示").WithMaxLength(200),
+					"linkPattern":     dataLinkSchema,
+				}),
+			},
+		},
+	},
+}
+
+// Categories 获取组件分类列表
+func Categories() []models.ComponentCategory {
+	return componentCategories
+}
+
+// Get 获取组件定义
+func Get(componentType string) (models.ComponentDefinition, error) {
+	for _, category := range componentCategories {
+		for _, definition := range category.Components {
+			if definition.Type == componentType {
+				return definition, nil
+			}
+		}
+	}
+
+	return models.ComponentDefinition{}, errors.New("组件类型不存在")
+}
+
+// Validate 按组件定义的Schema校验设置、内容、样式和各语言的翻译，
+// 校验失败时返回包含所有字段错误的jsonschema.ValidationError
+func Validate(component models.Component) error {
+	definition, err := Get(component.Type)
+	if err != nil {
+		return &jsonschema.ValidationError{Errors: []jsonschema.FieldError{{Field: "type", Message: "组件类型不存在"}}}
+	}
+
+	var fieldErrors []jsonschema.FieldError
+	check := func(schema *jsonschema.Schema, field string, value interface{}) {
+		if schema == nil {
+			return
+		}
+		if value == nil {
+			value = map[string]interface{}{}
+		}
+		var validationErr *jsonschema.ValidationError
+		if errors.As(schema.Validate(field, value), &validationErr) {
+			fieldErrors = append(fieldErrors, validationErr.Errors...)
+		}
+	}
+
+	// 设置和样式可以按设备覆盖，设置的覆盖只包含部分字段，不要求必填字段
+	fieldErrors = append(fieldErrors, responsive.Validate("settings", component.Settings, true)...)
+	fieldErrors = append(fieldErrors, responsive.Validate("style", component.Style, false)...)
+	if settings, ok := component.Settings.(map[string]interface{}); ok && definition.SettingsSchema != nil {
+		base, overrides := responsive.Split(settings)
+		check(definition.SettingsSchema, "settings", base)
+		overrideSchema := *definition.SettingsSchema
+		overrideSchema.Required = nil
+		for _, device := range responsive.Devices {
+			if override, exists := overrides[device]; exists {
+				check(&overrideSchema, "settings."+device, override)
+			}
+		}
+	} else {
+		check(definition.SettingsSchema, "settings", component.Settings)
+	}
+	check(definition.ContentSchema, "content", component.Content)
+	check(componentStyleSchema, "style", component.Style)
+
+	// 翻译只覆盖部分内容字段，不要求必填字段
+	if definition.ContentSchema != nil {
+		translationSchema := *definition.ContentSchema
+		translationSchema.Required = nil
+		for _, locale := range sortedLocales(component.Translations) {
+			check(&translationSchema, "translations."+locale, component.Translations[locale])
+		}
+	}
+
+	// 表单字段之间的约束无法用Schema表达，结构校验通过后单独检查
+	if component.Type == "form" && len(fieldErrors) == 0 {
+		fields, err := forms.ParseFields(component.Content)
+		if err != nil {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: "content.fields", Message: err.Error()})
+		} else {
+			fieldErrors = append(fieldErrors, forms.CheckFields(fields)...)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &jsonschema.ValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+func sortedLocales(translations map[string]map[string]interface{}) []string {
+	locales := make([]string, 0, len(translations))
+	for locale := range translations {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
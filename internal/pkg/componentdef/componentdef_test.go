@@ -0,0 +1,145 @@
+package componentdef
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/models"
+)
+
+// errorFields 返回校验失败的字段，校验通过时返回nil
+func errorFields(t *testing.T, component models.Component) []string {
+	t.Helper()
+	err := Validate(component)
+	if err == nil {
+		return nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("期望ValidationError，得到%v", err)
+	}
+	var fields []string
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	return fields
+}
+
+func TestDefaultsAreValid(t *testing.T) {
+	for _, category := range Categories() {
+		for _, definition := range category.Components {
+			component := models.Component{
+				Type:     definition.Type,
+				Settings: definition.DefaultSettings,
+				Content:  definition.DefaultContent,
+			}
+			if definition.Type == "image" || definition.Type == "video" {
+				component.Content = map[string]interface{}{"src": "/media/demo"}
+			}
+			if fields := errorFields(t, component); fields != nil {
+				t.Errorf("%s 的默认值无法通过校验: %v", definition.Type, fields)
+			}
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	definition, err := Get("heading")
+	if err != nil || definition.Name != "标题" {
+		t.Fatalf("获取组件定义失败: %+v, %v", definition, err)
+	}
+	if _, err := Get("iframe"); err == nil {
+		t.Fatal("不存在的组件类型应当报错")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		component models.Component
+		fields    []string
+	}{
+		{
+			name:      "未知类型",
+			component: models.Component{Type: "iframe"},
+			fields:    []string{"type"},
+		},
+		{
+			name: "有效的标题",
+			component: models.Component{
+				Type:     "heading",
+				Settings: map[string]interface{}{"level": "h1", "color": "#333"},
+				Content:  map[string]interface{}{"text": "欢迎"},
+				Style:    map[string]interface{}{"margin": "0"},
+			},
+		},
+		{
+			name: "设置和内容的错误",
+			component: models.Component{
+				Type:     "heading",
+				Settings: map[string]interface{}{"level": "h7", "color": "red;}", "unknown": true},
+				Content:  map[string]interface{}{},
+			},
+			fields: []string{"settings.color", "settings.level", "settings.unknown", "content.text"},
+		},
+		{
+			name: "脚本链接",
+			component: models.Component{
+				Type:    "button",
+				Content: map[string]interface{}{"text": "购买", "link": "javascript:alert(1)"},
+			},
+			fields: []string{"content.link"},
+		},
+		{
+			name: "整数范围",
+			component: models.Component{
+				Type:     "column",
+				Settings: map[string]interface{}{"span": 25},
+			},
+			fields: []string{"settings.span"},
+		},
+		{
+			name: "图片列表",
+			component: models.Component{
+				Type:    "gallery",
+				Content: map[string]interface{}{"images": []interface{}{map[string]interface{}{"src": "/a.png"}, map[string]interface{}{"alt": "缺少地址"}}},
+			},
+			fields: []string{"content.images[1].src"},
+		},
+		{
+			name: "样式必须是对象",
+			component: models.Component{
+				Type:    "text",
+				Content: map[string]interface{}{"text": "文本"},
+				Style:   "color:red",
+			},
+			fields: []string{"style"},
+		},
+		{
+			name: "翻译不要求必填字段",
+			component: models.Component{
+				Type:         "button",
+				Content:      map[string]interface{}{"text": "购买", "link": "/buy"},
+				Translations: map[string]map[string]interface{}{"en": {"link": "/en/buy"}},
+			},
+		},
+		{
+			name: "翻译中的错误",
+			component: models.Component{
+				Type:    "button",
+				Content: map[string]interface{}{"text": "购买"},
+				Translations: map[string]map[string]interface{}{
+					"ja": {"text": 1},
+					"en": {"link": "javascript:x", "color": "red"},
+				},
+			},
+			fields: []string{"translations.en.color", "translations.en.link", "translations.ja.text"},
+		},
+	}
+	for _, tt := range tests {
+		if fields := errorFields(t, tt.component); !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("%s: 错误字段为%v，期望%v", tt.name, fields, tt.fields)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Package datasource 为数据组件提供可插拔的数据源。
+// 渲染服务按组件设置中的查询向数据源取数，每次查询有超时限制，成功的结果在进程内缓存；
+// 数据源变慢或不可用时返回上次成功的结果，没有可用结果时返回错误，由组件降级显示
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout 单次查询的默认超时时间
+	DefaultTimeout = 2 * time.Second
+	// DefaultTTL 查询结果的默认缓存时间
+	DefaultTTL = time.Minute
+	// staleTTL 数据源不可用时，过期结果最多继续使用的时间
+	staleTTL = time.Hour
+	// failureBackoff 查询失败后在该时间内不再访问数据源，避免每次渲染都等待超时
+	failureBackoff = 10 * time.Second
+	// cacheSweepSize 缓存条目达到该数量时清理过期条目
+	cacheSweepSize = 1000
+)
+
+var (
+	// ErrUnknownSource 数据源没有注册
+	ErrUnknownSource = errors.New("数据源未配置")
+	// ErrTimeout 数据源在超时时间内没有返回
+	ErrTimeout = errors.New("数据源响应超时")
+)
+
+// Query 数据组件的查询条件，Page从1开始
+type Query struct {
+	Filters  map[string]interface{} `json:"filters,omitempty"`
+	Sort     string                 `json:"sort,omitempty"`
+	Order    string                 `json:"order,omitempty"` // asc或desc
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"pageSize"`
+}
+
+// Result 查询结果，Items中的字段由数据源定义
+type Result struct {
+	Items []map[string]interface{}
+	Total int
+	// Stale 数据源不可用，结果来自过期的缓存
+	Stale bool
+}
+
+// Pages 结果的总页数
+func (r Result) Pages(pageSize int) int {
+	if pageSize <= 0 || r.Total <= 0 {
+		return 1
+	}
+	return (r.Total + pageSize - 1) / pageSize
+}
+
+// Provider 数据源，Fetch应当在ctx结束时尽快返回
+type Provider interface {
+	Fetch(ctx context.Context, query Query) (Result, error)
+}
+
+// ProviderFunc 以函数形式实现的数据源
+type ProviderFunc func(ctx context.Context, query Query) (Result, error)
+
+// Fetch 调用查询函数
+func (f ProviderFunc) Fetch(ctx context.Context, query Query) (Result, error) {
+	return f(ctx, query)
+}
+
+// cacheEntry 一个查询的缓存状态
+type cacheEntry struct {
+	result    Result
+	fetchedAt time.Time // 最近一次成功的时间，零值表示从未成功
+	failedAt  time.Time // 最近一次失败的时间
+	err       error
+	loading   chan struct{} // 查询进行中时不为nil，查询结束后关闭
+}
+
+// Registry 数据源注册表，负责超时控制和结果缓存
+type Registry struct {
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu        sync.Mutex
+	providers map[string]Provider
+	cache     map[string]*cacheEntry
+}
+
+// NewRegistry 创建数据源注册表
+func NewRegistry(timeout time.Duration, ttl time.Duration) *Registry {
+	return &Registry{
+		timeout:   timeout,
+		ttl:       ttl,
+		providers: map[string]Provider{},
+		cache:     map[string]*cacheEntry{},
+	}
+}
+
+// TTL 查询结果的缓存时间
+func (r *Registry) TTL() time.Duration {
+	return r.ttl
+}
+
+// Register 注册数据源，同名重复注册会覆盖之前的数据源并清空缓存
+func (r *Registry) Register(name string, provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+	r.cache = map[string]*cacheEntry{}
+}
+
+// Has 判断数据源是否已注册
+func (r *Registry) Has(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.providers[name]
+	return ok
+}
+
+// Fetch 查询数据源。缓存未过期时直接返回缓存；同一查询同时只访问一次数据源，
+// 其他请求有过期结果时直接使用过期结果，否则等待本次查询完成
+func (r *Registry) Fetch(name string, query Query) (Result, error) {
+	key, err := cacheKey(name, query)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r.mu.Lock()
+	provider, ok := r.providers[name]
+	if !ok {
+		r.mu.Unlock()
+		return Result{}, ErrUnknownSource
+	}
+	now := time.Now()
+	entry, ok := r.cache[key]
+	if !ok {
+		r.sweepLocked(now)
+		entry = &cacheEntry{}
+		r.cache[key] = entry
+	}
+	if !entry.fetchedAt.IsZero() && now.Sub(entry.fetchedAt) < r.ttl {
+		result := entry.result
+		r.mu.Unlock()
+		return result, nil
+	}
+	if now.Sub(entry.failedAt) < failureBackoff {
+		result, err := entry.fallback(now)
+		r.mu.Unlock()
+		return result, err
+	}
+	if loading := entry.loading; loading != nil {
+		if !entry.fetchedAt.IsZero() && now.Sub(entry.fetchedAt) < staleTTL {
+			result := entry.result
+			r.mu.Unlock()
+			result.Stale = true
+			return result, nil
+		}
+		r.mu.Unlock()
+		<-loading
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if entry.err == nil {
+			return entry.result, nil
+		}
+		return entry.fallback(time.Now())
+	}
+	loading := make(chan struct{})
+	entry.loading = loading
+	r.mu.Unlock()
+
+	result, fetchErr := r.fetchWithTimeout(provider, query)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.loading = nil
+	close(loading)
+	now = time.Now()
+	entry.err = fetchErr
+	if fetchErr != nil {
+		entry.failedAt = now
+		return entry.fallback(now)
+	}
+	entry.result = result
+	entry.fetchedAt = now
+	entry.failedAt = time.Time{}
+	return result, nil
+}
+
+// fallback 查询失败时使用未超过staleTTL的过期结果
+func (e *cacheEntry) fallback(now time.Time) (Result, error) {
+	if !e.fetchedAt.IsZero() && now.Sub(e.fetchedAt) < staleTTL {
+		result := e.result
+		result.Stale = true
+		return result, nil
+	}
+	return Result{}, e.err
+}
+
+// fetchWithTimeout 在超时时间内等待数据源返回，数据源不响应ctx时放弃等待，其结果被丢弃
+func (r *Registry) fetchWithTimeout(provider Provider, query Query) (Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	type outcome struct {
+		result Result
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := provider.Fetch(ctx, query)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.result, out.err
+	case <-ctx.Done():
+		return Result{}, ErrTimeout
+	}
+}
+
+// sweepLocked 缓存条目过多时清理已无法使用的条目
+func (r *Registry) sweepLocked(now time.Time) {
+	if len(r.cache) < cacheSweepSize {
+		return
+	}
+	for key, entry := range r.cache {
+		if entry.loading == nil && now.Sub(entry.fetchedAt) >= staleTTL && now.Sub(entry.failedAt) >= failureBackoff {
+			delete(r.cache, key)
+		}
+	}
+}
+
+// cacheKey 由数据源名称和查询条件生成缓存键，map按键排序编码，相同的查询得到相同的键
+func cacheKey(name string, query Query) (string, error) {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return "", fmt.Errorf("查询条件无法编码: %w", err)
+	}
+	return name + ":" + string(data), nil
+}
@@ -0,0 +1,65 @@
+package datasource
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+	"wz-backend-go/api/rpc/content"
+)
+
+const (
+	// postStatusNormal 内容服务中正常展示的帖子状态
+	postStatusNormal = 1
+	// postExcerptLength 帖子摘要的最大字数
+	postExcerptLength = 120
+)
+
+// postProvider 通过内容服务的ListPosts接口取数
+type postProvider struct {
+	client content.ContentClient
+}
+
+// NewPostProvider 创建帖子数据源，只列出正常状态的帖子。
+// 过滤条件：categoryId、userId；内容服务按发布时间倒序返回，不支持其他排序
+func NewPostProvider(client content.ContentClient) Provider {
+	return &postProvider{client: client}
+}
+
+// Fetch 查询帖子列表
+func (p *postProvider) Fetch(ctx context.Context, query Query) (Result, error) {
+	resp, err := p.client.ListPosts(ctx, &content.ListPostsRequest{
+		CategoryId: int64(filterNumber(query.Filters, "categoryId")),
+		UserId:     int64(filterNumber(query.Filters, "userId")),
+		Status:     postStatusNormal,
+		Page:       int32(query.Page),
+		PageSize:   int32(query.PageSize),
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	items := make([]map[string]interface{}, 0, len(resp.Posts))
+	for _, post := range resp.Posts {
+		items = append(items, map[string]interface{}{
+			"id":           post.PostId,
+			"title":        post.Title,
+			"excerpt":      excerpt(post.Content, postExcerptLength),
+			"categoryId":   post.CategoryId,
+			"viewCount":    post.ViewCount,
+			"likeCount":    post.LikeCount,
+			"commentCount": post.CommentCount,
+			"createdAt":    post.CreatedAt,
+		})
+	}
+	return Result{Items: items, Total: int(resp.Total)}, nil
+}
+
+// excerpt 截取正文开头作为摘要，合并连续空白
+func excerpt(text string, length int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:length]) + "…"
+}
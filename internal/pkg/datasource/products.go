@@ -0,0 +1,110 @@
+package datasource
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"wz-backend-go/internal/domain/model"
+)
+
+// productSortFields 商品列表允许的排序字段，对应ListProducts的sortBy
+var productSortFields = map[string]string{
+	"latest": "created_at",
+	"price":  "price",
+	"sales":  "sales",
+}
+
+// productProvider 从交易服务的商品目录取数
+type productProvider struct {
+	service model.ProductService
+}
+
+// NewProductProvider 创建商品数据源。
+// 过滤条件：companyId（为0时不限商家）、category、keyword、priceMin、priceMax；排序：latest、price、sales
+func NewProductProvider(service model.ProductService) Provider {
+	return &productProvider{service: service}
+}
+
+// Fetch 查询商品列表。ProductService不接受context，超时由Registry控制
+func (p *productProvider) Fetch(ctx context.Context, query Query) (Result, error) {
+	sortBy, ok := productSortFields[query.Sort]
+	if !ok {
+		sortBy = productSortFields["latest"]
+	}
+	products, total, err := p.service.ListProducts(
+		int64(filterNumber(query.Filters, "companyId")),
+		filterString(query.Filters, "category"),
+		filterString(query.Filters, "keyword"),
+		filterNumber(query.Filters, "priceMin"),
+		filterNumber(query.Filters, "priceMax"),
+		sortBy,
+		sortOrder(query.Order),
+		query.Page,
+		query.PageSize,
+	)
+	if err != nil {
+		return Result{}, err
+	}
+
+	items := make([]map[string]interface{}, 0, len(products))
+	for _, product := range products {
+		if product == nil {
+			continue
+		}
+		items = append(items, map[string]interface{}{
+			"id":          product.ProductID,
+			"name":        product.Name,
+			"companyName": product.CompanyName,
+			"category":    product.Category,
+			"price":       product.Price,
+			"image":       firstImage(product.Images),
+			"stock":       product.Stock,
+			"sales":       product.Sales,
+			"description": product.Description,
+			"createdAt":   product.CreatedAt,
+		})
+	}
+	return Result{Items: items, Total: total}, nil
+}
+
+// firstImage 取商品的第一张图片，图片字段为JSON数组或逗号分隔的地址列表
+func firstImage(images string) string {
+	images = strings.TrimSpace(images)
+	if strings.HasPrefix(images, "[") {
+		var list []string
+		if json.Unmarshal([]byte(images), &list) == nil && len(list) > 0 {
+			return list[0]
+		}
+		return ""
+	}
+	first, _, _ := strings.Cut(images, ",")
+	return strings.TrimSpace(first)
+}
+
+// sortOrder 规范化排序方向，默认倒序
+func sortOrder(order string) string {
+	if strings.ToLower(order) == "asc" {
+		return "asc"
+	}
+	return "desc"
+}
+
+// filterString 读取字符串过滤条件
+func filterString(filters map[string]interface{}, key string) string {
+	value, _ := filters[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// filterNumber 读取数值过滤条件，没有设置时返回0
+func filterNumber(filters map[string]interface{}, key string) float64 {
+	switch v := filters[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,230 @@
+// Package forms 定义表单组件的字段结构，并提供字段定义和提交内容的校验，
+// 组件服务在保存表单组件时使用，渲染服务在接收提交时使用
+package forms
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+	"wz-backend-go/internal/pkg/jsonschema"
+)
+
+// 字段类型
+const (
+	FieldText   = "text"
+	FieldEmail  = "email"
+	FieldPhone  = "phone"
+	FieldSelect = "select"
+	FieldFile   = "file"
+)
+
+// HoneypotField 隐藏的防垃圾字段，正常用户看不到也不会填写
+const HoneypotField = "_hp"
+
+const (
+	// DefaultMaxFileSizeMB 文件字段未设置大小限制时的默认值
+	DefaultMaxFileSizeMB = 5
+	// MaxFileSizeMB 文件字段允许设置的最大值
+	MaxFileSizeMB = 20
+	// maxTextLength 文本字段未设置长度限制时的最大长度
+	maxTextLength = 5000
+)
+
+var (
+	fieldNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_]{0,49}$`)
+	phonePattern     = regexp.MustCompile(`^\+?[0-9][0-9 -]{5,19}$`)
+)
+
+// Field 表单字段定义
+type Field struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Required    bool     `json:"required,omitempty"`
+	Placeholder string   `json:"placeholder,omitempty"`
+	Multiline   bool     `json:"multiline,omitempty"` // 文本字段使用多行输入框
+	Options     []string `json:"options,omitempty"`   // 下拉选项，select字段必填
+	MinLength   int      `json:"minLength,omitempty"`
+	MaxLength   int      `json:"maxLength,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"`   // 文本字段需要匹配的正则表达式
+	Accept      []string `json:"accept,omitempty"`    // 文件字段允许的扩展名，如.pdf
+	MaxSizeMB   int      `json:"maxSizeMB,omitempty"` // 文件大小上限
+}
+
+// Upload 提交的文件信息
+type Upload struct {
+	Name string
+	Size int64
+}
+
+// FieldsSchema 表单组件content.fields的Schema
+func FieldsSchema() *jsonschema.Schema {
+	fields := jsonschema.Array("表单字段", jsonschema.Object(map[string]*jsonschema.Schema{
+		"name":        jsonschema.String("字段名").WithPattern(fieldNamePattern.String()),
+		"label":       jsonschema.String("标签").WithMaxLength(100),
+		"type":        jsonschema.Enum("字段类型", FieldText, FieldEmail, FieldPhone, FieldSelect, FieldFile),
+		"required":    jsonschema.Boolean("必填"),
+		"placeholder": jsonschema.String("占位提示").WithMaxLength(100),
+		"multiline":   jsonschema.Boolean("多行输入"),
+		"options":     jsonschema.Array("选项", jsonschema.String("选项").WithMaxLength(100)),
+		"minLength":   jsonschema.Integer("最小长度", 0, maxTextLength),
+		"maxLength":   jsonschema.Integer("最大长度", 1, maxTextLength),
+		"pattern":     jsonschema.String("正则表达式").WithMaxLength(200),
+		"accept":      jsonschema.Array("允许的扩展名", jsonschema.String("扩展名").WithPattern(`^\.[a-zA-Z0-9]{1,10}$`)),
+		"maxSizeMB":   jsonschema.Integer("文件大小上限（MB）", 1, MaxFileSizeMB),
+	}, "name", "label", "type"))
+	minItems := 1
+	fields.MinItems = &minItems
+	return fields
+}
+
+// ParseFields 从表单组件的Content中读取字段定义
+func ParseFields(content interface{}) ([]Field, error) {
+	contentMap, ok := content.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("表单内容格式错误")
+	}
+	data, err := json.Marshal(contentMap["fields"])
+	if err != nil {
+		return nil, err
+	}
+	var fields []Field
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("表单字段格式错误: %w", err)
+	}
+	return fields, nil
+}
+
+// CheckFields 检查字段定义中Schema无法表达的约束：字段名唯一、下拉字段有选项、正则表达式有效
+func CheckFields(fields []Field) []jsonschema.FieldError {
+	var errs []jsonschema.FieldError
+	seen := map[string]bool{}
+	for i, field := range fields {
+		path := fmt.Sprintf("content.fields[%d]", i)
+		if seen[field.Name] {
+			errs = append(errs, jsonschema.FieldError{Field: path + ".name", Message: "字段名重复"})
+		}
+		seen[field.Name] = true
+
+		if field.Type == FieldSelect && len(field.Options) == 0 {
+			errs = append(errs, jsonschema.FieldError{Field: path + ".options", Message: "下拉字段需要至少一个选项"})
+		}
+		if field.Pattern != "" {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				errs = append(errs, jsonschema.FieldError{Field: path + ".pattern", Message: "正则表达式无效"})
+			}
+		}
+		if field.MaxLength > 0 && field.MinLength > field.MaxLength {
+			errs = append(errs, jsonschema.FieldError{Field: path + ".minLength", Message: "不能大于最大长度"})
+		}
+	}
+	return errs
+}
+
+// ValidateSubmission 按字段定义校验提交的值和文件，未定义的字段忽略
+func ValidateSubmission(fields []Field, values map[string]string, uploads map[string]Upload) []jsonschema.FieldError {
+	var errs []jsonschema.FieldError
+	for _, field := range fields {
+		addError := func(format string, args ...interface{}) {
+			errs = append(errs, jsonschema.FieldError{Field: field.Name, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if field.Type == FieldFile {
+			upload, ok := uploads[field.Name]
+			if !ok {
+				if field.Required {
+					addError("请上传%s", field.Label)
+				}
+				continue
+			}
+			if upload.Size > int64(field.MaxFileSize()) {
+				addError("文件不能超过%dMB", field.MaxFileSize()>>20)
+			}
+			if !field.AcceptsFile(upload.Name) {
+				addError("只允许上传%s格式的文件", strings.Join(field.Accept, "、"))
+			}
+			continue
+		}
+
+		value := strings.TrimSpace(values[field.Name])
+		if value == "" {
+			if field.Required {
+				addError("请填写%s", field.Label)
+			}
+			continue
+		}
+
+		length := utf8.RuneCountInString(value)
+		maxLength := field.MaxLength
+		if maxLength == 0 {
+			maxLength = maxTextLength
+		}
+		if length > maxLength {
+			addError("长度不能超过%d", maxLength)
+		}
+		if field.MinLength > 0 && length < field.MinLength {
+			addError("长度不能少于%d", field.MinLength)
+		}
+
+		switch field.Type {
+		case FieldEmail:
+			if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+				addError("邮箱格式不正确")
+			}
+		case FieldPhone:
+			if !phonePattern.MatchString(value) {
+				addError("电话号码格式不正确")
+			}
+		case FieldSelect:
+			if !contains(field.Options, value) {
+				addError("请选择有效的选项")
+			}
+		case FieldText:
+			if field.Pattern != "" {
+				if pattern, err := regexp.Compile(field.Pattern); err == nil && !pattern.MatchString(value) {
+					addError("%s格式不正确", field.Label)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// MaxFileSize 文件字段的大小上限，单位字节
+func (f Field) MaxFileSize() int {
+	size := f.MaxSizeMB
+	if size <= 0 {
+		size = DefaultMaxFileSizeMB
+	}
+	if size > MaxFileSizeMB {
+		size = MaxFileSizeMB
+	}
+	return size << 20
+}
+
+// AcceptsFile 文件扩展名是否在允许范围内，未设置时允许所有文件
+func (f Field) AcceptsFile(name string) bool {
+	if len(f.Accept) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, accept := range f.Accept {
+		if strings.ToLower(accept) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Package globalsection 解析页面区块对站点全局区块的引用。
+// 全局区块本身也可以引用其他全局区块，解析时沿引用链查找，遇到循环或层级过深时放弃
+package globalsection
+
+import (
+	"errors"
+	"wz-backend-go/models"
+)
+
+// MaxDepth 引用链的最大层数
+const MaxDepth = 8
+
+var (
+	// ErrNotFound 引用的全局区块不存在
+	ErrNotFound = errors.New("全局区块不存在")
+	// ErrCycle 全局区块之间存在循环引用或引用层级过深
+	ErrCycle = errors.New("全局区块存在循环引用")
+)
+
+// Find 按ID查找全局区块
+func Find(globals []models.Section, id string) (models.Section, bool) {
+	for _, section := range globals {
+		if section.ID == id {
+			return section, true
+		}
+	}
+	return models.Section{}, false
+}
+
+// Target 沿引用链找到最终提供内容的全局区块，区块没有引用时返回其本身
+func Target(globals []models.Section, section models.Section) (models.Section, error) {
+	visited := map[string]bool{section.ID: true}
+	for depth := 0; section.GlobalSectionID != ""; depth++ {
+		if depth >= MaxDepth || visited[section.GlobalSectionID] {
+			return models.Section{}, ErrCycle
+		}
+		next, ok := Find(globals, section.GlobalSectionID)
+		if !ok {
+			return models.Section{}, ErrNotFound
+		}
+		visited[next.ID] = true
+		section = next
+	}
+	return section, nil
+}
+
+// ResolveSection 用全局区块的内容和组件替换引用区块的内容，保留引用区块自身的ID、位置和修订号
+func ResolveSection(globals []models.Section, section models.Section) (models.Section, error) {
+	if section.GlobalSectionID == "" {
+		return section, nil
+	}
+	target, err := Target(globals, section)
+	if err != nil {
+		return section, err
+	}
+
+	section.Type = target.Type
+	section.Title = target.Title
+	section.Settings = target.Settings
+	section.Style = target.Style
+	section.Translations = target.Translations
+	section.Components = target.Components
+	return section, nil
+}
+
+// Resolve 解析区块列表中的引用，无法解析的引用被跳过
+func Resolve(globals []models.Section, sections []models.Section) []models.Section {
+	resolved := make([]models.Section, 0, len(sections))
+	for _, section := range sections {
+		if section, err := ResolveSection(globals, section); err == nil {
+			resolved = append(resolved, section)
+		}
+	}
+	return resolved
+}
+
+// CheckReference 检查让全局区块sectionID引用targetID是否可行，sectionID为空表示新建的区块
+func CheckReference(globals []models.Section, sectionID string, targetID string) error {
+	target, ok := Find(globals, targetID)
+	if !ok {
+		return ErrNotFound
+	}
+	if sectionID == "" {
+		_, err := Target(globals, target)
+		return err
+	}
+	if targetID == sectionID {
+		return ErrCycle
+	}
+
+	// 假设引用已经建立，从该区块出发检查引用链
+	updated := make([]models.Section, len(globals))
+	copy(updated, globals)
+	for i := range updated {
+		if updated[i].ID == sectionID {
+			updated[i].GlobalSectionID = targetID
+			_, err := Target(updated, updated[i])
+			return err
+		}
+	}
+	return ErrNotFound
+}
+
+// Referencing 列出直接引用了指定全局区块的区块
+func Referencing(sections []models.Section, globalID string) []models.Section {
+	var result []models.Section
+	for _, section := range sections {
+		if section.GlobalSectionID == globalID {
+			result = append(result, section)
+		}
+	}
+	return result
+}
@@ -0,0 +1,153 @@
+// Package jsonschema 实现组件配置校验所需的JSON Schema子集：
+// type、properties、required、additionalProperties、items、enum、
+// minimum/maximum、minLength/maxLength、pattern、minItems/maxItems以及uri、color、length格式
+package jsonschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 支持的类型
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+)
+
+// 支持的字符串格式
+const (
+	FormatURI    = "uri"    // http(s)链接、站内路径、锚点、mailto:和tel:
+	FormatColor  = "color"  // 十六进制颜色、rgb()/rgba()/hsl()/hsla()或颜色名
+	FormatLength = "length" // CSS长度，如16px、1.5rem、100%、auto
+)
+
+// Schema JSON Schema描述，字段名与JSON Schema保持一致，便于编辑器直接使用
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+}
+
+// FieldError 单个字段的校验错误，Field为字段路径，如settings.level、content.images[0].src
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError 校验失败，包含所有字段错误
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldError := range e.Errors {
+		messages[i] = fieldError.Field + ": " + fieldError.Message
+	}
+	return "校验失败: " + strings.Join(messages, "; ")
+}
+
+// Object 创建不允许额外字段的对象Schema
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	closed := false
+	return &Schema{
+		Type:                 TypeObject,
+		Properties:           properties,
+		Required:             required,
+		AdditionalProperties: &closed,
+	}
+}
+
+// String 创建字符串Schema
+func String(title string) *Schema {
+	return &Schema{Type: TypeString, Title: title}
+}
+
+// Integer 创建取值范围为[min, max]的整数Schema
+func Integer(title string, min float64, max float64) *Schema {
+	return &Schema{Type: TypeInteger, Title: title, Minimum: &min, Maximum: &max}
+}
+
+// Number 创建取值范围为[min, max]的数值Schema
+func Number(title string, min float64, max float64) *Schema {
+	return &Schema{Type: TypeNumber, Title: title, Minimum: &min, Maximum: &max}
+}
+
+// Boolean 创建布尔Schema
+func Boolean(title string) *Schema {
+	return &Schema{Type: TypeBoolean, Title: title}
+}
+
+// Enum 创建只能取给定值的字符串Schema
+func Enum(title string, values ...string) *Schema {
+	enum := make([]interface{}, len(values))
+	for i, value := range values {
+		enum[i] = value
+	}
+	return &Schema{Type: TypeString, Title: title, Enum: enum}
+}
+
+// Array 创建数组Schema
+func Array(title string, items *Schema) *Schema {
+	return &Schema{Type: TypeArray, Title: title, Items: items}
+}
+
+// WithFormat 设置字符串格式
+func (s *Schema) WithFormat(format string) *Schema {
+	s.Format = format
+	return s
+}
+
+// WithMaxLength 设置字符串最大长度
+func (s *Schema) WithMaxLength(max int) *Schema {
+	s.MaxLength = &max
+	return s
+}
+
+// WithPattern 设置字符串需要匹配的正则表达式
+func (s *Schema) WithPattern(pattern string) *Schema {
+	s.Pattern = pattern
+	return s
+}
+
+// WithDefault 设置默认值
+func (s *Schema) WithDefault(value interface{}) *Schema {
+	s.Default = value
+	return s
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return TypeObject
+	case []interface{}:
+		return TypeArray
+	case string:
+		return TypeString
+	case bool:
+		return TypeBoolean
+	case float64, float32, int, int64, int32:
+		return TypeNumber
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,235 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	hexColorPattern  = regexp.MustCompile(`^#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+	funcColorPattern = regexp.MustCompile(`^(rgb|rgba|hsl|hsla)\(\s*[0-9.%\s,/+-]+\)$`)
+	namedColor       = regexp.MustCompile(`^[a-zA-Z]{3,20}$`)
+	lengthPattern    = regexp.MustCompile(`^(0|-?[0-9]*\.?[0-9]+(px|em|rem|%|vw|vh|vmin|vmax|pt|ch))$|^(auto)$`)
+	uriSchemePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+)
+
+// Validate 校验值是否符合Schema，field为根字段名，返回所有字段错误，校验通过时返回nil
+func (s *Schema) Validate(field string, value interface{}) error {
+	value, err := normalize(value)
+	if err != nil {
+		return &ValidationError{Errors: []FieldError{{Field: field, Message: "无法解析的值"}}}
+	}
+
+	var errs []FieldError
+	s.validate(field, value, &errs)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// normalize 将Go值转换为JSON解码后的通用形式，便于统一处理数字和嵌套结构
+func normalize(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, string, bool, float64:
+		return value, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+func (s *Schema) validate(field string, value interface{}, errs *[]FieldError) {
+	addError := func(format string, args ...interface{}) {
+		*errs = append(*errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !matchesType(s.Type, value) {
+		addError("应为%s类型，实际为%s", s.Type, describe(value))
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		addError("取值必须是%s之一", formatEnum(s.Enum))
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(field, v, errs)
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			addError("至少需要%d项", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			addError("最多允许%d项", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(fmt.Sprintf("%s[%d]", field, i), item, errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			addError("长度不能少于%d", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			addError("长度不能超过%d", *s.MaxLength)
+		}
+		if v != "" && s.Pattern != "" {
+			if pattern, err := regexp.Compile(s.Pattern); err == nil && !pattern.MatchString(v) {
+				addError("格式不正确")
+			}
+		}
+		if v != "" && s.Format != "" {
+			if message := checkFormat(s.Format, v); message != "" {
+				addError("%s", message)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			addError("不能小于%v", *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			addError("不能大于%v", *s.Maximum)
+		}
+	}
+}
+
+func (s *Schema) validateObject(field string, value map[string]interface{}, errs *[]FieldError) {
+	for _, name := range s.Required {
+		if item, ok := value[name]; !ok || item == nil || item == "" {
+			*errs = append(*errs, FieldError{Field: joinField(field, name), Message: "必填"})
+		}
+	}
+
+	// 按字段名排序，保证错误顺序稳定
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, FieldError{Field: joinField(field, name), Message: "不支持的字段"})
+			}
+			continue
+		}
+		// 可选字段允许为null，表示未设置
+		if value[name] == nil {
+			continue
+		}
+		property.validate(joinField(field, name), value[name], errs)
+	}
+}
+
+func joinField(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func matchesType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case "":
+		return true
+	case TypeObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case TypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case TypeNumber:
+		_, ok := value.(float64)
+		return ok
+	case TypeInteger:
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	}
+	return false
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		normalized, err := normalize(candidate)
+		if err == nil && reflect.DeepEqual(normalized, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, len(enum))
+	for i, value := range enum {
+		values[i] = fmt.Sprint(value)
+	}
+	return strings.Join(values, ", ")
+}
+
+// checkFormat 校验字符串格式，返回错误说明，格式正确或未知格式时返回空字符串
+func checkFormat(format string, value string) string {
+	switch format {
+	case FormatURI:
+		if isURI(value) {
+			return ""
+		}
+		return "不是有效的链接地址"
+	case FormatColor:
+		if hexColorPattern.MatchString(value) || funcColorPattern.MatchString(value) || namedColor.MatchString(value) {
+			return ""
+		}
+		return "不是有效的颜色"
+	case FormatLength:
+		if lengthPattern.MatchString(value) {
+			return ""
+		}
+		return "不是有效的CSS长度"
+	}
+	return ""
+}
+
+// isURI 允许http(s)链接、站内路径、锚点、mailto:和tel:，拒绝javascript:等其他协议
+func isURI(value string) bool {
+	if strings.ContainsAny(value, " \t\r\n") {
+		return false
+	}
+	if strings.HasPrefix(value, "//") {
+		return len(value) > 2
+	}
+	if strings.HasPrefix(value, "/") || strings.HasPrefix(value, "#") || strings.HasPrefix(value, "?") {
+		return true
+	}
+	scheme := strings.ToLower(uriSchemePattern.FindString(value))
+	switch scheme {
+	case "http:", "https:":
+		return len(value) > len(scheme)+2 && strings.HasPrefix(value[len(scheme):], "//")
+	case "mailto:", "tel:":
+		return len(value) > len(scheme)
+	case "":
+		// 不带协议的相对路径，如images/banner.jpg
+		return true
+	}
+	return false
+}
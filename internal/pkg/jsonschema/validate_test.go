@@ -0,0 +1,159 @@
+package jsonschema
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fieldErrors 返回校验得到的字段错误，校验通过时返回nil
+func fieldErrors(t *testing.T, schema *Schema, value interface{}) []FieldError {
+	t.Helper()
+	err := schema.Validate("value", value)
+	if err == nil {
+		return nil
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("期望ValidationError，得到%v", err)
+	}
+	return validationErr.Errors
+}
+
+func TestValidateTypes(t *testing.T) {
+	tests := []struct {
+		schema  *Schema
+		value   interface{}
+		message string
+	}{
+		{String("s"), "abc", ""},
+		{String("s"), 1, "应为string类型，实际为number"},
+		{Boolean("b"), true, ""},
+		{Boolean("b"), "true", "应为boolean类型，实际为string"},
+		{Integer("i", 0, 10), 3, ""},
+		{Integer("i", 0, 10), 3.5, "应为integer类型，实际为number"},
+		{Integer("i", 0, 10), 11, "不能大于10"},
+		{Integer("i", 0, 10), -1, "不能小于0"},
+		{Number("n", 0, 1), 0.5, ""},
+		{Number("n", 0, 1), nil, "应为number类型，实际为null"},
+		{Array("a", String("s")), []string{"a"}, ""},
+		{Array("a", String("s")), map[string]interface{}{}, "应为array类型，实际为object"},
+		{Object(nil), map[string]interface{}{}, ""},
+		{Object(nil), []interface{}{}, "应为object类型，实际为array"},
+		{Enum("e", "a", "b"), "b", ""},
+		{Enum("e", "a", "b"), "c", "取值必须是a, b之一"},
+		{String("s").WithMaxLength(3), "一二三", ""},
+		{String("s").WithMaxLength(3), "一二三四", "长度不能超过3"},
+		{String("s").WithPattern(`^[a-z]+$`), "abc", ""},
+		{String("s").WithPattern(`^[a-z]+$`), "ABC", "格式不正确"},
+		{String("s").WithPattern(`^[a-z]+$`), "", ""},
+	}
+	for _, tt := range tests {
+		errs := fieldErrors(t, tt.schema, tt.value)
+		if tt.message == "" {
+			if errs != nil {
+				t.Errorf("%v 不应报错，得到%v", tt.value, errs)
+			}
+			continue
+		}
+		want := []FieldError{{Field: "value", Message: tt.message}}
+		if !reflect.DeepEqual(errs, want) {
+			t.Errorf("%v 的错误为%v，期望%v", tt.value, errs, want)
+		}
+	}
+}
+
+func TestValidateFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		value  string
+		valid  bool
+	}{
+		{FormatURI, "https://example.com/a?b=1", true},
+		{FormatURI, "/products/1", true},
+		{FormatURI, "#contact", true},
+		{FormatURI, "?page=2", true},
+		{FormatURI, "images/banner.jpg", true},
+		{FormatURI, "//cdn.example.com/a.png", true},
+		{FormatURI, "mailto:hi@example.com", true},
+		{FormatURI, "tel:10086", true},
+		{FormatURI, "javascript:alert(1)", false},
+		{FormatURI, "JavaScript:alert(1)", false},
+		{FormatURI, "data:text/html,x", false},
+		{FormatURI, "https:example.com", false},
+		{FormatURI, "/a b", false},
+		{FormatURI, "//", false},
+		{FormatURI, "mailto:", false},
+		{FormatColor, "#fff", true},
+		{FormatColor, "#ffffff80", true},
+		{FormatColor, "rgba(0, 0, 0, .5)", true},
+		{FormatColor, "hsl(120 50% 50% / 0.3)", true},
+		{FormatColor, "transparent", true},
+		{FormatColor, "#ffff0", false},
+		{FormatColor, "rgb(0,0,0);x", false},
+		{FormatColor, "url(/a.png)", false},
+		{FormatLength, "16px", true},
+		{FormatLength, "1.5rem", true},
+		{FormatLength, "-2em", true},
+		{FormatLength, "100%", true},
+		{FormatLength, "0", true},
+		{FormatLength, "auto", true},
+		{FormatLength, "16", false},
+		{FormatLength, "calc(100% - 1px)", false},
+		{FormatLength, "10px;color:red", false},
+	}
+	for _, tt := range tests {
+		errs := fieldErrors(t, String("s").WithFormat(tt.format), tt.value)
+		if tt.valid && errs != nil {
+			t.Errorf("%s %q 不应报错，得到%v", tt.format, tt.value, errs)
+		}
+		if !tt.valid && errs == nil {
+			t.Errorf("%s %q 应当被拒绝", tt.format, tt.value)
+		}
+	}
+}
+
+func TestValidateObject(t *testing.T) {
+	schema := Object(map[string]*Schema{
+		"title": String("标题").WithMaxLength(5),
+		"link":  String("链接").WithFormat(FormatURI),
+		"images": Array("图片", Object(map[string]*Schema{
+			"src": String("地址").WithFormat(FormatURI),
+		}, "src")),
+	}, "title")
+
+	if errs := fieldErrors(t, schema, map[string]interface{}{"title": "标题", "link": nil}); errs != nil {
+		t.Fatalf("可选字段为null时不应报错，得到%v", errs)
+	}
+
+	type image struct {
+		Src string `json:"src"`
+	}
+	value := map[string]interface{}{
+		"extra":  1,
+		"link":   "javascript:void(0)",
+		"images": []image{{Src: "/a.png"}, {Src: ""}, {Src: "vbscript:x"}},
+	}
+	want := []FieldError{
+		{Field: "value.title", Message: "必填"},
+		{Field: "value.extra", Message: "不支持的字段"},
+		{Field: "value.images[1].src", Message: "必填"},
+		{Field: "value.images[2].src", Message: "不是有效的链接地址"},
+		{Field: "value.link", Message: "不是有效的链接地址"},
+	}
+	if errs := fieldErrors(t, schema, value); !reflect.DeepEqual(errs, want) {
+		t.Fatalf("错误为%v，期望%v", errs, want)
+	}
+
+	open := &Schema{Type: TypeObject, Properties: map[string]*Schema{"a": String("a")}}
+	if errs := fieldErrors(t, open, map[string]interface{}{"b": 1}); errs != nil {
+		t.Fatalf("未关闭的对象应当允许额外字段，得到%v", errs)
+	}
+}
+
+func TestValidationErrorMessage(t *testing.T) {
+	err := &ValidationError{Errors: []FieldError{{Field: "a", Message: "必填"}, {Field: "b.c", Message: "格式不正确"}}}
+	if err.Error() != "校验失败: a: 必填; b.c: 格式不正确" {
+		t.Fatalf("错误信息为%q", err.Error())
+	}
+}
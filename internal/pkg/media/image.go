@@ -0,0 +1,298 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"wz-backend-go/models"
+)
+
+// 图片类型
+const (
+	TypeJPEG = "image/jpeg"
+	TypePNG  = "image/png"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp"
+)
+
+// 缩放版本的格式，也是访问地址的扩展名
+const (
+	FormatJPEG = "jpg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// VariantWidths 可以生成的缩放宽度，只生成比原图窄的版本
+var VariantWidths = []int{320, 640, 960, 1280, 1920}
+
+// MaxPixels 生成缩放版本的原图最大像素数，更大的图片只提供原图，避免解码时占用过多内存
+const MaxPixels = 25_000_000
+
+// jpegQuality 生成JPEG缩放版本的质量
+const jpegQuality = 85
+
+// 生成缩放版本的错误
+var (
+	ErrInvalidVariant   = errors.New("无效的图片版本")
+	ErrNotResizable     = errors.New("该文件不能生成缩放版本")
+	ErrWebPUnavailable  = errors.New("未配置WebP编码器")
+	ErrUnsupportedImage = errors.New("不支持的图片格式")
+)
+
+// Info 按文件内容识别的类型和图片尺寸
+type Info struct {
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Probe 按文件内容识别类型，不信任客户端声明的类型；JPEG、PNG、GIF和WebP读取图片尺寸
+func Probe(data []byte) Info {
+	info := Info{ContentType: http.DetectContentType(data)}
+	switch info.ContentType {
+	case TypeJPEG, TypePNG, TypeGIF:
+		if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			info.Width, info.Height = config.Width, config.Height
+		}
+	case TypeWebP:
+		info.Width, info.Height = webpSize(data)
+	}
+	return info
+}
+
+// Resizable 判断文件能否生成缩放版本：JPEG或PNG图片，尺寸已知且不超过MaxPixels。
+// GIF可能是动画，缩放后会丢失动画，只提供原图
+func Resizable(contentType string, width int, height int) bool {
+	if width <= 0 || height <= 0 || width*height > MaxPixels {
+		return false
+	}
+	return SourceFormat(contentType) != ""
+}
+
+// SourceFormat 与原图对应的缩放版本格式：JPEG生成jpg，PNG生成png，其他类型为空
+func SourceFormat(contentType string) string {
+	switch contentType {
+	case TypeJPEG:
+		return FormatJPEG
+	case TypePNG:
+		return FormatPNG
+	}
+	return ""
+}
+
+// Widths 宽度为width的图片可以生成的缩放宽度
+func Widths(width int) []int {
+	var widths []int
+	for _, w := range VariantWidths {
+		if w < width {
+			widths = append(widths, w)
+		}
+	}
+	return widths
+}
+
+// VariantName 缩放版本的名称，如w640.webp
+func VariantName(width int, format string) string {
+	return fmt.Sprintf("w%d.%s", width, format)
+}
+
+// ParseVariantName 解析缩放版本的名称，宽度必须是VariantWidths之一，
+// 名称必须与VariantName生成的一致，w0640.jpg等写法不能指向同一个版本
+func ParseVariantName(name string) (width int, format string, err error) {
+	base, format, ok := strings.Cut(name, ".")
+	if !ok || !strings.HasPrefix(base, "w") {
+		return 0, "", ErrInvalidVariant
+	}
+	if format != FormatJPEG && format != FormatPNG && format != FormatWebP {
+		return 0, "", ErrInvalidVariant
+	}
+	width, err = strconv.Atoi(base[1:])
+	if err != nil || VariantName(width, format) != name {
+		return 0, "", ErrInvalidVariant
+	}
+	for _, w := range VariantWidths {
+		if w == width {
+			return width, format, nil
+		}
+	}
+	return 0, "", ErrInvalidVariant
+}
+
+// Encoder WebP编码器，标准库不支持WebP编码，由外部实现提供
+type Encoder interface {
+	Encode(ctx context.Context, img image.Image) ([]byte, error)
+}
+
+// GenerateVariant 把原图缩放到width宽并编码为format格式，返回版本信息和文件内容；
+// 格式为webp时使用webp编码器，未配置时返回ErrWebPUnavailable
+func GenerateVariant(ctx context.Context, data []byte, width int, format string, webp Encoder) (models.MediaVariant, []byte, error) {
+	if format == FormatWebP && webp == nil {
+		return models.MediaVariant{}, nil, ErrWebPUnavailable
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return models.MediaVariant{}, nil, ErrUnsupportedImage
+	}
+	if src.Bounds().Dx()*src.Bounds().Dy() > MaxPixels {
+		return models.MediaVariant{}, nil, ErrNotResizable
+	}
+
+	resized := Resize(src, width)
+	var encoded []byte
+	switch format {
+	case FormatJPEG:
+		var buffer bytes.Buffer
+		err = jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: jpegQuality})
+		encoded = buffer.Bytes()
+	case FormatPNG:
+		var buffer bytes.Buffer
+		err = png.Encode(&buffer, resized)
+		encoded = buffer.Bytes()
+	case FormatWebP:
+		encoded, err = webp.Encode(ctx, resized)
+	default:
+		return models.MediaVariant{}, nil, ErrInvalidVariant
+	}
+	if err != nil {
+		return models.MediaVariant{}, nil, err
+	}
+
+	variant := models.MediaVariant{
+		Name:   VariantName(width, format),
+		Width:  resized.Bounds().Dx(),
+		Height: resized.Bounds().Dy(),
+		Format: format,
+		Size:   int64(len(encoded)),
+	}
+	return variant, encoded, nil
+}
+
+// Resize 按比例把图片缩小到width宽，每个目标像素取对应源区域的平均值。
+// 只用于缩小，width不小于原图宽度时返回原图的副本
+func Resize(src image.Image, width int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+	if width >= sw {
+		return rgba
+	}
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, sh, height)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, sw, width)
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				offset := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += uint64(rgba.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+			count := uint64((y1 - y0) * (x1 - x0))
+			offset := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return dst
+}
+
+// span 目标坐标i对应的源坐标范围[start, end)，至少包含一个像素
+func span(i int, source int, target int) (int, int) {
+	start := i * source / target
+	end := (i + 1) * source / target
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// CommandEncoder 调用cwebp命令把图片编码为WebP
+type CommandEncoder struct {
+	Path    string // cwebp可执行文件
+	Quality int
+}
+
+// NewCommandEncoder 创建使用path处cwebp命令的WebP编码器
+func NewCommandEncoder(path string) *CommandEncoder {
+	return &CommandEncoder{Path: path, Quality: 80}
+}
+
+// Encode 把图片以PNG写入临时文件后交给cwebp转换
+func (e *CommandEncoder) Encode(ctx context.Context, img image.Image) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "media-webp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.png")
+	output := filepath.Join(dir, "output.webp")
+	file, err := os.Create(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, e.Path, "-quiet", "-q", strconv.Itoa(e.Quality), input, "-o", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("WebP编码失败: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output)
+}
+
+// webpSize 读取WebP文件头中的画布尺寸，支持有损（VP8）、无损（VP8L）和扩展（VP8X）格式
+func webpSize(data []byte) (int, int) {
+	if len(data) < 30 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return 0, 0
+	}
+	switch string(data[12:16]) {
+	case "VP8X":
+		width := int(data[24]) | int(data[25])<<8 | int(data[26])<<16
+		height := int(data[27]) | int(data[28])<<8 | int(data[29])<<16
+		return width + 1, height + 1
+	case "VP8L":
+		if data[20] != 0x2f {
+			return 0, 0
+		}
+		bits := binary.LittleEndian.Uint32(data[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1
+	case "VP8 ":
+		if data[23] != 0x9d || data[24] != 0x01 || data[25] != 0x2a {
+			return 0, 0
+		}
+		width := binary.LittleEndian.Uint16(data[26:28]) & 0x3fff
+		height := binary.LittleEndian.Uint16(data[28:30]) & 0x3fff
+		return int(width), int(height)
+	}
+	return 0, 0
+}
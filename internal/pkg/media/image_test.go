@@ -0,0 +1,246 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+// solidImage 创建纯色图片
+func solidImage(width int, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// webpHeader 构造WebP文件头，chunk为VP8X、VP8L或"VP8 "，payload从文件的第20个字节开始
+func webpHeader(chunk string, payload []byte) []byte {
+	data := make([]byte, 20, 20+len(payload))
+	copy(data, "RIFF")
+	binary.LittleEndian.PutUint32(data[4:8], uint32(12+len(payload)))
+	copy(data[8:], "WEBP"+chunk)
+	binary.LittleEndian.PutUint32(data[16:20], uint32(len(payload)))
+	return append(data, payload...)
+}
+
+func TestWebPSize(t *testing.T) {
+	extended := make([]byte, 10)
+	extended[4], extended[5], extended[6] = 0x7f, 0x07, 0x00 // 宽度-1 = 1919
+	extended[7], extended[8], extended[9] = 0x37, 0x04, 0x00 // 高度-1 = 1079
+
+	lossless := make([]byte, 10)
+	lossless[0] = 0x2f
+	binary.LittleEndian.PutUint32(lossless[1:5], uint32(640-1)|uint32(480-1)<<14)
+
+	lossy := []byte{0, 0, 0, 0x9d, 0x01, 0x2a, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(lossy[6:8], 320|0x4000) // 高两位为缩放标记
+	binary.LittleEndian.PutUint16(lossy[8:10], 240)
+
+	badLossless := append([]byte{}, lossless...)
+	badLossless[0] = 0
+	badLossy := append([]byte{}, lossy...)
+	badLossy[3] = 0
+
+	tests := []struct {
+		name   string
+		data   []byte
+		width  int
+		height int
+	}{
+		{"VP8X", webpHeader("VP8X", extended), 1920, 1080},
+		{"VP8L", webpHeader("VP8L", lossless), 640, 480},
+		{"VP8", webpHeader("VP8 ", lossy), 320, 240},
+		{"VP8L签名错误", webpHeader("VP8L", badLossless), 0, 0},
+		{"VP8起始码错误", webpHeader("VP8 ", badLossy), 0, 0},
+		{"未知的块", webpHeader("ALPH", extended), 0, 0},
+		{"长度不足", webpHeader("VP8X", extended)[:29], 0, 0},
+		{"不是WebP", append([]byte("RIFF\x00\x00\x00\x00WAVE"), make([]byte, 20)...), 0, 0},
+	}
+	for _, tt := range tests {
+		width, height := webpSize(tt.data)
+		if width != tt.width || height != tt.height {
+			t.Errorf("%s: 尺寸为%dx%d，期望%dx%d", tt.name, width, height, tt.width, tt.height)
+		}
+	}
+}
+
+func TestProbe(t *testing.T) {
+	img := solidImage(40, 30, color.White)
+	var jpegData, pngData, gifData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.Encode(&gifData, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	lossless := make([]byte, 10)
+	lossless[0] = 0x2f
+	binary.LittleEndian.PutUint32(lossless[1:5], uint32(40-1)|uint32(30-1)<<14)
+
+	tests := []struct {
+		name string
+		data []byte
+		info Info
+	}{
+		{"JPEG", jpegData.Bytes(), Info{ContentType: TypeJPEG, Width: 40, Height: 30}},
+		{"PNG", pngData.Bytes(), Info{ContentType: TypePNG, Width: 40, Height: 30}},
+		{"GIF", gifData.Bytes(), Info{ContentType: TypeGIF, Width: 40, Height: 30}},
+		{"WebP", webpHeader("VP8L", lossless), Info{ContentType: TypeWebP, Width: 40, Height: 30}},
+		{"截断的PNG", pngData.Bytes()[:16], Info{ContentType: TypePNG}},
+		{"声明为图片的HTML", []byte("<html><script>alert(1)</script></html>"), Info{ContentType: "text/html; charset=utf-8"}},
+		{"PDF", []byte("%PDF-1.4\n"), Info{ContentType: "application/pdf"}},
+	}
+	for _, tt := range tests {
+		if info := Probe(tt.data); info != tt.info {
+			t.Errorf("%s: 识别结果为%+v，期望%+v", tt.name, info, tt.info)
+		}
+	}
+}
+
+func TestParseVariantName(t *testing.T) {
+	for _, width := range VariantWidths {
+		for _, format := range []string{FormatJPEG, FormatPNG, FormatWebP} {
+			name := VariantName(width, format)
+			gotWidth, gotFormat, err := ParseVariantName(name)
+			if err != nil || gotWidth != width || gotFormat != format {
+				t.Errorf("%s: 解析结果为%d、%s、%v", name, gotWidth, gotFormat, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"", "w640", "640.jpg", "w.jpg", "w641.jpg", "w640.gif", "w640.jpg.png", "W640.jpg", "w0640.webp", "w+640.webp", "w-320.png", "w640.JPG", "../w640.jpg"} {
+		if _, _, err := ParseVariantName(name); !errors.Is(err, ErrInvalidVariant) {
+			t.Errorf("%q 应当是无效的版本名称，得到%v", name, err)
+		}
+	}
+}
+
+func TestWidthsAndResizable(t *testing.T) {
+	if got := Widths(1000); !reflect.DeepEqual(got, []int{320, 640, 960}) {
+		t.Fatalf("1000宽的图片可以生成%v", got)
+	}
+	if got := Widths(320); got != nil {
+		t.Fatalf("320宽的图片不应生成缩放版本，得到%v", got)
+	}
+
+	tests := []struct {
+		contentType string
+		width       int
+		height      int
+		resizable   bool
+	}{
+		{TypeJPEG, 1920, 1080, true},
+		{TypePNG, 5000, 5000, true},
+		{TypePNG, 5001, 5000, false},
+		{TypeGIF, 1920, 1080, false},
+		{TypeWebP, 1920, 1080, false},
+		{TypeJPEG, 0, 1080, false},
+	}
+	for _, tt := range tests {
+		if got := Resizable(tt.contentType, tt.width, tt.height); got != tt.resizable {
+			t.Errorf("%s %dx%d: 可以缩放为%v，期望%v", tt.contentType, tt.width, tt.height, got, tt.resizable)
+		}
+	}
+}
+
+func TestResize(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	src := solidImage(8, 4, red)
+	for y := 0; y < 4; y++ {
+		for x := 4; x < 8; x++ {
+			src.Set(x, y, blue)
+		}
+	}
+	resized := Resize(src, 2)
+	if resized.Bounds() != image.Rect(0, 0, 2, 1) {
+		t.Fatalf("缩放后的尺寸为%v", resized.Bounds())
+	}
+	if resized.RGBAAt(0, 0) != red || resized.RGBAAt(1, 0) != blue {
+		t.Fatalf("缩放后的颜色为%v、%v", resized.RGBAAt(0, 0), resized.RGBAAt(1, 0))
+	}
+
+	// 每个目标像素取源区域的平均值
+	stripes := solidImage(2, 2, color.Black)
+	stripes.Set(1, 0, color.White)
+	stripes.Set(1, 1, color.White)
+	if got := Resize(stripes, 1).RGBAAt(0, 0); got != (color.RGBA{127, 127, 127, 255}) {
+		t.Fatalf("平均后的颜色为%v", got)
+	}
+
+	// 高度至少为1
+	if got := Resize(solidImage(100, 1, red), 10).Bounds(); got != image.Rect(0, 0, 10, 1) {
+		t.Fatalf("缩放后的尺寸为%v", got)
+	}
+
+	// 不放大，返回从原点开始的副本
+	offset := src.SubImage(image.Rect(4, 0, 8, 4))
+	copied := Resize(offset, 100)
+	if copied.Bounds() != image.Rect(0, 0, 4, 4) || copied.RGBAAt(0, 0) != blue {
+		t.Fatalf("副本的尺寸为%v，颜色为%v", copied.Bounds(), copied.RGBAAt(0, 0))
+	}
+	copied.Set(0, 0, red)
+	if src.RGBAAt(4, 0) != blue {
+		t.Fatal("修改副本不应影响原图")
+	}
+}
+
+// fakeEncoder 记录收到的图片尺寸并返回固定内容
+type fakeEncoder struct {
+	bounds image.Rectangle
+}
+
+func (e *fakeEncoder) Encode(_ context.Context, img image.Image) ([]byte, error) {
+	e.bounds = img.Bounds()
+	return []byte("webp"), nil
+}
+
+func TestGenerateVariant(t *testing.T) {
+	var source bytes.Buffer
+	if err := png.Encode(&source, solidImage(800, 400, color.White)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	variant, data, err := GenerateVariant(ctx, source.Bytes(), 320, FormatJPEG, nil)
+	if err != nil {
+		t.Fatalf("生成JPEG版本失败: %v", err)
+	}
+	if variant.Name != "w320.jpg" || variant.Width != 320 || variant.Height != 160 || variant.Size != int64(len(data)) {
+		t.Fatalf("版本信息为%+v", variant)
+	}
+	if info := Probe(data); info != (Info{ContentType: TypeJPEG, Width: 320, Height: 160}) {
+		t.Fatalf("生成的文件为%+v", info)
+	}
+
+	encoder := &fakeEncoder{}
+	variant, data, err = GenerateVariant(ctx, source.Bytes(), 640, FormatWebP, encoder)
+	if err != nil || string(data) != "webp" || variant.Name != "w640.webp" || encoder.bounds != image.Rect(0, 0, 640, 320) {
+		t.Fatalf("生成WebP版本的结果为%+v、%q、%v，编码的尺寸为%v", variant, data, err, encoder.bounds)
+	}
+
+	if _, _, err := GenerateVariant(ctx, source.Bytes(), 640, FormatWebP, nil); !errors.Is(err, ErrWebPUnavailable) {
+		t.Fatalf("未配置WebP编码器时应当返回ErrWebPUnavailable，得到%v", err)
+	}
+	if _, _, err := GenerateVariant(ctx, []byte("not an image"), 640, FormatPNG, nil); !errors.Is(err, ErrUnsupportedImage) {
+		t.Fatalf("无法解码时应当返回ErrUnsupportedImage，得到%v", err)
+	}
+	if _, _, err := GenerateVariant(ctx, source.Bytes(), 640, "gif", nil); !errors.Is(err, ErrInvalidVariant) {
+		t.Fatalf("不支持的格式应当返回ErrInvalidVariant，得到%v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package media
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"wz-backend-go/models"
+)
+
+// AssetURL 媒体文件的访问地址，baseURL为媒体服务公开路由的地址
+func AssetURL(baseURL string, assetID string) string {
+	return strings.TrimRight(baseURL, "/") + "/" + assetID
+}
+
+// FindReferences 查找站点树中引用媒体文件的位置：站点的Logo、图标、缩略图和分享图片，
+// 以及页面和全局区块中组件的内容、设置、样式和翻译。字段值中包含文件ID即视为引用，
+// 访问地址的域名或前缀变化后仍能找到。published标记引用来自已发布的版本
+func FindReferences(site models.Site, assetID string, published bool) []models.MediaReference {
+	var references []models.MediaReference
+	siteFields := []struct {
+		field string
+		value string
+	}{
+		{"logo", site.Logo},
+		{"favicon", site.Favicon},
+		{"thumbnail", site.Thumbnail},
+		{"seo.image", site.SEO.Image},
+	}
+	for _, field := range siteFields {
+		if strings.Contains(field.value, assetID) {
+			references = append(references, models.MediaReference{
+				SiteID:    site.ID,
+				SiteName:  site.Name,
+				Field:     field.field,
+				Published: published,
+			})
+		}
+	}
+
+	componentReferences := func(page *models.Page, sections []models.Section) {
+		for _, section := range sections {
+			for _, component := range section.Components {
+				for _, field := range componentFields(component, assetID) {
+					reference := models.MediaReference{
+						SiteID:      site.ID,
+						SiteName:    site.Name,
+						ComponentID: component.ID,
+						Field:       field,
+						Published:   published,
+					}
+					if page != nil {
+						reference.PageID = page.ID
+						reference.PageName = page.Name
+					}
+					references = append(references, reference)
+				}
+			}
+		}
+	}
+	for i := range site.Pages {
+		componentReferences(&site.Pages[i], site.Pages[i].Sections)
+	}
+	componentReferences(nil, site.GlobalSections)
+	return references
+}
+
+// componentFields 组件中值包含文件ID的字段路径，如content.images[1].src
+func componentFields(component models.Component, assetID string) []string {
+	var fields []string
+	values := []struct {
+		field string
+		value interface{}
+	}{
+		{"content", component.Content},
+		{"settings", component.Settings},
+		{"style", component.Style},
+	}
+	for _, value := range values {
+		fields = append(fields, matchingPaths(value.value, value.field, assetID)...)
+	}
+	for locale, translation := range component.Translations {
+		fields = append(fields, matchingPaths(translation, "translations."+locale, assetID)...)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// matchingPaths 遍历JSON值，返回字符串中包含assetID的路径
+func matchingPaths(value interface{}, path string, assetID string) []string {
+	switch v := value.(type) {
+	case string:
+		if strings.Contains(v, assetID) {
+			return []string{path}
+		}
+	case map[string]interface{}:
+		var paths []string
+		for key, child := range v {
+			paths = append(paths, matchingPaths(child, path+"."+key, assetID)...)
+		}
+		return paths
+	case []interface{}:
+		var paths []string
+		for i, child := range v {
+			paths = append(paths, matchingPaths(child, fmt.Sprintf("%s[%d]", path, i), assetID)...)
+		}
+		return paths
+	case nil:
+		return nil
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+		return matchingPaths(normalize(value), path, assetID)
+	}
+	return nil
+}
+
+// normalize 把组件字段转换为JSON解码后的通用结构，内存存储中的值可能是其他类型
+func normalize(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var result interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+	return result
+}
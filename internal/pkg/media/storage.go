@@ -0,0 +1,142 @@
+// Package media 实现租户媒体库的文件存储、图片尺寸识别、缩放版本生成和引用查找。
+// 文件按内容的SHA-256保存，同一租户上传相同的文件只保存一份；缩放版本在第一次访问时生成
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound 存储中没有该文件
+var ErrNotFound = errors.New("文件不存在")
+
+// ErrInvalidKey 存储键包含不允许的字符或路径
+var ErrInvalidKey = errors.New("无效的存储键")
+
+// Storage 媒体文件的存储驱动。键由OriginalKey和VariantKey生成，
+// 只包含字母、数字、-、_、.和/，驱动可以直接作为文件路径或对象存储的对象名使用
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Open 打开文件，文件不存在时返回ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// OriginalKey 原始文件的存储键，按租户和内容哈希的前两位分目录
+func OriginalKey(tenantID string, hash string) string {
+	return safeSegment(tenantID) + "/" + hash[:2] + "/" + hash
+}
+
+// VariantKey 缩放版本的存储键，与原始文件放在同一目录
+func VariantKey(tenantID string, hash string, name string) string {
+	return OriginalKey(tenantID, hash) + "." + name
+}
+
+// LocalStorage 把文件保存在本地目录中的存储驱动
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage 创建本地存储，目录不存在时自动创建
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+// Put 先写入临时文件再重命名，读取方不会看到写了一半的文件
+func (s *LocalStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path 把存储键转换为目录中的文件路径，拒绝可能跳出目录的键
+func (s *LocalStorage) path(key string) (string, error) {
+	if !validKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// validKey 判断存储键只包含允许的字符，且每一段都不是空、.或..
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, r := range segment {
+			if !keyChar(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// safeSegment 把租户ID中不允许出现在存储键里的字符替换为_
+func safeSegment(value string) string {
+	segment := strings.Map(func(r rune) rune {
+		if keyChar(r) && r != '.' {
+			return r
+		}
+		return '_'
+	}, value)
+	if segment == "" {
+		return "_"
+	}
+	return segment
+}
+
+func keyChar(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'
+}
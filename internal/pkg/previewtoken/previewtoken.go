@@ -0,0 +1,98 @@
+// Package previewtoken 签发和校验站点预览链接中的令牌。
+// 令牌包含预览链接记录的ID、站点、页面、版本和过期时间，使用站点服务和渲染服务共享的密钥做HMAC-SHA256签名；
+// 签名只证明令牌由站点服务签发，链接是否已撤销、是否需要密码以记录为准
+package previewtoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalid 令牌格式错误或签名不匹配
+	ErrInvalid = errors.New("预览链接无效")
+	// ErrExpired 令牌已过期
+	ErrExpired = errors.New("预览链接已过期")
+)
+
+// Claims 令牌内容
+type Claims struct {
+	ID        string `json:"id"`
+	SiteID    string `json:"site"`
+	PageID    string `json:"page,omitempty"`
+	Version   int    `json:"ver,omitempty"`
+	ExpiresAt int64  `json:"exp"` // Unix时间戳，秒
+}
+
+// LoadSecret 读取PREVIEW_TOKEN_SECRET作为签名密钥。
+// 未设置时生成仅在本进程内有效的随机密钥，此时其他服务签发的预览链接无法通过校验
+func LoadSecret() []byte {
+	if secret := os.Getenv("PREVIEW_TOKEN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("生成预览链接密钥失败: %v", err)
+	}
+	log.Printf("未设置PREVIEW_TOKEN_SECRET，预览链接只能由本服务校验")
+	return secret
+}
+
+// Sign 签发令牌，格式为 base64url(内容).base64url(签名)
+func Sign(secret []byte, claims Claims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signature(secret, encoded)), nil
+}
+
+// Parse 校验令牌的签名和过期时间并返回令牌内容
+func Parse(secret []byte, token string) (Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrInvalid
+	}
+	expected, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(expected, signature(secret, encoded)) {
+		return Claims{}, ErrInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalid
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ID == "" || claims.SiteID == "" {
+		return Claims{}, ErrInvalid
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return Claims{}, ErrExpired
+	}
+	return claims, nil
+}
+
+// PasswordProof 访客输入正确密码后保存在Cookie中的凭证，与链接记录和密码哈希绑定，修改密码后旧凭证失效
+func PasswordProof(secret []byte, tokenID string, passwordHash string) string {
+	return base64.RawURLEncoding.EncodeToString(signature(secret, "password:"+tokenID+":"+passwordHash))
+}
+
+// CheckPasswordProof 校验Cookie中的密码凭证
+func CheckPasswordProof(secret []byte, tokenID string, passwordHash string, proof string) bool {
+	return hmac.Equal([]byte(proof), []byte(PasswordProof(secret, tokenID, passwordHash)))
+}
+
+func signature(secret []byte, message string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
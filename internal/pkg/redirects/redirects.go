@@ -0,0 +1,248 @@
+// Package redirects 校验和匹配站点的URL重定向规则。
+// 规则按精确匹配、前缀匹配、通配匹配的顺序生效，前缀规则中较长的来源优先，通配规则按添加顺序
+package redirects
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"wz-backend-go/models"
+)
+
+// 匹配方式
+const (
+	MatchExact    = "exact"    // 路径完全相同
+	MatchPrefix   = "prefix"   // 路径等于来源或以来源/开头，剩余部分追加到目标
+	MatchWildcard = "wildcard" // 来源中的*匹配任意字符，目标中的$1到$9引用匹配的内容
+)
+
+const (
+	maxSourceLength = 500
+	maxTargetLength = 1000
+)
+
+var captureRefPattern = regexp.MustCompile(`\$([1-9])`)
+
+// NormalizePath 规范化站内路径：以/开头，去掉末尾的/
+func NormalizePath(path string) string {
+	return "/" + strings.Trim(path, "/")
+}
+
+// Normalize 校验规则并填充默认值：匹配方式默认为exact，状态码默认为301，来源路径去掉末尾的/
+func Normalize(rule *models.SiteRedirect) error {
+	rule.Source = strings.TrimSpace(rule.Source)
+	rule.Target = strings.TrimSpace(rule.Target)
+	if rule.MatchType == "" {
+		rule.MatchType = MatchExact
+	}
+	if rule.StatusCode == 0 {
+		rule.StatusCode = 301
+	}
+
+	switch rule.MatchType {
+	case MatchExact, MatchPrefix, MatchWildcard:
+	default:
+		return errors.New("匹配方式只能是exact、prefix或wildcard")
+	}
+	if rule.StatusCode != 301 && rule.StatusCode != 302 {
+		return errors.New("状态码只能是301或302")
+	}
+
+	if err := checkSource(rule.Source, rule.MatchType); err != nil {
+		return err
+	}
+	rule.Source = NormalizePath(rule.Source)
+
+	if err := checkTarget(rule.Target, rule.MatchType); err != nil {
+		return err
+	}
+	if rule.MatchType == MatchExact && strings.HasPrefix(rule.Target, "/") && NormalizePath(rule.Target) == rule.Source {
+		return errors.New("目标不能与来源相同")
+	}
+	return nil
+}
+
+// checkSource 来源必须是不带查询参数的站内路径，只有通配规则可以包含*
+func checkSource(source string, matchType string) error {
+	switch {
+	case source == "":
+		return errors.New("来源路径不能为空")
+	case len(source) > maxSourceLength:
+		return errors.New("来源路径过长")
+	case !strings.HasPrefix(source, "/") || strings.HasPrefix(source, "//"):
+		return errors.New("来源必须是以/开头的站内路径")
+	case strings.ContainsAny(source, "?#"):
+		return errors.New("来源路径不能包含查询参数")
+	case hasSpaceOrControl(source):
+		return errors.New("来源路径不能包含空白字符")
+	}
+	wildcard := strings.Contains(source, "*")
+	if matchType == MatchWildcard && !wildcard {
+		return errors.New("通配规则的来源需要包含*")
+	}
+	if matchType != MatchWildcard && wildcard {
+		return errors.New("只有通配规则的来源可以包含*")
+	}
+	return nil
+}
+
+// checkTarget 目标必须是站内路径或http(s)地址，只有通配规则可以引用匹配的内容
+func checkTarget(target string, matchType string) error {
+	lower := strings.ToLower(target)
+	switch {
+	case target == "":
+		return errors.New("目标不能为空")
+	case len(target) > maxTargetLength:
+		return errors.New("目标过长")
+	case hasSpaceOrControl(target):
+		return errors.New("目标不能包含空白字符")
+	case strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//"):
+	case (strings.HasPrefix(lower, "https://") && len(target) > len("https://")) ||
+		(strings.HasPrefix(lower, "http://") && len(target) > len("http://")):
+	default:
+		return errors.New("目标必须是以/开头的站内路径或http(s)地址")
+	}
+	if matchType != MatchWildcard && captureRefPattern.MatchString(target) {
+		return errors.New("只有通配规则的目标可以使用$1等引用")
+	}
+	return nil
+}
+
+func hasSpaceOrControl(value string) bool {
+	for _, r := range value {
+		if r <= ' ' || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// Rules 按生效顺序排列并编译好的规则集，通配规则的正则表达式只在编译时生成一次。
+// 规则集创建后不再修改，可以在多个请求之间共用
+type Rules struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	rule    models.SiteRedirect
+	source  string
+	pattern *regexp.Regexp
+}
+
+// Compile 按优先级排列规则并编译通配规则
+func Compile(rules []models.SiteRedirect) *Rules {
+	sorted := ordered(rules)
+	compiled := make([]compiledRule, len(sorted))
+	for i, rule := range sorted {
+		compiled[i] = compiledRule{rule: rule, source: NormalizePath(rule.Source)}
+		if rule.MatchType == MatchWildcard {
+			compiled[i].pattern = wildcardPattern(compiled[i].source)
+		}
+	}
+	return &Rules{rules: compiled}
+}
+
+// Len 规则数量
+func (r *Rules) Len() int {
+	return len(r.rules)
+}
+
+// Match 按优先级查找匹配路径的规则，返回规则和跳转目标。
+// 目标是站内路径且会再次命中同一规则时视为循环，不会匹配
+func (r *Rules) Match(path string) (models.SiteRedirect, string, bool) {
+	path = NormalizePath(path)
+	for _, rule := range r.rules {
+		target, ok := rule.apply(path)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(target, "/") {
+			targetPath, _, _ := strings.Cut(target, "?")
+			targetPath = NormalizePath(targetPath)
+			if _, again := rule.apply(targetPath); again || targetPath == path {
+				continue
+			}
+		}
+		return rule.rule, target, true
+	}
+	return models.SiteRedirect{}, "", false
+}
+
+// Match 编译规则并查找匹配路径的规则，同一组规则需要多次匹配时使用Compile
+func Match(rules []models.SiteRedirect, path string) (models.SiteRedirect, string, bool) {
+	return Compile(rules).Match(path)
+}
+
+// ordered 按生效顺序排列规则：精确匹配，前缀匹配（来源较长的在前），通配匹配
+func ordered(rules []models.SiteRedirect) []models.SiteRedirect {
+	rank := map[string]int{MatchExact: 0, MatchPrefix: 1, MatchWildcard: 2}
+	result := append([]models.SiteRedirect(nil), rules...)
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if rank[a.MatchType] != rank[b.MatchType] {
+			return rank[a.MatchType] < rank[b.MatchType]
+		}
+		if a.MatchType == MatchPrefix {
+			return len(a.Source) > len(b.Source)
+		}
+		return false
+	})
+	return result
+}
+
+// apply 计算规则对路径的跳转目标，路径不匹配时返回false
+func (c compiledRule) apply(path string) (string, bool) {
+	rule := c.rule
+	source := c.source
+	switch rule.MatchType {
+	case MatchExact:
+		return rule.Target, path == source
+	case MatchPrefix:
+		if source == "/" {
+			return joinPath(rule.Target, strings.TrimPrefix(path, "/")), true
+		}
+		if path == source {
+			return rule.Target, true
+		}
+		if rest, ok := strings.CutPrefix(path, source+"/"); ok {
+			return joinPath(rule.Target, rest), true
+		}
+	case MatchWildcard:
+		matches := c.pattern.FindStringSubmatch(path)
+		if matches == nil {
+			return "", false
+		}
+		return captureRefPattern.ReplaceAllStringFunc(rule.Target, func(ref string) string {
+			index, _ := strconv.Atoi(ref[1:])
+			if index < len(matches) {
+				return matches[index]
+			}
+			return ""
+		}), true
+	}
+	return "", false
+}
+
+// wildcardPattern 把通配来源转换为正则表达式，每个*是一个捕获组
+func wildcardPattern(source string) *regexp.Regexp {
+	parts := strings.Split(source, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "(.*)") + "$")
+}
+
+// joinPath 把前缀规则匹配后剩余的路径追加到目标，目标中的查询参数保留在最后
+func joinPath(target string, rest string) string {
+	if rest == "" {
+		return target
+	}
+	base, query, hasQuery := strings.Cut(target, "?")
+	joined := strings.TrimSuffix(base, "/") + "/" + rest
+	if hasQuery {
+		joined += "?" + query
+	}
+	return joined
+}
@@ -0,0 +1,102 @@
+package redirects
+
+import (
+	"testing"
+	"wz-backend-go/models"
+)
+
+func TestMatch(t *testing.T) {
+	rules := []models.SiteRedirect{
+		{ID: "wildcard", Source: "/blog/*/*", Target: "/posts/$2?year=$1", MatchType: MatchWildcard},
+		{ID: "prefix-short", Source: "/docs", Target: "/help", MatchType: MatchPrefix},
+		{ID: "prefix-long", Source: "/docs/v1", Target: "https://old.example.com/manual?ref=site", MatchType: MatchPrefix},
+		{ID: "exact", Source: "/about-us", Target: "/about", MatchType: MatchExact},
+		{ID: "exact-docs", Source: "/docs/intro", Target: "/start", MatchType: MatchExact},
+		{ID: "unused-ref", Source: "/shop/*", Target: "/store/$1/$3", MatchType: MatchWildcard},
+	}
+
+	tests := []struct {
+		path   string
+		ruleID string
+		target string
+	}{
+		{"/about-us", "exact", "/about"},
+		{"about-us/", "exact", "/about"},
+		{"/about-us/team", "", ""},
+		{"/docs/intro", "exact-docs", "/start"},
+		{"/docs", "prefix-short", "/help"},
+		{"/docs/guide/install", "prefix-short", "/help/guide/install"},
+		{"/docs/v1/api", "prefix-long", "https://old.example.com/manual/api?ref=site"},
+		{"/docsx", "", ""},
+		{"/blog/2020/post", "wildcard", "/posts/post?year=2020"},
+		{"/blog/2020/05/post", "wildcard", "/posts/post?year=2020/05"},
+		{"/blog/2020", "", ""},
+		{"/shop/shoes", "unused-ref", "/store/shoes/"},
+	}
+	compiled := Compile(rules)
+	for _, tt := range tests {
+		rule, target, ok := compiled.Match(tt.path)
+		if tt.ruleID == "" {
+			if ok {
+				t.Errorf("%s 不应匹配，实际匹配%s", tt.path, rule.ID)
+			}
+			continue
+		}
+		if !ok || rule.ID != tt.ruleID || target != tt.target {
+			t.Errorf("%s 应由%s匹配到%s，实际为%s %s %v", tt.path, tt.ruleID, tt.target, rule.ID, target, ok)
+		}
+	}
+}
+
+func TestMatchSkipsLoops(t *testing.T) {
+	rules := []models.SiteRedirect{
+		{ID: "loop", Source: "/a", Target: "/a/b", MatchType: MatchPrefix},
+		{ID: "self", Source: "/x/*", Target: "/x/$1", MatchType: MatchWildcard},
+	}
+	for _, path := range []string{"/a", "/a/c", "/x/y"} {
+		if rule, target, ok := Match(rules, path); ok {
+			t.Errorf("%s 会循环跳转，不应匹配: %s %s", path, rule.ID, target)
+		}
+	}
+}
+
+func TestMatchRootPrefix(t *testing.T) {
+	rules := []models.SiteRedirect{{ID: "root", Source: "/", Target: "https://new.example.com", MatchType: MatchPrefix}}
+	_, target, ok := Match(rules, "/any/deep/path")
+	if !ok || target != "https://new.example.com/any/deep/path" {
+		t.Fatalf("根路径的前缀规则应匹配所有路径: %s %v", target, ok)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	rule := models.SiteRedirect{Source: " /old/ ", Target: "/new"}
+	if err := Normalize(&rule); err != nil {
+		t.Fatalf("有效规则校验失败: %v", err)
+	}
+	if rule.Source != "/old" || rule.MatchType != MatchExact || rule.StatusCode != 301 {
+		t.Fatalf("默认值错误: %+v", rule)
+	}
+
+	invalid := []models.SiteRedirect{
+		{Source: "", Target: "/new"},
+		{Source: "old", Target: "/new"},
+		{Source: "//evil.example.com", Target: "/new"},
+		{Source: "/old?x=1", Target: "/new"},
+		{Source: "/old page", Target: "/new"},
+		{Source: "/old/*", Target: "/new"},
+		{Source: "/old", Target: "/new", MatchType: MatchWildcard},
+		{Source: "/old", Target: "/new", MatchType: "regex"},
+		{Source: "/old", Target: "/new", StatusCode: 307},
+		{Source: "/old", Target: "javascript:alert(1)"},
+		{Source: "/old", Target: "//evil.example.com"},
+		{Source: "/old", Target: "https://"},
+		{Source: "/old", Target: "/new/$1"},
+		{Source: "/old", Target: "/old/"},
+	}
+	for _, rule := range invalid {
+		source, target := rule.Source, rule.Target
+		if err := Normalize(&rule); err == nil {
+			t.Errorf("来源%q目标%q应校验失败", source, target)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package rendercache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrCacheMiss 缓存不存在或已过期
+var ErrCacheMiss = errors.New("cache miss")
+
+// memoryBackend 进程内缓存存储
+type memoryBackend struct {
+	mu      sync.RWMutex
+	items   map[string][]byte
+	expires map[string]time.Time
+}
+
+// NewMemoryBackend 创建进程内缓存存储
+func NewMemoryBackend() Backend {
+	return &memoryBackend{
+		items:   map[string][]byte{},
+		expires: map[string]time.Time{},
+	}
+}
+
+func (b *memoryBackend) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.items[key] = data
+	if expiration > 0 {
+		b.expires[key] = time.Now().Add(expiration)
+	} else {
+		delete(b.expires, key)
+	}
+	return nil
+}
+
+func (b *memoryBackend) Get(ctx context.Context, key string, value interface{}) error {
+	b.mu.RLock()
+	data, ok := b.items[key]
+	expire, hasExpire := b.expires[key]
+	b.mu.RUnlock()
+
+	if !ok || (hasExpire && time.Now().After(expire)) {
+		return ErrCacheMiss
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (b *memoryBackend) DeleteByPrefix(ctx context.Context, prefix string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for key := range b.items {
+		// 顺便清理已过期的条目
+		expire, hasExpire := b.expires[key]
+		if strings.HasPrefix(key, prefix) || (hasExpire && now.After(expire)) {
+			delete(b.items, key)
+			delete(b.expires, key)
+		}
+	}
+	return nil
+}
+
+// redisBackend 基于Redis的缓存存储，多个服务共享同一份缓存
+type redisBackend struct {
+	client *redis.Client
+}
+
+// NewRedisBackend 创建Redis缓存存储
+func NewRedisBackend(client *redis.Client) Backend {
+	return &redisBackend{client: client}
+}
+
+func (b *redisBackend) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.client.Set(ctx, key, data, expiration).Err()
+}
+
+func (b *redisBackend) Get(ctx context.Context, key string, value interface{}) error {
+	data, err := b.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return ErrCacheMiss
+		}
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (b *redisBackend) DeleteByPrefix(ctx context.Context, prefix string) error {
+	var cursor uint64
+	for {
+		keys, next, err := b.client.Scan(ctx, cursor, prefix+"*", 100).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if err := b.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Open 根据环境变量创建渲染缓存，设置RENDER_CACHE_REDIS_ADDR时使用Redis，
+// 返回的shared表示缓存是否在服务之间共享
+func Open(ttl time.Duration) (cache *Cache, shared bool) {
+	addr := os.Getenv("RENDER_CACHE_REDIS_ADDR")
+	if addr == "" {
+		return New(NewMemoryBackend(), ttl), false
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv("RENDER_CACHE_REDIS_PASSWORD"),
+	})
+	return New(NewRedisBackend(client), ttl), true
+}
@@ -0,0 +1,136 @@
+// Package rendercache 缓存渲染后的站点页面HTML，并在站点数据变更时按范围失效
+package rendercache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+)
+
+// Backend 缓存存储，方法签名与service.CacheService一致，可以直接使用Redis缓存服务
+type Backend interface {
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string, value interface{}) error
+	DeleteByPrefix(ctx context.Context, prefix string) error
+}
+
+// Entry 缓存的渲染结果
+type Entry struct {
+	HTML         string    `json:"html"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// NewEntry 创建缓存条目并根据内容计算ETag
+func NewEntry(html string, lastModified time.Time) Entry {
+	sum := sha256.Sum256([]byte(html))
+	return Entry{
+		HTML:         html,
+		ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastModified: lastModified.UTC().Truncate(time.Second),
+	}
+}
+
+const keyPrefix = "render:"
+
+// DefaultTTL 渲染结果默认的最长缓存时间
+const DefaultTTL = time.Hour
+
+// PublishedKey 线上版本页面的缓存键，发布版本不可修改，版本号变化后自然使用新的键
+func PublishedKey(siteID string, version int, slug string, locale string, device string) string {
+	return fmt.Sprintf("%s%s:v%d:%s:%s:%s", keyPrefix, siteID, version, slug, locale, device)
+}
+
+// DraftKey 草稿预览页面的缓存键
+func DraftKey(siteID string, pageID string, locale string, device string) string {
+	return fmt.Sprintf("%s%s:draft:%s:%s:%s", keyPrefix, siteID, pageID, locale, device)
+}
+
+// RedirectsKey 站点重定向规则的标记键。渲染服务在进程内缓存编译后的规则并写入标记，
+// 规则、站点设置或线上版本变化时标记被删除，共享缓存时其他服务的修改也能使规则失效
+func RedirectsKey(siteID string) string {
+	return fmt.Sprintf("%s%s:redirects", keyPrefix, siteID)
+}
+
+// Cache 渲染结果缓存
+type Cache struct {
+	backend Backend
+	ttl     time.Duration
+}
+
+// New 创建渲染缓存，ttl为条目的最长保留时间
+func New(backend Backend, ttl time.Duration) *Cache {
+	return &Cache{backend: backend, ttl: ttl}
+}
+
+// Get 获取缓存条目
+func (c *Cache) Get(key string) (Entry, bool) {
+	var entry Entry
+	if err := c.backend.Get(context.Background(), key, &entry); err != nil {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Set 写入缓存条目，写入失败只记录日志
+func (c *Cache) Set(key string, entry Entry) {
+	c.SetTTL(key, entry, c.ttl)
+}
+
+// SetTTL 写入缓存条目并指定保留时间，用于包含实时数据、需要更早过期的页面，ttl不超过缓存的最长保留时间
+func (c *Cache) SetTTL(key string, entry Entry, ttl time.Duration) {
+	if ttl <= 0 || (c.ttl > 0 && ttl > c.ttl) {
+		ttl = c.ttl
+	}
+	if err := c.backend.Set(context.Background(), key, entry, ttl); err != nil {
+		log.Printf("写入渲染缓存%s失败: %v", key, err)
+	}
+}
+
+// GetStamp 获取标记的值，用于判断进程内缓存的数据是否仍然有效
+func (c *Cache) GetStamp(key string) (string, bool) {
+	var stamp string
+	if err := c.backend.Get(context.Background(), key, &stamp); err != nil {
+		return "", false
+	}
+	return stamp, true
+}
+
+// SetStamp 写入标记，保留时间为缓存的最长保留时间
+func (c *Cache) SetStamp(key string, stamp string) {
+	if err := c.backend.Set(context.Background(), key, stamp, c.ttl); err != nil {
+		log.Printf("写入缓存标记%s失败: %v", key, err)
+	}
+}
+
+// Invalidate 根据变更事件删除受影响的缓存条目。站点设置、线上版本和重定向规则的变化都会影响重定向的匹配
+func (c *Cache) Invalidate(event builder.ChangeEvent) {
+	var prefixes []string
+	switch event.Scope {
+	case builder.ScopePage:
+		prefixes = []string{fmt.Sprintf("%s%s:draft:%s:", keyPrefix, event.SiteID, event.PageID)}
+	case builder.ScopeSite:
+		prefixes = []string{fmt.Sprintf("%s%s:draft:", keyPrefix, event.SiteID), RedirectsKey(event.SiteID)}
+	case builder.ScopePublish:
+		prefixes = []string{fmt.Sprintf("%s%s:v", keyPrefix, event.SiteID), RedirectsKey(event.SiteID)}
+	case builder.ScopeRedirect:
+		prefixes = []string{RedirectsKey(event.SiteID)}
+	default:
+		return
+	}
+
+	for _, prefix := range prefixes {
+		if err := c.backend.DeleteByPrefix(context.Background(), prefix); err != nil {
+			log.Printf("清除渲染缓存%s失败: %v", prefix, err)
+		}
+	}
+}
+
+// Attach 订阅存储的变更事件，数据变化时自动失效缓存
+func (c *Cache) Attach(store *builder.Store) {
+	store.Subscribe(c.Invalidate)
+}
@@ -0,0 +1,172 @@
+// Package responsive 解析区块和组件按设备覆盖的样式、设置和显示状态。
+// Style和Settings中以设备名（desktop、tablet、mobile）为键的对象覆盖该设备下的同名字段，
+// Settings中的hidden（如{"mobile": true}）表示在对应设备上不显示
+package responsive
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"wz-backend-go/internal/pkg/jsonschema"
+)
+
+// 设备类型，与预览接口的device参数一致
+const (
+	Desktop = "desktop"
+	Tablet  = "tablet"
+	Mobile  = "mobile"
+)
+
+// HiddenKey Settings中控制各设备显示状态的字段
+const HiddenKey = "hidden"
+
+// Devices 所有设备类型，从宽到窄
+var Devices = []string{Desktop, Tablet, Mobile}
+
+// mediaQueries 设备对应的媒体查询，断点与预览容器宽度对应
+var mediaQueries = map[string]string{
+	Desktop: "(min-width: 1024px)",
+	Tablet:  "(min-width: 768px) and (max-width: 1023.98px)",
+	Mobile:  "(max-width: 767.98px)",
+}
+
+// MediaQuery 设备对应的媒体查询条件
+func MediaQuery(device string) string {
+	return mediaQueries[device]
+}
+
+// IsDevice 判断是否为支持的设备类型
+func IsDevice(name string) bool {
+	_, ok := mediaQueries[name]
+	return ok
+}
+
+// Normalize 规范化设备类型，未知的取值视为desktop
+func Normalize(device string) string {
+	if IsDevice(device) {
+		return device
+	}
+	return Desktop
+}
+
+// Split 拆分出所有设备共用的基础值和各设备的覆盖值，基础值不包含hidden
+func Split(values map[string]interface{}) (base map[string]interface{}, overrides map[string]map[string]interface{}) {
+	base = make(map[string]interface{}, len(values))
+	overrides = map[string]map[string]interface{}{}
+	for key, value := range values {
+		if key == HiddenKey {
+			continue
+		}
+		if IsDevice(key) {
+			if override, ok := value.(map[string]interface{}); ok && len(override) > 0 {
+				overrides[key] = override
+			}
+			continue
+		}
+		base[key] = value
+	}
+	return base, overrides
+}
+
+// Resolve 指定设备下生效的值，即基础值加上该设备的覆盖值
+func Resolve(values map[string]interface{}, device string) map[string]interface{} {
+	base, overrides := Split(values)
+	for key, value := range overrides[device] {
+		base[key] = value
+	}
+	return base
+}
+
+// Hidden 判断是否在指定设备上隐藏
+func Hidden(settings map[string]interface{}, device string) bool {
+	hidden, _ := settings[HiddenKey].(map[string]interface{})
+	value, _ := hidden[device].(bool)
+	return value
+}
+
+// HiddenDevices 隐藏的设备，按Devices的顺序
+func HiddenDevices(settings map[string]interface{}) []string {
+	var devices []string
+	for _, device := range Devices {
+		if Hidden(settings, device) {
+			devices = append(devices, device)
+		}
+	}
+	return devices
+}
+
+// Variant 设置相同的一组设备
+type Variant struct {
+	Devices  []string
+	Settings map[string]interface{}
+}
+
+// Variants 按生效的设置把未隐藏的设备分组，设置没有按设备覆盖时只有一组
+func Variants(settings map[string]interface{}) []Variant {
+	var variants []Variant
+	for _, device := range Devices {
+		if Hidden(settings, device) {
+			continue
+		}
+		resolved := Resolve(settings, device)
+		matched := false
+		for i := range variants {
+			if reflect.DeepEqual(variants[i].Settings, resolved) {
+				variants[i].Devices = append(variants[i].Devices, device)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			variants = append(variants, Variant{Devices: []string{device}, Settings: resolved})
+		}
+	}
+	return variants
+}
+
+// Validate 检查按设备覆盖的结构：设备键的值必须是对象；allowHidden为true时允许hidden，
+// 其值必须是设备名到布尔值的对象。字段本身的取值由调用方校验
+func Validate(field string, value interface{}, allowHidden bool) []jsonschema.FieldError {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var errs []jsonschema.FieldError
+	for _, device := range Devices {
+		if override, exists := values[device]; exists {
+			if _, ok := override.(map[string]interface{}); !ok {
+				errs = append(errs, jsonschema.FieldError{Field: field + "." + device, Message: "设备覆盖必须是对象"})
+			}
+		}
+	}
+
+	hidden, exists := values[HiddenKey]
+	if !exists {
+		return errs
+	}
+	if !allowHidden {
+		return append(errs, jsonschema.FieldError{Field: field + "." + HiddenKey, Message: "不支持的字段"})
+	}
+	hiddenMap, ok := hidden.(map[string]interface{})
+	if !ok {
+		return append(errs, jsonschema.FieldError{Field: field + "." + HiddenKey, Message: "必须是设备到布尔值的对象"})
+	}
+	devices := make([]string, 0, len(hiddenMap))
+	for device := range hiddenMap {
+		devices = append(devices, device)
+	}
+	sort.Strings(devices)
+	for _, device := range devices {
+		flag := hiddenMap[device]
+		switch {
+		case !IsDevice(device):
+			errs = append(errs, jsonschema.FieldError{Field: field + "." + HiddenKey + "." + device, Message: fmt.Sprintf("未知的设备类型，可选值: %s, %s, %s", Desktop, Tablet, Mobile)})
+		default:
+			if _, ok := flag.(bool); !ok {
+				errs = append(errs, jsonschema.FieldError{Field: field + "." + HiddenKey + "." + device, Message: "必须是布尔值"})
+			}
+		}
+	}
+	return errs
+}
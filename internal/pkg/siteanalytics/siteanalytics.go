@@ -0,0 +1,223 @@
+// Package siteanalytics 计算站点的访问统计：不使用Cookie的访客标识、设备识别，
+// 以及把公开页面上报的原始事件汇总为按小时、按天的统计。
+// 访客标识由每天更换的盐、站点、IP和User-Agent计算，同一访客在不同日期和不同站点的标识不同，无法跨天追踪
+package siteanalytics
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// 事件类型
+const (
+	EventPageview = "pageview"
+	EventClick    = "click"
+)
+
+// 统计指标
+const (
+	MetricPV    = "pv"    // 浏览量
+	MetricUV    = "uv"    // 独立访客数
+	MetricClick = "click" // 按钮和链接组件的点击量
+)
+
+// 分组维度
+const (
+	DimensionReferrer  = "referrer"  // 按来源域名统计浏览量
+	DimensionDevice    = "device"    // 按设备类型统计浏览量
+	DimensionComponent = "component" // 按组件统计点击量
+)
+
+// 设备类型
+const (
+	DeviceDesktop = "desktop"
+	DeviceTablet  = "tablet"
+	DeviceMobile  = "mobile"
+	DeviceBot     = "bot"
+)
+
+// maxReferrerLength 来源域名的最大长度，与AnalyticsRollup.Value的长度一致
+const maxReferrerLength = 255
+
+// LoadSecret 读取ANALYTICS_SECRET作为计算访客标识的密钥。
+// 未设置时生成仅在本进程内有效的随机密钥，多个实例或重启后同一访客会被计为不同访客
+func LoadSecret() []byte {
+	if secret := os.Getenv("ANALYTICS_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("生成访客标识密钥失败: %v", err)
+	}
+	log.Printf("未设置ANALYTICS_SECRET，独立访客只在本实例内去重")
+	return secret
+}
+
+// VisitorHash 计算访客在某一天访问某个站点的标识，不保存原始IP
+func VisitorHash(secret []byte, siteID string, ip string, userAgent string, at time.Time) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(at.In(time.Local).Format("2006-01-02")))
+	for _, part := range []string{siteID, ip, userAgent} {
+		mac.Write([]byte{0})
+		mac.Write([]byte(part))
+	}
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// botKeywords 爬虫和监控程序User-Agent中常见的关键字
+var botKeywords = []string{"bot", "spider", "crawl", "slurp", "headless", "lighthouse", "curl", "wget", "python-requests", "go-http-client"}
+
+// DetectDevice 根据User-Agent判断设备类型，无法判断时视为桌面设备
+func DetectDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return DeviceBot
+	}
+	for _, keyword := range botKeywords {
+		if strings.Contains(ua, keyword) {
+			return DeviceBot
+		}
+	}
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		return DeviceMobile
+	}
+	return DeviceDesktop
+}
+
+// ReferrerHost 取来源地址的域名，去掉www.前缀。
+// 来源无效、不是http(s)地址或与当前页面同一域名（站内跳转）时返回空
+func ReferrerHost(referrer string, pageHost string) string {
+	u, err := url.Parse(strings.TrimSpace(referrer))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	host := normalizeHost(u.Host)
+	if host == "" || host == normalizeHost(pageHost) || len(host) > maxReferrerLength {
+		return ""
+	}
+	return host
+}
+
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// PeriodStart 计算时间所在汇总时段的开始时间，日期按服务所在时区划分
+func PeriodStart(t time.Time, granularity string) time.Time {
+	t = t.In(time.Local)
+	if granularity == builder.GranularityDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, time.Local)
+}
+
+// NextPeriod 计算下一个汇总时段的开始时间
+func NextPeriod(start time.Time, granularity string) time.Time {
+	if granularity == builder.GranularityDay {
+		return start.AddDate(0, 0, 1)
+	}
+	return start.Add(time.Hour)
+}
+
+// rollupKey 汇总的分组
+type rollupKey struct {
+	pageID    string
+	metric    string
+	dimension string
+	value     string
+}
+
+// Rollup 把站点的事件按粒度汇总，返回每个时段的汇总。
+// 每个时段包含站点总量和每个页面的总量，以及站点和页面各自的来源、设备和组件分组；
+// 独立访客在时段内去重，因此按天的访客数不等于各小时之和
+func Rollup(siteID string, events []models.PageEvent, granularity string, now time.Time) map[time.Time][]models.AnalyticsRollup {
+	type period struct {
+		counts   map[rollupKey]int64
+		visitors map[rollupKey]map[string]bool
+	}
+	periods := map[time.Time]*period{}
+
+	for _, event := range events {
+		start := PeriodStart(event.CreatedAt, granularity)
+		p := periods[start]
+		if p == nil {
+			p = &period{counts: map[rollupKey]int64{}, visitors: map[rollupKey]map[string]bool{}}
+			periods[start] = p
+		}
+		// 站点和页面两个范围各统计一次
+		for _, pageID := range []string{"", event.PageID} {
+			switch event.Type {
+			case EventPageview:
+				p.counts[rollupKey{pageID: pageID, metric: MetricPV}]++
+				uv := rollupKey{pageID: pageID, metric: MetricUV}
+				if p.visitors[uv] == nil {
+					p.visitors[uv] = map[string]bool{}
+				}
+				p.visitors[uv][event.VisitorHash] = true
+				if event.Referrer != "" {
+					p.counts[rollupKey{pageID: pageID, metric: MetricPV, dimension: DimensionReferrer, value: event.Referrer}]++
+				}
+				p.counts[rollupKey{pageID: pageID, metric: MetricPV, dimension: DimensionDevice, value: event.Device}]++
+			case EventClick:
+				p.counts[rollupKey{pageID: pageID, metric: MetricClick}]++
+				if event.ComponentID != "" {
+					p.counts[rollupKey{pageID: pageID, metric: MetricClick, dimension: DimensionComponent, value: event.ComponentID}]++
+				}
+			}
+		}
+	}
+
+	result := make(map[time.Time][]models.AnalyticsRollup, len(periods))
+	for start, p := range periods {
+		for key, visitors := range p.visitors {
+			p.counts[key] = int64(len(visitors))
+		}
+		rollups := make([]models.AnalyticsRollup, 0, len(p.counts))
+		for key, count := range p.counts {
+			rollups = append(rollups, models.AnalyticsRollup{
+				SiteID:      siteID,
+				Granularity: granularity,
+				PeriodStart: start,
+				PageID:      key.pageID,
+				Metric:      key.metric,
+				Dimension:   key.dimension,
+				Value:       key.value,
+				Count:       count,
+				UpdatedAt:   now,
+			})
+		}
+		sort.Slice(rollups, func(i, j int) bool {
+			a, b := rollups[i], rollups[j]
+			if a.PageID != b.PageID {
+				return a.PageID < b.PageID
+			}
+			if a.Metric != b.Metric {
+				return a.Metric < b.Metric
+			}
+			if a.Dimension != b.Dimension {
+				return a.Dimension < b.Dimension
+			}
+			return a.Value < b.Value
+		})
+		result[start] = rollups
+	}
+	return result
+}
@@ -0,0 +1,398 @@
+// Package siteaudit 在发布前检查站点的失效链接和无障碍问题：导航和按钮指向不存在的页面、
+// 图片缺少替代文本、标题级别跳级、文字与背景颜色对比度不足、空区块、重复的页面标题，
+// 以及通过可替换的LinkChecker检查外部链接是否可以访问
+package siteaudit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/globalsection"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/models"
+)
+
+// 问题的严重程度，存在error级别的问题时发布检查不通过
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// 问题类型
+const (
+	CodeBrokenLink         = "broken-link"          // 站内链接指向不存在的页面
+	CodeBrokenExternalLink = "broken-external-link" // 外部链接无法访问
+	CodeMissingAlt         = "missing-alt"          // 图片缺少替代文本
+	CodeHeadingSkip        = "heading-skip"         // 标题级别跳级，如h2之后直接是h4
+	CodeLowContrast        = "low-contrast"         // 文字与背景颜色的对比度不足
+	CodeEmptySection       = "empty-section"        // 区块没有组件
+	CodeDuplicateTitle     = "duplicate-title"      // 多个页面的标题相同
+)
+
+// 文字与背景的对比度要求，低于minContrast为警告，低于minLargeContrast（大号文字的要求）为错误
+const (
+	minContrast      = 4.5
+	minLargeContrast = 3.0
+)
+
+// Issue 检查发现的问题，位置用页面、区块和组件ID表示，全局区块中的问题PageID为空
+type Issue struct {
+	Code        string `json:"code"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Path        string `json:"path"` // 如 pages[page1].sections[section1].components[comp1].content.alt
+	PageID      string `json:"pageId,omitempty"`
+	SectionID   string `json:"sectionId,omitempty"`
+	ComponentID string `json:"componentId,omitempty"`
+	Value       string `json:"value,omitempty"` // 相关的值，如链接地址、颜色
+}
+
+// Report 站点检查报告
+type Report struct {
+	SiteID    string    `json:"siteId"`
+	CheckedAt time.Time `json:"checkedAt"`
+	Errors    int       `json:"errors"`
+	Warnings  int       `json:"warnings"`
+	Issues    []Issue   `json:"issues"`
+}
+
+// Passed 报告中没有error级别的问题
+func (r Report) Passed() bool {
+	return r.Errors == 0
+}
+
+// location 问题所在的位置
+type location struct {
+	path        string
+	pageID      string
+	sectionID   string
+	componentID string
+}
+
+// auditor 一次检查的状态
+type auditor struct {
+	site     models.Site
+	slugs    map[string]bool
+	homepage bool
+	issues   []Issue
+	// external 外部链接及其出现的位置，按首次出现的顺序检查
+	external      map[string][]location
+	externalOrder []string
+}
+
+// Audit 检查站点树，site需要包含页面、区块、组件和全局区块。
+// checker为空时不检查外部链接
+func Audit(ctx context.Context, site models.Site, checker LinkChecker) Report {
+	a := &auditor{
+		site:     site,
+		slugs:    map[string]bool{},
+		external: map[string][]location{},
+	}
+	for _, page := range site.Pages {
+		a.slugs[strings.ToLower(page.Slug)] = true
+		if page.IsHomepage {
+			a.homepage = true
+		}
+	}
+
+	a.checkContrast()
+	a.checkNavigation("navigation.items", site.Navigation.Items)
+	a.checkTitles()
+	for _, page := range site.Pages {
+		a.checkPage(page)
+	}
+	for _, section := range site.GlobalSections {
+		a.checkSection(location{path: fmt.Sprintf("globalSections[%s]", section.ID), sectionID: section.ID}, section)
+	}
+	if checker != nil {
+		a.checkExternalLinks(ctx, checker)
+	}
+
+	report := Report{SiteID: site.ID, CheckedAt: time.Now(), Issues: a.issues}
+	if report.Issues == nil {
+		report.Issues = []Issue{}
+	}
+	for _, issue := range report.Issues {
+		if issue.Severity == SeverityError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+	return report
+}
+
+func (a *auditor) add(at location, code string, severity string, value string, message string) {
+	a.issues = append(a.issues, Issue{
+		Code:        code,
+		Severity:    severity,
+		Message:     message,
+		Path:        at.path,
+		PageID:      at.pageID,
+		SectionID:   at.sectionID,
+		ComponentID: at.componentID,
+		Value:       value,
+	})
+}
+
+// checkContrast 检查主题的文字颜色与背景颜色的对比度，启用深色模式时同时检查深色模式的颜色
+func (a *auditor) checkContrast() {
+	theme := sitetheme.Resolve(a.site.Theme)
+	type colorPair struct {
+		path       string
+		text       string
+		background string
+	}
+	pairs := []colorPair{{"theme.textColor", theme.TextColor, theme.BackgroundColor}}
+	if theme.DarkMode != sitetheme.DarkModeOff {
+		pairs = append(pairs, colorPair{"theme.darkTextColor", theme.DarkTextColor, theme.DarkBackgroundColor})
+	}
+
+	for _, pair := range pairs {
+		ratio, ok := ContrastRatio(pair.text, pair.background)
+		if !ok || ratio >= minContrast {
+			continue
+		}
+		severity := SeverityWarning
+		if ratio < minLargeContrast {
+			severity = SeverityError
+		}
+		a.add(location{path: pair.path}, CodeLowContrast, severity, pair.text+" / "+pair.background,
+			fmt.Sprintf("文字与背景颜色的对比度为%.2f:1，低于%.1f:1", ratio, minContrast))
+	}
+}
+
+// checkNavigation 检查导航项的链接，包括子菜单
+func (a *auditor) checkNavigation(path string, items []models.NavigationItem) {
+	for i, item := range items {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		if item.PageID != "" {
+			if !a.hasPage(item.PageID) {
+				a.add(location{path: itemPath + ".pageId"}, CodeBrokenLink, SeverityError, item.PageID, "导航项指向的页面不存在")
+			}
+		} else {
+			a.checkLink(location{path: itemPath + ".link"}, item.Link)
+		}
+		a.checkNavigation(itemPath+".children", item.Children)
+	}
+}
+
+// hasPage 判断站点是否有指定ID的页面
+func (a *auditor) hasPage(pageID string) bool {
+	for _, page := range a.site.Pages {
+		if page.ID == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTitles 检查重复的页面标题，未设置标题的页面使用页面名称
+func (a *auditor) checkTitles() {
+	first := map[string]models.Page{}
+	for _, page := range a.site.Pages {
+		title := strings.TrimSpace(page.Title)
+		if title == "" {
+			title = strings.TrimSpace(page.Name)
+		}
+		if title == "" {
+			continue
+		}
+		key := strings.ToLower(title)
+		if existing, ok := first[key]; ok {
+			a.add(location{path: fmt.Sprintf("pages[%s].title", page.ID), pageID: page.ID}, CodeDuplicateTitle, SeverityWarning, title,
+				fmt.Sprintf("页面标题与页面%s（%s）相同", existing.Name, existing.ID))
+			continue
+		}
+		first[key] = page
+	}
+}
+
+// checkPage 检查页面的区块和标题层级。引用全局区块的区块由全局区块统一检查内容，
+// 但仍按解析后的内容参与页面的标题层级检查
+func (a *auditor) checkPage(page models.Page) {
+	pagePath := fmt.Sprintf("pages[%s]", page.ID)
+	// 页面没有h1时区块标题从h2开始，视为从1级开始
+	previous := 1
+	for _, section := range page.Sections {
+		at := location{path: fmt.Sprintf("%s.sections[%s]", pagePath, section.ID), pageID: page.ID, sectionID: section.ID}
+		resolved := section
+		if section.GlobalSectionID != "" {
+			target, err := globalsection.ResolveSection(a.site.GlobalSections, section)
+			if err != nil {
+				continue
+			}
+			resolved = target
+		} else {
+			a.checkSection(at, section)
+		}
+
+		// 区块标题渲染为h2
+		if strings.TrimSpace(resolved.Title) != "" {
+			previous = a.checkHeading(location{path: at.path + ".title", pageID: page.ID, sectionID: section.ID}, previous, 2)
+		}
+		for _, component := range resolved.Components {
+			if component.Type != "heading" {
+				continue
+			}
+			componentAt := location{path: fmt.Sprintf("%s.components[%s].settings.level", at.path, component.ID), pageID: page.ID, sectionID: section.ID, componentID: component.ID}
+			previous = a.checkHeading(componentAt, previous, headingLevel(component))
+		}
+	}
+}
+
+// checkHeading 检查标题级别没有跳级，返回当前的级别
+func (a *auditor) checkHeading(at location, previous int, level int) int {
+	if level > previous+1 {
+		a.add(at, CodeHeadingSkip, SeverityWarning, fmt.Sprintf("h%d", level),
+			fmt.Sprintf("标题从h%d跳到h%d，屏幕阅读器用户可能会错过内容结构", previous, level))
+	}
+	return level
+}
+
+// headingLevel 标题组件的级别，未设置时与组件定义的默认值一致为h2
+func headingLevel(component models.Component) int {
+	settings, _ := component.Settings.(map[string]interface{})
+	level, _ := settings["level"].(string)
+	if len(level) == 2 && level[0] == 'h' && level[1] >= '1' && level[1] <= '6' {
+		return int(level[1] - '0')
+	}
+	return 2
+}
+
+// checkSection 检查区块是否为空以及组件中的图片和链接
+func (a *auditor) checkSection(at location, section models.Section) {
+	if len(section.Components) == 0 {
+		a.add(at, CodeEmptySection, SeverityWarning, section.Title, "区块没有任何组件")
+	}
+	for _, component := range section.Components {
+		componentAt := location{
+			path:        fmt.Sprintf("%s.components[%s]", at.path, component.ID),
+			pageID:      at.pageID,
+			sectionID:   section.ID,
+			componentID: component.ID,
+		}
+		a.checkImages(componentAt, component)
+		a.checkContentLinks(componentAt, "content", component.Content)
+	}
+}
+
+// checkImages 检查图片、轮播图和图库的替代文本
+func (a *auditor) checkImages(at location, component models.Component) {
+	content, _ := component.Content.(map[string]interface{})
+	missingAlt := func(field string, image map[string]interface{}) {
+		alt, _ := image["alt"].(string)
+		if strings.TrimSpace(alt) != "" {
+			return
+		}
+		src, _ := image["src"].(string)
+		a.add(location{path: at.path + "." + field, pageID: at.pageID, sectionID: at.sectionID, componentID: at.componentID},
+			CodeMissingAlt, SeverityWarning, src, "图片缺少替代文本")
+	}
+
+	switch component.Type {
+	case "image":
+		if content != nil {
+			missingAlt("content.alt", content)
+		}
+	case "carousel", "gallery":
+		images, _ := content["images"].([]interface{})
+		for i, item := range images {
+			if image, ok := item.(map[string]interface{}); ok {
+				missingAlt(fmt.Sprintf("content.images[%d].alt", i), image)
+			}
+		}
+	}
+}
+
+// checkContentLinks 检查组件内容中名为link或href的字段
+func (a *auditor) checkContentLinks(at location, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := v[key]
+			fieldPath := path + "." + key
+			if link, ok := item.(string); ok && (key == "link" || key == "href") {
+				a.checkLink(location{path: at.path + "." + fieldPath, pageID: at.pageID, sectionID: at.sectionID, componentID: at.componentID}, link)
+				continue
+			}
+			a.checkContentLinks(at, fieldPath, item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			a.checkContentLinks(at, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	}
+}
+
+// checkLink 检查单个链接：站内链接必须指向存在的页面，外部链接留到最后统一检查
+func (a *auditor) checkLink(at location, link string) {
+	link = strings.TrimSpace(link)
+	if link == "" || strings.HasPrefix(link, "#") {
+		return
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		a.add(at, CodeBrokenLink, SeverityError, link, "链接格式无效")
+		return
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		if u.Host == "" {
+			a.add(at, CodeBrokenLink, SeverityError, link, "链接格式无效")
+			return
+		}
+		if !a.siteHost(u.Hostname()) {
+			if _, seen := a.external[link]; !seen {
+				a.externalOrder = append(a.externalOrder, link)
+			}
+			a.external[link] = append(a.external[link], at)
+			return
+		}
+	case "":
+		if u.Host != "" {
+			// 省略协议的外部地址，如//example.com/page
+			return
+		}
+	default:
+		// mailto:、tel:等不是页面地址
+		return
+	}
+
+	if !a.pageExists(u.Path) {
+		a.add(at, CodeBrokenLink, SeverityError, link, "链接指向的页面不存在")
+	}
+}
+
+// siteHost 判断域名是否为站点自己的域名
+func (a *auditor) siteHost(hostname string) bool {
+	return a.site.Domain != "" && strings.EqualFold(a.site.Domain, hostname)
+}
+
+// pageExists 判断站内路径是否对应站点的页面。路径可以带/render/sites/<站点ID>前缀和语言前缀，
+// 空路径为首页；不带/的相对路径视为页面slug
+func (a *auditor) pageExists(pagePath string) bool {
+	pagePath = strings.TrimPrefix(pagePath, "/render/sites/"+a.site.ID)
+	parts := strings.SplitN(strings.Trim(pagePath, "/"), "/", 2)
+	if prefix := sitelocale.Normalize(parts[0]); prefix != "" && sitelocale.IsEnabled(a.site, prefix) {
+		if len(parts) == 1 {
+			return a.homepage
+		}
+		parts = parts[1:]
+	}
+	slug := strings.ToLower(strings.Trim(strings.Join(parts, "/"), "/"))
+	if slug == "" {
+		return a.homepage
+	}
+	return a.slugs[slug]
+}
@@ -0,0 +1,193 @@
+package siteaudit
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"wz-backend-go/models"
+)
+
+// fakeChecker 按预设结果检查外部链接，并记录每个链接的检查次数
+type fakeChecker struct {
+	mu      sync.Mutex
+	results map[string]error
+	checked map[string]int
+}
+
+func (c *fakeChecker) Check(_ context.Context, link string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked[link]++
+	return c.results[link]
+}
+
+func auditSite() models.Site {
+	button := func(id string, link string) models.Component {
+		return models.Component{ID: id, Type: "button", Content: map[string]interface{}{"text": "查看", "link": link}}
+	}
+	return models.Site{
+		ID:            "s1",
+		Domain:        "www.example.com",
+		Locales:       []string{"zh-CN", "en"},
+		DefaultLocale: "zh-CN",
+		Theme: models.ThemeConfig{
+			TextColor:           "#777777",
+			BackgroundColor:     "#ffffff",
+			DarkMode:            "on",
+			DarkTextColor:       "#333333",
+			DarkBackgroundColor: "#222222",
+		},
+		Navigation: models.Navigation{Items: []models.NavigationItem{
+			{ID: "n1", PageID: "home"},
+			{ID: "n2", PageID: "gone"},
+			{ID: "n3", Link: "/en/about", Children: []models.NavigationItem{
+				{ID: "n4", Link: "/missing"},
+				{ID: "n5", Link: "mailto:hi@example.com"},
+				{ID: "n6", Link: "#contact"},
+			}},
+		}},
+		Pages: []models.Page{
+			{
+				ID: "home", Slug: "home", Title: "首页", IsHomepage: true,
+				Sections: []models.Section{
+					{ID: "sec1", Title: "介绍", Components: []models.Component{
+						{ID: "h", Type: "heading", Settings: map[string]interface{}{"level": "h4"}, Content: map[string]interface{}{"text": "小标题"}},
+						{ID: "img", Type: "image", Content: map[string]interface{}{"src": "/media/a.png", "alt": " "}},
+						button("btn", "https://ext.example.org/a"),
+						{ID: "txt", Type: "text", Content: map[string]interface{}{"items": []interface{}{
+							map[string]interface{}{"href": "/render/sites/s1/en"},
+							map[string]interface{}{"href": "https://www.example.com/About"},
+							map[string]interface{}{"href": "//cdn.example.org/x"},
+						}}},
+					}},
+					{ID: "sec2"},
+				},
+			},
+			{
+				ID: "about", Slug: "about", Name: "关于", Title: "首页",
+				Sections: []models.Section{
+					{ID: "sec3", GlobalSectionID: "g1"},
+					{ID: "sec4", GlobalSectionID: "nope"},
+				},
+			},
+		},
+		GlobalSections: []models.Section{
+			{ID: "g1", Title: "页脚", Components: []models.Component{
+				{ID: "gal", Type: "gallery", Content: map[string]interface{}{"images": []interface{}{
+					map[string]interface{}{"src": "/media/b.png"},
+					map[string]interface{}{"src": "/media/c.png", "alt": "产品"},
+				}}},
+				button("btn2", "https://ext.example.org/a"),
+				button("btn3", "https://gone.example.org/"),
+			}},
+		},
+	}
+}
+
+func TestAudit(t *testing.T) {
+	checker := &fakeChecker{
+		results: map[string]error{
+			"https://ext.example.org/a": &StatusError{StatusCode: 503},
+			"https://gone.example.org/": &StatusError{StatusCode: 404},
+		},
+		checked: map[string]int{},
+	}
+	report := Audit(context.Background(), auditSite(), checker)
+
+	type found struct {
+		code     string
+		severity string
+		path     string
+	}
+	var issues []found
+	for _, issue := range report.Issues {
+		issues = append(issues, found{issue.Code, issue.Severity, issue.Path})
+	}
+	want := []found{
+		{CodeLowContrast, SeverityWarning, "theme.textColor"},
+		{CodeLowContrast, SeverityError, "theme.darkTextColor"},
+		{CodeBrokenLink, SeverityError, "navigation.items[1].pageId"},
+		{CodeBrokenLink, SeverityError, "navigation.items[2].children[0].link"},
+		{CodeDuplicateTitle, SeverityWarning, "pages[about].title"},
+		{CodeMissingAlt, SeverityWarning, "pages[home].sections[sec1].components[img].content.alt"},
+		{CodeHeadingSkip, SeverityWarning, "pages[home].sections[sec1].components[h].settings.level"},
+		{CodeEmptySection, SeverityWarning, "pages[home].sections[sec2]"},
+		{CodeMissingAlt, SeverityWarning, "globalSections[g1].components[gal].content.images[0].alt"},
+		{CodeBrokenExternalLink, SeverityWarning, "pages[home].sections[sec1].components[btn].content.link"},
+		{CodeBrokenExternalLink, SeverityWarning, "globalSections[g1].components[btn2].content.link"},
+		{CodeBrokenExternalLink, SeverityError, "globalSections[g1].components[btn3].content.link"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Fatalf("问题为\n%v\n期望\n%v", issues, want)
+	}
+	if report.SiteID != "s1" || report.Errors != 4 || report.Warnings != 8 || report.Passed() {
+		t.Fatalf("报告统计为%d个错误、%d个警告", report.Errors, report.Warnings)
+	}
+
+	missingAlt := report.Issues[5]
+	if missingAlt.PageID != "home" || missingAlt.SectionID != "sec1" || missingAlt.ComponentID != "img" || missingAlt.Value != "/media/a.png" {
+		t.Fatalf("问题位置为%+v", missingAlt)
+	}
+	if global := report.Issues[8]; global.PageID != "" || global.SectionID != "g1" || global.ComponentID != "gal" {
+		t.Fatalf("全局区块中问题的位置为%+v", global)
+	}
+	wantChecked := map[string]int{"https://ext.example.org/a": 1, "https://gone.example.org/": 1}
+	if !reflect.DeepEqual(checker.checked, wantChecked) {
+		t.Fatalf("外部链接的检查次数为%v", checker.checked)
+	}
+}
+
+func TestAuditWithoutChecker(t *testing.T) {
+	site := auditSite()
+	site.Theme = models.ThemeConfig{}
+	site.Navigation.Items = nil
+	site.GlobalSections = nil
+	site.Pages[1].Title = "关于我们"
+	site.Pages[0].Sections = site.Pages[0].Sections[:1]
+	site.Pages[0].Sections[0].Components[0].Settings = map[string]interface{}{"level": "h3"}
+	site.Pages[0].Sections[0].Components[1].Content = map[string]interface{}{"src": "/media/a.png", "alt": "产品图"}
+
+	report := Audit(context.Background(), site, nil)
+	if len(report.Issues) != 0 || !report.Passed() {
+		t.Fatalf("不应有问题，得到%+v", report.Issues)
+	}
+
+	empty := Audit(context.Background(), models.Site{ID: "empty"}, nil)
+	if empty.Issues == nil || !empty.Passed() {
+		t.Fatalf("空站点的报告为%+v", empty)
+	}
+}
+
+func TestPageExists(t *testing.T) {
+	a := &auditor{site: auditSite(), slugs: map[string]bool{"about": true, "products/list": true}}
+	tests := []struct {
+		path   string
+		exists bool
+	}{
+		{"/about", true},
+		{"about", true},
+		{"/About/", true},
+		{"/en/about", true},
+		{"/zh-cn/about", true},
+		{"/render/sites/s1/about", true},
+		{"/render/sites/s1/en/products/list", true},
+		{"/fr/about", false},
+		{"/missing", false},
+		{"/render/sites/s2/about", false},
+		{"/", false},
+		{"/en", false},
+	}
+	for _, tt := range tests {
+		if got := a.pageExists(tt.path); got != tt.exists {
+			t.Errorf("%s: 页面存在为%v，期望%v", tt.path, got, tt.exists)
+		}
+	}
+
+	a.homepage = true
+	for _, path := range []string{"", "/", "/en", "/render/sites/s1"} {
+		if !a.pageExists(path) {
+			t.Errorf("%q 应当指向首页", path)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package siteaudit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// namedColors 常用颜色名对应的RGB值，其他颜色名无法计算对比度，不做检查
+var namedColors = map[string][3]float64{
+	"black":  {0, 0, 0},
+	"white":  {255, 255, 255},
+	"gray":   {128, 128, 128},
+	"grey":   {128, 128, 128},
+	"silver": {192, 192, 192},
+	"red":    {255, 0, 0},
+	"green":  {0, 128, 0},
+	"blue":   {0, 0, 255},
+	"navy":   {0, 0, 128},
+	"yellow": {255, 255, 0},
+	"orange": {255, 165, 0},
+	"purple": {128, 0, 128},
+}
+
+// ContrastRatio 按WCAG 2计算两种颜色的对比度，范围为1到21。
+// 支持十六进制颜色、rgb()/rgba()、hsl()/hsla()和常用颜色名，忽略透明度；无法解析时返回false
+func ContrastRatio(foreground string, background string) (float64, bool) {
+	fg, ok := parseColor(foreground)
+	if !ok {
+		return 0, false
+	}
+	bg, ok := parseColor(background)
+	if !ok {
+		return 0, false
+	}
+	lighter, darker := luminance(fg), luminance(bg)
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	return (lighter + 0.05) / (darker + 0.05), true
+}
+
+// luminance 计算颜色的相对亮度
+func luminance(rgb [3]float64) float64 {
+	var channels [3]float64
+	for i, value := range rgb {
+		c := value / 255
+		if c <= 0.03928 {
+			channels[i] = c / 12.92
+		} else {
+			channels[i] = math.Pow((c+0.055)/1.055, 2.4)
+		}
+	}
+	return 0.2126*channels[0] + 0.7152*channels[1] + 0.0722*channels[2]
+}
+
+// parseColor 解析颜色为0到255的RGB值
+func parseColor(color string) ([3]float64, bool) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if rgb, ok := namedColors[color]; ok {
+		return rgb, true
+	}
+	if strings.HasPrefix(color, "#") {
+		return parseHexColor(color[1:])
+	}
+
+	name, args, ok := strings.Cut(color, "(")
+	if !ok || !strings.HasSuffix(args, ")") {
+		return [3]float64{}, false
+	}
+	fields := strings.FieldsFunc(strings.TrimSuffix(args, ")"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '/'
+	})
+	if len(fields) < 3 {
+		return [3]float64{}, false
+	}
+	switch name {
+	case "rgb", "rgba":
+		var rgb [3]float64
+		for i := 0; i < 3; i++ {
+			value, ok := parseComponent(fields[i], 255)
+			if !ok {
+				return [3]float64{}, false
+			}
+			rgb[i] = math.Max(0, math.Min(255, value))
+		}
+		return rgb, true
+	case "hsl", "hsla":
+		hue, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "deg"), 64)
+		if err != nil {
+			return [3]float64{}, false
+		}
+		saturation, ok1 := parseComponent(fields[1], 1)
+		lightness, ok2 := parseComponent(fields[2], 1)
+		if !ok1 || !ok2 {
+			return [3]float64{}, false
+		}
+		return hslToRGB(hue, saturation, lightness), true
+	}
+	return [3]float64{}, false
+}
+
+// parseHexColor 解析3、4、6或8位的十六进制颜色
+func parseHexColor(hex string) ([3]float64, bool) {
+	switch len(hex) {
+	case 3, 4:
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	case 6, 8:
+		hex = hex[:6]
+	default:
+		return [3]float64{}, false
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return [3]float64{}, false
+	}
+	return [3]float64{float64(value >> 16 & 0xff), float64(value >> 8 & 0xff), float64(value & 0xff)}, true
+}
+
+// parseComponent 解析颜色分量，百分比按scale换算
+func parseComponent(field string, scale float64) (float64, bool) {
+	if percent, ok := strings.CutSuffix(field, "%"); ok {
+		value, err := strconv.ParseFloat(percent, 64)
+		return value / 100 * scale, err == nil
+	}
+	value, err := strconv.ParseFloat(field, 64)
+	return value, err == nil
+}
+
+// hslToRGB 把色相（度）、饱和度和亮度（0到1）转换为RGB
+func hslToRGB(hue float64, saturation float64, lightness float64) [3]float64 {
+	hue = math.Mod(math.Mod(hue, 360)+360, 360) / 360
+	saturation = math.Max(0, math.Min(1, saturation))
+	lightness = math.Max(0, math.Min(1, lightness))
+	if saturation == 0 {
+		return [3]float64{lightness * 255, lightness * 255, lightness * 255}
+	}
+	var q float64
+	if lightness < 0.5 {
+		q = lightness * (1 + saturation)
+	} else {
+		q = lightness + saturation - lightness*saturation
+	}
+	p := 2*lightness - q
+	channel := func(t float64) float64 {
+		switch {
+		case t < 0:
+			t++
+		case t > 1:
+			t--
+		}
+		switch {
+		case t < 1.0/6:
+			return p + (q-p)*6*t
+		case t < 0.5:
+			return q
+		case t < 2.0/3:
+			return p + (q-p)*(2.0/3-t)*6
+		}
+		return p
+	}
+	return [3]float64{channel(hue+1.0/3) * 255, channel(hue) * 255, channel(hue-1.0/3) * 255}
+}
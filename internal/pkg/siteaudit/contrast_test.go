@@ -0,0 +1,46 @@
+package siteaudit
+
+import (
+	"math"
+	"testing"
+)
+
+func TestContrastRatio(t *testing.T) {
+	tests := []struct {
+		foreground string
+		background string
+		ratio      float64
+	}{
+		{"black", "white", 21},
+		{"#FFF", "#000", 21},
+		{"#ffffff80", "#000000", 21},
+		{"#fff8", "black", 21},
+		{"rgb(255, 255, 255)", "rgba(0,0,0,0.5)", 21},
+		{"rgb(100%, 100%, 100%)", "black", 21},
+		{"hsl(0, 0%, 100%)", "hsl(120deg 50% 0%)", 21},
+		{"#777777", "#ffffff", 4.48},
+		{"#333333", "#ffffff", 12.63},
+		{"red", "white", 4},
+		{"hsl(0, 100%, 50%)", "#fff", 4},
+		{"#2196f3", "#2196F3", 1},
+	}
+	for _, tt := range tests {
+		ratio, ok := ContrastRatio(tt.foreground, tt.background)
+		if !ok {
+			t.Errorf("%s / %s 应当可以计算对比度", tt.foreground, tt.background)
+			continue
+		}
+		if math.Abs(ratio-tt.ratio) > 0.01 {
+			t.Errorf("%s / %s 的对比度为%.2f，期望%.2f", tt.foreground, tt.background, ratio, tt.ratio)
+		}
+	}
+
+	for _, color := range []string{"transparent", "#12345", "#ggg", "rgb(1, 2)", "rgb(a, b, c)", "hwb(0 0% 0%)", "var(--x)", ""} {
+		if _, ok := ContrastRatio(color, "white"); ok {
+			t.Errorf("%q 不应计算对比度", color)
+		}
+		if _, ok := ContrastRatio("white", color); ok {
+			t.Errorf("背景%q 不应计算对比度", color)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package siteaudit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxConcurrentChecks 同时检查的外部链接数量
+const maxConcurrentChecks = 8
+
+// LinkChecker 检查外部链接是否可以访问，链接失效时返回错误
+type LinkChecker interface {
+	Check(ctx context.Context, link string) error
+}
+
+// StatusError 外部链接返回了表示失败的HTTP状态码
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("返回状态码%d", e.StatusCode)
+}
+
+// errPrivateAddress 外部链接解析到内网地址，检查时拒绝连接，避免借站点检查探测内网
+var errPrivateAddress = errors.New("链接指向内网地址")
+
+// HTTPChecker 用HEAD请求检查外部链接，只连接公网地址
+type HTTPChecker struct {
+	client *http.Client
+}
+
+// NewHTTPChecker 创建HTTP链接检查器，timeout为单个链接的超时时间
+func NewHTTPChecker(timeout time.Duration) *HTTPChecker {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+	return &HTTPChecker{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+		},
+	}
+}
+
+// Check 发送HEAD请求，跟随重定向后状态码为4xx或5xx时视为失效。
+// 不支持HEAD的服务器（405、501）视为可以访问
+func (c *HTTPChecker) Check(ctx context.Context, link string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, link, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "wz-site-audit/1.0")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusNotImplemented {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// publicIP 判断是否为公网地址
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// checkExternalLinks 并发检查收集到的外部链接，同一链接只检查一次，失效时在每个出现的位置报告。
+// 页面不存在（404、410）为错误，其他失败可能是临时的，为警告
+func (a *auditor) checkExternalLinks(ctx context.Context, checker LinkChecker) {
+	results := make([]error, len(a.externalOrder))
+	semaphore := make(chan struct{}, maxConcurrentChecks)
+	var wg sync.WaitGroup
+	for i, link := range a.externalOrder {
+		wg.Add(1)
+		go func(i int, link string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			results[i] = checker.Check(ctx, link)
+		}(i, link)
+	}
+	wg.Wait()
+
+	for i, link := range a.externalOrder {
+		err := results[i]
+		if err == nil {
+			continue
+		}
+		severity := SeverityWarning
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && (statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone) {
+			severity = SeverityError
+		}
+		for _, at := range a.external[link] {
+			a.add(at, CodeBrokenExternalLink, severity, link, "外部链接无法访问: "+err.Error())
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package siteaudit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPCheckerStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("应当使用HEAD请求，得到%s", r.Method)
+		}
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/gone", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// 测试服务器监听在本机地址，这里使用不限制地址的客户端
+	checker := &HTTPChecker{client: server.Client()}
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/ok", 0},
+		{"/no-head", 0},
+		{"/moved", http.StatusGone},
+		{"/error", http.StatusInternalServerError},
+		{"/missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		err := checker.Check(context.Background(), server.URL+tt.path)
+		var statusErr *StatusError
+		switch {
+		case tt.status == 0 && err != nil:
+			t.Errorf("%s 不应报错，得到%v", tt.path, err)
+		case tt.status != 0 && (!errors.As(err, &statusErr) || statusErr.StatusCode != tt.status):
+			t.Errorf("%s 期望状态码%d，得到%v", tt.path, tt.status, err)
+		}
+	}
+}
+
+func TestHTTPCheckerRejectsPrivateAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("不应连接内网地址")
+	}))
+	defer server.Close()
+
+	err := NewHTTPChecker(time.Second).Check(context.Background(), server.URL)
+	if !errors.Is(err, errPrivateAddress) {
+		t.Fatalf("期望拒绝内网地址，得到%v", err)
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"8.8.8.8", true},
+		{"2001:4860:4860::8888", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := publicIP(net.ParseIP(tt.ip)); got != tt.public {
+			t.Errorf("%s: 公网地址为%v，期望%v", tt.ip, got, tt.public)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package sitelocale 处理站点构建器的多语言内容：语言协商、翻译回退和缺失翻译检查
+package sitelocale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"wz-backend-go/models"
+)
+
+// FallbackLocale 站点未设置默认语言时使用的语言
+const FallbackLocale = "zh-CN"
+
+// Normalize 规范化语言代码，如en-us转为en-US，无效时返回空字符串
+func Normalize(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	if len(parts) == 0 || len(parts) > 2 {
+		return ""
+	}
+	language := strings.ToLower(parts[0])
+	if len(language) < 2 || len(language) > 3 || !isLetters(language) {
+		return ""
+	}
+	if len(parts) == 1 {
+		return language
+	}
+	region := parts[1]
+	if (len(region) != 2 && len(region) != 4) || !isLetters(region) {
+		return ""
+	}
+	if len(region) == 2 {
+		region = strings.ToUpper(region)
+	} else {
+		// 文字代码，如zh-Hant
+		region = strings.ToUpper(region[:1]) + strings.ToLower(region[1:])
+	}
+	return language + "-" + region
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// DefaultLocale 站点的默认语言
+func DefaultLocale(site models.Site) string {
+	if site.DefaultLocale != "" {
+		return site.DefaultLocale
+	}
+	return FallbackLocale
+}
+
+// Enabled 站点启用的语言，默认语言排在第一位
+func Enabled(site models.Site) []string {
+	defaultLocale := DefaultLocale(site)
+	locales := []string{defaultLocale}
+	for _, locale := range site.Locales {
+		if locale != defaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	return locales
+}
+
+// IsEnabled 检查语言是否在站点启用的语言中
+func IsEnabled(site models.Site, locale string) bool {
+	for _, enabled := range Enabled(site) {
+		if enabled == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate 规范化站点的语言设置，默认语言不在启用列表中时自动加入
+func Validate(site *models.Site) error {
+	var locales []string
+	seen := map[string]bool{}
+	for _, locale := range site.Locales {
+		normalized := Normalize(locale)
+		if normalized == "" {
+			return fmt.Errorf("无效的语言代码: %s", locale)
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			locales = append(locales, normalized)
+		}
+	}
+
+	if site.DefaultLocale != "" {
+		normalized := Normalize(site.DefaultLocale)
+		if normalized == "" {
+			return fmt.Errorf("无效的默认语言: %s", site.DefaultLocale)
+		}
+		site.DefaultLocale = normalized
+	} else if len(locales) > 0 {
+		site.DefaultLocale = locales[0]
+	}
+	if site.DefaultLocale != "" && len(locales) > 0 && !seen[site.DefaultLocale] {
+		locales = append([]string{site.DefaultLocale}, locales...)
+	}
+
+	site.Locales = locales
+	return nil
+}
+
+// Match 在站点启用的语言中查找与候选语言匹配的语言，先精确匹配再按主语言匹配
+func Match(site models.Site, candidate string) (string, bool) {
+	candidate = Normalize(candidate)
+	if candidate == "" {
+		return "", false
+	}
+	enabled := Enabled(site)
+	for _, locale := range enabled {
+		if locale == candidate {
+			return locale, true
+		}
+	}
+	language := strings.SplitN(candidate, "-", 2)[0]
+	for _, locale := range enabled {
+		if strings.SplitN(locale, "-", 2)[0] == language {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Negotiate 依次根据Cookie和Accept-Language选择语言，都不匹配时使用默认语言
+func Negotiate(site models.Site, cookie string, acceptLanguage string) string {
+	if locale, ok := Match(site, cookie); ok {
+		return locale
+	}
+	for _, candidate := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := Match(site, candidate); ok {
+			return locale
+		}
+	}
+	return DefaultLocale(site)
+}
+
+// parseAcceptLanguage 按q值从高到低返回Accept-Language中的语言
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+	var items []weighted
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		if fields[0] == "" || fields[0] == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if q > 0 {
+			items = append(items, weighted{locale: fields[0], q: q})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].q > items[j].q })
+
+	locales := make([]string, len(items))
+	for i, item := range items {
+		locales[i] = item.locale
+	}
+	return locales
+}
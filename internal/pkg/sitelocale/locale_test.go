@@ -0,0 +1,128 @@
+package sitelocale
+
+import (
+	"reflect"
+	"testing"
+	"wz-backend-go/models"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{"en", "en"},
+		{"EN", "en"},
+		{"en-us", "en-US"},
+		{"en_US", "en-US"},
+		{" zh-cn ", "zh-CN"},
+		{"zh-hant", "zh-Hant"},
+		{"yue", "yue"},
+		{"", ""},
+		{"e", ""},
+		{"engl", ""},
+		{"en-", ""},
+		{"en-U", ""},
+		{"en-USA", ""},
+		{"en-US-x", ""},
+		{"e1", ""},
+		{"../en", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.locale); got != tt.want {
+			t.Errorf("Normalize(%q) = %q，期望%q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if got := Enabled(models.Site{}); !reflect.DeepEqual(got, []string{FallbackLocale}) {
+		t.Fatalf("未设置语言的站点启用%v", got)
+	}
+	site := models.Site{Locales: []string{"en", "zh-CN", "ja"}, DefaultLocale: "zh-CN"}
+	if got := Enabled(site); !reflect.DeepEqual(got, []string{"zh-CN", "en", "ja"}) {
+		t.Fatalf("默认语言应排在第一位，得到%v", got)
+	}
+	if !IsEnabled(site, "ja") || IsEnabled(site, "fr") || IsEnabled(site, "JA") {
+		t.Fatal("IsEnabled应按规范化后的语言代码精确比较")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		site    models.Site
+		locales []string
+		def     string
+		valid   bool
+	}{
+		{models.Site{}, nil, "", true},
+		{models.Site{Locales: []string{"en_us", "zh-cn", "en-US"}}, []string{"en-US", "zh-CN"}, "en-US", true},
+		{models.Site{Locales: []string{"en"}, DefaultLocale: "zh-cn"}, []string{"zh-CN", "en"}, "zh-CN", true},
+		{models.Site{DefaultLocale: "ja"}, nil, "ja", true},
+		{models.Site{Locales: []string{"en", "english"}}, nil, "", false},
+		{models.Site{Locales: []string{"en"}, DefaultLocale: "x"}, nil, "", false},
+	}
+	for _, tt := range tests {
+		site := tt.site
+		err := Validate(&site)
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("%+v 应当校验失败", tt.site)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(site.Locales, tt.locales) || site.DefaultLocale != tt.def {
+			t.Errorf("%+v 校验后为%v、%q、%v，期望%v、%q", tt.site, site.Locales, site.DefaultLocale, err, tt.locales, tt.def)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	site := models.Site{Locales: []string{"zh-CN", "en-GB", "en-US", "pt"}}
+	tests := []struct {
+		candidate string
+		want      string
+		ok        bool
+	}{
+		{"en-us", "en-US", true},
+		{"en", "en-GB", true},
+		{"en-AU", "en-GB", true},
+		{"zh", "zh-CN", true},
+		{"zh-TW", "zh-CN", true},
+		{"pt-BR", "pt", true},
+		{"fr", "", false},
+		{"", "", false},
+		{"*", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Match(site, tt.candidate)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Match(%q) = %q, %v，期望%q, %v", tt.candidate, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	site := models.Site{Locales: []string{"zh-CN", "en", "ja"}, DefaultLocale: "zh-CN"}
+	tests := []struct {
+		name           string
+		cookie         string
+		acceptLanguage string
+		want           string
+	}{
+		{"Cookie优先", "ja", "en", "ja"},
+		{"无效的Cookie", "fr", "en-US,en;q=0.9", "en"},
+		{"按q值排序", "", "fr;q=1, ja;q=0.8, en;q=0.9", "en"},
+		{"相同q值保持顺序", "", "ja, en", "ja"},
+		{"q为0表示不接受", "", "en;q=0, fr", "zh-CN"},
+		{"忽略通配符", "", "*, ja;q=0.1", "ja"},
+		{"无效的q值按1处理", "", "en;q=x, ja;q=0.5", "en"},
+		{"都不匹配", "", "fr-FR, de", "zh-CN"},
+		{"没有偏好", "", "", "zh-CN"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(site, tt.cookie, tt.acceptLanguage); got != tt.want {
+			t.Errorf("%s: 选择了%q，期望%q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package sitelocale
+
+import (
+	"fmt"
+	"sort"
+	"wz-backend-go/models"
+)
+
+// Localize 返回站点树在指定语言下的副本，缺少翻译的字段使用默认语言的内容
+func Localize(site models.Site, locale string) models.Site {
+	if locale == DefaultLocale(site) {
+		return site
+	}
+
+	pages := make([]models.Page, len(site.Pages))
+	for i, page := range site.Pages {
+		pages[i] = LocalizePage(page, locale)
+	}
+	site.Pages = pages
+
+	globals := make([]models.Section, len(site.GlobalSections))
+	for i, section := range site.GlobalSections {
+		globals[i] = LocalizeSection(section, locale)
+	}
+	site.GlobalSections = globals
+	return site
+}
+
+// LocalizePage 返回页面在指定语言下的副本
+func LocalizePage(page models.Page, locale string) models.Page {
+	if translation, ok := page.Translations[locale]; ok {
+		if translation.Name != "" {
+			page.Name = translation.Name
+		}
+		if translation.Title != "" {
+			page.Title = translation.Title
+		}
+		if translation.Description != "" {
+			page.Description = translation.Description
+		}
+		if len(translation.Keywords) > 0 {
+			page.Keywords = translation.Keywords
+		}
+	}
+
+	sections := make([]models.Section, len(page.Sections))
+	for i, section := range page.Sections {
+		sections[i] = LocalizeSection(section, locale)
+	}
+	page.Sections = sections
+	return page
+}
+
+// LocalizeSection 返回区块及其组件在指定语言下的副本
+func LocalizeSection(section models.Section, locale string) models.Section {
+	if translation, ok := section.Translations[locale]; ok && translation.Title != "" {
+		section.Title = translation.Title
+	}
+	components := make([]models.Component, len(section.Components))
+	for i, component := range section.Components {
+		components[i] = LocalizeComponent(component, locale)
+	}
+	section.Components = components
+	return section
+}
+
+// LocalizeComponent 用翻译覆盖组件Content中的字段，未翻译的字段保持默认语言的值
+func LocalizeComponent(component models.Component, locale string) models.Component {
+	translation, ok := component.Translations[locale]
+	if !ok || len(translation) == 0 {
+		return component
+	}
+
+	content := map[string]interface{}{}
+	if original, ok := component.Content.(map[string]interface{}); ok {
+		for key, value := range original {
+			content[key] = value
+		}
+	}
+	for key, value := range translation {
+		if value != nil && value != "" {
+			content[key] = value
+		}
+	}
+	component.Content = content
+	return component
+}
+
+// MissingTranslation 缺少翻译的字段
+type MissingTranslation struct {
+	Locale       string      `json:"locale"`
+	Path         string      `json:"path"`             // 如 pages[page1].sections[section1].components[comp1].content.text
+	PageID       string      `json:"pageId,omitempty"` // 全局区块的字段为空
+	SectionID    string      `json:"sectionId,omitempty"`
+	ComponentID  string      `json:"componentId,omitempty"`
+	Field        string      `json:"field"`
+	DefaultValue interface{} `json:"defaultValue"`
+}
+
+// FindMissing 列出站点树在各非默认语言下缺少翻译的文本字段，locale不为空时只检查该语言
+func FindMissing(site models.Site, locale string) []MissingTranslation {
+	missing := []MissingTranslation{}
+	for _, target := range Enabled(site)[1:] {
+		if locale != "" && target != locale {
+			continue
+		}
+		for _, page := range site.Pages {
+			missing = append(missing, findMissingInPage(page, target)...)
+		}
+		for _, section := range site.GlobalSections {
+			missing = append(missing, findMissingInSection("", fmt.Sprintf("globalSections[%s]", section.ID), section, target)...)
+		}
+	}
+	return missing
+}
+
+func findMissingInPage(page models.Page, locale string) []MissingTranslation {
+	var missing []MissingTranslation
+	pagePath := fmt.Sprintf("pages[%s]", page.ID)
+	translation := page.Translations[locale]
+	for _, field := range []struct {
+		name       string
+		value      string
+		translated string
+	}{
+		{"name", page.Name, translation.Name},
+		{"title", page.Title, translation.Title},
+		{"description", page.Description, translation.Description},
+	} {
+		if field.value != "" && field.translated == "" {
+			missing = append(missing, MissingTranslation{
+				Locale:       locale,
+				Path:         pagePath + "." + field.name,
+				PageID:       page.ID,
+				Field:        field.name,
+				DefaultValue: field.value,
+			})
+		}
+	}
+
+	for _, section := range page.Sections {
+		sectionPath := fmt.Sprintf("%s.sections[%s]", pagePath, section.ID)
+		missing = append(missing, findMissingInSection(page.ID, sectionPath, section, locale)...)
+	}
+	return missing
+}
+
+// findMissingInSection 检查区块标题和组件内容，引用全局区块的区块没有自己的内容，由全局区块统一检查
+func findMissingInSection(pageID string, sectionPath string, section models.Section, locale string) []MissingTranslation {
+	if section.GlobalSectionID != "" {
+		return nil
+	}
+
+	var missing []MissingTranslation
+	if section.Title != "" && section.Translations[locale].Title == "" {
+		missing = append(missing, MissingTranslation{
+			Locale:       locale,
+			Path:         sectionPath + ".title",
+			PageID:       pageID,
+			SectionID:    section.ID,
+			Field:        "title",
+			DefaultValue: section.Title,
+		})
+	}
+
+	for _, component := range section.Components {
+		content, ok := component.Content.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		translated := component.Translations[locale]
+		for _, key := range sortedKeys(content) {
+			// 只检查文本字段，图片地址等可以在各语言间共用
+			text, ok := content[key].(string)
+			if !ok || text == "" || isTranslated(translated, key) || !isTranslatableField(key) {
+				continue
+			}
+			missing = append(missing, MissingTranslation{
+				Locale:       locale,
+				Path:         fmt.Sprintf("%s.components[%s].content.%s", sectionPath, component.ID, key),
+				PageID:       pageID,
+				SectionID:    section.ID,
+				ComponentID:  component.ID,
+				Field:        "content." + key,
+				DefaultValue: text,
+			})
+		}
+	}
+	return missing
+}
+
+func isTranslated(translation map[string]interface{}, key string) bool {
+	value, ok := translation[key]
+	return ok && value != nil && value != ""
+}
+
+// 不需要翻译的内容字段
+var untranslatableFields = map[string]bool{
+	"src":    true,
+	"poster": true,
+	"file":   true,
+	"url":    true,
+	"link":   true,
+	"href":   true,
+	"email":  true,
+	"phone":  true,
+}
+
+func isTranslatableField(key string) bool {
+	return !untranslatableFields[key]
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
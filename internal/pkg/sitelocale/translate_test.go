@@ -0,0 +1,105 @@
+package sitelocale
+
+import (
+	"reflect"
+	"testing"
+	"wz-backend-go/models"
+)
+
+func translatedSite() models.Site {
+	return models.Site{
+		Locales: []string{"zh-CN", "en", "ja"},
+		Pages: []models.Page{{
+			ID:          "page1",
+			Name:        "首页",
+			Title:       "企业官网",
+			Description: "欢迎",
+			Translations: map[string]models.PageTranslation{
+				"en": {Title: "Company Home"},
+			},
+			Sections: []models.Section{
+				{
+					ID:           "section1",
+					Title:        "联系我们",
+					Translations: map[string]models.SectionTranslation{"en": {Title: "Contact Us"}},
+					Components: []models.Component{{
+						ID:           "comp1",
+						Content:      map[string]interface{}{"text": "电话", "link": "/contact", "note": ""},
+						Translations: map[string]map[string]interface{}{"en": {"text": "Phone", "link": ""}},
+					}},
+				},
+				{ID: "section2", GlobalSectionID: "global1"},
+			},
+		}},
+		GlobalSections: []models.Section{{
+			ID: "global1",
+			Components: []models.Component{{
+				ID:      "comp2",
+				Content: map[string]interface{}{"text": "版权所有", "count": 3.0},
+			}},
+		}},
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	site := translatedSite()
+	if got := Localize(site, "zh-CN"); !reflect.DeepEqual(got, site) {
+		t.Fatal("默认语言应返回原站点")
+	}
+
+	en := Localize(site, "en")
+	page := en.Pages[0]
+	if page.Name != "首页" || page.Title != "Company Home" || page.Description != "欢迎" {
+		t.Fatalf("页面翻译应只覆盖已翻译的字段: %+v", page)
+	}
+	if page.Sections[0].Title != "Contact Us" {
+		t.Fatalf("区块标题为%q", page.Sections[0].Title)
+	}
+	want := map[string]interface{}{"text": "Phone", "link": "/contact", "note": ""}
+	if content := page.Sections[0].Components[0].Content; !reflect.DeepEqual(content, want) {
+		t.Fatalf("组件内容为%v，期望%v", content, want)
+	}
+
+	// 翻译不应修改原站点
+	if site.Pages[0].Title != "企业官网" || site.Pages[0].Sections[0].Components[0].Content.(map[string]interface{})["text"] != "电话" {
+		t.Fatal("本地化修改了原站点")
+	}
+
+	ja := Localize(site, "ja")
+	if ja.Pages[0].Title != "企业官网" || ja.Pages[0].Sections[0].Components[0].Content.(map[string]interface{})["text"] != "电话" {
+		t.Fatal("没有翻译的语言应回退到默认语言的内容")
+	}
+}
+
+func TestFindMissing(t *testing.T) {
+	type field struct {
+		locale string
+		path   string
+	}
+	var found []field
+	for _, missing := range FindMissing(translatedSite(), "") {
+		found = append(found, field{missing.Locale, missing.Path})
+	}
+	want := []field{
+		{"en", "pages[page1].name"},
+		{"en", "pages[page1].description"},
+		{"en", "globalSections[global1].components[comp2].content.text"},
+		{"ja", "pages[page1].name"},
+		{"ja", "pages[page1].title"},
+		{"ja", "pages[page1].description"},
+		{"ja", "pages[page1].sections[section1].title"},
+		{"ja", "pages[page1].sections[section1].components[comp1].content.text"},
+		{"ja", "globalSections[global1].components[comp2].content.text"},
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Fatalf("缺少的翻译为\n%v\n期望\n%v", found, want)
+	}
+
+	ja := FindMissing(translatedSite(), "ja")
+	if len(ja) != 6 || ja[4].ComponentID != "comp1" || ja[4].SectionID != "section1" || ja[4].PageID != "page1" || ja[4].DefaultValue != "电话" {
+		t.Fatalf("只检查ja时得到%+v", ja)
+	}
+	if missing := FindMissing(models.Site{Pages: translatedSite().Pages}, ""); len(missing) != 0 {
+		t.Fatalf("只有默认语言的站点不应缺少翻译，得到%+v", missing)
+	}
+}
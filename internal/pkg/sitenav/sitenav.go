@@ -0,0 +1,357 @@
+// Package sitenav 维护站点导航树并生成渲染用的导航链接。
+// 导航项可以指向站内页面（PageID），渲染时按页面当前的Slug和语言生成地址，页面改名或改Slug后导航自动跟随
+package sitenav
+
+import (
+	"errors"
+	"strings"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/models"
+)
+
+// 导航类型
+const (
+	TypeHorizontal = "horizontal" // 横向菜单，子项为下拉菜单
+	TypeVertical   = "vertical"   // 纵向菜单，子项缩进显示
+	TypeMegaMenu   = "mega-menu"  // 超级菜单，第二级为分组，第三级为分组内的链接
+)
+
+// MaxDepth 导航的最大层级，超级菜单使用全部三级
+const MaxDepth = 3
+
+// 编辑导航的错误
+var (
+	ErrItemNotFound   = errors.New("导航项不存在")
+	ErrInvalidParent  = errors.New("不能把导航项移动到自身或其下级导航项中")
+	ErrTooDeep        = errors.New("导航最多支持3级")
+	ErrLabelRequired  = errors.New("不指向页面的导航项必须填写名称")
+	ErrInvalidType    = errors.New("导航类型必须是horizontal、vertical或mega-menu")
+	ErrExternalPage   = errors.New("指向站内页面的导航项不能标记为外部链接")
+	ErrDuplicateItems = errors.New("导航项ID重复")
+)
+
+// ValidType 判断导航类型是否有效，空值按横向菜单处理
+func ValidType(navigationType string) bool {
+	switch navigationType {
+	case "", TypeHorizontal, TypeVertical, TypeMegaMenu:
+		return true
+	}
+	return false
+}
+
+// NormalizeItem 校验单个导航项并清理字段，指向页面的导航项清空Link，不检查子项
+func NormalizeItem(item *models.NavigationItem) error {
+	item.Label = strings.TrimSpace(item.Label)
+	item.Link = strings.TrimSpace(item.Link)
+	item.PageID = strings.TrimSpace(item.PageID)
+	item.Icon = strings.TrimSpace(item.Icon)
+	if item.PageID != "" {
+		if item.IsExternalLink {
+			return ErrExternalPage
+		}
+		item.Link = ""
+		return nil
+	}
+	if item.Label == "" {
+		return ErrLabelRequired
+	}
+	return nil
+}
+
+// Validate 校验整个导航树：导航项有效、ID不重复、层级不超过MaxDepth，调用前先用AssignIDs补全ID
+func Validate(items []models.NavigationItem) error {
+	seen := map[string]bool{}
+	var walk func(items []models.NavigationItem, depth int) error
+	walk = func(items []models.NavigationItem, depth int) error {
+		if len(items) > 0 && depth > MaxDepth {
+			return ErrTooDeep
+		}
+		for i := range items {
+			if seen[items[i].ID] {
+				return ErrDuplicateItems
+			}
+			seen[items[i].ID] = true
+			if err := NormalizeItem(&items[i]); err != nil {
+				return err
+			}
+			if err := walk(items[i].Children, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(items, 1)
+}
+
+// AssignIDs 为没有ID的导航项生成ID
+func AssignIDs(items []models.NavigationItem, newID func() string) {
+	for i := range items {
+		if items[i].ID == "" {
+			items[i].ID = newID()
+		}
+		AssignIDs(items[i].Children, newID)
+	}
+}
+
+// Find 查找导航项，返回导航项及其上级的ID（顶层为空）
+func Find(items []models.NavigationItem, id string) (item models.NavigationItem, parentID string, ok bool) {
+	for _, current := range items {
+		if current.ID == id {
+			return current, "", true
+		}
+		if found, parent, ok := Find(current.Children, id); ok {
+			if parent == "" {
+				parent = current.ID
+			}
+			return found, parent, true
+		}
+	}
+	return models.NavigationItem{}, "", false
+}
+
+// Insert 把导航项插入到parentID的子项中第index个位置，parentID为空时插入顶层，
+// index超出范围时追加到末尾
+func Insert(items []models.NavigationItem, parentID string, index int, item models.NavigationItem) ([]models.NavigationItem, error) {
+	if parentID == "" {
+		if height(item) > MaxDepth {
+			return nil, ErrTooDeep
+		}
+		return insertAt(items, index, item), nil
+	}
+
+	inserted := false
+	var walk func(items []models.NavigationItem, depth int) ([]models.NavigationItem, error)
+	walk = func(items []models.NavigationItem, depth int) ([]models.NavigationItem, error) {
+		result := make([]models.NavigationItem, len(items))
+		copy(result, items)
+		for i := range result {
+			if result[i].ID == parentID {
+				if depth+height(item) > MaxDepth {
+					return nil, ErrTooDeep
+				}
+				result[i].Children = insertAt(result[i].Children, index, item)
+				inserted = true
+				return result, nil
+			}
+			children, err := walk(result[i].Children, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result[i].Children = children
+		}
+		return result, nil
+	}
+	result, err := walk(items, 1)
+	if err != nil {
+		return nil, err
+	}
+	if !inserted {
+		return nil, ErrItemNotFound
+	}
+	return result, nil
+}
+
+// Update 用item替换ID相同的导航项，保留原有的子项
+func Update(items []models.NavigationItem, item models.NavigationItem) ([]models.NavigationItem, bool) {
+	updated := false
+	var walk func(items []models.NavigationItem) []models.NavigationItem
+	walk = func(items []models.NavigationItem) []models.NavigationItem {
+		result := make([]models.NavigationItem, len(items))
+		for i, current := range items {
+			if current.ID == item.ID {
+				item.Children = current.Children
+				current = item
+				updated = true
+			} else {
+				current.Children = walk(current.Children)
+			}
+			result[i] = current
+		}
+		return result
+	}
+	result := walk(items)
+	return result, updated
+}
+
+// Remove 删除导航项及其子项，返回删除的导航项
+func Remove(items []models.NavigationItem, id string) ([]models.NavigationItem, models.NavigationItem, bool) {
+	var removed models.NavigationItem
+	found := false
+	var walk func(items []models.NavigationItem) []models.NavigationItem
+	walk = func(items []models.NavigationItem) []models.NavigationItem {
+		result := make([]models.NavigationItem, 0, len(items))
+		for _, current := range items {
+			if current.ID == id && !found {
+				removed = current
+				found = true
+				continue
+			}
+			current.Children = walk(current.Children)
+			result = append(result, current)
+		}
+		return result
+	}
+	result := walk(items)
+	return result, removed, found
+}
+
+// Move 把导航项连同子项移动到parentID的子项中第index个位置，parentID为空时移动到顶层
+func Move(items []models.NavigationItem, id string, parentID string, index int) ([]models.NavigationItem, error) {
+	item, _, ok := Find(items, id)
+	if !ok {
+		return nil, ErrItemNotFound
+	}
+	if parentID == id {
+		return nil, ErrInvalidParent
+	}
+	if parentID != "" {
+		if _, _, inside := Find(item.Children, parentID); inside {
+			return nil, ErrInvalidParent
+		}
+	}
+	remaining, _, _ := Remove(items, id)
+	return Insert(remaining, parentID, index, item)
+}
+
+// AppendPage 在导航顶层末尾添加指向页面的导航项，页面已在导航中时不添加
+func AppendPage(items []models.NavigationItem, id string, pageID string) ([]models.NavigationItem, bool) {
+	if referencesPage(items, pageID) {
+		return items, false
+	}
+	return append(items, models.NavigationItem{ID: id, PageID: pageID}), true
+}
+
+// RemovePage 删除指向页面的导航项，其子项移到被删除导航项的位置，返回删除的数量
+func RemovePage(items []models.NavigationItem, pageID string) ([]models.NavigationItem, int) {
+	removed := 0
+	var walk func(items []models.NavigationItem) []models.NavigationItem
+	walk = func(items []models.NavigationItem) []models.NavigationItem {
+		result := make([]models.NavigationItem, 0, len(items))
+		for _, current := range items {
+			children := walk(current.Children)
+			if current.PageID == pageID {
+				removed++
+				result = append(result, children...)
+				continue
+			}
+			current.Children = children
+			result = append(result, current)
+		}
+		return result
+	}
+	result := walk(items)
+	return result, removed
+}
+
+// RemapPages 按新旧页面ID的对应关系更新导航项引用的页面，复制站点重新生成页面ID后使用
+func RemapPages(items []models.NavigationItem, pageIDs map[string]string) []models.NavigationItem {
+	result := make([]models.NavigationItem, len(items))
+	for i, item := range items {
+		if newID, ok := pageIDs[item.PageID]; ok && item.PageID != "" {
+			item.PageID = newID
+		}
+		item.Children = RemapPages(item.Children, pageIDs)
+		result[i] = item
+	}
+	return result
+}
+
+// FromPages 按页面顺序为每个页面生成一个导航项，newID生成导航项ID
+func FromPages(pages []models.Page, newID func() string) []models.NavigationItem {
+	items := make([]models.NavigationItem, 0, len(pages))
+	for _, page := range pages {
+		items = append(items, models.NavigationItem{ID: newID(), PageID: page.ID})
+	}
+	return items
+}
+
+// Link 渲染用的导航链接，Href为空的导航项只显示名称（如超级菜单的分组标题）
+type Link struct {
+	ID       string
+	Label    string
+	Href     string
+	Icon     string
+	External bool
+	Children []Link
+}
+
+// Resolve 生成渲染用的导航链接：指向页面的导航项用pageHref生成地址，名称为空时使用页面名称；
+// 页面已删除的导航项连同子项一起省略。site应为已按语言处理过的站点树
+func Resolve(site models.Site, pageHref func(models.Page) string) []Link {
+	pages := make(map[string]models.Page, len(site.Pages))
+	for _, page := range site.Pages {
+		pages[page.ID] = page
+	}
+
+	var walk func(items []models.NavigationItem) []Link
+	walk = func(items []models.NavigationItem) []Link {
+		links := make([]Link, 0, len(items))
+		for _, item := range items {
+			link := Link{
+				ID:       item.ID,
+				Label:    item.Label,
+				Href:     item.Link,
+				Icon:     item.Icon,
+				External: item.IsExternalLink,
+			}
+			if item.PageID != "" {
+				page, ok := pages[item.PageID]
+				if !ok {
+					continue
+				}
+				link.Href = pageHref(page)
+				if link.Label == "" {
+					link.Label = page.Name
+				}
+			}
+			link.Children = walk(item.Children)
+			links = append(links, link)
+		}
+		return links
+	}
+	return walk(site.Navigation.Items)
+}
+
+// PagePath 页面在站点内的路径，首页为/，非默认语言的页面带语言前缀
+func PagePath(site models.Site, page models.Page, locale string) string {
+	prefix := ""
+	if locale != "" && locale != sitelocale.DefaultLocale(site) {
+		prefix = "/" + locale
+	}
+	if page.IsHomepage {
+		return prefix + "/"
+	}
+	return prefix + "/" + strings.Trim(page.Slug, "/")
+}
+
+// referencesPage 判断导航中是否已有指向页面的导航项
+func referencesPage(items []models.NavigationItem, pageID string) bool {
+	for _, item := range items {
+		if item.PageID == pageID || referencesPage(item.Children, pageID) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertAt 在第index个位置插入导航项，index超出范围时追加到末尾
+func insertAt(items []models.NavigationItem, index int, item models.NavigationItem) []models.NavigationItem {
+	if index < 0 || index > len(items) {
+		index = len(items)
+	}
+	result := make([]models.NavigationItem, 0, len(items)+1)
+	result = append(result, items[:index]...)
+	result = append(result, item)
+	return append(result, items[index:]...)
+}
+
+// height 导航项及其子项占用的层数
+func height(item models.NavigationItem) int {
+	max := 0
+	for _, child := range item.Children {
+		if h := height(child); h > max {
+			max = h
+		}
+	}
+	return max + 1
+}
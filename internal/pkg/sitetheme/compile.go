@@ -0,0 +1,195 @@
+package sitetheme
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"wz-backend-go/models"
+)
+
+// 未设置或取值无效时使用的默认值
+var defaults = models.ThemeConfig{
+	PrimaryColor:        "#2196F3",
+	SecondaryColor:      "#607D8B",
+	AccentColor:         "#FF9800",
+	TextColor:           "#333333",
+	BackgroundColor:     "#FFFFFF",
+	FontFamily:          "-apple-system, BlinkMacSystemFont, 'Segoe UI', 'PingFang SC', 'Microsoft YaHei', sans-serif",
+	HeaderStyle:         HeaderStandard,
+	BorderRadius:        "medium",
+	Spacing:             SpacingNormal,
+	DarkMode:            DarkModeOff,
+	DarkTextColor:       "#E0E0E0",
+	DarkBackgroundColor: "#121212",
+}
+
+// radii 圆角档位对应的长度
+var radii = map[string]string{
+	"none":   "0",
+	"small":  "4px",
+	"medium": "8px",
+	"large":  "16px",
+}
+
+// spacings 间距档位对应的区块间距和容器内边距
+var spacings = map[string][2]string{
+	SpacingCompact: {"16px", "12px"},
+	SpacingNormal:  {"30px", "20px"},
+	SpacingRelaxed: {"56px", "32px"},
+}
+
+// Stylesheet 编译后的主题样式表
+type Stylesheet struct {
+	CSS  string
+	Hash string // CSS内容的哈希，用于样式表地址，内容变化时地址随之变化
+}
+
+// FileName 带内容哈希的样式表文件名
+func (s Stylesheet) FileName() string {
+	return "theme-" + s.Hash + ".css"
+}
+
+// Compile 把主题编译为样式表：设计变量输出为:root中的CSS自定义属性，页面样式通过var()引用。
+// 无效的变量使用默认值，无法通过清理的自定义CSS会被忽略，不会影响页面的其他样式
+func Compile(theme models.ThemeConfig) Stylesheet {
+	theme = Resolve(theme)
+
+	var builder strings.Builder
+	builder.WriteString(":root {\n")
+	writeVar := func(name string, value string) {
+		builder.WriteString("  --wz-" + name + ": " + value + ";\n")
+	}
+	writeVar("color-primary", theme.PrimaryColor)
+	writeVar("color-secondary", theme.SecondaryColor)
+	writeVar("color-accent", theme.AccentColor)
+	if theme.DarkMode == DarkModeOn {
+		writeVar("color-text", theme.DarkTextColor)
+		writeVar("color-background", theme.DarkBackgroundColor)
+	} else {
+		writeVar("color-text", theme.TextColor)
+		writeVar("color-background", theme.BackgroundColor)
+	}
+	writeVar("font-family", theme.FontFamily)
+	writeVar("radius", radiusLength(theme.BorderRadius))
+	writeVar("space-section", spacings[theme.Spacing][0])
+	writeVar("space-container", spacings[theme.Spacing][1])
+	builder.WriteString("}\n")
+
+	if theme.DarkMode == DarkModeAuto {
+		builder.WriteString("@media (prefers-color-scheme: dark) {\n  :root {\n")
+		builder.WriteString("    --wz-color-text: " + theme.DarkTextColor + ";\n")
+		builder.WriteString("    --wz-color-background: " + theme.DarkBackgroundColor + ";\n")
+		builder.WriteString("  }\n}\n")
+	}
+	if theme.DarkMode != DarkModeOff {
+		builder.WriteString(":root { color-scheme: light dark; }\n")
+	}
+
+	builder.WriteString(baseCSS)
+	builder.WriteString(headerCSS[theme.HeaderStyle])
+
+	if theme.CustomCSS != "" {
+		custom, err := SanitizeCSS(theme.CustomCSS)
+		if err != nil {
+			log.Printf("忽略无效的自定义CSS: %v", err)
+		} else if custom != "" {
+			builder.WriteString("/* 自定义CSS */\n")
+			builder.WriteString(custom)
+		}
+	}
+
+	css := builder.String()
+	sum := sha256.Sum256([]byte(css))
+	return Stylesheet{CSS: css, Hash: hex.EncodeToString(sum[:])[:16]}
+}
+
+// Resolve 用默认值替换空的或无效的变量，得到渲染时实际使用的主题
+func Resolve(theme models.ThemeConfig) models.ThemeConfig {
+	pick := func(field string, value string, fallback string) string {
+		if value == "" || Schema.Properties[field].Validate(field, value) != nil {
+			return fallback
+		}
+		return value
+	}
+	return models.ThemeConfig{
+		PrimaryColor:        pick("primaryColor", theme.PrimaryColor, defaults.PrimaryColor),
+		SecondaryColor:      pick("secondaryColor", theme.SecondaryColor, defaults.SecondaryColor),
+		AccentColor:         pick("accentColor", theme.AccentColor, defaults.AccentColor),
+		TextColor:           pick("textColor", theme.TextColor, defaults.TextColor),
+		BackgroundColor:     pick("backgroundColor", theme.BackgroundColor, defaults.BackgroundColor),
+		FontFamily:          pick("fontFamily", theme.FontFamily, defaults.FontFamily),
+		HeaderStyle:         pick("headerStyle", theme.HeaderStyle, defaults.HeaderStyle),
+		BorderRadius:        pick("borderRadius", theme.BorderRadius, defaults.BorderRadius),
+		Spacing:             pick("spacing", theme.Spacing, defaults.Spacing),
+		DarkMode:            pick("darkMode", theme.DarkMode, defaults.DarkMode),
+		DarkTextColor:       pick("darkTextColor", theme.DarkTextColor, defaults.DarkTextColor),
+		DarkBackgroundColor: pick("darkBackgroundColor", theme.DarkBackgroundColor, defaults.DarkBackgroundColor),
+		CustomCSS:           theme.CustomCSS,
+	}
+}
+
+// radiusLength 圆角档位转换为长度，其他取值已经是CSS长度
+func radiusLength(radius string) string {
+	if length, ok := radii[radius]; ok {
+		return length
+	}
+	return radius
+}
+
+// baseCSS 引用设计变量的页面样式
+const baseCSS = `body {
+  font-family: var(--wz-font-family);
+  color: var(--wz-color-text);
+  background-color: var(--wz-color-background);
+}
+a { color: var(--wz-color-primary); }
+.container { padding: 0 var(--wz-space-container); }
+.section { margin-bottom: var(--wz-space-section); }
+.btn {
+  background-color: var(--wz-color-primary);
+  color: #fff;
+  border: none;
+  padding: 8px 16px;
+  border-radius: var(--wz-radius);
+  cursor: pointer;
+}
+.btn-outline {
+  background-color: transparent;
+  color: var(--wz-color-primary);
+  border: 1px solid var(--wz-color-primary);
+}
+.btn-text { background-color: transparent; color: var(--wz-color-primary); }
+.component img { border-radius: var(--wz-radius); }
+.component input, .component textarea, .component select { border-radius: var(--wz-radius); }
+footer { color: var(--wz-color-secondary); }
+.site-nav ul { list-style: none; margin: 0; padding: 0; }
+.site-nav a, .site-nav .nav-label { display: block; padding: 8px 12px; text-decoration: none; }
+.site-nav .nav-item { position: relative; }
+.site-nav-horizontal > .nav-menu, .site-nav-mega-menu > .nav-menu { display: flex; gap: 20px; }
+.site-nav-horizontal .nav-submenu, .site-nav-mega-menu .nav-mega {
+  display: none;
+  position: absolute;
+  top: 100%;
+  left: 0;
+  z-index: 100;
+  background-color: var(--wz-color-background);
+  border-radius: var(--wz-radius);
+  box-shadow: 0 4px 12px rgba(0, 0, 0, 0.15);
+}
+.site-nav-horizontal .nav-submenu { min-width: 180px; }
+.site-nav-horizontal .nav-submenu .nav-submenu { top: 0; left: 100%; }
+.site-nav-horizontal .nav-item:hover > .nav-submenu, .site-nav-horizontal .nav-item:focus-within > .nav-submenu { display: block; }
+.site-nav-vertical .nav-submenu { padding-left: 16px; }
+.site-nav-mega-menu .nav-mega { gap: 32px; padding: 16px; }
+.site-nav-mega-menu .nav-item:hover > .nav-mega, .site-nav-mega-menu .nav-item:focus-within > .nav-mega { display: flex; }
+.site-nav-mega-menu .nav-mega-column { min-width: 160px; }
+.site-nav-mega-menu .nav-mega-column > a, .site-nav-mega-menu .nav-mega-column > .nav-label { font-weight: bold; }
+`
+
+// headerCSS 各页头样式的规则，只作用于默认页头；使用全局区块作为页头时由区块自己的样式决定
+var headerCSS = map[string]string{
+	HeaderStandard: "header:not(.global-section) { display: flex; align-items: center; justify-content: space-between; padding: var(--wz-space-container) 0; }\n",
+	HeaderCentered: "header:not(.global-section) { text-align: center; padding: var(--wz-space-container) 0; }\nheader:not(.global-section) nav ul { justify-content: center; }\n",
+	HeaderMinimal:  "header:not(.global-section) { display: flex; align-items: center; justify-content: space-between; padding: 8px 0; }\nheader:not(.global-section) .logo img { max-height: 32px !important; }\n",
+}
@@ -0,0 +1,355 @@
+package sitetheme
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxCSSDepth 自定义CSS中@media、@supports等规则的最大嵌套层数
+const maxCSSDepth = 3
+
+var (
+	cssPropertyPattern = regexp.MustCompile(`^(--[a-zA-Z0-9_-]+|-?[a-zA-Z][a-zA-Z0-9-]*)$`)
+	cssKeyframesName   = regexp.MustCompile(`^[a-zA-Z_-][a-zA-Z0-9_-]*$`)
+	cssURLPattern      = regexp.MustCompile(`(?i)url\(\s*(['"]?)([^'")]*)(['"]?)\s*\)`)
+	// 值和条件中禁止出现的写法，可以执行脚本或绕过属性检查
+	cssForbiddenWords = []string{"expression(", "javascript:", "vbscript:", "behavior", "-moz-binding", "@import"}
+)
+
+// CSSError 自定义CSS的解析错误
+type CSSError struct {
+	Line    int
+	Message string
+}
+
+func (e *CSSError) Error() string {
+	return fmt.Sprintf("第%d行: %s", e.Line, e.Message)
+}
+
+// SanitizeCSS 解析自定义CSS并重新输出，去掉注释，是站点中所有用户CSS共用的校验规则。
+// 拒绝@import等引入外部样式的规则、脚本表达式、非http(s)和站内路径的url()、转义字符以及可以跳出<style>的<，
+// 只允许普通规则和@media、@supports、@keyframes、@font-face
+func SanitizeCSS(css string) (string, error) {
+	cleaned, err := stripComments(css)
+	if err != nil {
+		return "", err
+	}
+	parser := &cssParser{src: cleaned, line: 1}
+	if err := parser.parseRules(0); err != nil {
+		return "", err
+	}
+	return parser.out.String(), nil
+}
+
+// CheckDeclaration 检查单条样式声明，用于组件的内联样式等由属性和值拼成的CSS。
+// 属性名和值的规则与SanitizeCSS相同，值中还不能有注释和;{}，字符串和括号必须成对
+func CheckDeclaration(property string, value string) error {
+	if !cssPropertyPattern.MatchString(property) {
+		return &CSSError{Line: 1, Message: fmt.Sprintf("无效的属性名%q", property)}
+	}
+	if strings.ContainsAny(value, ";{}") || strings.Contains(value, "/*") {
+		return &CSSError{Line: 1, Message: "样式值中不能包含;{}和注释"}
+	}
+	if _, err := stripComments(value); err != nil {
+		return err
+	}
+	parser := &cssParser{src: value, line: 1}
+	if _, _, err := parser.readUntil(""); err != nil {
+		return err
+	}
+	return parser.checkValue(value)
+}
+
+// stripComments 去掉注释并检查禁止的字符，注释替换为空格，保留换行以便报告行号
+func stripComments(css string) (string, error) {
+	var builder strings.Builder
+	line := 1
+	for i := 0; i < len(css); i++ {
+		c := css[i]
+		switch {
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			end := strings.Index(css[i+2:], "*/")
+			if end < 0 {
+				return "", &CSSError{Line: line, Message: "注释没有结束"}
+			}
+			comment := css[i : i+2+end+2]
+			newlines := strings.Count(comment, "\n")
+			line += newlines
+			builder.WriteString(" " + strings.Repeat("\n", newlines))
+			i += len(comment) - 1
+			continue
+		case c == '\\':
+			return "", &CSSError{Line: line, Message: "不支持转义字符"}
+		case c == '<':
+			return "", &CSSError{Line: line, Message: "不允许使用<"}
+		case c == '\n':
+			line++
+		case c < 0x20 && c != '\t' && c != '\r':
+			return "", &CSSError{Line: line, Message: "包含控制字符"}
+		}
+		builder.WriteByte(c)
+	}
+	return builder.String(), nil
+}
+
+// cssParser 自定义CSS的递归下降解析器
+type cssParser struct {
+	src  string
+	pos  int
+	line int
+	out  strings.Builder
+}
+
+func (p *cssParser) errorf(format string, args ...interface{}) error {
+	return &CSSError{Line: p.line, Message: fmt.Sprintf(format, args...)}
+}
+
+// skipSpace 跳过空白并计算行号
+func (p *cssParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\n':
+			p.line++
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+// readUntil 读取到stops中的任一字符为止，跳过字符串和括号中的内容，返回读取的文本和结束字符，读到末尾时结束字符为0
+func (p *cssParser) readUntil(stops string) (string, byte, error) {
+	start := p.pos
+	depth := 0
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], c)
+			if end < 0 || strings.Contains(p.src[p.pos+1:p.pos+1+end], "\n") {
+				return "", 0, p.errorf("字符串没有结束")
+			}
+			p.pos += end + 2
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return "", 0, p.errorf("多余的)")
+			}
+			depth--
+		case c == '\n':
+			p.line++
+		case depth == 0 && strings.IndexByte(stops, c) >= 0:
+			text := strings.TrimSpace(p.src[start:p.pos])
+			p.pos++
+			return text, c, nil
+		}
+		p.pos++
+	}
+	if depth > 0 {
+		return "", 0, p.errorf("括号没有结束")
+	}
+	return strings.TrimSpace(p.src[start:]), 0, nil
+}
+
+// parseRules 解析规则列表，depth大于0时读到}结束
+func (p *cssParser) parseRules(depth int) error {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			if depth > 0 {
+				return p.errorf("缺少}")
+			}
+			return nil
+		}
+		switch p.src[p.pos] {
+		case '}':
+			if depth == 0 {
+				return p.errorf("多余的}")
+			}
+			p.pos++
+			return nil
+		case '@':
+			if err := p.parseAtRule(depth); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseStyleRule(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseStyleRule 解析选择器和声明块
+func (p *cssParser) parseStyleRule() error {
+	selector, stop, err := p.readUntil("{};")
+	if err != nil {
+		return err
+	}
+	if stop != '{' {
+		return p.errorf("选择器%q后缺少{", selector)
+	}
+	if selector == "" {
+		return p.errorf("缺少选择器")
+	}
+	if strings.ContainsAny(selector, "@") {
+		return p.errorf("无效的选择器%q", selector)
+	}
+	p.out.WriteString(collapseSpace(selector) + " {\n")
+	if err := p.parseDeclarations(); err != nil {
+		return err
+	}
+	p.out.WriteString("}\n")
+	return nil
+}
+
+// parseDeclarations 解析声明块，读到}结束
+func (p *cssParser) parseDeclarations() error {
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return p.errorf("缺少}")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			return nil
+		}
+		declaration, stop, err := p.readUntil(";{}")
+		if err != nil {
+			return err
+		}
+		switch stop {
+		case 0:
+			return p.errorf("缺少}")
+		case '{':
+			return p.errorf("声明块中不能嵌套规则")
+		}
+		if declaration != "" {
+			if err := p.writeDeclaration(declaration); err != nil {
+				return err
+			}
+		}
+		if stop == '}' {
+			return nil
+		}
+	}
+}
+
+// writeDeclaration 校验并输出单条声明
+func (p *cssParser) writeDeclaration(declaration string) error {
+	property, value, ok := strings.Cut(declaration, ":")
+	property = strings.TrimSpace(property)
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return p.errorf("无效的声明%q", declaration)
+	}
+	if !cssPropertyPattern.MatchString(property) {
+		return p.errorf("无效的属性名%q", property)
+	}
+	if err := p.checkValue(declaration); err != nil {
+		return err
+	}
+	p.out.WriteString("  " + strings.ToLower(property) + ": " + value + ";\n")
+	return nil
+}
+
+// checkValue 检查声明或@规则条件中是否有禁止的写法
+func (p *cssParser) checkValue(value string) error {
+	lower := strings.ToLower(value)
+	for _, word := range cssForbiddenWords {
+		if strings.Contains(lower, word) {
+			return p.errorf("不允许使用%s", word)
+		}
+	}
+	urls := cssURLPattern.FindAllStringSubmatch(value, -1)
+	if strings.Count(lower, "url(") != len(urls) {
+		return p.errorf("无效的url()")
+	}
+	for _, match := range urls {
+		if match[1] != match[3] || !allowedURL(strings.TrimSpace(match[2])) {
+			return p.errorf("url()只能使用http(s)地址或站内路径")
+		}
+	}
+	return nil
+}
+
+// allowedURL 允许http(s)地址和站内路径
+func allowedURL(url string) bool {
+	lower := strings.ToLower(url)
+	switch {
+	case strings.HasPrefix(lower, "https://"), strings.HasPrefix(lower, "http://"):
+		return len(url) > len("https://")
+	case strings.HasPrefix(url, "/") && !strings.HasPrefix(url, "//"):
+		return true
+	}
+	return false
+}
+
+// parseAtRule 解析@规则
+func (p *cssParser) parseAtRule(depth int) error {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) && (p.src[p.pos] == '-' || isLetter(p.src[p.pos])) {
+		p.pos++
+	}
+	name := strings.ToLower(p.src[start+1 : p.pos])
+	prelude, stop, err := p.readUntil("{};")
+	if err != nil {
+		return err
+	}
+
+	switch name {
+	case "media", "supports", "keyframes", "-webkit-keyframes":
+	case "font-face":
+		if prelude != "" {
+			return p.errorf("@font-face不需要条件")
+		}
+	default:
+		return p.errorf("不允许使用@%s", name)
+	}
+	if stop != '{' {
+		return p.errorf("@%s后缺少{", name)
+	}
+	if depth >= maxCSSDepth {
+		return p.errorf("规则嵌套过深")
+	}
+	if err := p.checkValue(prelude); err != nil {
+		return err
+	}
+
+	switch name {
+	case "font-face":
+		p.out.WriteString("@font-face {\n")
+		if err := p.parseDeclarations(); err != nil {
+			return err
+		}
+	case "keyframes", "-webkit-keyframes":
+		if !cssKeyframesName.MatchString(prelude) {
+			return p.errorf("无效的动画名称%q", prelude)
+		}
+		fallthrough
+	default:
+		if prelude == "" {
+			return p.errorf("@%s缺少条件", name)
+		}
+		p.out.WriteString("@" + name + " " + collapseSpace(prelude) + " {\n")
+		if err := p.parseRules(depth + 1); err != nil {
+			return err
+		}
+	}
+	p.out.WriteString("}\n")
+	return nil
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// collapseSpace 把连续的空白合并为一个空格
+func collapseSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
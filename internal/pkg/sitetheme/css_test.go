@@ -0,0 +1,131 @@
+package sitetheme
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeCSS(t *testing.T) {
+	tests := []struct {
+		name string
+		css  string
+		want string
+	}{
+		{"空", "", ""},
+		{"普通规则", "h1,h2 {color:red;margin : 0 auto}", "h1,h2 {\n  color: red;\n  margin: 0 auto;\n}\n"},
+		{"去掉注释", "/* 标题 */\na{ /* x */ color:blue; }", "a {\n  color: blue;\n}\n"},
+		{"属性名转小写", "a{COLOR:Red}", "a {\n  color: Red;\n}\n"},
+		{"自定义属性", "a{--brand-color:#fff}", "a {\n  --brand-color: #fff;\n}\n"},
+		{"http地址", "a{background:url('https://cdn.example.com/a.png')}", "a {\n  background: url('https://cdn.example.com/a.png');\n}\n"},
+		{"站内路径", "a{background:url(/media/a.png) no-repeat}", "a {\n  background: url(/media/a.png) no-repeat;\n}\n"},
+		{"字符串中的分号", `a::before{content:"a;b"}`, "a::before {\n  content: \"a;b\";\n}\n"},
+		{"media", "@media (max-width: 600px) { .nav { display:none } }", "@media (max-width: 600px) {\n.nav {\n  display: none;\n}\n}\n"},
+		{"嵌套media和supports", "@media print{@supports (display:grid){a{display:grid}}}", "@media print {\n@supports (display:grid) {\na {\n  display: grid;\n}\n}\n}\n"},
+		{"keyframes", "@keyframes fade{from{opacity:0}to{opacity:1}}", "@keyframes fade {\nfrom {\n  opacity: 0;\n}\nto {\n  opacity: 1;\n}\n}\n"},
+		{"font-face", "@font-face{font-family:Brand;src:url(/media/brand.woff2)}", "@font-face {\n  font-family: Brand;\n  src: url(/media/brand.woff2);\n}\n"},
+	}
+	for _, tt := range tests {
+		got, err := SanitizeCSS(tt.css)
+		if err != nil {
+			t.Errorf("%s: 不应报错，得到%v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: 输出为%q，期望%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeCSSRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		css     string
+		line    int
+		message string
+	}{
+		{"转义字符", `a{color:red\;}`, 1, "不支持转义字符"},
+		{"跳出style", "a{color:red}\n</style><script>", 2, "不允许使用<"},
+		{"控制字符", "a{color:red\x00}", 1, "包含控制字符"},
+		{"注释没有结束", "a{color:red} /* x", 1, "注释没有结束"},
+		{"import", "@import url(https://example.com/a.css);", 1, "不允许使用@import"},
+		{"import在值中", "a{background:x @import}", 1, "不允许使用@import"},
+		{"expression", "a{width:expression(alert(1))}", 1, "不允许使用expression("},
+		{"javascript地址", "a{background:url(javascript:alert(1))}", 1, "不允许使用javascript:"},
+		{"behavior", "a{behavior:url(/x.htc)}", 1, "不允许使用behavior"},
+		{"moz-binding", "a{-moz-binding:url(/x.xml)}", 1, "不允许使用-moz-binding"},
+		{"data地址", "a{background:url(data:image/png;base64,AAAA)}", 1, "url()只能使用http(s)地址或站内路径"},
+		{"协议相对地址", "a{background:url(//evil.example.com/a.png)}", 1, "url()只能使用http(s)地址或站内路径"},
+		{"相对路径", "a{background:url(a.png)}", 1, "url()只能使用http(s)地址或站内路径"},
+		{"引号不成对", `a{background:url('/a.png")}`, 1, "字符串没有结束"},
+		{"media条件中的url", "@media (x: url(ftp://a)) {a{color:red}}", 1, "url()只能使用http(s)地址或站内路径"},
+		{"字符串没有结束", "a{content:\"abc\n}", 1, "字符串没有结束"},
+		{"括号没有结束", "a{width:calc(1px}", 1, "括号没有结束"},
+		{"多余的括号", "a{width:1px)}", 1, "多余的)"},
+		{"多余的}", "a{color:red}}", 1, "多余的}"},
+		{"缺少}", "a{color:red", 1, "缺少}"},
+		{"缺少选择器", "{color:red}", 1, "缺少选择器"},
+		{"未知的@规则", "@charset \"utf-8\";", 1, "不允许使用@charset"},
+		{"page规则", "@page{margin:0}", 1, "不允许使用@page"},
+		{"media缺少条件", "@media{a{color:red}}", 1, "@media缺少条件"},
+		{"font-face带条件", "@font-face x{src:url(/a.woff)}", 1, "@font-face不需要条件"},
+		{"无效的动画名称", "@keyframes 1fade{from{opacity:0}}", 1, "无效的动画名称\"1fade\""},
+		{"嵌套过深", "@media a{@media b{@media c{@media d{x{color:red}}}}}", 1, "规则嵌套过深"},
+		{"声明块中嵌套规则", "a{color:red; b{color:blue}}", 1, "声明块中不能嵌套规则"},
+		{"无效的声明", "a{color}", 1, "无效的声明\"color\""},
+		{"无效的属性名", "a{co lor:red}", 1, "无效的属性名\"co lor\""},
+		{"行号", "a{color:red}\n\nb{\n  background:url(a.png);\n}", 4, "url()只能使用http(s)地址或站内路径"},
+	}
+	for _, tt := range tests {
+		_, err := SanitizeCSS(tt.css)
+		var cssErr *CSSError
+		if !errors.As(err, &cssErr) {
+			t.Errorf("%s: 期望CSSError，得到%v", tt.name, err)
+			continue
+		}
+		if cssErr.Line != tt.line || cssErr.Message != tt.message {
+			t.Errorf("%s: 错误为第%d行 %q，期望第%d行 %q", tt.name, cssErr.Line, cssErr.Message, tt.line, tt.message)
+		}
+	}
+}
+
+func TestCheckDeclaration(t *testing.T) {
+	tests := []struct {
+		property string
+		value    string
+		valid    bool
+	}{
+		{"color", "#333", true},
+		{"font-family", `"Noto Sans", sans-serif`, true},
+		{"background-image", "url(https://cdn.example.com/a.png)", true},
+		{"width", "calc(100% - 2rem)", true},
+		{"--gap", "12px", true},
+		{"color", "red;background:url(javascript:x)", false},
+		{"color", "red}a{color:blue", false},
+		{"color", "red /* x */", false},
+		{"color", `red\9`, false},
+		{"content", `"</style>"`, false},
+		{"width", "expression(alert(1))", false},
+		{"background", "url(data:text/html,x)", false},
+		{"content", `"abc`, false},
+		{"width", "calc(1px", false},
+		{"color:", "red", false},
+		{"", "red", false},
+	}
+	for _, tt := range tests {
+		err := CheckDeclaration(tt.property, tt.value)
+		if tt.valid && err != nil {
+			t.Errorf("%s: %s 不应报错，得到%v", tt.property, tt.value, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: %s 应当被拒绝", tt.property, tt.value)
+		}
+	}
+}
+
+func TestCSSErrorMessage(t *testing.T) {
+	_, err := SanitizeCSS("a{}\n@import x;")
+	if err == nil || !strings.HasPrefix(err.Error(), "第2行: ") {
+		t.Fatalf("错误信息应当包含行号，得到%v", err)
+	}
+}
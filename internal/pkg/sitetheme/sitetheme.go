@@ -0,0 +1,101 @@
+// Package sitetheme 校验站点主题的设计变量，把主题编译为带内容哈希的样式表，并清理自定义CSS。
+// 站点构建器的models.ThemeConfig和后台主题库domain.Theme的Config使用同一套变量和编译规则
+package sitetheme
+
+import (
+	"encoding/json"
+	"errors"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/models"
+)
+
+// maxCustomCSSLength 自定义CSS的最大长度
+const maxCustomCSSLength = 20000
+
+// fontFamilyPattern 逗号分隔的字体名，字体名可以用成对的引号括起来
+const fontFamilyPattern = `^\s*([\p{L}\p{N} ._-]+|'[\p{L}\p{N} ._-]+'|"[\p{L}\p{N} ._-]+")(\s*,\s*([\p{L}\p{N} ._-]+|'[\p{L}\p{N} ._-]+'|"[\p{L}\p{N} ._-]+"))*\s*$`
+
+// Schema 主题配置的Schema，编辑器可以据此生成主题面板；空字符串表示使用默认值，不参与校验
+var Schema = jsonschema.Object(map[string]*jsonschema.Schema{
+	"primaryColor":        jsonschema.String("主色").WithFormat(jsonschema.FormatColor),
+	"secondaryColor":      jsonschema.String("辅助色").WithFormat(jsonschema.FormatColor),
+	"accentColor":         jsonschema.String("强调色").WithFormat(jsonschema.FormatColor),
+	"textColor":           jsonschema.String("文字颜色").WithFormat(jsonschema.FormatColor),
+	"backgroundColor":     jsonschema.String("背景颜色").WithFormat(jsonschema.FormatColor),
+	"fontFamily":          jsonschema.String("字体").WithPattern(fontFamilyPattern).WithMaxLength(200),
+	"headerStyle":         jsonschema.Enum("页头样式", HeaderStandard, HeaderCentered, HeaderMinimal),
+	"borderRadius":        jsonschema.String("圆角").WithPattern(`^(none|small|medium|large|0|[0-9]*\.?[0-9]+(px|rem|em|%))$`),
+	"spacing":             jsonschema.Enum("间距", SpacingCompact, SpacingNormal, SpacingRelaxed),
+	"darkMode":            jsonschema.Enum("深色模式", DarkModeOff, DarkModeAuto, DarkModeOn),
+	"darkTextColor":       jsonschema.String("深色模式文字颜色").WithFormat(jsonschema.FormatColor),
+	"darkBackgroundColor": jsonschema.String("深色模式背景颜色").WithFormat(jsonschema.FormatColor),
+	"customCSS":           jsonschema.String("自定义CSS").WithMaxLength(maxCustomCSSLength),
+})
+
+// 页头样式
+const (
+	HeaderStandard = "standard"
+	HeaderCentered = "centered"
+	HeaderMinimal  = "minimal"
+)
+
+// 间距
+const (
+	SpacingCompact = "compact"
+	SpacingNormal  = "normal"
+	SpacingRelaxed = "relaxed"
+)
+
+// 深色模式
+const (
+	DarkModeOff  = "off"
+	DarkModeAuto = "auto"
+	DarkModeOn   = "on"
+)
+
+// Validate 校验主题配置，自定义CSS需要能通过SanitizeCSS。
+// 校验失败时返回包含所有字段错误的jsonschema.ValidationError，field为错误字段的前缀
+func Validate(field string, theme models.ThemeConfig) error {
+	var fieldErrors []jsonschema.FieldError
+	var validationErr *jsonschema.ValidationError
+	if errors.As(Schema.Validate(field, tokenMap(theme)), &validationErr) {
+		fieldErrors = append(fieldErrors, validationErr.Errors...)
+	}
+	if len(theme.CustomCSS) <= maxCustomCSSLength {
+		if _, err := SanitizeCSS(theme.CustomCSS); err != nil {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: field + ".customCSS", Message: err.Error()})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return &jsonschema.ValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+// ParseConfig 解析后台主题库中的主题配置，未知字段会被忽略，配置必须能通过Validate
+func ParseConfig(config string) (models.ThemeConfig, error) {
+	var theme models.ThemeConfig
+	if config == "" {
+		return theme, nil
+	}
+	if err := json.Unmarshal([]byte(config), &theme); err != nil {
+		return models.ThemeConfig{}, errors.New("主题配置不是有效的JSON对象")
+	}
+	if err := Validate("config", theme); err != nil {
+		return models.ThemeConfig{}, err
+	}
+	return theme, nil
+}
+
+// tokenMap 把主题配置转换为map，去掉表示默认值的空字符串
+func tokenMap(theme models.ThemeConfig) map[string]interface{} {
+	data, _ := json.Marshal(theme)
+	var values map[string]interface{}
+	_ = json.Unmarshal(data, &values)
+	for key, value := range values {
+		if value == "" {
+			delete(values, key)
+		}
+	}
+	return values
+}
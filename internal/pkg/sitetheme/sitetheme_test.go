@@ -0,0 +1,128 @@
+package sitetheme
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/models"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []models.ThemeConfig{
+		{},
+		{
+			PrimaryColor:        "#1a73e8",
+			SecondaryColor:      "rgba(0, 0, 0, 0.5)",
+			AccentColor:         "hsl(30, 100%, 50%)",
+			TextColor:           "black",
+			BackgroundColor:     "#fff",
+			FontFamily:          `"PingFang SC", 'Noto Sans', sans-serif`,
+			HeaderStyle:         HeaderCentered,
+			BorderRadius:        "0.5rem",
+			Spacing:             SpacingRelaxed,
+			DarkMode:            DarkModeAuto,
+			DarkTextColor:       "#eee",
+			DarkBackgroundColor: "#111",
+			CustomCSS:           "@media (max-width: 600px) { .nav { display: none } }",
+		},
+		{BorderRadius: "large"},
+		{BorderRadius: "0"},
+		{FontFamily: "思源黑体, serif"},
+	}
+	for _, theme := range valid {
+		if err := Validate("theme", theme); err != nil {
+			t.Errorf("%+v 不应报错，得到%v", theme, err)
+		}
+	}
+
+	tests := []struct {
+		theme  models.ThemeConfig
+		fields []string
+	}{
+		{models.ThemeConfig{PrimaryColor: "#12345"}, []string{"theme.primaryColor"}},
+		{models.ThemeConfig{TextColor: "red;}body{display:none"}, []string{"theme.textColor"}},
+		{models.ThemeConfig{BackgroundColor: "url(/a.png)"}, []string{"theme.backgroundColor"}},
+		{models.ThemeConfig{FontFamily: "Arial; color: red"}, []string{"theme.fontFamily"}},
+		{models.ThemeConfig{FontFamily: `"Arial', serif`}, []string{"theme.fontFamily"}},
+		{models.ThemeConfig{HeaderStyle: "sticky"}, []string{"theme.headerStyle"}},
+		{models.ThemeConfig{BorderRadius: "5vh"}, []string{"theme.borderRadius"}},
+		{models.ThemeConfig{Spacing: "wide"}, []string{"theme.spacing"}},
+		{models.ThemeConfig{DarkMode: "true"}, []string{"theme.darkMode"}},
+		{models.ThemeConfig{CustomCSS: "@import url(https://example.com/a.css);"}, []string{"theme.customCSS"}},
+		{models.ThemeConfig{CustomCSS: strings.Repeat("a", maxCustomCSSLength+1)}, []string{"theme.customCSS"}},
+		{
+			models.ThemeConfig{PrimaryColor: "nope!", Spacing: "wide", CustomCSS: "a{width:expression(1)}"},
+			[]string{"theme.customCSS", "theme.primaryColor", "theme.spacing"},
+		},
+	}
+	for _, tt := range tests {
+		err := Validate("theme", tt.theme)
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Errorf("%+v 期望ValidationError，得到%v", tt.theme, err)
+			continue
+		}
+		var fields []string
+		for _, fieldErr := range validationErr.Errors {
+			fields = append(fields, fieldErr.Field)
+		}
+		sort.Strings(fields)
+		if strings.Join(fields, ",") != strings.Join(tt.fields, ",") {
+			t.Errorf("%+v 的错误字段为%v，期望%v", tt.theme, fields, tt.fields)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	theme, err := ParseConfig(`{"primaryColor":"#000","unknown":1}`)
+	if err != nil || theme.PrimaryColor != "#000" {
+		t.Fatalf("解析结果为%+v, %v", theme, err)
+	}
+	if theme, err := ParseConfig(""); err != nil || theme != (models.ThemeConfig{}) {
+		t.Fatalf("空配置应当返回零值，得到%+v, %v", theme, err)
+	}
+	if _, err := ParseConfig(`[1]`); err == nil {
+		t.Fatal("非对象的配置应当被拒绝")
+	}
+	if _, err := ParseConfig(`{"darkMode":"always"}`); err == nil {
+		t.Fatal("无效的变量应当被拒绝")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	base := Compile(models.ThemeConfig{})
+	if !strings.Contains(base.CSS, "--wz-color-primary: "+defaults.PrimaryColor+";") {
+		t.Fatalf("空主题应当使用默认值:\n%s", base.CSS)
+	}
+	if base.FileName() != "theme-"+base.Hash+".css" || len(base.Hash) != 16 {
+		t.Fatalf("文件名为%q", base.FileName())
+	}
+	if Compile(models.ThemeConfig{}).Hash != base.Hash {
+		t.Fatal("相同的主题应当得到相同的哈希")
+	}
+
+	invalid := Compile(models.ThemeConfig{PrimaryColor: "red;}body{display:none", Spacing: "wide"})
+	if invalid.Hash != base.Hash {
+		t.Fatalf("无效的变量应当回退为默认值:\n%s", invalid.CSS)
+	}
+
+	custom := Compile(models.ThemeConfig{PrimaryColor: "#ff0000", CustomCSS: "a{color:var(--wz-color-primary)}"})
+	if custom.Hash == base.Hash {
+		t.Fatal("主题变化后哈希应当变化")
+	}
+	if !strings.Contains(custom.CSS, "--wz-color-primary: #ff0000;") || !strings.HasSuffix(custom.CSS, "/* 自定义CSS */\na {\n  color: var(--wz-color-primary);\n}\n") {
+		t.Fatalf("样式表缺少主色或自定义CSS:\n%s", custom.CSS)
+	}
+
+	ignored := Compile(models.ThemeConfig{CustomCSS: "@import url(https://example.com/a.css);"})
+	if ignored.Hash != base.Hash || strings.Contains(ignored.CSS, "@import") {
+		t.Fatalf("无效的自定义CSS应当被忽略:\n%s", ignored.CSS)
+	}
+
+	auto := Compile(models.ThemeConfig{DarkMode: DarkModeAuto, DarkBackgroundColor: "#000"})
+	if !strings.Contains(auto.CSS, "@media (prefers-color-scheme: dark)") || !strings.Contains(auto.CSS, "--wz-color-background: #000;") {
+		t.Fatalf("跟随系统的深色模式缺少媒体查询:\n%s", auto.CSS)
+	}
+}
@@ -0,0 +1,443 @@
+package builder
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+)
+
+// 存储契约测试：内存存储和数据库存储对相同的操作必须有相同的结果，
+// 服务只依赖这里检查的行为。设置BUILDER_TEST_DB_DSN时同时在MySQL上运行
+
+func TestMemoryStoreContract(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) *Store {
+		return NewMemoryStore()
+	})
+}
+
+func TestGormStoreContract(t *testing.T) {
+	dsn := os.Getenv("BUILDER_TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("未设置BUILDER_TEST_DB_DSN")
+	}
+	store, err := openGormStore(dsn)
+	if err != nil {
+		t.Fatalf("连接测试数据库失败: %v", err)
+	}
+	runStoreContract(t, func(t *testing.T) *Store {
+		return store
+	})
+}
+
+// runStoreContract 在newStore创建的存储上运行所有契约测试，每个子测试使用独立的站点
+func runStoreContract(t *testing.T, newStore func(t *testing.T) *Store) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store *Store)
+	}{
+		{"NotFound", contractNotFound},
+		{"RevisionConflict", contractRevisionConflict},
+		{"Reorder", contractReorder},
+		{"ReturnedRecordsAreCopies", contractReturnedRecordsAreCopies},
+		{"DuplicateKeys", contractDuplicateKeys},
+		{"CreateSiteTree", contractCreateSiteTree},
+		{"DeleteSiteTree", contractDeleteSiteTree},
+		{"Publish", contractPublish},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.run(t, newStore(t))
+		})
+	}
+}
+
+// createTestSite 创建包含一个页面、一个区块和一个组件的站点，测试结束时删除
+func createTestSite(t *testing.T, store *Store) models.Site {
+	t.Helper()
+	tree := models.Site{
+		Name:     "契约测试站点",
+		TenantID: "tenant_" + uuid.NewString()[:8],
+		Status:   "draft",
+		Pages: []models.Page{{
+			Name:       "首页",
+			Slug:       "home",
+			IsHomepage: true,
+			Sections: []models.Section{{
+				Type:     "content",
+				Settings: map[string]interface{}{"padding": "20px"},
+				Components: []models.Component{{
+					Type:     "text",
+					Content:  map[string]interface{}{"text": "你好", "items": []interface{}{map[string]interface{}{"label": "a"}}},
+					Settings: map[string]interface{}{"align": "left"},
+				}},
+			}},
+		}},
+	}
+	if err := store.CreateSiteTree(&tree); err != nil {
+		t.Fatalf("创建站点失败: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := store.DeleteSiteTree(tree.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			t.Errorf("清理站点失败: %v", err)
+		}
+	})
+	return tree
+}
+
+func contractNotFound(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+	page := site.Pages[0]
+	section := page.Sections[0]
+	missing := uuid.NewString()
+
+	checks := map[string]error{}
+	_, checks["Sites.Get"] = store.Sites.Get(missing)
+	checks["Sites.Update"] = store.Sites.Update(&models.Site{ID: missing})
+	checks["Sites.Delete"] = store.Sites.Delete(missing)
+	_, checks["Pages.Get"] = store.Pages.Get(site.ID, missing)
+	_, checks["Pages.Get其他站点"] = store.Pages.Get(missing, page.ID)
+	checks["Pages.Update"] = store.Pages.Update(&models.Page{ID: missing, SiteID: site.ID, Revision: 1})
+	checks["Pages.Delete"] = store.Pages.Delete(site.ID, missing)
+	_, checks["Sections.Get"] = store.Sections.Get(page.ID, missing)
+	checks["Sections.Update"] = store.Sections.Update(&models.Section{ID: missing, PageID: page.ID, Revision: 1})
+	checks["Sections.Delete"] = store.Sections.Delete(page.ID, missing)
+	_, checks["Components.Get"] = store.Components.Get(section.ID, missing)
+	_, checks["Components.Get其他区块"] = store.Components.Get(missing, section.Components[0].ID)
+	checks["Components.Update"] = store.Components.Update(&models.Component{ID: missing, SectionID: section.ID, Revision: 1})
+	checks["Components.Delete"] = store.Components.Delete(section.ID, missing)
+	_, checks["Versions.Get"] = store.Versions.Get(site.ID, 1)
+	_, checks["Versions.Latest"] = store.Versions.Latest(site.ID)
+	_, checks["Domains.Get"] = store.Domains.Get(site.ID, missing)
+	_, checks["Domains.GetByHostname"] = store.Domains.GetByHostname(missing + ".example.com")
+	_, checks["LoadSiteTree"] = store.LoadSiteTree(missing)
+
+	for name, err := range checks {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s 应返回ErrNotFound，实际为 %v", name, err)
+		}
+	}
+}
+
+func contractRevisionConflict(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+
+	page, err := store.Pages.Get(site.ID, site.Pages[0].ID)
+	if err != nil {
+		t.Fatalf("获取页面失败: %v", err)
+	}
+	if page.Revision != 1 {
+		t.Fatalf("新建页面的修订号应为1，实际为%d", page.Revision)
+	}
+	stale := page
+	page.Title = "第一次修改"
+	if err := store.Pages.Update(&page); err != nil {
+		t.Fatalf("更新页面失败: %v", err)
+	}
+	if page.Revision != 2 {
+		t.Fatalf("更新后修订号应为2，实际为%d", page.Revision)
+	}
+
+	stale.Title = "过期的修改"
+	err = store.Pages.Update(&stale)
+	var conflict *ConflictError
+	if !errors.Is(err, ErrRevisionConflict) || !errors.As(err, &conflict) {
+		t.Fatalf("修订号过期时应返回ConflictError，实际为 %v", err)
+	}
+	current, ok := conflict.Current.(models.Page)
+	if !ok || current.Revision != 2 || current.Title != "第一次修改" {
+		t.Fatalf("ConflictError应包含存储中的当前页面: %+v", conflict.Current)
+	}
+	stored, err := store.Pages.Get(site.ID, page.ID)
+	if err != nil || stored.Title != "第一次修改" {
+		t.Fatalf("冲突的更新不能写入: %+v %v", stored, err)
+	}
+
+	section, err := store.Sections.Get(page.ID, site.Pages[0].Sections[0].ID)
+	if err != nil {
+		t.Fatalf("获取区块失败: %v", err)
+	}
+	section.Revision++
+	if err := store.Sections.Update(&section); !errors.Is(err, ErrRevisionConflict) {
+		t.Fatalf("区块修订号超前时应返回冲突，实际为 %v", err)
+	}
+
+	component, err := store.Components.Get(section.ID, site.Pages[0].Sections[0].Components[0].ID)
+	if err != nil {
+		t.Fatalf("获取组件失败: %v", err)
+	}
+	stale2 := component
+	component.Name = "新名称"
+	if err := store.Components.Update(&component); err != nil {
+		t.Fatalf("更新组件失败: %v", err)
+	}
+	if err := store.Components.Update(&stale2); !errors.As(err, &conflict) {
+		t.Fatalf("组件修订号过期时应返回ConflictError，实际为 %v", err)
+	}
+	if current, ok := conflict.Current.(models.Component); !ok || current.Name != "新名称" {
+		t.Fatalf("ConflictError应包含存储中的当前组件: %+v", conflict.Current)
+	}
+}
+
+func contractReorder(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+	second := models.Page{SiteID: site.ID, Name: "关于", Slug: "about"}
+	if err := store.Pages.Create(&second); err != nil {
+		t.Fatalf("创建页面失败: %v", err)
+	}
+	if second.Revision != 1 || second.SortOrder != 1 {
+		t.Fatalf("新页面应追加到末尾: sortOrder=%d revision=%d", second.SortOrder, second.Revision)
+	}
+
+	if err := store.Pages.Reorder(site.ID, []string{second.ID, site.Pages[0].ID}); err != nil {
+		t.Fatalf("排序失败: %v", err)
+	}
+	pages, err := store.Pages.ListBySite(site.ID)
+	if err != nil || len(pages) != 2 || pages[0].ID != second.ID || pages[0].SortOrder != 0 || pages[1].SortOrder != 1 {
+		t.Fatalf("排序结果错误: %+v %v", pages, err)
+	}
+	if err := store.Pages.Reorder(site.ID, []string{second.ID}); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("排序列表不完整时应返回ErrInvalidOrder，实际为 %v", err)
+	}
+	if err := store.Pages.Reorder(site.ID, []string{second.ID, uuid.NewString()}); !errors.Is(err, ErrInvalidOrder) {
+		t.Fatalf("排序列表包含其他页面时应返回ErrInvalidOrder，实际为 %v", err)
+	}
+}
+
+func contractReturnedRecordsAreCopies(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+	section := site.Pages[0].Sections[0]
+	componentID := section.Components[0].ID
+
+	component, err := store.Components.Get(section.ID, componentID)
+	if err != nil {
+		t.Fatalf("获取组件失败: %v", err)
+	}
+	content := component.Content.(map[string]interface{})
+	content["text"] = "被修改"
+	content["items"].([]interface{})[0].(map[string]interface{})["label"] = "被修改"
+	component.Settings.(map[string]interface{})["align"] = "right"
+
+	listed, err := store.Components.ListBySection(section.ID)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("列出组件失败: %v", err)
+	}
+	listed[0].Content.(map[string]interface{})["text"] = "列表中被修改"
+
+	stored, err := store.Components.Get(section.ID, componentID)
+	if err != nil {
+		t.Fatalf("获取组件失败: %v", err)
+	}
+	storedContent := stored.Content.(map[string]interface{})
+	if storedContent["text"] != "你好" || storedContent["items"].([]interface{})[0].(map[string]interface{})["label"] != "a" {
+		t.Fatalf("修改返回的组件内容不能影响存储: %+v", storedContent)
+	}
+	if stored.Settings.(map[string]interface{})["align"] != "left" {
+		t.Fatalf("修改返回的组件设置不能影响存储: %+v", stored.Settings)
+	}
+
+	sectionCopy, err := store.Sections.Get(section.PageID, section.ID)
+	if err != nil {
+		t.Fatalf("获取区块失败: %v", err)
+	}
+	sectionCopy.Settings.(map[string]interface{})["padding"] = "0"
+	storedSection, err := store.Sections.Get(section.PageID, section.ID)
+	if err != nil || storedSection.Settings.(map[string]interface{})["padding"] != "20px" {
+		t.Fatalf("修改返回的区块设置不能影响存储: %+v %v", storedSection.Settings, err)
+	}
+
+	// 写入之后继续修改传入的对象也不能影响存储
+	stored.Content.(map[string]interface{})["text"] = "更新"
+	if err := store.Components.Update(&stored); err != nil {
+		t.Fatalf("更新组件失败: %v", err)
+	}
+	stored.Content.(map[string]interface{})["text"] = "更新后被修改"
+	updated, err := store.Components.Get(section.ID, componentID)
+	if err != nil || updated.Content.(map[string]interface{})["text"] != "更新" {
+		t.Fatalf("修改已写入的组件不能影响存储: %+v %v", updated.Content, err)
+	}
+}
+
+func contractDuplicateKeys(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+	hostname := uuid.NewString()[:8] + ".example.com"
+	first := models.SiteDomain{SiteID: site.ID, Hostname: hostname, CreatedAt: time.Now()}
+	if err := store.Domains.Create(&first); err != nil {
+		t.Fatalf("创建域名失败: %v", err)
+	}
+	second := models.SiteDomain{SiteID: site.ID, Hostname: hostname, CreatedAt: time.Now()}
+	if err := store.Domains.Create(&second); !errors.Is(err, ErrDuplicateHostname) {
+		t.Fatalf("重复的域名应返回ErrDuplicateHostname，实际为 %v", err)
+	}
+
+	hash := uuid.NewString()
+	asset := models.MediaAsset{TenantID: site.TenantID, Hash: hash, FileName: "a.png", CreatedAt: time.Now()}
+	if err := store.Media.Create(&asset); err != nil {
+		t.Fatalf("创建媒体文件失败: %v", err)
+	}
+	t.Cleanup(func() { store.Media.Delete(asset.TenantID, asset.ID) })
+	duplicate := models.MediaAsset{TenantID: site.TenantID, Hash: hash, FileName: "b.png", CreatedAt: time.Now()}
+	if err := store.Media.Create(&duplicate); !errors.Is(err, ErrDuplicateMedia) {
+		t.Fatalf("租户内重复的文件应返回ErrDuplicateMedia，实际为 %v", err)
+	}
+	other := models.MediaAsset{TenantID: site.TenantID + "_other", Hash: hash, FileName: "a.png", CreatedAt: time.Now()}
+	if err := store.Media.Create(&other); err != nil {
+		t.Fatalf("不同租户可以有相同的文件: %v", err)
+	}
+	store.Media.Delete(other.TenantID, other.ID)
+}
+
+func contractCreateSiteTree(t *testing.T, store *Store) {
+	tree := models.Site{
+		ID:       "source-site",
+		Name:     "带全局区块的站点",
+		TenantID: "tenant_contract",
+		GlobalSections: []models.Section{{
+			ID:         "source-header",
+			Type:       "header",
+			Components: []models.Component{{ID: "source-logo", Type: "image"}},
+		}},
+		HeaderSectionID: "source-header",
+		Navigation: models.Navigation{Items: []models.NavigationItem{
+			{ID: "nav-about", PageID: "source-about"},
+		}},
+		Pages: []models.Page{
+			{ID: "source-home", Name: "首页", Slug: "home", IsHomepage: true, Sections: []models.Section{
+				{ID: "source-ref", GlobalSectionID: "source-header"},
+				{ID: "source-body", Type: "content", Components: []models.Component{
+					{ID: "source-text", Type: "text"}, {ID: "source-button", Type: "button"},
+				}},
+			}},
+			{ID: "source-about", Name: "关于", Slug: "about"},
+		},
+	}
+	if err := store.CreateSiteTree(&tree); err != nil {
+		t.Fatalf("创建站点树失败: %v", err)
+	}
+	t.Cleanup(func() { store.DeleteSiteTree(tree.ID) })
+
+	if tree.ID == "" || tree.ID == "source-site" {
+		t.Fatalf("站点ID应重新生成: %s", tree.ID)
+	}
+	loaded, err := store.LoadSiteTree(tree.ID)
+	if err != nil {
+		t.Fatalf("加载站点树失败: %v", err)
+	}
+	if len(loaded.Pages) != 2 || loaded.Pages[0].Slug != "home" || loaded.Pages[1].Slug != "about" {
+		t.Fatalf("页面应按原顺序保存: %+v", loaded.Pages)
+	}
+	home := loaded.Pages[0]
+	if home.ID == "source-home" || len(home.Sections) != 2 || len(home.Sections[1].Components) != 2 {
+		t.Fatalf("页面、区块和组件应全部保存并重新生成ID: %+v", home)
+	}
+	if component := home.Sections[1].Components[0]; component.ID == "source-text" || component.Type != "text" {
+		t.Fatalf("组件ID应重新生成: %+v", component)
+	}
+	if len(loaded.GlobalSections) != 1 || len(loaded.GlobalSections[0].Components) != 1 {
+		t.Fatalf("全局区块及其组件应保存: %+v", loaded.GlobalSections)
+	}
+	header := loaded.GlobalSections[0].ID
+	if header == "source-header" || home.Sections[0].GlobalSectionID != header || loaded.HeaderSectionID != header {
+		t.Fatalf("全局区块的引用应更新为新ID: header=%s ref=%s site=%s", header, home.Sections[0].GlobalSectionID, loaded.HeaderSectionID)
+	}
+	if items := loaded.Navigation.Items; len(items) != 1 || items[0].PageID != loaded.Pages[1].ID {
+		t.Fatalf("导航对页面的引用应更新为新ID: %+v", loaded.Navigation.Items)
+	}
+}
+
+func contractDeleteSiteTree(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+	page := site.Pages[0]
+	section := page.Sections[0]
+	global := models.Section{PageID: GlobalSectionsPageID(site.ID), Type: "footer"}
+	if err := store.Sections.Create(&global); err != nil {
+		t.Fatalf("创建全局区块失败: %v", err)
+	}
+	domain := models.SiteDomain{SiteID: site.ID, Hostname: uuid.NewString()[:8] + ".example.com", CreatedAt: time.Now()}
+	if err := store.Domains.Create(&domain); err != nil {
+		t.Fatalf("创建域名失败: %v", err)
+	}
+	if _, err := store.PublishSite(site.ID, "user_contract", ""); err != nil {
+		t.Fatalf("发布站点失败: %v", err)
+	}
+
+	if err := store.DeleteSiteTree(site.ID); err != nil {
+		t.Fatalf("删除站点树失败: %v", err)
+	}
+	if _, err := store.Sites.Get(site.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("站点应被删除: %v", err)
+	}
+	if pages, err := store.Pages.ListBySite(site.ID); err != nil || len(pages) != 0 {
+		t.Fatalf("页面应被删除: %+v %v", pages, err)
+	}
+	if sections, err := store.Sections.ListByPage(page.ID); err != nil || len(sections) != 0 {
+		t.Fatalf("区块应被删除: %+v %v", sections, err)
+	}
+	if components, err := store.Components.ListBySection(section.ID); err != nil || len(components) != 0 {
+		t.Fatalf("组件应被删除: %+v %v", components, err)
+	}
+	if globals, err := store.Sections.ListByPage(GlobalSectionsPageID(site.ID)); err != nil || len(globals) != 0 {
+		t.Fatalf("全局区块应被删除: %+v %v", globals, err)
+	}
+	if _, err := store.Domains.GetByHostname(domain.Hostname); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("域名应被删除: %v", err)
+	}
+	if err := store.DeleteSiteTree(site.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("删除不存在的站点应返回ErrNotFound，实际为 %v", err)
+	}
+}
+
+func contractPublish(t *testing.T, store *Store) {
+	site := createTestSite(t, store)
+	var events []ChangeEvent
+	store.Subscribe(func(event ChangeEvent) {
+		if event.SiteID == site.ID {
+			events = append(events, event)
+		}
+	})
+
+	if _, err := store.PublishPage(site.ID, site.Pages[0].ID, "user_contract", ""); !errors.Is(err, ErrSiteNotPublished) {
+		t.Fatalf("站点未发布时不能单独发布页面，实际为 %v", err)
+	}
+	first, err := store.PublishSite(site.ID, "user_contract", "第一次发布")
+	if err != nil {
+		t.Fatalf("发布站点失败: %v", err)
+	}
+	second, err := store.PublishSite(site.ID, "user_contract", "")
+	if err != nil {
+		t.Fatalf("发布站点失败: %v", err)
+	}
+	if first.Version != 1 || second.Version != 2 {
+		t.Fatalf("版本号应依次递增: %d %d", first.Version, second.Version)
+	}
+	latest, err := store.Versions.Latest(site.ID)
+	if err != nil || latest.Version != 2 || len(latest.Snapshot.Pages) != 1 {
+		t.Fatalf("最新版本应包含完整站点树: %+v %v", latest, err)
+	}
+	current, err := store.Sites.Get(site.ID)
+	if err != nil || current.Status != "published" || current.PublishedVersion != 2 {
+		t.Fatalf("发布后应更新站点状态: %+v %v", current, err)
+	}
+
+	if err := store.UnpublishSite(site.ID); err != nil {
+		t.Fatalf("下线站点失败: %v", err)
+	}
+	if err := store.UnpublishSite(site.ID); err != nil {
+		t.Fatalf("重复下线不应出错: %v", err)
+	}
+	current, err = store.Sites.Get(site.ID)
+	if err != nil || current.Status != "draft" || current.PublishedVersion != 2 {
+		t.Fatalf("下线后应保留版本号: %+v %v", current, err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("两次发布和一次下线应各发布一个事件，实际为%d个", len(events))
+	}
+	for _, event := range events {
+		if event.Scope != ScopePublish {
+			t.Fatalf("发布事件的范围错误: %+v", event)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package builder
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"wz-backend-go/models"
+)
+
+// ErrDuplicateHostname 域名已被其他站点绑定
+var ErrDuplicateHostname = errors.New("域名已被绑定")
+
+// ErrDomainNotVerified 域名尚未通过验证
+var ErrDomainNotVerified = errors.New("域名尚未通过验证")
+
+// NormalizeHostname 规范化请求头或用户输入中的主机名：去掉端口和末尾的点并转为小写
+func NormalizeHostname(host string) (string, error) {
+	host = strings.TrimSpace(strings.ToLower(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+
+	if host == "" || len(host) > 253 || net.ParseIP(host) != nil {
+		return "", errors.New("无效的域名")
+	}
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return "", errors.New("无效的域名")
+	}
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", errors.New("无效的域名")
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return "", errors.New("无效的域名")
+			}
+		}
+	}
+	return host, nil
+}
+
+// ResolveHostname 根据主机名查找已验证的域名记录，并返回站点的主域名记录
+func (s *Store) ResolveHostname(host string) (domain models.SiteDomain, primary models.SiteDomain, err error) {
+	hostname, err := NormalizeHostname(host)
+	if err != nil {
+		return models.SiteDomain{}, models.SiteDomain{}, ErrNotFound
+	}
+	domain, err = s.Domains.GetByHostname(hostname)
+	if err != nil {
+		return models.SiteDomain{}, models.SiteDomain{}, err
+	}
+	if !domain.Verified {
+		return models.SiteDomain{}, models.SiteDomain{}, ErrNotFound
+	}
+
+	primary = domain
+	if !domain.IsPrimary {
+		domains, err := s.Domains.ListBySite(domain.SiteID)
+		if err != nil {
+			return models.SiteDomain{}, models.SiteDomain{}, err
+		}
+		for _, d := range domains {
+			if d.IsPrimary && d.Verified {
+				primary = d
+				break
+			}
+		}
+	}
+	return domain, primary, nil
+}
+
+// SetPrimaryDomain 将已验证的域名设为站点主域名，同时同步站点的Domain字段
+func (s *Store) SetPrimaryDomain(siteID string, domainID string) (models.SiteDomain, error) {
+	target, err := s.Domains.Get(siteID, domainID)
+	if err != nil {
+		return models.SiteDomain{}, err
+	}
+	if !target.Verified {
+		return models.SiteDomain{}, ErrDomainNotVerified
+	}
+
+	domains, err := s.Domains.ListBySite(siteID)
+	if err != nil {
+		return models.SiteDomain{}, err
+	}
+	for _, d := range domains {
+		if d.IsPrimary == (d.ID == domainID) {
+			continue
+		}
+		d.IsPrimary = d.ID == domainID
+		if err := s.Domains.Update(&d); err != nil {
+			return models.SiteDomain{}, err
+		}
+	}
+	target.IsPrimary = true
+
+	return target, s.syncSiteDomain(siteID, target.Hostname)
+}
+
+// RemoveDomain 解绑域名，解绑主域名时清空站点的Domain字段
+func (s *Store) RemoveDomain(siteID string, domainID string) error {
+	domain, err := s.Domains.Get(siteID, domainID)
+	if err != nil {
+		return err
+	}
+	if err := s.Domains.Delete(siteID, domainID); err != nil {
+		return err
+	}
+	if domain.IsPrimary {
+		return s.syncSiteDomain(siteID, "")
+	}
+	return nil
+}
+
+// syncSiteDomain 更新站点记录中的主域名，供站点地图和导出等使用
+func (s *Store) syncSiteDomain(siteID string, hostname string) error {
+	site, err := s.Sites.Get(siteID)
+	if err != nil {
+		return err
+	}
+	if site.Domain == hostname {
+		return nil
+	}
+	site.Domain = hostname
+	return s.Sites.Update(&site)
+}
@@ -0,0 +1,56 @@
+package builder
+
+// ChangeScope 数据变更影响的范围
+type ChangeScope string
+
+const (
+	// ScopePage 页面内的区块或组件变化，只影响该页面
+	ScopePage ChangeScope = "page"
+	// ScopeSite 站点设置或页面列表变化，影响站点的所有页面
+	ScopeSite ChangeScope = "site"
+	// ScopePublish 站点线上版本变化
+	ScopePublish ChangeScope = "publish"
+	// ScopeRedirect 站点的重定向规则变化
+	ScopeRedirect ChangeScope = "redirect"
+)
+
+// ChangeEvent 站点数据变更事件，ScopePage时PageID为变化的页面
+type ChangeEvent struct {
+	SiteID string
+	PageID string
+	Scope  ChangeScope
+}
+
+// Subscribe 订阅数据变更事件，监听函数在发布事件的goroutine中同步执行
+func (s *Store) Subscribe(listener func(ChangeEvent)) {
+	s.listenerMu.Lock()
+	defer s.listenerMu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+// Notify 发布数据变更事件
+func (s *Store) Notify(event ChangeEvent) {
+	s.listenerMu.RLock()
+	listeners := s.listeners
+	s.listenerMu.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// NotifyPageChanged 发布页面内容变化事件
+func (s *Store) NotifyPageChanged(siteID string, pageID string) {
+	s.Notify(ChangeEvent{SiteID: siteID, PageID: pageID, Scope: ScopePage})
+}
+
+// NotifySiteChanged 发布站点整体变化事件
+func (s *Store) NotifySiteChanged(siteID string) {
+	s.Notify(ChangeEvent{SiteID: siteID, Scope: ScopeSite})
+}
+
+// NotifyRedirectsChanged 发布站点重定向规则变化事件
+func (s *Store) NotifyRedirectsChanged(siteID string) {
+	s.Notify(ChangeEvent{SiteID: siteID, Scope: ScopeRedirect})
+}
@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+)
+
+// GlobalSectionsPageID 全局区块与页面区块使用同一个区块仓储，以该值作为全局区块的PageID
+func GlobalSectionsPageID(siteID string) string {
+	return "global:" + siteID
+}
+
+// LoadGlobalSections 加载站点的全局区块及其组件
+func (s *Store) LoadGlobalSections(siteID string) ([]models.Section, error) {
+	sections, err := s.Sections.ListByPage(GlobalSectionsPageID(siteID))
+	if err != nil {
+		return nil, err
+	}
+	for i := range sections {
+		components, err := s.Components.ListBySection(sections[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		sections[i].Components = components
+	}
+	return sections, nil
+}
+
+// renewGlobalSectionIDs 为站点树中的全局区块生成新ID，并更新页面区块、全局区块之间的引用
+// 以及站点的页头页脚，指向树中不存在的区块的引用会被清除
+func renewGlobalSectionIDs(tree *models.Site) {
+	renamed := map[string]string{}
+	for i := range tree.GlobalSections {
+		newID := uuid.NewString()
+		if tree.GlobalSections[i].ID != "" {
+			renamed[tree.GlobalSections[i].ID] = newID
+		}
+		tree.GlobalSections[i].ID = newID
+	}
+
+	for i := range tree.GlobalSections {
+		tree.GlobalSections[i].GlobalSectionID = renamed[tree.GlobalSections[i].GlobalSectionID]
+	}
+	for i := range tree.Pages {
+		for j := range tree.Pages[i].Sections {
+			section := &tree.Pages[i].Sections[j]
+			section.GlobalSectionID = renamed[section.GlobalSectionID]
+		}
+	}
+	tree.HeaderSectionID = renamed[tree.HeaderSectionID]
+	tree.FooterSectionID = renamed[tree.FooterSectionID]
+}
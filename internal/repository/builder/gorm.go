@@ -0,0 +1,956 @@
+package builder
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"wz-backend-go/internal/domain"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NewGormStore 创建基于GORM的存储
+func NewGormStore(db *gorm.DB) *Store {
+	store := gormRepositories(db)
+	store.transaction = func(fn func(tx *Store) error) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return fn(gormRepositories(tx))
+		})
+	}
+	return store
+}
+
+// gormRepositories 创建使用db的仓储集合，db为事务时所有仓储在同一事务中读写
+func gormRepositories(db *gorm.DB) *Store {
+	return &Store{
+		Sites:      &gormSiteRepository{db: db},
+		Pages:      &gormPageRepository{db: db},
+		Sections:   &gormSectionRepository{db: db},
+		Components: &gormComponentRepository{db: db},
+		Templates:  &gormTemplateRepository{db: db},
+		Versions:   &gormVersionRepository{db: db},
+		Domains:    &gormDomainRepository{db: db},
+
+		FormSubmissions: &gormFormSubmissionRepository{db: db},
+		FormSettings:    &gormFormSettingsRepository{db: db},
+		PreviewTokens:   &gormPreviewTokenRepository{db: db},
+		Themes:          &gormThemeRepository{db: db},
+		Redirects:       &gormRedirectRepository{db: db},
+		Schedules:       &gormScheduleRepository{db: db},
+		Analytics:       &gormAnalyticsRepository{db: db},
+		EditHistory:     &gormEditHistoryRepository{db: db},
+		Media:           &gormMediaRepository{db: db},
+	}
+}
+
+// AutoMigrate 创建或更新站点构建器的数据表，主题表属于后台，不在这里迁移
+func AutoMigrate(db *gorm.DB) error {
+	return db.AutoMigrate(
+		&models.Site{},
+		&models.Page{},
+		&models.Section{},
+		&models.Component{},
+		&models.SiteTemplate{},
+		&models.SiteVersion{},
+		&models.SiteDomain{},
+		&models.FormSubmission{},
+		&models.FormNotificationSettings{},
+		&models.PreviewToken{},
+		&models.SiteRedirect{},
+		&models.PublishSchedule{},
+		&models.PageEvent{},
+		&models.AnalyticsRollup{},
+		&models.EditOperation{},
+		&models.MediaAsset{},
+	)
+}
+
+// translateError 将GORM的未找到错误转换为ErrNotFound
+func translateError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// nextSortOrder 计算父级下新记录的排序值
+func nextSortOrder(db *gorm.DB, model interface{}, parentColumn string, parentID string) (int, error) {
+	var count int64
+	if err := db.Model(model).Where(parentColumn+" = ?", parentID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// revisionConflict 条件更新未命中时，用最新记录组成ConflictError
+func revisionConflict[T any](current T, err error) error {
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Current: current}
+}
+
+// reorderRows 在事务中按给定ID顺序重写sort_order
+func reorderRows(db *gorm.DB, model interface{}, parentColumn string, parentID string, ids []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var existing []string
+		if err := tx.Model(model).Where(parentColumn+" = ?", parentID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(existing) != len(ids) {
+			return ErrInvalidOrder
+		}
+
+		known := make(map[string]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+		for i, id := range ids {
+			if !known[id] {
+				return ErrInvalidOrder
+			}
+			delete(known, id)
+			if err := tx.Model(model).Where("id = ? AND "+parentColumn+" = ?", id, parentID).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deleteAndCompact 删除记录并重新编排剩余记录的排序
+func deleteAndCompact(db *gorm.DB, model interface{}, parentColumn string, parentID string, id string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND "+parentColumn+" = ?", id, parentID).Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+
+		var remaining []string
+		if err := tx.Model(model).Where(parentColumn+" = ?", parentID).Order("sort_order").Pluck("id", &remaining).Error; err != nil {
+			return err
+		}
+		for i, remainingID := range remaining {
+			if err := tx.Model(model).Where("id = ?", remainingID).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// gormSiteRepository 站点GORM仓储
+type gormSiteRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormSiteRepository) List(filter SiteFilter) ([]models.Site, error) {
+	query := r.db.Model(&models.Site{})
+	if filter.TenantID != "" {
+		query = query.Where("tenant_id = ?", filter.TenantID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Search != "" {
+		like := "%" + filter.Search + "%"
+		query = query.Where("name LIKE ? OR description LIKE ?", like, like)
+	}
+
+	var sites []models.Site
+	err := query.Order("created_at").Find(&sites).Error
+	return sites, err
+}
+
+func (r *gormSiteRepository) Get(siteID string) (models.Site, error) {
+	var site models.Site
+	err := r.db.Where("id = ?", siteID).First(&site).Error
+	return site, translateError(err)
+}
+
+func (r *gormSiteRepository) Create(site *models.Site) error {
+	if site.ID == "" {
+		site.ID = uuid.NewString()
+	}
+	return r.db.Create(site).Error
+}
+
+func (r *gormSiteRepository) Update(site *models.Site) error {
+	if _, err := r.Get(site.ID); err != nil {
+		return err
+	}
+	return r.db.Model(&models.Site{}).Where("id = ?", site.ID).Select("*").Updates(site).Error
+}
+
+func (r *gormSiteRepository) Delete(siteID string) error {
+	result := r.db.Where("id = ?", siteID).Delete(&models.Site{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gormPageRepository 页面GORM仓储
+type gormPageRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormPageRepository) ListBySite(siteID string) ([]models.Page, error) {
+	var pages []models.Page
+	err := r.db.Where("site_id = ?", siteID).Order("sort_order").Find(&pages).Error
+	return pages, err
+}
+
+func (r *gormPageRepository) Get(siteID string, pageID string) (models.Page, error) {
+	var page models.Page
+	err := r.db.Where("id = ? AND site_id = ?", pageID, siteID).First(&page).Error
+	return page, translateError(err)
+}
+
+func (r *gormPageRepository) Create(page *models.Page) error {
+	if page.ID == "" {
+		page.ID = uuid.NewString()
+	}
+	order, err := nextSortOrder(r.db, &models.Page{}, "site_id", page.SiteID)
+	if err != nil {
+		return err
+	}
+	page.SortOrder = order
+	page.Revision = 1
+	return r.db.Create(page).Error
+}
+
+func (r *gormPageRepository) Update(page *models.Page) error {
+	existing, err := r.Get(page.SiteID, page.ID)
+	if err != nil {
+		return err
+	}
+	if existing.Revision != page.Revision {
+		return &ConflictError{Current: existing}
+	}
+
+	// 以修订号为条件更新，防止读取之后被其他请求抢先修改
+	expected := page.Revision
+	page.SortOrder = existing.SortOrder
+	page.Revision = expected + 1
+	result := r.db.Model(&models.Page{}).Where("id = ? AND revision = ?", page.ID, expected).Select("*").Updates(page)
+	if result.Error != nil || result.RowsAffected == 0 {
+		page.Revision = expected
+		if result.Error != nil {
+			return result.Error
+		}
+		return revisionConflict(r.Get(page.SiteID, page.ID))
+	}
+	return nil
+}
+
+func (r *gormPageRepository) Touch(siteID string, pageID string, at time.Time) error {
+	result := r.db.Model(&models.Page{}).Where("id = ? AND site_id = ?", pageID, siteID).UpdateColumn("updated_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormPageRepository) Delete(siteID string, pageID string) error {
+	return deleteAndCompact(r.db, &models.Page{}, "site_id", siteID, pageID)
+}
+
+func (r *gormPageRepository) Reorder(siteID string, pageIDs []string) error {
+	return reorderRows(r.db, &models.Page{}, "site_id", siteID, pageIDs)
+}
+
+// gormSectionRepository 区块GORM仓储
+type gormSectionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormSectionRepository) ListByPage(pageID string) ([]models.Section, error) {
+	var sections []models.Section
+	err := r.db.Where("page_id = ?", pageID).Order("sort_order").Find(&sections).Error
+	return sections, err
+}
+
+func (r *gormSectionRepository) Get(pageID string, sectionID string) (models.Section, error) {
+	var section models.Section
+	err := r.db.Where("id = ? AND page_id = ?", sectionID, pageID).First(&section).Error
+	return section, translateError(err)
+}
+
+func (r *gormSectionRepository) Create(section *models.Section) error {
+	if section.ID == "" {
+		section.ID = uuid.NewString()
+	}
+	order, err := nextSortOrder(r.db, &models.Section{}, "page_id", section.PageID)
+	if err != nil {
+		return err
+	}
+	section.SortOrder = order
+	section.Revision = 1
+	return r.db.Create(section).Error
+}
+
+func (r *gormSectionRepository) Update(section *models.Section) error {
+	existing, err := r.Get(section.PageID, section.ID)
+	if err != nil {
+		return err
+	}
+	if existing.Revision != section.Revision {
+		return &ConflictError{Current: existing}
+	}
+
+	// 以修订号为条件更新，防止读取之后被其他请求抢先修改
+	expected := section.Revision
+	section.SortOrder = existing.SortOrder
+	section.Revision = expected + 1
+	result := r.db.Model(&models.Section{}).Where("id = ? AND revision = ?", section.ID, expected).Select("*").Updates(section)
+	if result.Error != nil || result.RowsAffected == 0 {
+		section.Revision = expected
+		if result.Error != nil {
+			return result.Error
+		}
+		return revisionConflict(r.Get(section.PageID, section.ID))
+	}
+	return nil
+}
+
+func (r *gormSectionRepository) Delete(pageID string, sectionID string) error {
+	return deleteAndCompact(r.db, &models.Section{}, "page_id", pageID, sectionID)
+}
+
+func (r *gormSectionRepository) Reorder(pageID string, sectionIDs []string) error {
+	return reorderRows(r.db, &models.Section{}, "page_id", pageID, sectionIDs)
+}
+
+// gormComponentRepository 组件GORM仓储
+type gormComponentRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormComponentRepository) ListBySection(sectionID string) ([]models.Component, error) {
+	var components []models.Component
+	err := r.db.Where("section_id = ?", sectionID).Order("sort_order").Find(&components).Error
+	return components, err
+}
+
+func (r *gormComponentRepository) Get(sectionID string, componentID string) (models.Component, error) {
+	var component models.Component
+	err := r.db.Where("id = ? AND section_id = ?", componentID, sectionID).First(&component).Error
+	return component, translateError(err)
+}
+
+func (r *gormComponentRepository) Create(component *models.Component) error {
+	if component.ID == "" {
+		component.ID = uuid.NewString()
+	}
+	order, err := nextSortOrder(r.db, &models.Component{}, "section_id", component.SectionID)
+	if err != nil {
+		return err
+	}
+	component.SortOrder = order
+	component.Revision = 1
+	return r.db.Create(component).Error
+}
+
+func (r *gormComponentRepository) Update(component *models.Component) error {
+	existing, err := r.Get(component.SectionID, component.ID)
+	if err != nil {
+		return err
+	}
+	if existing.Revision != component.Revision {
+		return &ConflictError{Current: existing}
+	}
+
+	// 以修订号为条件更新，防止读取之后被其他请求抢先修改
+	expected := component.Revision
+	component.SortOrder = existing.SortOrder
+	component.Revision = expected + 1
+	result := r.db.Model(&models.Component{}).Where("id = ? AND revision = ?", component.ID, expected).Select("*").Updates(component)
+	if result.Error != nil || result.RowsAffected == 0 {
+		component.Revision = expected
+		if result.Error != nil {
+			return result.Error
+		}
+		return revisionConflict(r.Get(component.SectionID, component.ID))
+	}
+	return nil
+}
+
+func (r *gormComponentRepository) Delete(sectionID string, componentID string) error {
+	return deleteAndCompact(r.db, &models.Component{}, "section_id", sectionID, componentID)
+}
+
+func (r *gormComponentRepository) Reorder(sectionID string, componentIDs []string) error {
+	return reorderRows(r.db, &models.Component{}, "section_id", sectionID, componentIDs)
+}
+
+// gormTemplateRepository 模板GORM仓储
+type gormTemplateRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormTemplateRepository) List() ([]models.SiteTemplate, error) {
+	var templates []models.SiteTemplate
+	err := r.db.Order("id").Find(&templates).Error
+	return templates, err
+}
+
+func (r *gormTemplateRepository) Get(templateID string) (models.SiteTemplate, error) {
+	var template models.SiteTemplate
+	err := r.db.Where("id = ?", templateID).First(&template).Error
+	return template, translateError(err)
+}
+
+func (r *gormTemplateRepository) Create(template *models.SiteTemplate) error {
+	if template.ID == "" {
+		template.ID = uuid.NewString()
+	}
+	return r.db.Create(template).Error
+}
+
+// gormVersionRepository 发布版本GORM仓储
+type gormVersionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormVersionRepository) ListBySite(siteID string) ([]models.SiteVersion, error) {
+	var versions []models.SiteVersion
+	err := r.db.Where("site_id = ?", siteID).Order("version DESC").Find(&versions).Error
+	return versions, err
+}
+
+func (r *gormVersionRepository) Get(siteID string, version int) (models.SiteVersion, error) {
+	var v models.SiteVersion
+	err := r.db.Where("site_id = ? AND version = ?", siteID, version).First(&v).Error
+	return v, translateError(err)
+}
+
+func (r *gormVersionRepository) Latest(siteID string) (models.SiteVersion, error) {
+	var v models.SiteVersion
+	err := r.db.Where("site_id = ?", siteID).Order("version DESC").First(&v).Error
+	return v, translateError(err)
+}
+
+// Create 版本号由(site_id, version)唯一索引保证不重复
+func (r *gormVersionRepository) Create(version *models.SiteVersion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var latest int
+		if err := tx.Model(&models.SiteVersion{}).Where("site_id = ?", version.SiteID).
+			Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+			return err
+		}
+		version.Version = latest + 1
+		if version.ID == "" {
+			version.ID = uuid.NewString()
+		}
+		return tx.Create(version).Error
+	})
+}
+
+// gormDomainRepository 站点域名GORM仓储
+type gormDomainRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormDomainRepository) ListBySite(siteID string) ([]models.SiteDomain, error) {
+	var domains []models.SiteDomain
+	err := r.db.Where("site_id = ?", siteID).Order("created_at").Find(&domains).Error
+	return domains, err
+}
+
+func (r *gormDomainRepository) Get(siteID string, domainID string) (models.SiteDomain, error) {
+	var domain models.SiteDomain
+	err := r.db.Where("id = ? AND site_id = ?", domainID, siteID).First(&domain).Error
+	return domain, translateError(err)
+}
+
+func (r *gormDomainRepository) GetByHostname(hostname string) (models.SiteDomain, error) {
+	var domain models.SiteDomain
+	err := r.db.Where("hostname = ?", hostname).First(&domain).Error
+	return domain, translateError(err)
+}
+
+// Create 域名由hostname唯一索引保证不重复
+func (r *gormDomainRepository) Create(domain *models.SiteDomain) error {
+	if _, err := r.GetByHostname(domain.Hostname); err == nil {
+		return ErrDuplicateHostname
+	} else if err != ErrNotFound {
+		return err
+	}
+	if domain.ID == "" {
+		domain.ID = uuid.NewString()
+	}
+	err := r.db.Create(domain).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateHostname
+	}
+	return err
+}
+
+func (r *gormDomainRepository) Update(domain *models.SiteDomain) error {
+	if _, err := r.Get(domain.SiteID, domain.ID); err != nil {
+		return err
+	}
+	return r.db.Model(&models.SiteDomain{}).Where("id = ?", domain.ID).Select("*").Updates(domain).Error
+}
+
+func (r *gormDomainRepository) Delete(siteID string, domainID string) error {
+	result := r.db.Where("id = ? AND site_id = ?", domainID, siteID).Delete(&models.SiteDomain{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gormFormSubmissionRepository 表单提交GORM仓储
+type gormFormSubmissionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormFormSubmissionRepository) ListBySite(siteID string, componentID string) ([]models.FormSubmission, error) {
+	query := r.db.Where("site_id = ?", siteID)
+	if componentID != "" {
+		query = query.Where("component_id = ?", componentID)
+	}
+	var submissions []models.FormSubmission
+	err := query.Order("created_at DESC").Find(&submissions).Error
+	return submissions, err
+}
+
+func (r *gormFormSubmissionRepository) Get(siteID string, submissionID string) (models.FormSubmission, error) {
+	var submission models.FormSubmission
+	err := r.db.Where("id = ? AND site_id = ?", submissionID, siteID).First(&submission).Error
+	return submission, translateError(err)
+}
+
+func (r *gormFormSubmissionRepository) Create(submission *models.FormSubmission) error {
+	if submission.ID == "" {
+		submission.ID = uuid.NewString()
+	}
+	return r.db.Create(submission).Error
+}
+
+func (r *gormFormSubmissionRepository) Delete(siteID string, submissionID string) error {
+	result := r.db.Where("id = ? AND site_id = ?", submissionID, siteID).Delete(&models.FormSubmission{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gormFormSettingsRepository 表单通知设置GORM仓储
+type gormFormSettingsRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormFormSettingsRepository) Get(tenantID string) (models.FormNotificationSettings, error) {
+	var settings models.FormNotificationSettings
+	err := r.db.Where("tenant_id = ?", tenantID).First(&settings).Error
+	return settings, translateError(err)
+}
+
+func (r *gormFormSettingsRepository) Save(settings *models.FormNotificationSettings) error {
+	return r.db.Save(settings).Error
+}
+
+// gormPreviewTokenRepository 预览链接GORM仓储
+type gormPreviewTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormPreviewTokenRepository) ListBySite(siteID string) ([]models.PreviewToken, error) {
+	var tokens []models.PreviewToken
+	err := r.db.Where("site_id = ?", siteID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *gormPreviewTokenRepository) Get(siteID string, tokenID string) (models.PreviewToken, error) {
+	var token models.PreviewToken
+	err := r.db.Where("id = ? AND site_id = ?", tokenID, siteID).First(&token).Error
+	return token, translateError(err)
+}
+
+func (r *gormPreviewTokenRepository) Create(token *models.PreviewToken) error {
+	if token.ID == "" {
+		token.ID = uuid.NewString()
+	}
+	return r.db.Create(token).Error
+}
+
+func (r *gormPreviewTokenRepository) Update(token *models.PreviewToken) error {
+	if _, err := r.Get(token.SiteID, token.ID); err != nil {
+		return err
+	}
+	return r.db.Model(&models.PreviewToken{}).Where("id = ?", token.ID).Select("*").Updates(token).Error
+}
+
+func (r *gormPreviewTokenRepository) Delete(siteID string, tokenID string) error {
+	result := r.db.Where("id = ? AND site_id = ?", tokenID, siteID).Delete(&models.PreviewToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gormRedirectRepository 重定向规则GORM仓储
+type gormRedirectRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRedirectRepository) ListBySite(siteID string) ([]models.SiteRedirect, error) {
+	var redirects []models.SiteRedirect
+	err := r.db.Where("site_id = ?", siteID).Order("created_at, id").Find(&redirects).Error
+	return redirects, err
+}
+
+func (r *gormRedirectRepository) Get(siteID string, redirectID string) (models.SiteRedirect, error) {
+	var redirect models.SiteRedirect
+	err := r.db.Where("id = ? AND site_id = ?", redirectID, siteID).First(&redirect).Error
+	return redirect, translateError(err)
+}
+
+func (r *gormRedirectRepository) Create(redirect *models.SiteRedirect) error {
+	if redirect.ID == "" {
+		redirect.ID = uuid.NewString()
+	}
+	return r.db.Create(redirect).Error
+}
+
+func (r *gormRedirectRepository) Update(redirect *models.SiteRedirect) error {
+	if _, err := r.Get(redirect.SiteID, redirect.ID); err != nil {
+		return err
+	}
+	return r.db.Model(&models.SiteRedirect{}).Where("id = ?", redirect.ID).Select("*").Updates(redirect).Error
+}
+
+func (r *gormRedirectRepository) Delete(siteID string, redirectID string) error {
+	result := r.db.Where("id = ? AND site_id = ?", redirectID, siteID).Delete(&models.SiteRedirect{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormRedirectRepository) RecordHit(siteID string, redirectID string, at time.Time) error {
+	result := r.db.Model(&models.SiteRedirect{}).
+		Where("id = ? AND site_id = ?", redirectID, siteID).
+		UpdateColumns(map[string]interface{}{"hits": gorm.Expr("hits + 1"), "last_hit_at": at})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gormScheduleRepository 定时发布任务GORM仓储，状态变更使用带状态条件的更新，保证多个实例中只有一个能领取任务
+type gormScheduleRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormScheduleRepository) ListBySite(siteID string) ([]models.PublishSchedule, error) {
+	var schedules []models.PublishSchedule
+	err := r.db.Where("site_id = ?", siteID).Order("run_at DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *gormScheduleRepository) Get(siteID string, scheduleID string) (models.PublishSchedule, error) {
+	var schedule models.PublishSchedule
+	err := r.db.Where("id = ? AND site_id = ?", scheduleID, siteID).First(&schedule).Error
+	return schedule, translateError(err)
+}
+
+func (r *gormScheduleRepository) Create(schedule *models.PublishSchedule) error {
+	if schedule.ID == "" {
+		schedule.ID = uuid.NewString()
+	}
+	return r.db.Create(schedule).Error
+}
+
+func (r *gormScheduleRepository) ListDue(now time.Time, limit int) ([]models.PublishSchedule, error) {
+	var schedules []models.PublishSchedule
+	err := r.db.Where("status = ? AND run_at <= ?", SchedulePending, now).Order("run_at").Limit(limit).Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *gormScheduleRepository) ListRunning(before time.Time) ([]models.PublishSchedule, error) {
+	var schedules []models.PublishSchedule
+	err := r.db.Where("status = ? AND started_at < ?", ScheduleRunning, before).Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *gormScheduleRepository) Transition(schedule *models.PublishSchedule, from string) (bool, error) {
+	result := r.db.Model(&models.PublishSchedule{}).
+		Where("id = ? AND site_id = ? AND status = ?", schedule.ID, schedule.SiteID, from).
+		Select("*").Updates(schedule)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *gormScheduleRepository) Delete(siteID string, scheduleID string) error {
+	result := r.db.Where("id = ? AND site_id = ?", scheduleID, siteID).Delete(&models.PublishSchedule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// gormAnalyticsRepository 访问事件和汇总统计GORM仓储
+type gormAnalyticsRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormAnalyticsRepository) RecordEvent(event *models.PageEvent) error {
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	return r.db.Create(event).Error
+}
+
+func (r *gormAnalyticsRepository) ListEvents(siteID string, from time.Time, to time.Time) ([]models.PageEvent, error) {
+	var events []models.PageEvent
+	err := r.db.Where("site_id = ? AND created_at >= ? AND created_at < ?", siteID, from, to).Find(&events).Error
+	return events, err
+}
+
+func (r *gormAnalyticsRepository) ListEventSites(from time.Time, to time.Time) ([]string, error) {
+	var siteIDs []string
+	err := r.db.Model(&models.PageEvent{}).Where("created_at >= ? AND created_at < ?", from, to).Distinct().Pluck("site_id", &siteIDs).Error
+	return siteIDs, err
+}
+
+func (r *gormAnalyticsRepository) DeleteEventsBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.PageEvent{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *gormAnalyticsRepository) ReplaceRollups(siteID string, granularity string, periodStart time.Time, rollups []models.AnalyticsRollup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("site_id = ? AND granularity = ? AND period_start = ?", siteID, granularity, periodStart).
+			Delete(&models.AnalyticsRollup{}).Error
+		if err != nil || len(rollups) == 0 {
+			return err
+		}
+		for i := range rollups {
+			if rollups[i].ID == "" {
+				rollups[i].ID = uuid.NewString()
+			}
+		}
+		return tx.CreateInBatches(rollups, 200).Error
+	})
+}
+
+func (r *gormAnalyticsRepository) ListRollups(siteID string, granularity string, from time.Time, to time.Time) ([]models.AnalyticsRollup, error) {
+	var rollups []models.AnalyticsRollup
+	err := r.db.Where("site_id = ? AND granularity = ? AND period_start >= ? AND period_start < ?", siteID, granularity, from, to).
+		Order("period_start").Find(&rollups).Error
+	return rollups, err
+}
+
+func (r *gormAnalyticsRepository) DeleteBySite(siteID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("site_id = ?", siteID).Delete(&models.PageEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("site_id = ?", siteID).Delete(&models.AnalyticsRollup{}).Error
+	})
+}
+
+// gormEditHistoryRepository 编辑历史GORM仓储
+type gormEditHistoryRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormEditHistoryRepository) List(siteID string, filter EditHistoryFilter) ([]models.EditOperation, error) {
+	query := r.db.Where("site_id = ?", siteID)
+	if filter.PageID != "" {
+		query = query.Where("page_id = ?", filter.PageID)
+	}
+	if filter.SessionID != "" {
+		query = query.Where("session_id = ?", filter.SessionID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var operations []models.EditOperation
+	err := query.Order("created_at DESC").Find(&operations).Error
+	return operations, err
+}
+
+func (r *gormEditHistoryRepository) Get(siteID string, operationID string) (models.EditOperation, error) {
+	var operation models.EditOperation
+	err := r.db.Where("id = ? AND site_id = ?", operationID, siteID).First(&operation).Error
+	return operation, translateError(err)
+}
+
+func (r *gormEditHistoryRepository) Create(operation *models.EditOperation) error {
+	if operation.ID == "" {
+		operation.ID = uuid.NewString()
+	}
+	return r.db.Create(operation).Error
+}
+
+func (r *gormEditHistoryRepository) Update(operation *models.EditOperation) error {
+	result := r.db.Model(&models.EditOperation{}).
+		Where("id = ? AND site_id = ?", operation.ID, operation.SiteID).
+		Select("*").Updates(operation)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormEditHistoryRepository) DiscardUndone(siteID string, sessionID string) error {
+	return r.db.Model(&models.EditOperation{}).
+		Where("site_id = ? AND session_id = ? AND status = ?", siteID, sessionID, EditUndone).
+		Update("status", EditDiscarded).Error
+}
+
+func (r *gormEditHistoryRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Where("created_at < ?", before).Delete(&models.EditOperation{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *gormEditHistoryRepository) DeleteBySite(siteID string) error {
+	return r.db.Where("site_id = ?", siteID).Delete(&models.EditOperation{}).Error
+}
+
+// gormThemeRepository 后台主题表的GORM仓储
+type gormThemeRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormThemeRepository) ListEnabled() ([]domain.Theme, error) {
+	var themes []domain.Theme
+	err := r.db.Table("themes").Where("status = ?", 1).Order("is_default DESC, id").Find(&themes).Error
+	return themes, err
+}
+
+func (r *gormThemeRepository) Get(id int64) (domain.Theme, error) {
+	var theme domain.Theme
+	err := r.db.Table("themes").Where("id = ?", id).First(&theme).Error
+	return theme, translateError(err)
+}
+
+func (r *gormThemeRepository) Create(theme *domain.Theme) error {
+	return r.db.Table("themes").Create(theme).Error
+}
+
+// gormMediaRepository 媒体文件GORM仓储，租户内的文件由tenant_id和hash的唯一索引保证不重复
+type gormMediaRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormMediaRepository) List(tenantID string, filter MediaFilter) ([]models.MediaAsset, error) {
+	query := r.db.Where("tenant_id = ?", tenantID)
+	if filter.ContentType != "" {
+		if strings.HasSuffix(filter.ContentType, "/") {
+			query = query.Where("content_type LIKE ?", filter.ContentType+"%")
+		} else {
+			query = query.Where("content_type = ?", filter.ContentType)
+		}
+	}
+	if filter.UploadedBy != "" {
+		query = query.Where("uploaded_by = ?", filter.UploadedBy)
+	}
+	if filter.Search != "" {
+		query = query.Where("file_name LIKE ?", "%"+filter.Search+"%")
+	}
+	var assets []models.MediaAsset
+	err := query.Order("created_at DESC, id").Find(&assets).Error
+	return assets, err
+}
+
+func (r *gormMediaRepository) Get(tenantID string, assetID string) (models.MediaAsset, error) {
+	var asset models.MediaAsset
+	err := r.db.Where("id = ? AND tenant_id = ?", assetID, tenantID).First(&asset).Error
+	return asset, translateError(err)
+}
+
+func (r *gormMediaRepository) GetByID(assetID string) (models.MediaAsset, error) {
+	var asset models.MediaAsset
+	err := r.db.Where("id = ?", assetID).First(&asset).Error
+	return asset, translateError(err)
+}
+
+func (r *gormMediaRepository) GetByHash(tenantID string, hash string) (models.MediaAsset, error) {
+	var asset models.MediaAsset
+	err := r.db.Where("tenant_id = ? AND hash = ?", tenantID, hash).First(&asset).Error
+	return asset, translateError(err)
+}
+
+func (r *gormMediaRepository) Create(asset *models.MediaAsset) error {
+	if _, err := r.GetByHash(asset.TenantID, asset.Hash); err == nil {
+		return ErrDuplicateMedia
+	} else if err != ErrNotFound {
+		return err
+	}
+	if asset.ID == "" {
+		asset.ID = uuid.NewString()
+	}
+	err := r.db.Create(asset).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateMedia
+	}
+	return err
+}
+
+func (r *gormMediaRepository) Update(asset *models.MediaAsset) error {
+	if _, err := r.Get(asset.TenantID, asset.ID); err != nil {
+		return err
+	}
+	return r.db.Model(&models.MediaAsset{}).Where("id = ?", asset.ID).Select("*").Updates(asset).Error
+}
+
+func (r *gormMediaRepository) Delete(tenantID string, assetID string) error {
+	result := r.db.Where("id = ? AND tenant_id = ?", assetID, tenantID).Delete(&models.MediaAsset{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
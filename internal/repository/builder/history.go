@@ -0,0 +1,81 @@
+package builder
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+	"wz-backend-go/models"
+)
+
+// EditHistoryRetention 编辑历史的保留时间，过期的操作不能再撤销，删除的区块和组件也不能再恢复
+const EditHistoryRetention = 30 * 24 * time.Hour
+
+// EditorSessionHeader 编辑器传递会话ID的请求头，编辑器每次打开页面时生成新的会话ID
+const EditorSessionHeader = "X-Editor-Session"
+
+// Editor 发起修改的用户和编辑器会话，SessionID为空时修改只记录历史，不能撤销
+type Editor struct {
+	UserID    string
+	SessionID string
+}
+
+// RecordEdit 把一次修改记录到页面的编辑历史，会话中已撤销的操作随之不能重做。
+// 记录失败只写日志，不影响已经完成的修改
+func (s *Store) RecordEdit(editor Editor, operation models.EditOperation) {
+	var err error
+	if operation.Before, err = cloneEditState(operation.Before); err == nil {
+		operation.After, err = cloneEditState(operation.After)
+	}
+	if err != nil {
+		log.Printf("记录编辑历史失败: %v", err)
+		return
+	}
+
+	operation.ID = ""
+	operation.UserID = editor.UserID
+	operation.SessionID = editor.SessionID
+	operation.Status = EditApplied
+	operation.CreatedAt = time.Now()
+	if editor.SessionID != "" {
+		if err := s.EditHistory.DiscardUndone(operation.SiteID, editor.SessionID); err != nil {
+			log.Printf("记录编辑历史失败: %v", err)
+			return
+		}
+	}
+	if err := s.EditHistory.Create(&operation); err != nil {
+		log.Printf("记录编辑历史失败: %v", err)
+	}
+}
+
+// LoadSectionTree 加载区块及其组件
+func (s *Store) LoadSectionTree(pageID string, sectionID string) (models.Section, error) {
+	section, err := s.Sections.Get(pageID, sectionID)
+	if err != nil {
+		return models.Section{}, err
+	}
+	components, err := s.Components.ListBySection(sectionID)
+	if err != nil {
+		return models.Section{}, err
+	}
+	if components == nil {
+		components = []models.Component{}
+	}
+	section.Components = components
+	return section, nil
+}
+
+// cloneEditState 深拷贝修改前后的状态，保证历史不受后续编辑影响
+func cloneEditState(state *models.EditState) (*models.EditState, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var cloned models.EditState
+	if err := json.Unmarshal(data, &cloned); err != nil {
+		return nil, err
+	}
+	return &cloned, nil
+}
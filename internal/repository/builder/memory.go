@@ -0,0 +1,1271 @@
+package builder
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"wz-backend-go/internal/domain"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidOrder 排序列表与现有记录不匹配
+var ErrInvalidOrder = errors.New("排序列表与现有记录不匹配")
+
+// NewMemoryStore 创建内存存储，数据只在进程内有效，用于测试和本地开发
+func NewMemoryStore() *Store {
+	return &Store{
+		Sites: &memorySiteRepository{},
+		Pages: &memoryPageRepository{items: newOrderedCollection(
+			func(p *models.Page) *string { return &p.ID },
+			func(p *models.Page) string { return p.SiteID },
+			func(p *models.Page) *int { return &p.SortOrder },
+			func(p *models.Page) *int { return &p.Revision },
+			clonePageRecord,
+		)},
+		Sections: &memorySectionRepository{items: newOrderedCollection(
+			func(s *models.Section) *string { return &s.ID },
+			func(s *models.Section) string { return s.PageID },
+			func(s *models.Section) *int { return &s.SortOrder },
+			func(s *models.Section) *int { return &s.Revision },
+			cloneSectionRecord,
+		)},
+		Components: &memoryComponentRepository{items: newOrderedCollection(
+			func(c *models.Component) *string { return &c.ID },
+			func(c *models.Component) string { return c.SectionID },
+			func(c *models.Component) *int { return &c.SortOrder },
+			func(c *models.Component) *int { return &c.Revision },
+			cloneComponentRecord,
+		)},
+		Templates: &memoryTemplateRepository{},
+		Versions:  &memoryVersionRepository{versions: map[string][]models.SiteVersion{}},
+		Domains:   &memoryDomainRepository{},
+
+		FormSubmissions: &memoryFormSubmissionRepository{},
+		FormSettings:    &memoryFormSettingsRepository{settings: map[string]models.FormNotificationSettings{}},
+		PreviewTokens:   &memoryPreviewTokenRepository{},
+		Themes:          &memoryThemeRepository{},
+		Redirects:       &memoryRedirectRepository{},
+		Schedules:       &memoryScheduleRepository{},
+		Analytics:       &memoryAnalyticsRepository{},
+		EditHistory:     &memoryEditHistoryRepository{},
+		Media:           &memoryMediaRepository{},
+	}
+}
+
+// orderedCollection 按父级ID分组、保持顺序的内存集合，保存和返回的记录都经过clone深拷贝
+type orderedCollection[T any] struct {
+	mu        sync.RWMutex
+	groups    map[string][]T
+	id        func(*T) *string
+	parent    func(*T) string
+	sortOrder func(*T) *int
+	revision  func(*T) *int
+	clone     func(T) T
+}
+
+func newOrderedCollection[T any](id func(*T) *string, parent func(*T) string, sortOrder func(*T) *int, revision func(*T) *int, clone func(T) T) *orderedCollection[T] {
+	return &orderedCollection[T]{
+		groups:    map[string][]T{},
+		id:        id,
+		parent:    parent,
+		sortOrder: sortOrder,
+		revision:  revision,
+		clone:     clone,
+	}
+}
+
+func (c *orderedCollection[T]) list(parentID string) []T {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	items := c.groups[parentID]
+	result := make([]T, len(items))
+	for i, item := range items {
+		result[i] = c.clone(item)
+	}
+	return result
+}
+
+func (c *orderedCollection[T]) get(parentID string, id string) (T, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, item := range c.groups[parentID] {
+		if *c.id(&item) == id {
+			return c.clone(item), nil
+		}
+	}
+	var zero T
+	return zero, ErrNotFound
+}
+
+// create 追加到父级末尾，未指定ID时生成UUID
+func (c *orderedCollection[T]) create(item *T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if *c.id(item) == "" {
+		*c.id(item) = uuid.NewString()
+	}
+	parentID := c.parent(item)
+	*c.sortOrder(item) = len(c.groups[parentID])
+	*c.revision(item) = 1
+	c.groups[parentID] = append(c.groups[parentID], c.clone(*item))
+}
+
+// update 替换记录内容，保持原有位置；修订号与存储不一致时返回ConflictError，成功后修订号加一
+func (c *orderedCollection[T]) update(item *T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := c.groups[c.parent(item)]
+	for i := range items {
+		if *c.id(&items[i]) == *c.id(item) {
+			if *c.revision(item) != *c.revision(&items[i]) {
+				return &ConflictError{Current: c.clone(items[i])}
+			}
+			*c.sortOrder(item) = *c.sortOrder(&items[i])
+			*c.revision(item) = *c.revision(&items[i]) + 1
+			items[i] = c.clone(*item)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// modify 在锁内原地修改记录，不检查也不改变修订号
+func (c *orderedCollection[T]) modify(parentID string, id string, fn func(*T)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := c.groups[parentID]
+	for i := range items {
+		if *c.id(&items[i]) == id {
+			fn(&items[i])
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (c *orderedCollection[T]) delete(parentID string, id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := c.groups[parentID]
+	for i := range items {
+		if *c.id(&items[i]) == id {
+			remaining := append(items[:i:i], items[i+1:]...)
+			for j := range remaining {
+				*c.sortOrder(&remaining[j]) = j
+			}
+			c.groups[parentID] = remaining
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (c *orderedCollection[T]) reorder(parentID string, ids []string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := c.groups[parentID]
+	if len(ids) != len(items) {
+		return ErrInvalidOrder
+	}
+
+	byID := make(map[string]T, len(items))
+	for _, item := range items {
+		byID[*c.id(&item)] = item
+	}
+
+	reordered := make([]T, 0, len(ids))
+	for i, id := range ids {
+		item, exists := byID[id]
+		if !exists {
+			return ErrInvalidOrder
+		}
+		delete(byID, id)
+		*c.sortOrder(&item) = i
+		reordered = append(reordered, item)
+	}
+	c.groups[parentID] = reordered
+	return nil
+}
+
+// memorySiteRepository 站点内存仓储
+type memorySiteRepository struct {
+	mu    sync.RWMutex
+	sites []models.Site
+}
+
+func (r *memorySiteRepository) List(filter SiteFilter) ([]models.Site, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.Site
+	search := strings.ToLower(filter.Search)
+	for _, site := range r.sites {
+		if filter.TenantID != "" && site.TenantID != filter.TenantID {
+			continue
+		}
+		if filter.Status != "" && site.Status != filter.Status {
+			continue
+		}
+		if search != "" &&
+			!strings.Contains(strings.ToLower(site.Name), search) &&
+			!strings.Contains(strings.ToLower(site.Description), search) {
+			continue
+		}
+		result = append(result, cloneSiteRecord(site))
+	}
+	return result, nil
+}
+
+func (r *memorySiteRepository) Get(siteID string) (models.Site, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, site := range r.sites {
+		if site.ID == siteID {
+			return cloneSiteRecord(site), nil
+		}
+	}
+	return models.Site{}, ErrNotFound
+}
+
+func (r *memorySiteRepository) Create(site *models.Site) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if site.ID == "" {
+		site.ID = uuid.NewString()
+	}
+	r.sites = append(r.sites, cloneSiteRecord(*site))
+	return nil
+}
+
+func (r *memorySiteRepository) Update(site *models.Site) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.sites {
+		if r.sites[i].ID == site.ID {
+			r.sites[i] = cloneSiteRecord(*site)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memorySiteRepository) Delete(siteID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.sites {
+		if r.sites[i].ID == siteID {
+			r.sites = append(r.sites[:i], r.sites[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// memoryPageRepository 页面内存仓储
+type memoryPageRepository struct {
+	items *orderedCollection[models.Page]
+}
+
+func (r *memoryPageRepository) ListBySite(siteID string) ([]models.Page, error) {
+	return r.items.list(siteID), nil
+}
+
+func (r *memoryPageRepository) Get(siteID string, pageID string) (models.Page, error) {
+	return r.items.get(siteID, pageID)
+}
+
+func (r *memoryPageRepository) Create(page *models.Page) error {
+	r.items.create(page)
+	return nil
+}
+
+func (r *memoryPageRepository) Update(page *models.Page) error {
+	return r.items.update(page)
+}
+
+func (r *memoryPageRepository) Touch(siteID string, pageID string, at time.Time) error {
+	return r.items.modify(siteID, pageID, func(p *models.Page) { p.UpdatedAt = at })
+}
+
+func (r *memoryPageRepository) Delete(siteID string, pageID string) error {
+	return r.items.delete(siteID, pageID)
+}
+
+func (r *memoryPageRepository) Reorder(siteID string, pageIDs []string) error {
+	return r.items.reorder(siteID, pageIDs)
+}
+
+// memorySectionRepository 区块内存仓储
+type memorySectionRepository struct {
+	items *orderedCollection[models.Section]
+}
+
+func (r *memorySectionRepository) ListByPage(pageID string) ([]models.Section, error) {
+	return r.items.list(pageID), nil
+}
+
+func (r *memorySectionRepository) Get(pageID string, sectionID string) (models.Section, error) {
+	return r.items.get(pageID, sectionID)
+}
+
+func (r *memorySectionRepository) Create(section *models.Section) error {
+	r.items.create(section)
+	return nil
+}
+
+func (r *memorySectionRepository) Update(section *models.Section) error {
+	return r.items.update(section)
+}
+
+func (r *memorySectionRepository) Delete(pageID string, sectionID string) error {
+	return r.items.delete(pageID, sectionID)
+}
+
+func (r *memorySectionRepository) Reorder(pageID string, sectionIDs []string) error {
+	return r.items.reorder(pageID, sectionIDs)
+}
+
+// memoryComponentRepository 组件内存仓储
+type memoryComponentRepository struct {
+	items *orderedCollection[models.Component]
+}
+
+func (r *memoryComponentRepository) ListBySection(sectionID string) ([]models.Component, error) {
+	return r.items.list(sectionID), nil
+}
+
+func (r *memoryComponentRepository) Get(sectionID string, componentID string) (models.Component, error) {
+	return r.items.get(sectionID, componentID)
+}
+
+func (r *memoryComponentRepository) Create(component *models.Component) error {
+	r.items.create(component)
+	return nil
+}
+
+func (r *memoryComponentRepository) Update(component *models.Component) error {
+	return r.items.update(component)
+}
+
+func (r *memoryComponentRepository) Delete(sectionID string, componentID string) error {
+	return r.items.delete(sectionID, componentID)
+}
+
+func (r *memoryComponentRepository) Reorder(sectionID string, componentIDs []string) error {
+	return r.items.reorder(sectionID, componentIDs)
+}
+
+// memoryTemplateRepository 模板内存仓储
+type memoryTemplateRepository struct {
+	mu        sync.RWMutex
+	templates []models.SiteTemplate
+}
+
+func (r *memoryTemplateRepository) List() ([]models.SiteTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]models.SiteTemplate, len(r.templates))
+	copy(result, r.templates)
+	return result, nil
+}
+
+func (r *memoryTemplateRepository) Get(templateID string) (models.SiteTemplate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, template := range r.templates {
+		if template.ID == templateID {
+			return template, nil
+		}
+	}
+	return models.SiteTemplate{}, ErrNotFound
+}
+
+func (r *memoryTemplateRepository) Create(template *models.SiteTemplate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if template.ID == "" {
+		template.ID = uuid.NewString()
+	}
+	r.templates = append(r.templates, *template)
+	return nil
+}
+
+// memoryVersionRepository 发布版本内存仓储
+type memoryVersionRepository struct {
+	mu       sync.RWMutex
+	versions map[string][]models.SiteVersion
+}
+
+func (r *memoryVersionRepository) ListBySite(siteID string) ([]models.SiteVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.versions[siteID]
+	result := make([]models.SiteVersion, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		result = append(result, cloneVersionRecord(versions[i]))
+	}
+	return result, nil
+}
+
+func (r *memoryVersionRepository) Get(siteID string, version int) (models.SiteVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, v := range r.versions[siteID] {
+		if v.Version == version {
+			return cloneVersionRecord(v), nil
+		}
+	}
+	return models.SiteVersion{}, ErrNotFound
+}
+
+func (r *memoryVersionRepository) Latest(siteID string) (models.SiteVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := r.versions[siteID]
+	if len(versions) == 0 {
+		return models.SiteVersion{}, ErrNotFound
+	}
+	return cloneVersionRecord(versions[len(versions)-1]), nil
+}
+
+func (r *memoryVersionRepository) Create(version *models.SiteVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version.Version = len(r.versions[version.SiteID]) + 1
+	if version.ID == "" {
+		version.ID = uuid.NewString()
+	}
+	r.versions[version.SiteID] = append(r.versions[version.SiteID], cloneVersionRecord(*version))
+	return nil
+}
+
+// memoryDomainRepository 站点域名内存仓储
+type memoryDomainRepository struct {
+	mu      sync.RWMutex
+	domains []models.SiteDomain
+}
+
+func (r *memoryDomainRepository) ListBySite(siteID string) ([]models.SiteDomain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.SiteDomain
+	for _, domain := range r.domains {
+		if domain.SiteID == siteID {
+			result = append(result, domain)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryDomainRepository) Get(siteID string, domainID string) (models.SiteDomain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, domain := range r.domains {
+		if domain.ID == domainID && domain.SiteID == siteID {
+			return domain, nil
+		}
+	}
+	return models.SiteDomain{}, ErrNotFound
+}
+
+func (r *memoryDomainRepository) GetByHostname(hostname string) (models.SiteDomain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, domain := range r.domains {
+		if domain.Hostname == hostname {
+			return domain, nil
+		}
+	}
+	return models.SiteDomain{}, ErrNotFound
+}
+
+func (r *memoryDomainRepository) Create(domain *models.SiteDomain) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.domains {
+		if existing.Hostname == domain.Hostname {
+			return ErrDuplicateHostname
+		}
+	}
+	if domain.ID == "" {
+		domain.ID = uuid.NewString()
+	}
+	r.domains = append(r.domains, *domain)
+	return nil
+}
+
+func (r *memoryDomainRepository) Update(domain *models.SiteDomain) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.domains {
+		if r.domains[i].ID == domain.ID && r.domains[i].SiteID == domain.SiteID {
+			r.domains[i] = *domain
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryDomainRepository) Delete(siteID string, domainID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.domains {
+		if r.domains[i].ID == domainID && r.domains[i].SiteID == siteID {
+			r.domains = append(r.domains[:i], r.domains[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// memoryFormSubmissionRepository 表单提交内存仓储，按提交顺序保存
+type memoryFormSubmissionRepository struct {
+	mu          sync.RWMutex
+	submissions []models.FormSubmission
+}
+
+func (r *memoryFormSubmissionRepository) ListBySite(siteID string, componentID string) ([]models.FormSubmission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.FormSubmission
+	for i := len(r.submissions) - 1; i >= 0; i-- {
+		submission := r.submissions[i]
+		if submission.SiteID != siteID {
+			continue
+		}
+		if componentID != "" && submission.ComponentID != componentID {
+			continue
+		}
+		result = append(result, submission)
+	}
+	return result, nil
+}
+
+func (r *memoryFormSubmissionRepository) Get(siteID string, submissionID string) (models.FormSubmission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, submission := range r.submissions {
+		if submission.ID == submissionID && submission.SiteID == siteID {
+			return submission, nil
+		}
+	}
+	return models.FormSubmission{}, ErrNotFound
+}
+
+func (r *memoryFormSubmissionRepository) Create(submission *models.FormSubmission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if submission.ID == "" {
+		submission.ID = uuid.NewString()
+	}
+	r.submissions = append(r.submissions, *submission)
+	return nil
+}
+
+func (r *memoryFormSubmissionRepository) Delete(siteID string, submissionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.submissions {
+		if r.submissions[i].ID == submissionID && r.submissions[i].SiteID == siteID {
+			r.submissions = append(r.submissions[:i], r.submissions[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// memoryFormSettingsRepository 表单通知设置内存仓储
+type memoryFormSettingsRepository struct {
+	mu       sync.RWMutex
+	settings map[string]models.FormNotificationSettings
+}
+
+func (r *memoryFormSettingsRepository) Get(tenantID string) (models.FormNotificationSettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings, ok := r.settings[tenantID]
+	if !ok {
+		return models.FormNotificationSettings{}, ErrNotFound
+	}
+	return settings, nil
+}
+
+func (r *memoryFormSettingsRepository) Save(settings *models.FormNotificationSettings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.settings[settings.TenantID] = *settings
+	return nil
+}
+
+// memoryPreviewTokenRepository 预览链接内存仓储，按创建顺序保存
+type memoryPreviewTokenRepository struct {
+	mu     sync.RWMutex
+	tokens []models.PreviewToken
+}
+
+func (r *memoryPreviewTokenRepository) ListBySite(siteID string) ([]models.PreviewToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.PreviewToken
+	for i := len(r.tokens) - 1; i >= 0; i-- {
+		if r.tokens[i].SiteID == siteID {
+			result = append(result, r.tokens[i])
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryPreviewTokenRepository) Get(siteID string, tokenID string) (models.PreviewToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.ID == tokenID && token.SiteID == siteID {
+			return token, nil
+		}
+	}
+	return models.PreviewToken{}, ErrNotFound
+}
+
+func (r *memoryPreviewTokenRepository) Create(token *models.PreviewToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token.ID == "" {
+		token.ID = uuid.NewString()
+	}
+	r.tokens = append(r.tokens, *token)
+	return nil
+}
+
+func (r *memoryPreviewTokenRepository) Update(token *models.PreviewToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].ID == token.ID && r.tokens[i].SiteID == token.SiteID {
+			r.tokens[i] = *token
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryPreviewTokenRepository) Delete(siteID string, tokenID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tokens {
+		if r.tokens[i].ID == tokenID && r.tokens[i].SiteID == siteID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// memoryRedirectRepository 重定向规则内存仓储，按创建顺序保存
+type memoryRedirectRepository struct {
+	mu        sync.RWMutex
+	redirects []models.SiteRedirect
+}
+
+func (r *memoryRedirectRepository) ListBySite(siteID string) ([]models.SiteRedirect, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.SiteRedirect
+	for _, redirect := range r.redirects {
+		if redirect.SiteID == siteID {
+			result = append(result, redirect)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryRedirectRepository) Get(siteID string, redirectID string) (models.SiteRedirect, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, redirect := range r.redirects {
+		if redirect.ID == redirectID && redirect.SiteID == siteID {
+			return redirect, nil
+		}
+	}
+	return models.SiteRedirect{}, ErrNotFound
+}
+
+func (r *memoryRedirectRepository) Create(redirect *models.SiteRedirect) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if redirect.ID == "" {
+		redirect.ID = uuid.NewString()
+	}
+	r.redirects = append(r.redirects, *redirect)
+	return nil
+}
+
+func (r *memoryRedirectRepository) Update(redirect *models.SiteRedirect) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.redirects {
+		if r.redirects[i].ID == redirect.ID && r.redirects[i].SiteID == redirect.SiteID {
+			r.redirects[i] = *redirect
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryRedirectRepository) Delete(siteID string, redirectID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.redirects {
+		if r.redirects[i].ID == redirectID && r.redirects[i].SiteID == siteID {
+			r.redirects = append(r.redirects[:i], r.redirects[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryRedirectRepository) RecordHit(siteID string, redirectID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.redirects {
+		if r.redirects[i].ID == redirectID && r.redirects[i].SiteID == siteID {
+			r.redirects[i].Hits++
+			r.redirects[i].LastHitAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// memoryScheduleRepository 定时发布任务内存仓储
+type memoryScheduleRepository struct {
+	mu        sync.RWMutex
+	schedules []models.PublishSchedule
+}
+
+func (r *memoryScheduleRepository) ListBySite(siteID string) ([]models.PublishSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.PublishSchedule
+	for _, schedule := range r.schedules {
+		if schedule.SiteID == siteID {
+			result = append(result, schedule)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RunAt.After(result[j].RunAt)
+	})
+	return result, nil
+}
+
+func (r *memoryScheduleRepository) Get(siteID string, scheduleID string) (models.PublishSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, schedule := range r.schedules {
+		if schedule.ID == scheduleID && schedule.SiteID == siteID {
+			return schedule, nil
+		}
+	}
+	return models.PublishSchedule{}, ErrNotFound
+}
+
+func (r *memoryScheduleRepository) Create(schedule *models.PublishSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schedule.ID == "" {
+		schedule.ID = uuid.NewString()
+	}
+	r.schedules = append(r.schedules, *schedule)
+	return nil
+}
+
+func (r *memoryScheduleRepository) ListDue(now time.Time, limit int) ([]models.PublishSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.PublishSchedule
+	for _, schedule := range r.schedules {
+		if schedule.Status == SchedulePending && !schedule.RunAt.After(now) {
+			result = append(result, schedule)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].RunAt.Before(result[j].RunAt)
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (r *memoryScheduleRepository) ListRunning(before time.Time) ([]models.PublishSchedule, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.PublishSchedule
+	for _, schedule := range r.schedules {
+		if schedule.Status == ScheduleRunning && schedule.StartedAt != nil && schedule.StartedAt.Before(before) {
+			result = append(result, schedule)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryScheduleRepository) Transition(schedule *models.PublishSchedule, from string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.schedules {
+		if r.schedules[i].ID == schedule.ID && r.schedules[i].SiteID == schedule.SiteID {
+			if r.schedules[i].Status != from {
+				return false, nil
+			}
+			r.schedules[i] = *schedule
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryScheduleRepository) Delete(siteID string, scheduleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.schedules {
+		if r.schedules[i].ID == scheduleID && r.schedules[i].SiteID == siteID {
+			r.schedules = append(r.schedules[:i], r.schedules[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// memoryAnalyticsRepository 访问事件和汇总统计内存仓储
+type memoryAnalyticsRepository struct {
+	mu      sync.RWMutex
+	events  []models.PageEvent
+	rollups []models.AnalyticsRollup
+}
+
+func (r *memoryAnalyticsRepository) RecordEvent(event *models.PageEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *memoryAnalyticsRepository) ListEvents(siteID string, from time.Time, to time.Time) ([]models.PageEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.PageEvent
+	for _, event := range r.events {
+		if event.SiteID == siteID && !event.CreatedAt.Before(from) && event.CreatedAt.Before(to) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryAnalyticsRepository) ListEventSites(from time.Time, to time.Time) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []string
+	seen := map[string]bool{}
+	for _, event := range r.events {
+		if !seen[event.SiteID] && !event.CreatedAt.Before(from) && event.CreatedAt.Before(to) {
+			seen[event.SiteID] = true
+			result = append(result, event.SiteID)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryAnalyticsRepository) DeleteEventsBefore(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.events[:0]
+	for _, event := range r.events {
+		if !event.CreatedAt.Before(before) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(r.events) - len(kept))
+	r.events = kept
+	return deleted, nil
+}
+
+func (r *memoryAnalyticsRepository) ReplaceRollups(siteID string, granularity string, periodStart time.Time, rollups []models.AnalyticsRollup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.rollups[:0]
+	for _, rollup := range r.rollups {
+		if rollup.SiteID != siteID || rollup.Granularity != granularity || !rollup.PeriodStart.Equal(periodStart) {
+			kept = append(kept, rollup)
+		}
+	}
+	for _, rollup := range rollups {
+		if rollup.ID == "" {
+			rollup.ID = uuid.NewString()
+		}
+		kept = append(kept, rollup)
+	}
+	r.rollups = kept
+	return nil
+}
+
+func (r *memoryAnalyticsRepository) ListRollups(siteID string, granularity string, from time.Time, to time.Time) ([]models.AnalyticsRollup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.AnalyticsRollup
+	for _, rollup := range r.rollups {
+		if rollup.SiteID == siteID && rollup.Granularity == granularity &&
+			!rollup.PeriodStart.Before(from) && rollup.PeriodStart.Before(to) {
+			result = append(result, rollup)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].PeriodStart.Before(result[j].PeriodStart)
+	})
+	return result, nil
+}
+
+func (r *memoryAnalyticsRepository) DeleteBySite(siteID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := r.events[:0]
+	for _, event := range r.events {
+		if event.SiteID != siteID {
+			events = append(events, event)
+		}
+	}
+	r.events = events
+	rollups := r.rollups[:0]
+	for _, rollup := range r.rollups {
+		if rollup.SiteID != siteID {
+			rollups = append(rollups, rollup)
+		}
+	}
+	r.rollups = rollups
+	return nil
+}
+
+// memoryEditHistoryRepository 编辑历史内存仓储，按记录顺序保存
+type memoryEditHistoryRepository struct {
+	mu         sync.RWMutex
+	operations []models.EditOperation
+}
+
+func (r *memoryEditHistoryRepository) List(siteID string, filter EditHistoryFilter) ([]models.EditOperation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.EditOperation
+	for i := len(r.operations) - 1; i >= 0; i-- {
+		operation := r.operations[i]
+		if operation.SiteID != siteID ||
+			(filter.PageID != "" && operation.PageID != filter.PageID) ||
+			(filter.SessionID != "" && operation.SessionID != filter.SessionID) ||
+			(filter.Status != "" && operation.Status != filter.Status) ||
+			(filter.Action != "" && operation.Action != filter.Action) ||
+			(filter.EntityType != "" && operation.EntityType != filter.EntityType) {
+			continue
+		}
+		result = append(result, operation)
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryEditHistoryRepository) Get(siteID string, operationID string) (models.EditOperation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, operation := range r.operations {
+		if operation.ID == operationID && operation.SiteID == siteID {
+			return operation, nil
+		}
+	}
+	return models.EditOperation{}, ErrNotFound
+}
+
+func (r *memoryEditHistoryRepository) Create(operation *models.EditOperation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if operation.ID == "" {
+		operation.ID = uuid.NewString()
+	}
+	r.operations = append(r.operations, *operation)
+	return nil
+}
+
+func (r *memoryEditHistoryRepository) Update(operation *models.EditOperation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.operations {
+		if r.operations[i].ID == operation.ID && r.operations[i].SiteID == operation.SiteID {
+			r.operations[i] = *operation
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryEditHistoryRepository) DiscardUndone(siteID string, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.operations {
+		operation := &r.operations[i]
+		if operation.SiteID == siteID && operation.SessionID == sessionID && operation.Status == EditUndone {
+			operation.Status = EditDiscarded
+		}
+	}
+	return nil
+}
+
+func (r *memoryEditHistoryRepository) DeleteBefore(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.operations[:0]
+	for _, operation := range r.operations {
+		if !operation.CreatedAt.Before(before) {
+			kept = append(kept, operation)
+		}
+	}
+	deleted := int64(len(r.operations) - len(kept))
+	r.operations = kept
+	return deleted, nil
+}
+
+func (r *memoryEditHistoryRepository) DeleteBySite(siteID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.operations[:0]
+	for _, operation := range r.operations {
+		if operation.SiteID != siteID {
+			kept = append(kept, operation)
+		}
+	}
+	r.operations = kept
+	return nil
+}
+
+// memoryThemeRepository 主题内存仓储，按创建顺序保存
+type memoryThemeRepository struct {
+	mu     sync.RWMutex
+	themes []domain.Theme
+	nextID int64
+}
+
+func (r *memoryThemeRepository) ListEnabled() ([]domain.Theme, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []domain.Theme
+	for _, theme := range r.themes {
+		if theme.Status == 1 {
+			result = append(result, theme)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].IsDefault > result[j].IsDefault
+	})
+	return result, nil
+}
+
+func (r *memoryThemeRepository) Get(id int64) (domain.Theme, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, theme := range r.themes {
+		if theme.ID == id {
+			return theme, nil
+		}
+	}
+	return domain.Theme{}, ErrNotFound
+}
+
+func (r *memoryThemeRepository) Create(theme *domain.Theme) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	theme.ID = r.nextID
+	r.themes = append(r.themes, *theme)
+	return nil
+}
+
+// memoryMediaRepository 媒体文件内存仓储，按上传顺序保存
+type memoryMediaRepository struct {
+	mu     sync.RWMutex
+	assets []models.MediaAsset
+}
+
+func (r *memoryMediaRepository) List(tenantID string, filter MediaFilter) ([]models.MediaAsset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []models.MediaAsset
+	for i := len(r.assets) - 1; i >= 0; i-- {
+		asset := r.assets[i]
+		if asset.TenantID != tenantID {
+			continue
+		}
+		if filter.ContentType != "" && !matchContentType(asset.ContentType, filter.ContentType) {
+			continue
+		}
+		if filter.UploadedBy != "" && asset.UploadedBy != filter.UploadedBy {
+			continue
+		}
+		if filter.Search != "" && !strings.Contains(strings.ToLower(asset.FileName), strings.ToLower(filter.Search)) {
+			continue
+		}
+		result = append(result, asset)
+	}
+	return result, nil
+}
+
+func (r *memoryMediaRepository) Get(tenantID string, assetID string) (models.MediaAsset, error) {
+	asset, err := r.GetByID(assetID)
+	if err != nil || asset.TenantID != tenantID {
+		return models.MediaAsset{}, ErrNotFound
+	}
+	return asset, nil
+}
+
+func (r *memoryMediaRepository) GetByID(assetID string) (models.MediaAsset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, asset := range r.assets {
+		if asset.ID == assetID {
+			return asset, nil
+		}
+	}
+	return models.MediaAsset{}, ErrNotFound
+}
+
+func (r *memoryMediaRepository) GetByHash(tenantID string, hash string) (models.MediaAsset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, asset := range r.assets {
+		if asset.TenantID == tenantID && asset.Hash == hash {
+			return asset, nil
+		}
+	}
+	return models.MediaAsset{}, ErrNotFound
+}
+
+func (r *memoryMediaRepository) Create(asset *models.MediaAsset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.assets {
+		if existing.TenantID == asset.TenantID && existing.Hash == asset.Hash {
+			return ErrDuplicateMedia
+		}
+	}
+	if asset.ID == "" {
+		asset.ID = uuid.NewString()
+	}
+	r.assets = append(r.assets, *asset)
+	return nil
+}
+
+func (r *memoryMediaRepository) Update(asset *models.MediaAsset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.assets {
+		if r.assets[i].ID == asset.ID && r.assets[i].TenantID == asset.TenantID {
+			r.assets[i] = *asset
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (r *memoryMediaRepository) Delete(tenantID string, assetID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.assets {
+		if r.assets[i].ID == assetID && r.assets[i].TenantID == tenantID {
+			r.assets = append(r.assets[:i], r.assets[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// matchContentType 判断文件类型是否符合过滤条件，以/结尾的条件按前缀匹配
+func matchContentType(contentType string, filter string) bool {
+	if strings.HasSuffix(filter, "/") {
+		return strings.HasPrefix(contentType, filter)
+	}
+	return contentType == filter
+}
@@ -0,0 +1,151 @@
+package builder
+
+import "wz-backend-go/models"
+
+// 内存仓储保存和返回的都是记录的深拷贝，调用方修改Settings、Content等JSON字段中的map
+// 不会影响存储中的数据，与数据库存储每次读取都得到新对象的行为一致
+
+// cloneJSONValue 深拷贝JSON字段的值，递归复制map和切片，其他值原样返回
+func cloneJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return cloneJSONMap(v)
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		cloned := make([]interface{}, len(v))
+		for i, item := range v {
+			cloned[i] = cloneJSONValue(item)
+		}
+		return cloned
+	case []map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		cloned := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			cloned[i] = cloneJSONMap(item)
+		}
+		return cloned
+	case []string:
+		return cloneStrings(v)
+	}
+	return value
+}
+
+func cloneJSONMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	cloned := make(map[string]interface{}, len(m))
+	for key, item := range m {
+		cloned[key] = cloneJSONValue(item)
+	}
+	return cloned
+}
+
+func cloneStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append([]string{}, values...)
+}
+
+// cloneSiteRecord 深拷贝站点，包括其中的页面和全局区块
+func cloneSiteRecord(site models.Site) models.Site {
+	site.Locales = cloneStrings(site.Locales)
+	site.SEO.Keywords = cloneStrings(site.SEO.Keywords)
+	site.Footer = cloneJSONValue(site.Footer)
+	site.Navigation.Style = cloneJSONValue(site.Navigation.Style)
+	site.Navigation.Items = cloneNavigationItems(site.Navigation.Items)
+	if site.Pages != nil {
+		pages := make([]models.Page, len(site.Pages))
+		for i, page := range site.Pages {
+			pages[i] = clonePageRecord(page)
+		}
+		site.Pages = pages
+	}
+	site.GlobalSections = cloneSectionRecords(site.GlobalSections)
+	return site
+}
+
+func cloneNavigationItems(items []models.NavigationItem) []models.NavigationItem {
+	if items == nil {
+		return nil
+	}
+	cloned := make([]models.NavigationItem, len(items))
+	for i, item := range items {
+		item.Children = cloneNavigationItems(item.Children)
+		cloned[i] = item
+	}
+	return cloned
+}
+
+// clonePageRecord 深拷贝页面，包括其中的区块
+func clonePageRecord(page models.Page) models.Page {
+	page.Keywords = cloneStrings(page.Keywords)
+	if page.Translations != nil {
+		translations := make(map[string]models.PageTranslation, len(page.Translations))
+		for locale, translation := range page.Translations {
+			translation.Keywords = cloneStrings(translation.Keywords)
+			translations[locale] = translation
+		}
+		page.Translations = translations
+	}
+	page.Sections = cloneSectionRecords(page.Sections)
+	return page
+}
+
+func cloneSectionRecords(sections []models.Section) []models.Section {
+	if sections == nil {
+		return nil
+	}
+	cloned := make([]models.Section, len(sections))
+	for i, section := range sections {
+		cloned[i] = cloneSectionRecord(section)
+	}
+	return cloned
+}
+
+// cloneSectionRecord 深拷贝区块，包括其中的组件
+func cloneSectionRecord(section models.Section) models.Section {
+	section.Settings = cloneJSONValue(section.Settings)
+	section.Style = cloneJSONValue(section.Style)
+	if section.Translations != nil {
+		translations := make(map[string]models.SectionTranslation, len(section.Translations))
+		for locale, translation := range section.Translations {
+			translations[locale] = translation
+		}
+		section.Translations = translations
+	}
+	if section.Components != nil {
+		components := make([]models.Component, len(section.Components))
+		for i, component := range section.Components {
+			components[i] = cloneComponentRecord(component)
+		}
+		section.Components = components
+	}
+	return section
+}
+
+// cloneComponentRecord 深拷贝组件的设置、内容、样式和翻译
+func cloneComponentRecord(component models.Component) models.Component {
+	component.Settings = cloneJSONValue(component.Settings)
+	component.Content = cloneJSONValue(component.Content)
+	component.Style = cloneJSONValue(component.Style)
+	if component.Translations != nil {
+		translations := make(map[string]map[string]interface{}, len(component.Translations))
+		for locale, fields := range component.Translations {
+			translations[locale] = cloneJSONMap(fields)
+		}
+		component.Translations = translations
+	}
+	return component
+}
+
+// cloneVersionRecord 深拷贝发布版本的快照
+func cloneVersionRecord(version models.SiteVersion) models.SiteVersion {
+	version.Snapshot = cloneSiteRecord(version.Snapshot)
+	return version
+}
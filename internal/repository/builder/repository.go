@@ -0,0 +1,536 @@
+package builder
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"wz-backend-go/internal/domain"
+	"wz-backend-go/internal/pkg/sitenav"
+	"wz-backend-go/models"
+)
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("记录不存在")
+
+// SiteFilter 站点列表过滤条件
+type SiteFilter struct {
+	TenantID string
+	Status   string
+	Search   string // 按名称和描述模糊匹配
+}
+
+// SiteRepository 站点仓储接口
+type SiteRepository interface {
+	List(filter SiteFilter) ([]models.Site, error)
+	Get(siteID string) (models.Site, error)
+	Create(site *models.Site) error
+	Update(site *models.Site) error
+	Delete(siteID string) error
+}
+
+// PageRepository 页面仓储接口，列表按SortOrder升序。
+// 页面、区块和组件的Update要求传入的Revision与存储一致，否则返回ConflictError，成功后Revision加一
+type PageRepository interface {
+	ListBySite(siteID string) ([]models.Page, error)
+	Get(siteID string, pageID string) (models.Page, error)
+	Create(page *models.Page) error
+	Update(page *models.Page) error
+	// Touch 只更新页面的UpdatedAt，不改变修订号
+	Touch(siteID string, pageID string, at time.Time) error
+	Delete(siteID string, pageID string) error
+	// Reorder 按给定ID顺序重写SortOrder
+	Reorder(siteID string, pageIDs []string) error
+}
+
+// SectionRepository 区块仓储接口，列表按SortOrder升序
+type SectionRepository interface {
+	ListByPage(pageID string) ([]models.Section, error)
+	Get(pageID string, sectionID string) (models.Section, error)
+	Create(section *models.Section) error
+	Update(section *models.Section) error
+	Delete(pageID string, sectionID string) error
+	Reorder(pageID string, sectionIDs []string) error
+}
+
+// ComponentRepository 组件仓储接口，列表按SortOrder升序
+type ComponentRepository interface {
+	ListBySection(sectionID string) ([]models.Component, error)
+	Get(sectionID string, componentID string) (models.Component, error)
+	Create(component *models.Component) error
+	Update(component *models.Component) error
+	Delete(sectionID string, componentID string) error
+	Reorder(sectionID string, componentIDs []string) error
+}
+
+// TemplateRepository 站点模板仓储接口
+type TemplateRepository interface {
+	List() ([]models.SiteTemplate, error)
+	Get(templateID string) (models.SiteTemplate, error)
+	Create(template *models.SiteTemplate) error
+}
+
+// SiteVersionRepository 站点发布版本仓储接口，版本创建后不可修改
+type SiteVersionRepository interface {
+	// ListBySite 按版本号倒序列出
+	ListBySite(siteID string) ([]models.SiteVersion, error)
+	Get(siteID string, version int) (models.SiteVersion, error)
+	Latest(siteID string) (models.SiteVersion, error)
+	// Create 分配下一个版本号并保存
+	Create(version *models.SiteVersion) error
+}
+
+// DomainRepository 站点域名仓储接口，域名全局唯一
+type DomainRepository interface {
+	ListBySite(siteID string) ([]models.SiteDomain, error)
+	Get(siteID string, domainID string) (models.SiteDomain, error)
+	GetByHostname(hostname string) (models.SiteDomain, error)
+	Create(domain *models.SiteDomain) error
+	Update(domain *models.SiteDomain) error
+	Delete(siteID string, domainID string) error
+}
+
+// FormSubmissionRepository 表单提交仓储接口
+type FormSubmissionRepository interface {
+	// ListBySite 按提交时间倒序列出，componentID为空时列出站点所有表单的提交
+	ListBySite(siteID string, componentID string) ([]models.FormSubmission, error)
+	Get(siteID string, submissionID string) (models.FormSubmission, error)
+	Create(submission *models.FormSubmission) error
+	Delete(siteID string, submissionID string) error
+}
+
+// FormSettingsRepository 租户表单通知设置仓储接口
+type FormSettingsRepository interface {
+	Get(tenantID string) (models.FormNotificationSettings, error)
+	Save(settings *models.FormNotificationSettings) error
+}
+
+// PreviewTokenRepository 预览链接仓储接口
+type PreviewTokenRepository interface {
+	// ListBySite 按创建时间倒序列出
+	ListBySite(siteID string) ([]models.PreviewToken, error)
+	Get(siteID string, tokenID string) (models.PreviewToken, error)
+	Create(token *models.PreviewToken) error
+	Update(token *models.PreviewToken) error
+	Delete(siteID string, tokenID string) error
+}
+
+// RedirectRepository 站点URL重定向规则仓储接口
+type RedirectRepository interface {
+	// ListBySite 按创建顺序列出
+	ListBySite(siteID string) ([]models.SiteRedirect, error)
+	Get(siteID string, redirectID string) (models.SiteRedirect, error)
+	Create(redirect *models.SiteRedirect) error
+	Update(redirect *models.SiteRedirect) error
+	Delete(siteID string, redirectID string) error
+	// RecordHit 命中次数加一并记录命中时间，不修改UpdatedAt
+	RecordHit(siteID string, redirectID string, at time.Time) error
+}
+
+// 定时发布任务的状态
+const (
+	SchedulePending   = "pending"
+	ScheduleRunning   = "running"
+	ScheduleDone      = "done"
+	ScheduleFailed    = "failed"
+	ScheduleCancelled = "cancelled"
+)
+
+// ScheduleRepository 定时发布任务仓储接口
+type ScheduleRepository interface {
+	// ListBySite 按执行时间倒序列出，包括已执行和已取消的任务
+	ListBySite(siteID string) ([]models.PublishSchedule, error)
+	Get(siteID string, scheduleID string) (models.PublishSchedule, error)
+	Create(schedule *models.PublishSchedule) error
+	// ListDue 列出执行时间不晚于now的待执行任务，按执行时间升序，最多limit条
+	ListDue(now time.Time, limit int) ([]models.PublishSchedule, error)
+	// ListRunning 列出开始执行时间早于before仍在执行中的任务
+	ListRunning(before time.Time) ([]models.PublishSchedule, error)
+	// Transition 仅当存储中的状态为from时写入schedule，返回是否写入。
+	// 多个实例同时领取同一任务时只有一个能成功
+	Transition(schedule *models.PublishSchedule, from string) (bool, error)
+	Delete(siteID string, scheduleID string) error
+}
+
+// 访问统计的汇总粒度
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+// AnalyticsRepository 公开页面访问事件和汇总统计仓储接口
+type AnalyticsRepository interface {
+	RecordEvent(event *models.PageEvent) error
+	// ListEvents 列出站点在[from, to)内的事件
+	ListEvents(siteID string, from time.Time, to time.Time) ([]models.PageEvent, error)
+	// ListEventSites 列出在[from, to)内有事件的站点
+	ListEventSites(from time.Time, to time.Time) ([]string, error)
+	// DeleteEventsBefore 删除早于before的事件，返回删除的数量
+	DeleteEventsBefore(before time.Time) (int64, error)
+	// ReplaceRollups 用rollups替换站点在该粒度和时段的全部汇总，重复执行结果相同
+	ReplaceRollups(siteID string, granularity string, periodStart time.Time, rollups []models.AnalyticsRollup) error
+	// ListRollups 列出站点在[from, to)内开始的汇总，按时段升序
+	ListRollups(siteID string, granularity string, from time.Time, to time.Time) ([]models.AnalyticsRollup, error)
+	// DeleteBySite 删除站点的全部事件和汇总
+	DeleteBySite(siteID string) error
+}
+
+// 编辑历史中操作的状态
+const (
+	EditApplied   = "applied"
+	EditUndone    = "undone"
+	EditDiscarded = "discarded" // 撤销后会话又有了新操作，不能再重做
+)
+
+// 编辑历史的操作类型和对象类型
+const (
+	EditCreate  = "create"
+	EditUpdate  = "update"
+	EditDelete  = "delete"
+	EditReorder = "reorder"
+
+	EditEntitySection   = "section"
+	EditEntityComponent = "component"
+)
+
+// EditHistoryFilter 编辑历史过滤条件，空字段不过滤
+type EditHistoryFilter struct {
+	PageID     string
+	SessionID  string
+	Status     string
+	Action     string
+	EntityType string
+	Limit      int // 为0时不限制数量
+}
+
+// EditHistoryRepository 页面编辑历史仓储接口
+type EditHistoryRepository interface {
+	// List 按记录时间倒序列出站点的操作
+	List(siteID string, filter EditHistoryFilter) ([]models.EditOperation, error)
+	Get(siteID string, operationID string) (models.EditOperation, error)
+	Create(operation *models.EditOperation) error
+	Update(operation *models.EditOperation) error
+	// DiscardUndone 把会话中已撤销的操作标记为不能重做
+	DiscardUndone(siteID string, sessionID string) error
+	// DeleteBefore 删除早于before的操作，返回删除的数量
+	DeleteBefore(before time.Time) (int64, error)
+	DeleteBySite(siteID string) error
+}
+
+// ErrDuplicateMedia 租户的媒体库中已有内容相同的文件
+var ErrDuplicateMedia = errors.New("媒体库中已有相同的文件")
+
+// MediaFilter 媒体文件过滤条件，空字段不过滤
+type MediaFilter struct {
+	ContentType string // 完整类型（image/png）或类型前缀（image/）
+	UploadedBy  string
+	Search      string // 按文件名模糊匹配
+}
+
+// MediaRepository 租户媒体库仓储接口
+type MediaRepository interface {
+	// List 按上传时间倒序列出租户的文件
+	List(tenantID string, filter MediaFilter) ([]models.MediaAsset, error)
+	Get(tenantID string, assetID string) (models.MediaAsset, error)
+	// GetByID 不限租户按ID获取，用于公开访问文件
+	GetByID(assetID string) (models.MediaAsset, error)
+	GetByHash(tenantID string, hash string) (models.MediaAsset, error)
+	// Create 租户中已有内容相同的文件时返回ErrDuplicateMedia
+	Create(asset *models.MediaAsset) error
+	Update(asset *models.MediaAsset) error
+	Delete(tenantID string, assetID string) error
+}
+
+// ThemeRepository 后台主题库仓储接口。主题由后台的ThemeService维护，站点构建器只读取，
+// Create仅用于初始化演示数据
+type ThemeRepository interface {
+	// ListEnabled 列出已启用的主题，默认主题在前
+	ListEnabled() ([]domain.Theme, error)
+	Get(id int64) (domain.Theme, error)
+	Create(theme *domain.Theme) error
+}
+
+// Store 站点构建器的仓储集合，各服务通过同一个Store读写数据
+type Store struct {
+	Sites      SiteRepository
+	Pages      PageRepository
+	Sections   SectionRepository
+	Components ComponentRepository
+	Templates  TemplateRepository
+	Versions   SiteVersionRepository
+	Domains    DomainRepository
+
+	FormSubmissions FormSubmissionRepository
+	FormSettings    FormSettingsRepository
+	PreviewTokens   PreviewTokenRepository
+	Themes          ThemeRepository
+	Redirects       RedirectRepository
+	Schedules       ScheduleRepository
+	Analytics       AnalyticsRepository
+	EditHistory     EditHistoryRepository
+	Media           MediaRepository
+
+	// transaction 在数据库事务中执行，内存存储为nil
+	transaction func(fn func(tx *Store) error) error
+
+	listenerMu sync.RWMutex
+	listeners  []func(ChangeEvent)
+}
+
+// Transaction 在事务中执行fn，fn返回错误时回滚。数据库存储中fn收到的Store的所有仓储使用同一事务，
+// 内存存储没有事务，fn直接在当前Store上执行。事务中的Store不发布变更事件，需要在提交后通过s发布
+func (s *Store) Transaction(fn func(tx *Store) error) error {
+	if s.transaction == nil {
+		return fn(s)
+	}
+	return s.transaction(fn)
+}
+
+// LoadPageTree 加载页面的区块和组件
+func (s *Store) LoadPageTree(page *models.Page) error {
+	sections, err := s.Sections.ListByPage(page.ID)
+	if err != nil {
+		return err
+	}
+	for i := range sections {
+		components, err := s.Components.ListBySection(sections[i].ID)
+		if err != nil {
+			return err
+		}
+		sections[i].Components = components
+	}
+	page.Sections = sections
+	return nil
+}
+
+// LoadSiteTree 加载站点及其所有页面、区块、组件和全局区块
+func (s *Store) LoadSiteTree(siteID string) (models.Site, error) {
+	site, err := s.Sites.Get(siteID)
+	if err != nil {
+		return models.Site{}, err
+	}
+
+	pages, err := s.Pages.ListBySite(siteID)
+	if err != nil {
+		return models.Site{}, err
+	}
+	for i := range pages {
+		if err := s.LoadPageTree(&pages[i]); err != nil {
+			return models.Site{}, err
+		}
+	}
+	site.Pages = pages
+
+	globals, err := s.LoadGlobalSections(siteID)
+	if err != nil {
+		return models.Site{}, err
+	}
+	site.GlobalSections = globals
+	return site, nil
+}
+
+// DeletePageTree 删除页面及其区块和组件
+func (s *Store) DeletePageTree(siteID string, pageID string) error {
+	sections, err := s.Sections.ListByPage(pageID)
+	if err != nil {
+		return err
+	}
+	for _, section := range sections {
+		if err := s.DeleteSectionTree(pageID, section.ID); err != nil {
+			return err
+		}
+	}
+	return s.Pages.Delete(siteID, pageID)
+}
+
+// DeleteSectionTree 删除区块及其组件
+func (s *Store) DeleteSectionTree(pageID string, sectionID string) error {
+	components, err := s.Components.ListBySection(sectionID)
+	if err != nil {
+		return err
+	}
+	for _, component := range components {
+		if err := s.Components.Delete(sectionID, component.ID); err != nil {
+			return err
+		}
+	}
+	return s.Sections.Delete(pageID, sectionID)
+}
+
+// DeleteSiteTree 在事务中删除站点及其所有页面、全局区块、域名、表单提交、预览链接、重定向规则、定时发布任务、访问统计和编辑历史
+func (s *Store) DeleteSiteTree(siteID string) error {
+	return s.Transaction(func(tx *Store) error {
+		return tx.deleteSiteTree(siteID)
+	})
+}
+
+// deleteSiteTree 依次删除站点的数据，最后删除站点本身
+func (s *Store) deleteSiteTree(siteID string) error {
+	pages, err := s.Pages.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+	for _, page := range pages {
+		if err := s.DeletePageTree(siteID, page.ID); err != nil {
+			return err
+		}
+	}
+	globalPageID := GlobalSectionsPageID(siteID)
+	globals, err := s.Sections.ListByPage(globalPageID)
+	if err != nil {
+		return err
+	}
+	for _, section := range globals {
+		if err := s.DeleteSectionTree(globalPageID, section.ID); err != nil {
+			return err
+		}
+	}
+	domains, err := s.Domains.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+	for _, domain := range domains {
+		if err := s.Domains.Delete(siteID, domain.ID); err != nil {
+			return err
+		}
+	}
+	submissions, err := s.FormSubmissions.ListBySite(siteID, "")
+	if err != nil {
+		return err
+	}
+	for _, submission := range submissions {
+		if err := s.FormSubmissions.Delete(siteID, submission.ID); err != nil {
+			return err
+		}
+	}
+	tokens, err := s.PreviewTokens.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if err := s.PreviewTokens.Delete(siteID, token.ID); err != nil {
+			return err
+		}
+	}
+	redirects, err := s.Redirects.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+	for _, redirect := range redirects {
+		if err := s.Redirects.Delete(siteID, redirect.ID); err != nil {
+			return err
+		}
+	}
+	schedules, err := s.Schedules.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		if err := s.Schedules.Delete(siteID, schedule.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.Analytics.DeleteBySite(siteID); err != nil {
+		return err
+	}
+	if err := s.EditHistory.DeleteBySite(siteID); err != nil {
+		return err
+	}
+	return s.Sites.Delete(siteID)
+}
+
+// CreateSiteTree 在事务中保存完整的站点树，站点、页面、全局区块、区块和组件的ID会重新生成，
+// 页面对全局区块的引用和导航对页面的引用随之更新。中途失败时数据库存储回滚事务，内存存储删除已写入的部分
+func (s *Store) CreateSiteTree(tree *models.Site) error {
+	return s.Transaction(func(tx *Store) error {
+		return tx.createSiteTree(tree)
+	})
+}
+
+// createSiteTree 依次创建站点、全局区块和页面，最后更新导航中的页面引用
+func (s *Store) createSiteTree(tree *models.Site) error {
+	renewGlobalSectionIDs(tree)
+	oldPageIDs := make([]string, len(tree.Pages))
+	for i, page := range tree.Pages {
+		oldPageIDs[i] = page.ID
+	}
+	pages := tree.Pages
+	globals := tree.GlobalSections
+	tree.ID = ""
+	tree.Pages = nil
+	tree.GlobalSections = nil
+	if err := s.Sites.Create(tree); err != nil {
+		return err
+	}
+	if err := s.createSectionTrees(GlobalSectionsPageID(tree.ID), globals); err != nil {
+		s.DeleteSiteTree(tree.ID)
+		return err
+	}
+	if err := s.createPageTrees(tree.ID, pages); err != nil {
+		s.DeleteSiteTree(tree.ID)
+		return err
+	}
+
+	pageIDs := map[string]string{}
+	for i, oldID := range oldPageIDs {
+		if oldID != "" {
+			pageIDs[oldID] = pages[i].ID
+		}
+	}
+	if len(pageIDs) > 0 && len(tree.Navigation.Items) > 0 {
+		tree.Navigation.Items = sitenav.RemapPages(tree.Navigation.Items, pageIDs)
+		if err := s.Sites.Update(tree); err != nil {
+			s.DeleteSiteTree(tree.ID)
+			return err
+		}
+	}
+	tree.Pages = pages
+	tree.GlobalSections = globals
+	return nil
+}
+
+// createPageTrees 在站点下依次创建页面、区块和组件，生成的ID回写到传入的切片
+func (s *Store) createPageTrees(siteID string, pages []models.Page) error {
+	for i := range pages {
+		page := &pages[i]
+		sections := page.Sections
+		page.ID = ""
+		page.SiteID = siteID
+		page.Sections = nil
+		if err := s.Pages.Create(page); err != nil {
+			return err
+		}
+
+		for j := range sections {
+			sections[j].ID = ""
+		}
+		if err := s.createSectionTrees(page.ID, sections); err != nil {
+			return err
+		}
+		page.Sections = sections
+	}
+	return nil
+}
+
+// createSectionTrees 在页面下依次创建区块和组件，区块ID为空时由存储生成，组件ID总是重新生成
+func (s *Store) createSectionTrees(pageID string, sections []models.Section) error {
+	for i := range sections {
+		section := &sections[i]
+		components := section.Components
+		section.PageID = pageID
+		section.Components = nil
+		if err := s.Sections.Create(section); err != nil {
+			return err
+		}
+
+		for k := range components {
+			component := &components[k]
+			component.ID = ""
+			component.SectionID = section.ID
+			if err := s.Components.Create(component); err != nil {
+				return err
+			}
+		}
+		section.Components = components
+	}
+	return nil
+}
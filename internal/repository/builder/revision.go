@@ -0,0 +1,19 @@
+package builder
+
+import "errors"
+
+// ErrRevisionConflict 提交的修订号与存储中的记录不一致，记录已被其他人修改
+var ErrRevisionConflict = errors.New("内容已被其他人修改，请基于最新内容重新编辑")
+
+// ConflictError 修订号冲突，Current为存储中的最新记录
+type ConflictError struct {
+	Current interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return ErrRevisionConflict.Error()
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrRevisionConflict
+}
@@ -0,0 +1,272 @@
+package builder
+
+import (
+	"time"
+	"wz-backend-go/internal/domain"
+	"wz-backend-go/models"
+)
+
+// SeedDemoData 写入演示站点和模板，用于未配置数据库时的本地开发
+func SeedDemoData(store *Store) error {
+	site := models.Site{
+		ID:          "1",
+		Name:        "企业展示站点",
+		Description: "适合企业官网使用的模板",
+		Domain:      "company.wanzhimarket.com",
+		Logo:        "/img/logo1.png",
+		Favicon:     "/img/favicon1.ico",
+		TenantID:    "tenant_456",
+		Locales:     []string{"zh-CN", "en"},
+		Theme: models.ThemeConfig{
+			PrimaryColor:    "#FF5722",
+			SecondaryColor:  "#2196F3",
+			AccentColor:     "#4CAF50",
+			TextColor:       "#333333",
+			BackgroundColor: "#FFFFFF",
+			FontFamily:      "Arial, sans-serif",
+			HeaderStyle:     "standard",
+			BorderRadius:    "medium",
+			CustomCSS:       "",
+		},
+		Navigation: models.Navigation{
+			Type:  "horizontal",
+			Items: []models.NavigationItem{},
+			Style: map[string]string{},
+		},
+		CreatedAt: time.Now().Add(-24 * time.Hour),
+		UpdatedAt: time.Now().Add(-12 * time.Hour),
+		Status:    "published",
+		Thumbnail: "/img/site-thumbnail1.jpg",
+	}
+	if err := store.Sites.Create(&site); err != nil {
+		return err
+	}
+
+	pages := []models.Page{
+		{
+			ID:          "page1",
+			SiteID:      "1",
+			Name:        "首页",
+			Slug:        "home",
+			Title:       "企业官网首页",
+			Description: "欢迎访问我们的企业官网",
+			Keywords:    []string{"企业", "官网", "首页"},
+			Translations: map[string]models.PageTranslation{
+				"en": {Name: "Home", Title: "Company Home", Description: "Welcome to our company website"},
+			},
+			IsHomepage: true,
+			Layout:     "default",
+			CreatedAt:  time.Now().Add(-24 * time.Hour),
+			UpdatedAt:  time.Now().Add(-12 * time.Hour),
+		},
+		{
+			ID:          "page2",
+			SiteID:      "1",
+			Name:        "关于我们",
+			Slug:        "about",
+			Title:       "关于我们 - 企业官网",
+			Description: "了解我们的企业文化和团队",
+			Keywords:    []string{"关于", "企业文化", "团队"},
+			IsHomepage:  false,
+			Layout:      "default",
+			CreatedAt:   time.Now().Add(-24 * time.Hour),
+			UpdatedAt:   time.Now().Add(-12 * time.Hour),
+		},
+	}
+	for i := range pages {
+		if err := store.Pages.Create(&pages[i]); err != nil {
+			return err
+		}
+	}
+
+	sections := []models.Section{
+		{
+			ID:       "section1",
+			PageID:   "page1",
+			Type:     "header",
+			Title:    "页面头部",
+			Settings: map[string]interface{}{"backgroundColor": "#f5f5f5"},
+			Style:    map[string]interface{}{"padding": "20px"},
+		},
+		{
+			ID:       "section2",
+			PageID:   "page1",
+			Type:     "content",
+			Title:    "主要内容",
+			Settings: map[string]interface{}{"columns": 1},
+			Style:    map[string]interface{}{"margin": "20px 0"},
+		},
+		{
+			// 全局区块，在关于我们页面中被引用
+			ID:     "global-contact",
+			PageID: GlobalSectionsPageID("1"),
+			Type:   "content",
+			Title:  "联系我们",
+			Translations: map[string]models.SectionTranslation{
+				"en": {Title: "Contact Us"},
+			},
+		},
+		{
+			ID:              "section3",
+			PageID:          "page2",
+			GlobalSectionID: "global-contact",
+		},
+	}
+	for i := range sections {
+		if err := store.Sections.Create(&sections[i]); err != nil {
+			return err
+		}
+	}
+
+	components := []models.Component{
+		{
+			ID:        "comp1",
+			SectionID: "section1",
+			Type:      "heading",
+			Name:      "网站标题",
+			Settings: map[string]interface{}{
+				"level":     "h1",
+				"textAlign": "center",
+			},
+			Content: map[string]interface{}{
+				"text": "企业官网",
+			},
+			Translations: map[string]map[string]interface{}{
+				"en": {"text": "Company Website"},
+			},
+			Style: map[string]interface{}{
+				"marginBottom": "20px",
+			},
+		},
+		{
+			ID:        "comp2",
+			SectionID: "section1",
+			Type:      "text",
+			Name:      "欢迎文本",
+			Settings: map[string]interface{}{
+				"textAlign": "center",
+				"fontSize":  "18px",
+			},
+			Content: map[string]interface{}{
+				"text": "欢迎访问我们的企业官网",
+			},
+			Style: map[string]interface{}{
+				"color": "#666",
+			},
+		},
+		{
+			ID:        "comp3",
+			SectionID: "section2",
+			Type:      "image",
+			Name:      "宣传图片",
+			Settings: map[string]interface{}{
+				"width":     "100%",
+				"objectFit": "cover",
+			},
+			Content: map[string]interface{}{
+				"src": "/img/banner.jpg",
+				"alt": "企业宣传图",
+			},
+			Style: map[string]interface{}{
+				"borderRadius": "8px",
+			},
+		},
+		{
+			ID:        "comp4",
+			SectionID: "global-contact",
+			Type:      "text",
+			Name:      "联系方式",
+			Content: map[string]interface{}{
+				"text": "电话：400-000-0000",
+			},
+			Translations: map[string]map[string]interface{}{
+				"en": {"text": "Tel: 400-000-0000"},
+			},
+		},
+	}
+	for i := range components {
+		if err := store.Components.Create(&components[i]); err != nil {
+			return err
+		}
+	}
+
+	verifiedAt := time.Now().Add(-24 * time.Hour)
+	domain := models.SiteDomain{
+		SiteID:             "1",
+		Hostname:           "company.wanzhimarket.com",
+		VerificationMethod: "dns",
+		VerificationToken:  "demo",
+		Verified:           true,
+		VerifiedAt:         &verifiedAt,
+		IsPrimary:          true,
+		CreatedAt:          verifiedAt,
+	}
+	if err := store.Domains.Create(&domain); err != nil {
+		return err
+	}
+
+	templates := []models.SiteTemplate{
+		{
+			ID:          "t1",
+			Name:        "企业展示",
+			Thumbnail:   "/img/template1.jpg",
+			Description: "适合企业官网使用的模板",
+			Config:      `{"pages":[{"name":"首页","layout":"default"},{"name":"关于我们","layout":"default"},{"name":"产品服务","layout":"default"},{"name":"联系我们","layout":"default"}]}`,
+		},
+		{
+			ID:          "t2",
+			Name:        "产品展示",
+			Thumbnail:   "/img/template2.jpg",
+			Description: "重点突出产品的模板",
+			Config:      `{"pages":[{"name":"首页","layout":"full-width"},{"name":"产品目录","layout":"sidebar"},{"name":"产品详情","layout":"default"},{"name":"联系我们","layout":"default"}]}`,
+		},
+		{
+			ID:          "t3",
+			Name:        "简约风格",
+			Thumbnail:   "/img/template3.jpg",
+			Description: "简洁大方的设计风格",
+			Config:      `{"pages":[{"name":"首页","layout":"default"},{"name":"博客","layout":"sidebar"},{"name":"作品集","layout":"full-width"},{"name":"关于","layout":"default"}]}`,
+		},
+	}
+	for i := range templates {
+		if err := store.Templates.Create(&templates[i]); err != nil {
+			return err
+		}
+	}
+
+	return seedThemes(store)
+}
+
+// seedThemes 初始化后台主题库中的系统主题，租户ID为0表示所有租户可用
+func seedThemes(store *Store) error {
+	themes := []domain.Theme{
+		{
+			Name:        "商务蓝",
+			Code:        "business-blue",
+			Preview:     "/img/theme-business-blue.jpg",
+			Description: "稳重的蓝色商务风格",
+			Status:      1,
+			IsDefault:   1,
+			Config:      `{"primaryColor":"#1565C0","secondaryColor":"#546E7A","accentColor":"#FFB300","textColor":"#263238","backgroundColor":"#FFFFFF","fontFamily":"'PingFang SC', 'Microsoft YaHei', sans-serif","headerStyle":"standard","borderRadius":"small","spacing":"normal","darkMode":"off"}`,
+			CreatedAt:   time.Now().Add(-24 * time.Hour),
+			UpdatedAt:   time.Now().Add(-24 * time.Hour),
+		},
+		{
+			Name:        "暖色活力",
+			Code:        "warm",
+			Preview:     "/img/theme-warm.jpg",
+			Description: "明亮的暖色调，跟随系统切换深色模式",
+			Status:      1,
+			Config:      `{"primaryColor":"#FF5722","secondaryColor":"#795548","accentColor":"#FFC107","textColor":"#3E2723","backgroundColor":"#FFF8F1","headerStyle":"centered","borderRadius":"large","spacing":"relaxed","darkMode":"auto","darkTextColor":"#F5E9E2","darkBackgroundColor":"#1E1410"}`,
+			CreatedAt:   time.Now().Add(-24 * time.Hour),
+			UpdatedAt:   time.Now().Add(-24 * time.Hour),
+		},
+	}
+	for i := range themes {
+		if err := store.Themes.Create(&themes[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
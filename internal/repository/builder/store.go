@@ -0,0 +1,35 @@
+package builder
+
+import (
+	"fmt"
+	"os"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// OpenStore 根据环境变量创建存储，设置BUILDER_DB_DSN时使用MySQL，否则使用带演示数据的内存存储
+func OpenStore() (*Store, error) {
+	dsn := os.Getenv("BUILDER_DB_DSN")
+	if dsn == "" {
+		store := NewMemoryStore()
+		if err := SeedDemoData(store); err != nil {
+			return nil, err
+		}
+		return store, nil
+	}
+	return openGormStore(dsn)
+}
+
+// openGormStore 连接MySQL并迁移数据表
+func openGormStore(dsn string) (*Store, error) {
+	// 唯一索引冲突转换为gorm.ErrDuplicatedKey，仓储据此返回ErrDuplicateHostname等错误
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	if err := AutoMigrate(db); err != nil {
+		return nil, fmt.Errorf("迁移数据表失败: %w", err)
+	}
+	return NewGormStore(db), nil
+}
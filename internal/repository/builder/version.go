@@ -0,0 +1,278 @@
+package builder
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+	"wz-backend-go/models"
+)
+
+var (
+	// ErrSiteNotPublished 站点已下线或没有发布版本，不能单独发布或下线页面
+	ErrSiteNotPublished = errors.New("站点未发布")
+	// ErrHomepageUnpublish 首页不能单独下线
+	ErrHomepageUnpublish = errors.New("首页不能单独下线")
+)
+
+// CloneSite 深拷贝站点树，保证快照不受后续编辑影响
+func CloneSite(site models.Site) (models.Site, error) {
+	data, err := json.Marshal(site)
+	if err != nil {
+		return models.Site{}, err
+	}
+	var cloned models.Site
+	if err := json.Unmarshal(data, &cloned); err != nil {
+		return models.Site{}, err
+	}
+	return cloned, nil
+}
+
+// CloneSection 深拷贝区块及其组件
+func CloneSection(section models.Section) (models.Section, error) {
+	data, err := json.Marshal(section)
+	if err != nil {
+		return models.Section{}, err
+	}
+	var cloned models.Section
+	if err := json.Unmarshal(data, &cloned); err != nil {
+		return models.Section{}, err
+	}
+	return cloned, nil
+}
+
+// PublishSite 冻结站点当前的草稿树为新的发布版本
+func (s *Store) PublishSite(siteID string, publishedBy string, note string) (models.SiteVersion, error) {
+	return s.publishInTransaction(siteID, func(tx *Store) (models.SiteVersion, error) {
+		tree, err := tx.LoadSiteTree(siteID)
+		if err != nil {
+			return models.SiteVersion{}, err
+		}
+		return tx.publishSnapshot(tree, publishedBy, note, 0)
+	})
+}
+
+// PublishSnapshot 将站点树保存为新的发布版本并更新站点的发布状态，rollbackFrom非0表示由回滚生成
+func (s *Store) PublishSnapshot(tree models.Site, publishedBy string, note string, rollbackFrom int) (models.SiteVersion, error) {
+	return s.publishInTransaction(tree.ID, func(tx *Store) (models.SiteVersion, error) {
+		return tx.publishSnapshot(tree, publishedBy, note, rollbackFrom)
+	})
+}
+
+// publishInTransaction 在事务中读取并修改站点的发布状态，提交后发布线上版本变化事件
+func (s *Store) publishInTransaction(siteID string, publish func(tx *Store) (models.SiteVersion, error)) (models.SiteVersion, error) {
+	var version models.SiteVersion
+	err := s.Transaction(func(tx *Store) error {
+		var err error
+		version, err = publish(tx)
+		return err
+	})
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+
+	s.Notify(ChangeEvent{SiteID: siteID, Scope: ScopePublish})
+	return version, nil
+}
+
+// publishSnapshot 保存发布版本并更新站点的发布状态，不发布变更事件
+func (s *Store) publishSnapshot(tree models.Site, publishedBy string, note string, rollbackFrom int) (models.SiteVersion, error) {
+	snapshot, err := CloneSite(tree)
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+
+	now := time.Now()
+	snapshot.Status = "published"
+	snapshot.PublishedAt = &now
+
+	version := models.SiteVersion{
+		SiteID:       tree.ID,
+		Snapshot:     snapshot,
+		PublishedBy:  publishedBy,
+		Note:         note,
+		RollbackFrom: rollbackFrom,
+		CreatedAt:    now,
+	}
+	if err := s.Versions.Create(&version); err != nil {
+		return models.SiteVersion{}, err
+	}
+	// 版本号由仓储分配，快照中的版本号在创建后回填
+	version.Snapshot.PublishedVersion = version.Version
+
+	site, err := s.Sites.Get(tree.ID)
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+	site.Status = "published"
+	site.PublishedAt = &now
+	site.PublishedVersion = version.Version
+	site.UpdatedAt = now
+	if err := s.Sites.Update(&site); err != nil {
+		return models.SiteVersion{}, err
+	}
+	return version, nil
+}
+
+// UnpublishSite 下线站点，保留发布版本，重新发布后恢复访问
+func (s *Store) UnpublishSite(siteID string) error {
+	changed := false
+	err := s.Transaction(func(tx *Store) error {
+		site, err := tx.Sites.Get(siteID)
+		if err != nil {
+			return err
+		}
+		if site.Status != "published" {
+			return nil
+		}
+		site.Status = "draft"
+		site.UpdatedAt = time.Now()
+		changed = true
+		return tx.Sites.Update(&site)
+	})
+	if err != nil || !changed {
+		return err
+	}
+
+	s.Notify(ChangeEvent{SiteID: siteID, Scope: ScopePublish})
+	return nil
+}
+
+// PublishPage 把单个页面的草稿发布到线上版本，线上版本的其他页面和站点设置保持不变。
+// 页面引用了线上版本中没有的全局区块时一并发布这些全局区块
+func (s *Store) PublishPage(siteID string, pageID string, publishedBy string, note string) (models.SiteVersion, error) {
+	return s.publishInTransaction(siteID, func(tx *Store) (models.SiteVersion, error) {
+		return tx.publishPage(siteID, pageID, publishedBy, note)
+	})
+}
+
+// publishPage 把页面草稿合并到线上版本并保存为新的发布版本
+func (s *Store) publishPage(siteID string, pageID string, publishedBy string, note string) (models.SiteVersion, error) {
+	published, err := s.livePublishedSite(siteID)
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+	draft, err := s.LoadSiteTree(siteID)
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+
+	var page *models.Page
+	for i := range draft.Pages {
+		if draft.Pages[i].ID == pageID {
+			page = &draft.Pages[i]
+		}
+	}
+	if page == nil {
+		return models.SiteVersion{}, ErrNotFound
+	}
+
+	// 按草稿中的页面顺序排列，线上版本中已在草稿里删除的页面保留在最后
+	livePages := map[string]models.Page{}
+	for _, live := range published.Pages {
+		livePages[live.ID] = live
+	}
+	// 线上的首页保持为首页，首页的变更随整站发布生效
+	if live, ok := livePages[pageID]; ok && live.IsHomepage {
+		page.IsHomepage = true
+	}
+	livePages[pageID] = *page
+	var pages []models.Page
+	for _, draftPage := range draft.Pages {
+		if live, ok := livePages[draftPage.ID]; ok {
+			if page.IsHomepage && live.ID != pageID {
+				live.IsHomepage = false
+			}
+			pages = append(pages, live)
+			delete(livePages, draftPage.ID)
+		}
+	}
+	for _, live := range published.Pages {
+		if _, ok := livePages[live.ID]; ok {
+			pages = append(pages, live)
+		}
+	}
+	published.Pages = pages
+
+	liveGlobals := map[string]bool{}
+	for _, section := range published.GlobalSections {
+		liveGlobals[section.ID] = true
+	}
+	for _, section := range draft.GlobalSections {
+		if !liveGlobals[section.ID] {
+			published.GlobalSections = append(published.GlobalSections, section)
+		}
+	}
+
+	return s.publishSnapshot(published, publishedBy, note, 0)
+}
+
+// UnpublishPage 从线上版本中移除页面，草稿中的页面保持不变，首页不能下线
+func (s *Store) UnpublishPage(siteID string, pageID string, publishedBy string, note string) (models.SiteVersion, error) {
+	return s.publishInTransaction(siteID, func(tx *Store) (models.SiteVersion, error) {
+		return tx.unpublishPage(siteID, pageID, publishedBy, note)
+	})
+}
+
+// unpublishPage 从线上版本中移除页面并保存为新的发布版本
+func (s *Store) unpublishPage(siteID string, pageID string, publishedBy string, note string) (models.SiteVersion, error) {
+	published, err := s.livePublishedSite(siteID)
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+
+	pages := make([]models.Page, 0, len(published.Pages))
+	for _, page := range published.Pages {
+		if page.ID != pageID {
+			pages = append(pages, page)
+			continue
+		}
+		if page.IsHomepage {
+			return models.SiteVersion{}, ErrHomepageUnpublish
+		}
+	}
+	if len(pages) == len(published.Pages) {
+		return models.SiteVersion{}, ErrNotFound
+	}
+	published.Pages = pages
+
+	return s.publishSnapshot(published, publishedBy, note, 0)
+}
+
+// livePublishedSite 获取正在线上的站点版本，站点已下线时返回ErrSiteNotPublished
+func (s *Store) livePublishedSite(siteID string) (models.Site, error) {
+	site, err := s.Sites.Get(siteID)
+	if err != nil {
+		return models.Site{}, err
+	}
+	if site.Status != "published" {
+		return models.Site{}, ErrSiteNotPublished
+	}
+	return s.GetPublishedSite(siteID)
+}
+
+// GetPublishedSite 获取站点线上版本的完整站点树
+func (s *Store) GetPublishedSite(siteID string) (models.Site, error) {
+	version, err := s.Versions.Latest(siteID)
+	if err == nil {
+		site := version.Snapshot
+		site.PublishedVersion = version.Version
+		// 域名不属于发布内容，始终使用站点当前的主域名
+		if current, err := s.Sites.Get(siteID); err == nil {
+			site.Domain = current.Domain
+		}
+		return site, nil
+	}
+	if err != ErrNotFound {
+		return models.Site{}, err
+	}
+
+	// 兼容引入版本之前发布的站点，没有版本记录时使用当前数据
+	site, err := s.Sites.Get(siteID)
+	if err != nil {
+		return models.Site{}, err
+	}
+	if site.Status != "published" {
+		return models.Site{}, ErrNotFound
+	}
+	return s.LoadSiteTree(siteID)
+}
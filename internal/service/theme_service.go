@@ -2,6 +2,7 @@ package service
 
 import (
 	"wz-backend-go/internal/domain"
+	"wz-backend-go/internal/pkg/sitetheme"
 	"wz-backend-go/internal/repository/sql"
 
 	"github.com/zeromicro/go-zero/core/logx"
@@ -183,7 +184,12 @@ func (s *ThemeServiceImpl) GetDefaultTheme(tenantID int64) (*domain.Theme, error
 
 // validateTheme 验证主题
 func (s *ThemeServiceImpl) validateTheme(theme *domain.Theme) error {
-	// 这里可以添加业务规则验证
+	// 主题配置与站点构建器使用同一套设计变量，需要能应用到站点
+	if _, err := sitetheme.ParseConfig(theme.Config); err != nil {
+		logx.Errorf("主题配置无效: %v", err)
+		return err
+	}
+	// 这里可以添加其他业务规则验证
 	// 例如：主题名称不能为空、主题代码必须唯一等
 	return nil
 }
@@ -11,6 +11,10 @@ func Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 获取Authorization头
 		authHeader := c.GetHeader("Authorization")
+		// 浏览器建立WebSocket连接时无法设置请求头，允许通过access_token参数传递令牌
+		if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证信息"})
 			c.Abort()
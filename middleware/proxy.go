@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxies 从TRUSTED_PROXIES读取可信的反向代理地址（逗号分隔的IP或CIDR），
+// 只有来自这些地址的请求才使用X-Forwarded-For中的客户端IP。未设置时不信任任何代理，
+// c.ClientIP()返回连接的对端地址，访客无法通过伪造请求头绕过按IP的限流
+func TrustedProxies() []string {
+	var proxies []string
+	for _, value := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			proxies = append(proxies, value)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PageEvent 公开页面上报的原始访问事件，定期汇总为AnalyticsRollup，汇总后按保留期清理
+type PageEvent struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	SiteID      string    `json:"siteId" gorm:"size:64;index:idx_page_event_site_time"`
+	PageID      string    `json:"pageId" gorm:"size:64"`
+	Type        string    `json:"type" gorm:"size:16"`                  // pageview, click
+	ComponentID string    `json:"componentId,omitempty" gorm:"size:64"` // 点击的按钮或链接组件
+	VisitorHash string    `json:"-" gorm:"size:64"`                     // 访客标识，由每日更换的盐、IP和User-Agent计算，不保存原始IP
+	Referrer    string    `json:"referrer,omitempty" gorm:"size:255"`   // 来源域名，站内跳转和直接访问为空
+	Device      string    `json:"device" gorm:"size:16"`                // desktop, tablet, mobile, bot
+	Locale      string    `json:"locale,omitempty" gorm:"size:16"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"index:idx_page_event_site_time"`
+}
+
+// AnalyticsRollup 按小时或按天汇总的访问统计。
+// PageID为空表示整个站点；Dimension为空时是总量，否则按来源、设备或组件分组
+type AnalyticsRollup struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	SiteID      string    `json:"siteId" gorm:"size:64;uniqueIndex:idx_analytics_rollup"`
+	Granularity string    `json:"granularity" gorm:"size:8;uniqueIndex:idx_analytics_rollup"` // hour, day
+	PeriodStart time.Time `json:"periodStart" gorm:"uniqueIndex:idx_analytics_rollup"`
+	PageID      string    `json:"pageId,omitempty" gorm:"size:64;uniqueIndex:idx_analytics_rollup"`
+	Metric      string    `json:"metric" gorm:"size:16;uniqueIndex:idx_analytics_rollup"`              // pv, uv, click
+	Dimension   string    `json:"dimension,omitempty" gorm:"size:16;uniqueIndex:idx_analytics_rollup"` // referrer, device, component
+	Value       string    `json:"value,omitempty" gorm:"size:255;uniqueIndex:idx_analytics_rollup"`    // 分组的值，如来源域名、设备类型、组件ID
+	Count       int64     `json:"count"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SiteDomain 站点绑定的域名，验证通过后才会用于访问站点
+type SiteDomain struct {
+	ID                 string     `json:"id" gorm:"primaryKey"`
+	SiteID             string     `json:"siteId" gorm:"index"`
+	Hostname           string     `json:"hostname" gorm:"size:255;uniqueIndex"`
+	VerificationMethod string     `json:"verificationMethod"` // dns, http
+	VerificationToken  string     `json:"verificationToken"`
+	Verified           bool       `json:"verified"`
+	VerifiedAt         *time.Time `json:"verifiedAt"`
+	IsPrimary          bool       `json:"isPrimary"` // 主域名，其他已验证域名会301跳转到主域名
+	CreatedAt          time.Time  `json:"createdAt"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// EditOperation 页面编辑历史中的一次修改，记录修改人、编辑会话和修改前后的状态。
+// 同一会话的操作可以按顺序撤销和重做，删除操作的Before保存被删除的区块或组件，用于恢复
+type EditOperation struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	SiteID     string     `json:"siteId" gorm:"size:64;index:idx_edit_operation_page"`
+	PageID     string     `json:"pageId" gorm:"size:64;index:idx_edit_operation_page"`
+	SessionID  string     `json:"sessionId,omitempty" gorm:"size:64;index"` // 编辑器会话，为空时操作只记录不能撤销
+	UserID     string     `json:"userId" gorm:"size:64"`
+	Action     string     `json:"action" gorm:"size:16"`     // create, update, delete, reorder
+	EntityType string     `json:"entityType" gorm:"size:16"` // section, component
+	EntityID   string     `json:"entityId,omitempty" gorm:"size:64"`
+	ParentID   string     `json:"parentId" gorm:"size:64"` // 区块所在的页面或组件所在的区块
+	Before     *EditState `json:"before,omitempty" gorm:"type:json;serializer:json"`
+	After      *EditState `json:"after,omitempty" gorm:"type:json;serializer:json"`
+	Status     string     `json:"status" gorm:"size:16"` // applied, undone, discarded
+	UndoneBy   string     `json:"undoneBy,omitempty" gorm:"size:64"`
+	UndoneAt   *time.Time `json:"undoneAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"index"`
+}
+
+// EditState 修改前或修改后的状态，区块包含其组件；排序操作只记录同级的ID顺序
+type EditState struct {
+	Section   *Section   `json:"section,omitempty"`
+	Component *Component `json:"component,omitempty"`
+	Order     []string   `json:"order,omitempty"`
+}
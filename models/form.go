@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// FormSubmission 访客提交的表单内容
+type FormSubmission struct {
+	ID          string            `json:"id" gorm:"primaryKey"`
+	SiteID      string            `json:"siteId" gorm:"index"`
+	PageID      string            `json:"pageId"`
+	ComponentID string            `json:"componentId" gorm:"index"` // 表单组件ID
+	FormName    string            `json:"formName"`
+	Values      map[string]string `json:"values" gorm:"type:json;serializer:json"`
+	Files       []FormFile        `json:"files,omitempty" gorm:"type:json;serializer:json"`
+	IP          string            `json:"ip"`
+	UserAgent   string            `json:"userAgent"`
+	CreatedAt   time.Time         `json:"createdAt" gorm:"index"`
+}
+
+// FormFile 表单提交的文件，StoredName为保存在上传目录中的相对路径
+type FormFile struct {
+	Field       string `json:"field"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"contentType"`
+	StoredName  string `json:"storedName"`
+}
+
+// FormNotificationSettings 租户的表单通知设置，收到提交时通知Recipients中的用户
+type FormNotificationSettings struct {
+	TenantID   string    `json:"tenantId" gorm:"primaryKey"`
+	Recipients []int64   `json:"recipients" gorm:"type:json;serializer:json"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// MediaAsset 租户媒体库中的文件，同一租户内容相同的文件只保存一份
+type MediaAsset struct {
+	ID          string         `json:"id" gorm:"primaryKey"`
+	TenantID    string         `json:"tenantId" gorm:"size:64;uniqueIndex:idx_media_tenant_hash"`
+	Hash        string         `json:"hash" gorm:"size:64;uniqueIndex:idx_media_tenant_hash"` // 文件内容的SHA-256，十六进制
+	FileName    string         `json:"fileName" gorm:"size:255"`
+	ContentType string         `json:"contentType" gorm:"size:100"`
+	Size        int64          `json:"size"`
+	Width       int            `json:"width,omitempty"` // 图片的像素尺寸，非图片为0
+	Height      int            `json:"height,omitempty"`
+	StorageKey  string         `json:"-" gorm:"size:255"`
+	Variants    []MediaVariant `json:"variants" gorm:"type:json;serializer:json"` // 已生成的缩放版本
+	URL         string         `json:"url" gorm:"-"`                              // 访问地址，由媒体服务按配置填写
+	UploadedBy  string         `json:"uploadedBy,omitempty" gorm:"size:64"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	UpdatedAt   time.Time      `json:"updatedAt"`
+}
+
+// MediaVariant 图片按宽度缩放并转换格式后的版本，按需生成
+type MediaVariant struct {
+	Name   string `json:"name"` // 如w640.webp，也是访问地址的最后一段
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"` // jpg, png, webp
+	Size   int64  `json:"size"`
+}
+
+// MediaReference 引用媒体文件的位置
+type MediaReference struct {
+	SiteID      string `json:"siteId"`
+	SiteName    string `json:"siteName"`
+	PageID      string `json:"pageId,omitempty"`
+	PageName    string `json:"pageName,omitempty"`
+	ComponentID string `json:"componentId,omitempty"` // 为空时是站点设置（如Logo）引用
+	Field       string `json:"field"`                 // 引用所在的字段，如content.src
+	Published   bool   `json:"published"`             // 引用来自已发布的版本而不是草稿
+}
@@ -1,77 +1,109 @@
 package models
 
-import "time"
+import (
+	"time"
+	"wz-backend-go/internal/pkg/jsonschema"
+)
 
 // Page 页面模型
 type Page struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	SiteID      string    `json:"siteId" gorm:"index"`
-	Name        string    `json:"name"`
-	Slug        string    `json:"slug" gorm:"index"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	Keywords    []string  `json:"keywords" gorm:"type:json"`
-	IsHomepage  bool      `json:"isHomepage"`
-	Layout      string    `json:"layout"` // default, full-width, sidebar
-	Sections    []Section `json:"sections" gorm:"-"` // 不存储在同一表
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	SortOrder   int       `json:"sortOrder" gorm:"index"`
+	ID           string                     `json:"id" gorm:"primaryKey"`
+	SiteID       string                     `json:"siteId" gorm:"index"`
+	Name         string                     `json:"name"`
+	Slug         string                     `json:"slug" gorm:"index"`
+	Title        string                     `json:"title"`
+	Description  string                     `json:"description"`
+	Keywords     []string                   `json:"keywords" gorm:"type:json;serializer:json"`
+	IsHomepage   bool                       `json:"isHomepage"`
+	Layout       string                     `json:"layout"`                                                  // default, full-width, sidebar
+	Translations map[string]PageTranslation `json:"translations,omitempty" gorm:"type:json;serializer:json"` // 按语言覆盖的内容
+	Sections     []Section                  `json:"sections" gorm:"-"`                                       // 不存储在同一表
+	VisibleFrom  *time.Time                 `json:"visibleFrom,omitempty"`                                   // 可见时间窗口的开始，为空时不限制
+	VisibleUntil *time.Time                 `json:"visibleUntil,omitempty"`                                  // 可见时间窗口的结束（不含），为空时不限制
+	CreatedAt    time.Time                  `json:"createdAt"`
+	UpdatedAt    time.Time                  `json:"updatedAt"`
+	SortOrder    int                        `json:"sortOrder" gorm:"index"`
+	Revision     int                        `json:"revision" gorm:"not null;default:1"` // 修订号，每次更新递增，用于检测并发修改
 }
 
-// Section 页面区块模型
+// Section 页面区块模型。GlobalSectionID不为空时区块只是对站点全局区块的引用，
+// 渲染时使用全局区块的内容和组件
 type Section struct {
-	ID         string      `json:"id" gorm:"primaryKey"`
-	PageID     string      `json:"pageId" gorm:"index"`
-	Type       string      `json:"type"`
-	Title      string      `json:"title"`
-	Settings   interface{} `json:"settings" gorm:"type:json"`
-	Components []Component `json:"components" gorm:"-"` // 不存储在同一表
-	Style      interface{} `json:"style" gorm:"type:json"`
-	SortOrder  int         `json:"sortOrder" gorm:"index"`
+	ID              string                        `json:"id" gorm:"primaryKey"`
+	PageID          string                        `json:"pageId" gorm:"index"`
+	GlobalSectionID string                        `json:"globalSectionId,omitempty" gorm:"index"`
+	Type            string                        `json:"type"`
+	Title           string                        `json:"title"`
+	Settings        interface{}                   `json:"settings" gorm:"type:json;serializer:json"`
+	Components      []Component                   `json:"components" gorm:"-"` // 不存储在同一表
+	Style           interface{}                   `json:"style" gorm:"type:json;serializer:json"`
+	Translations    map[string]SectionTranslation `json:"translations,omitempty" gorm:"type:json;serializer:json"`
+	SortOrder       int                           `json:"sortOrder" gorm:"index"`
+	Revision        int                           `json:"revision" gorm:"not null;default:1"`
 }
 
 // Component 组件模型
 type Component struct {
-	ID        string      `json:"id" gorm:"primaryKey"`
-	SectionID string      `json:"sectionId" gorm:"index"`
-	Type      string      `json:"type"`
-	Name      string      `json:"name"`
-	Settings  interface{} `json:"settings" gorm:"type:json"`
-	Content   interface{} `json:"content" gorm:"type:json"`
-	Style     interface{} `json:"style" gorm:"type:json"`
-	SortOrder int         `json:"sortOrder" gorm:"index"`
+	ID           string                            `json:"id" gorm:"primaryKey"`
+	SectionID    string                            `json:"sectionId" gorm:"index"`
+	Type         string                            `json:"type"`
+	Name         string                            `json:"name"`
+	Settings     interface{}                       `json:"settings" gorm:"type:json;serializer:json"`
+	Content      interface{}                       `json:"content" gorm:"type:json;serializer:json"`
+	Style        interface{}                       `json:"style" gorm:"type:json;serializer:json"`
+	SortOrder    int                               `json:"sortOrder" gorm:"index"`
+	Translations map[string]map[string]interface{} `json:"translations,omitempty" gorm:"type:json;serializer:json"` // 按语言覆盖Content中的字段
+	Revision     int                               `json:"revision" gorm:"not null;default:1"`
+}
+
+// PageTranslation 页面在某种语言下的内容，空字段使用默认语言的值
+type PageTranslation struct {
+	Name        string   `json:"name,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+}
+
+// SectionTranslation 区块在某种语言下的内容
+type SectionTranslation struct {
+	Title string `json:"title,omitempty"`
 }
 
 // Navigation 导航配置
 type Navigation struct {
-	Type  string          `json:"type"` // horizontal, vertical, mega-menu
-	Items []NavigationItem `json:"items"`
-	Style interface{}     `json:"style"`
+	Type     string           `json:"type"` // horizontal, vertical, mega-menu
+	Items    []NavigationItem `json:"items"`
+	Style    interface{}      `json:"style"`
+	AutoSync bool             `json:"autoSync"` // 新建页面时自动加入导航，删除页面时移除对应的导航项
 }
 
 // NavigationItem 导航项
 type NavigationItem struct {
-	ID            string           `json:"id"`
-	Label         string           `json:"label"`
-	Link          string           `json:"link"`
-	Icon          string           `json:"icon,omitempty"`
-	Children      []NavigationItem `json:"children,omitempty"`
-	IsExternalLink bool            `json:"isExternalLink"`
+	ID             string           `json:"id"`
+	Label          string           `json:"label"` // 指向页面时可以为空，使用页面名称
+	Link           string           `json:"link"`
+	PageID         string           `json:"pageId,omitempty"` // 指向站内页面，渲染时按页面当前的Slug生成链接，不使用Link
+	Icon           string           `json:"icon,omitempty"`
+	Children       []NavigationItem `json:"children,omitempty"`
+	IsExternalLink bool             `json:"isExternalLink"`
 }
 
 // ComponentCategory 组件分类
 type ComponentCategory struct {
-	ID          string                `json:"id"`
-	Name        string                `json:"name"`
-	Components  []ComponentDefinition `json:"components"`
+	ID         string                `json:"id"`
+	Name       string                `json:"name"`
+	Components []ComponentDefinition `json:"components"`
 }
 
-// ComponentDefinition 组件定义
+// ComponentDefinition 组件定义，SettingsSchema和ContentSchema描述组件可配置的字段，
+// 写入组件时据此校验，编辑器也可以据此生成属性面板
 type ComponentDefinition struct {
-	Type            string      `json:"type"`
-	Name            string      `json:"name"`
-	Icon            string      `json:"icon"`
-	Description     string      `json:"description"`
-	DefaultSettings interface{} `json:"defaultSettings"`
-} 
\ No newline at end of file
+	Type            string             `json:"type"`
+	Name            string             `json:"name"`
+	Icon            string             `json:"icon"`
+	Description     string             `json:"description"`
+	DefaultSettings interface{}        `json:"defaultSettings"`
+	DefaultContent  interface{}        `json:"defaultContent,omitempty"`
+	SettingsSchema  *jsonschema.Schema `json:"settingsSchema,omitempty"`
+	ContentSchema   *jsonschema.Schema `json:"contentSchema,omitempty"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// PreviewToken 站点预览链接，可以把未发布的草稿或指定的发布版本分享给未登录的访客
+type PreviewToken struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	SiteID       string     `json:"siteId" gorm:"size:64;index"`
+	PageID       string     `json:"pageId,omitempty"` // 为空时可以预览站点的所有页面
+	Version      int        `json:"version"`          // 0表示当前草稿，否则为发布版本号
+	Note         string     `json:"note,omitempty"`   // 备注，如分享对象
+	PasswordHash string     `json:"-"`                // 为空时不需要密码
+	ExpiresAt    time.Time  `json:"expiresAt"`
+	RevokedAt    *time.Time `json:"revokedAt,omitempty"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt" gorm:"index"`
+}
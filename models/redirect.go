@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SiteRedirect 站点的URL重定向规则，公开页面在查找页面之前按规则跳转
+type SiteRedirect struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	SiteID     string     `json:"siteId" gorm:"size:64;index"`
+	Source     string     `json:"source" gorm:"size:500"`          // 站内路径，如/old-page；通配规则用*匹配任意字符
+	Target     string     `json:"target" gorm:"size:1000"`         // 站内路径或http(s)地址，通配规则可以用$1到$9引用*匹配的内容
+	MatchType  string     `json:"matchType" gorm:"size:16"`        // exact, prefix, wildcard
+	StatusCode int        `json:"statusCode"`                      // 301或302
+	PageID     string     `json:"pageId,omitempty" gorm:"size:64"` // 修改页面Slug时自动生成的规则所属的页面，手动添加的规则为空
+	Hits       int64      `json:"hits"`
+	LastHitAt  *time.Time `json:"lastHitAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
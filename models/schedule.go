@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PublishSchedule 定时发布任务。PageID为空时作用于整个站点：publish发布当前草稿，unpublish下线站点；
+// PageID不为空时只把该页面的草稿发布到线上版本或从线上版本中移除，其他页面保持不变。
+// 任务记录同时作为审计记录，保留创建人、取消人和执行结果
+type PublishSchedule struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	SiteID      string     `json:"siteId" gorm:"size:64;index"`
+	PageID      string     `json:"pageId,omitempty" gorm:"size:64"`
+	Action      string     `json:"action" gorm:"size:16"` // publish, unpublish
+	RunAt       time.Time  `json:"runAt" gorm:"index"`
+	Status      string     `json:"status" gorm:"size:16;index"` // pending, running, done, failed, cancelled
+	Note        string     `json:"note,omitempty"`
+	CreatedBy   string     `json:"createdBy"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CancelledBy string     `json:"cancelledBy,omitempty"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+	ClaimedBy   string     `json:"claimedBy,omitempty"` // 执行任务的服务实例
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Version     int        `json:"version,omitempty"` // 执行后产生的发布版本号
+	Error       string     `json:"error,omitempty"`
+}
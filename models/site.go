@@ -4,35 +4,76 @@ import "time"
 
 // Site 站点模型
 type Site struct {
-	ID          string       `json:"id" gorm:"primaryKey"`
-	Name        string       `json:"name"`
-	Description string       `json:"description"`
-	Domain      string       `json:"domain"`
-	Logo        string       `json:"logo"`
-	Favicon     string       `json:"favicon"`
-	TenantID    string       `json:"tenantId"` // 企业/组织ID
-	Theme       ThemeConfig  `json:"theme" gorm:"embedded"`
-	Pages       []Page       `json:"pages" gorm:"-"` // 不存储在同一表
-	Navigation  Navigation   `json:"navigation" gorm:"type:json"`
-	Footer      interface{}  `json:"footer" gorm:"type:json"`
-	Thumbnail   string       `json:"thumbnail"`
-	CreatedAt   time.Time    `json:"createdAt"`
-	UpdatedAt   time.Time    `json:"updatedAt"`
-	PublishedAt *time.Time   `json:"publishedAt"`
-	Status      string       `json:"status"` // draft, published, archived
-}
-
-// ThemeConfig 主题配置
+	ID               string      `json:"id" gorm:"primaryKey"`
+	Name             string      `json:"name"`
+	Description      string      `json:"description"`
+	Domain           string      `json:"domain"` // 主域名，由域名管理维护
+	Logo             string      `json:"logo"`
+	Favicon          string      `json:"favicon"`
+	TenantID         string      `json:"tenantId"` // 企业/组织ID
+	Theme            ThemeConfig `json:"theme" gorm:"embedded"`
+	ThemeID          int64       `json:"themeId,omitempty"` // 应用的后台主题，主题配置在应用时复制到Theme
+	Pages            []Page      `json:"pages" gorm:"-"`    // 不存储在同一表
+	Navigation       Navigation  `json:"navigation" gorm:"type:json;serializer:json"`
+	Footer           interface{} `json:"footer" gorm:"type:json;serializer:json"`
+	GlobalSections   []Section   `json:"globalSections,omitempty" gorm:"-"` // 可被多个页面引用的全局区块
+	HeaderSectionID  string      `json:"headerSectionId,omitempty"`         // 作为页头的全局区块，为空时使用默认页头
+	FooterSectionID  string      `json:"footerSectionId,omitempty"`         // 作为页脚的全局区块，为空时使用默认页脚
+	SEO              SiteSEO     `json:"seo" gorm:"embedded;embeddedPrefix:seo_"`
+	Locales          []string    `json:"locales" gorm:"type:json;serializer:json"` // 启用的语言，为空表示只使用默认语言
+	DefaultLocale    string      `json:"defaultLocale"`                            // 默认语言，为空时为zh-CN
+	Thumbnail        string      `json:"thumbnail"`
+	CreatedAt        time.Time   `json:"createdAt"`
+	UpdatedAt        time.Time   `json:"updatedAt"`
+	PublishedAt      *time.Time  `json:"publishedAt"`
+	PublishedVersion int         `json:"publishedVersion"` // 当前线上版本号，0表示没有发布版本
+	Status           string      `json:"status"`           // draft, published, archived
+}
+
+// SiteVersion 站点发布版本，保存发布时冻结的完整站点树
+type SiteVersion struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	SiteID       string    `json:"siteId" gorm:"size:64;uniqueIndex:idx_site_version"`
+	Version      int       `json:"version" gorm:"uniqueIndex:idx_site_version"`
+	Snapshot     Site      `json:"snapshot" gorm:"type:json;serializer:json"` // 包含页面、区块和组件
+	PublishedBy  string    `json:"publishedBy"`
+	Note         string    `json:"note"`
+	RollbackFrom int       `json:"rollbackFrom,omitempty"` // 回滚生成的版本记录来源版本号
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// SiteVersionChange 两个版本之间的单项差异
+type SiteVersionChange struct {
+	Path   string      `json:"path"`   // 如 pages[page1].sections[section1].components[comp1].content
+	Action string      `json:"action"` // added, removed, modified
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// SiteSEO 站点级SEO设置，页面没有设置标题、描述和关键词时使用
+type SiteSEO struct {
+	Title       string   `json:"title"` // 首页标题
+	Description string   `json:"description"`
+	Keywords    []string `json:"keywords" gorm:"type:json;serializer:json"`
+	Image       string   `json:"image"`   // 分享图片，未设置时使用站点缩略图或Logo
+	NoIndex     bool     `json:"noIndex"` // 禁止搜索引擎收录
+}
+
+// ThemeConfig 主题配置，由sitetheme编译为样式表，后台主题库的主题配置使用相同的字段
 type ThemeConfig struct {
-	PrimaryColor    string `json:"primaryColor"`
-	SecondaryColor  string `json:"secondaryColor"`
-	AccentColor     string `json:"accentColor"`
-	TextColor       string `json:"textColor"`
-	BackgroundColor string `json:"backgroundColor"`
-	FontFamily      string `json:"fontFamily"`
-	HeaderStyle     string `json:"headerStyle"` // standard, centered, minimal
-	BorderRadius    string `json:"borderRadius"` // none, small, medium, large
-	CustomCSS       string `json:"customCSS"`
+	PrimaryColor        string `json:"primaryColor"`
+	SecondaryColor      string `json:"secondaryColor"`
+	AccentColor         string `json:"accentColor"`
+	TextColor           string `json:"textColor"`
+	BackgroundColor     string `json:"backgroundColor"`
+	FontFamily          string `json:"fontFamily"`
+	HeaderStyle         string `json:"headerStyle"`  // standard, centered, minimal
+	BorderRadius        string `json:"borderRadius"` // none, small, medium, large或CSS长度
+	Spacing             string `json:"spacing"`      // compact, normal, relaxed
+	DarkMode            string `json:"darkMode"`     // off, auto（跟随系统）, on
+	DarkTextColor       string `json:"darkTextColor"`
+	DarkBackgroundColor string `json:"darkBackgroundColor"`
+	CustomCSS           string `json:"customCSS"` // 保存前经过解析和清理
 }
 
 // SiteTemplate 站点模板
@@ -41,5 +82,29 @@ type SiteTemplate struct {
 	Name        string `json:"name"`
 	Thumbnail   string `json:"thumbnail"`
 	Description string `json:"description"`
-	Config      string `json:"config" gorm:"type:json"` // 模板配置，JSON格式
-} 
\ No newline at end of file
+	Config      string `json:"config" gorm:"type:json"`         // 模板配置，JSON格式，结构见SiteTemplateConfig
+	TenantID    string `json:"tenantId,omitempty" gorm:"index"` // 为空表示系统模板，否则为租户私有模板
+}
+
+// SiteTemplateConfig 站点模板配置，模板中的ID在创建站点时会重新生成
+type SiteTemplateConfig struct {
+	Theme      *ThemeConfig `json:"theme,omitempty"`
+	Navigation *Navigation  `json:"navigation,omitempty"`
+	Footer     interface{}  `json:"footer,omitempty"`
+	Pages      []Page       `json:"pages"` // 页面包含区块，区块包含组件
+	// GlobalSections 全局区块，页面区块通过globalSectionId引用，ID只需在模板内唯一
+	GlobalSections  []Section `json:"globalSections,omitempty"`
+	HeaderSectionID string    `json:"headerSectionId,omitempty"`
+	FooterSectionID string    `json:"footerSectionId,omitempty"`
+}
+
+// TemplateOverrides 从模板创建站点时租户自定义的内容，空值表示使用模板默认值
+type TemplateOverrides struct {
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Logo           string `json:"logo"`
+	Favicon        string `json:"favicon"`
+	PrimaryColor   string `json:"primaryColor"`
+	SecondaryColor string `json:"secondaryColor"`
+	FontFamily     string `json:"fontFamily"`
+}
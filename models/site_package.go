@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// 站点导出包的格式标识和当前版本，导入时拒绝未知格式和更高版本
+const (
+	SitePackageFormat  = "wz-site-package"
+	SitePackageVersion = 1
+)
+
+// SitePackage 站点导出包，用于在租户和环境之间迁移或复制站点。
+// Site包含主题、导航、页面、区块、组件和全局区块，保留原ID以便导入时重写页面之间的引用；
+// 图片等资源以地址引用，不包含在导出包中
+type SitePackage struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exportedAt"`
+	Site       Site           `json:"site"`
+	Domains    []string       `json:"domains,omitempty"`   // 导出时站点绑定的域名，导入时用于把指向这些域名的链接改为站内路径
+	Templates  []SiteTemplate `json:"templates,omitempty"` // 导出时选择携带的租户私有模板
+}
+
+// SiteImportConflict 导入时发现的冲突及处理方式
+type SiteImportConflict struct {
+	Type       string `json:"type"` // slug, domain, name, theme, template
+	Path       string `json:"path"` // 冲突在导出包中的位置，如pages[1].slug
+	Value      string `json:"value"`
+	Message    string `json:"message"`
+	Resolution string `json:"resolution"` // 自动处理的方式，如renamed、skipped
+}
+
+// SiteImportResult 导入站点的结果，DryRun时只检查不写入，SiteID为空
+type SiteImportResult struct {
+	DryRun    bool                 `json:"dryRun"`
+	SiteID    string               `json:"siteId,omitempty"`
+	Pages     int                  `json:"pages"`
+	Sections  int                  `json:"sections"`
+	Templates int                  `json:"templates"`
+	Links     int                  `json:"links"` // 重写的站内链接数量
+	Conflicts []SiteImportConflict `json:"conflicts"`
+}
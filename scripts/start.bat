@@ -7,6 +7,7 @@ go build -o bin\site-service.exe services\site-service\main.go
 go build -o bin\page-service.exe services\page-service\main.go
 go build -o bin\component-service.exe services\component-service\main.go
 go build -o bin\render-service.exe services\render-service\main.go
+go build -o bin\media-service.exe services\media-service\main.go
 go build -o bin\api-gateway.exe api-gateway\main.go
 
 echo 启动服务...
@@ -18,6 +19,8 @@ start cmd /k "cd /d %~dp0\.. && bin\component-service.exe"
 timeout /t 2
 start cmd /k "cd /d %~dp0\.. && bin\render-service.exe"
 timeout /t 2
+start cmd /k "cd /d %~dp0\.. && bin\media-service.exe"
+timeout /t 2
 start cmd /k "cd /d %~dp0\.. && bin\api-gateway.exe"
 
 echo 所有服务已启动
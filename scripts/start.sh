@@ -8,6 +8,7 @@ go build -o bin/site-service services/site-service/main.go
 go build -o bin/page-service services/page-service/main.go
 go build -o bin/component-service services/component-service/main.go
 go build -o bin/render-service services/render-service/main.go
+go build -o bin/media-service services/media-service/main.go
 go build -o bin/api-gateway api-gateway/main.go
 
 echo "启动服务..."
@@ -19,6 +20,8 @@ bin/component-service > logs/component-service.log 2>&1 &
 sleep 2
 bin/render-service > logs/render-service.log 2>&1 &
 sleep 2
+bin/media-service > logs/media-service.log 2>&1 &
+sleep 2
 bin/api-gateway > logs/api-gateway.log 2>&1 &
 
 echo "所有服务已启动"
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"wz-backend-go/internal/pkg/collab"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/services/component-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publishChange 以当前用户的身份发布组件变更的协作事件
+func publishChange(c *gin.Context, event collab.Event) {
+	event.SiteID = c.Param("siteId")
+	event.PageID = c.Param("pageId")
+	event.SectionID = c.Param("sectionId")
+	event.UserID = c.GetString("user_id")
+	service.PublishEvent(event)
+}
+
+// currentEditor 当前用户和请求头中的编辑器会话，页面上组件的修改记录在该会话的编辑历史中
+func currentEditor(c *gin.Context) builder.Editor {
+	return builder.Editor{
+		UserID:    c.GetString("user_id"),
+		SessionID: c.GetHeader(builder.EditorSessionHeader),
+	}
+}
+
+// respondWriteError 返回写入组件失败的响应，校验失败时返回400及字段错误，修订号冲突时返回409及最新内容
+func respondWriteError(c *gin.Context, err error) {
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "组件配置校验失败",
+			"fields": validationErr.Errors,
+		})
+		return
+	}
+
+	var conflict *builder.ConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   conflict.Error(),
+			"current": conflict.Current,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"wz-backend-go/internal/pkg/collab"
 	"wz-backend-go/models"
 	"wz-backend-go/services/component-service/service"
 
@@ -59,12 +60,18 @@ func AddComponent(c *gin.Context) {
 	component.SectionID = sectionID
 
 	// 添加组件
-	addedComponent, err := service.AddComponent(siteID, pageID, sectionID, component)
+	addedComponent, err := service.AddComponent(siteID, pageID, sectionID, component, currentEditor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWriteError(c, err)
 		return
 	}
 
+	publishChange(c, collab.Event{
+		Type:        collab.EventComponentCreated,
+		ComponentID: addedComponent.ID,
+		Revision:    addedComponent.Revision,
+		Data:        addedComponent,
+	})
 	c.JSON(http.StatusCreated, addedComponent)
 }
 
@@ -97,12 +104,18 @@ func UpdateComponent(c *gin.Context) {
 	component.SectionID = sectionID
 
 	// 更新组件
-	updatedComponent, err := service.UpdateComponent(siteID, pageID, sectionID, component)
+	updatedComponent, err := service.UpdateComponent(siteID, pageID, sectionID, component, currentEditor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondWriteError(c, err)
 		return
 	}
 
+	publishChange(c, collab.Event{
+		Type:        collab.EventComponentUpdated,
+		ComponentID: updatedComponent.ID,
+		Revision:    updatedComponent.Revision,
+		Data:        updatedComponent,
+	})
 	c.JSON(http.StatusOK, updatedComponent)
 }
 
@@ -125,11 +138,12 @@ func DeleteComponent(c *gin.Context) {
 	}
 
 	// 删除组件
-	if err := service.DeleteComponent(siteID, pageID, sectionID, componentID); err != nil {
+	if err := service.DeleteComponent(siteID, pageID, sectionID, componentID, currentEditor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	publishChange(c, collab.Event{Type: collab.EventComponentDeleted, ComponentID: componentID})
 	c.JSON(http.StatusOK, gin.H{"message": "组件已删除"})
 }
 
@@ -157,10 +171,11 @@ func ReorderComponents(c *gin.Context) {
 	}
 
 	// 重新排序组件
-	if err := service.ReorderComponents(siteID, pageID, sectionID, componentOrder); err != nil {
+	if err := service.ReorderComponents(siteID, pageID, sectionID, componentOrder, currentEditor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	publishChange(c, collab.Event{Type: collab.EventComponentsReordered, Data: componentOrder})
 	c.JSON(http.StatusOK, gin.H{"message": "组件顺序已更新"})
 }
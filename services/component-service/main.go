@@ -3,8 +3,12 @@ package main
 import (
 	"log"
 	"os"
+	"wz-backend-go/internal/pkg/collab"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/middleware"
 	"wz-backend-go/services/component-service/handlers"
+	"wz-backend-go/services/component-service/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,8 +24,27 @@ func main() {
 	}
 	gin.SetMode(ginMode)
 
-	// 创建Gin引擎
+	// 初始化数据存储
+	store, err := builder.OpenStore()
+	if err != nil {
+		log.Fatalf("初始化数据存储失败: %v", err)
+	}
+	service.SetStore(store)
+
+	// 渲染缓存在服务之间共享时，编辑数据后直接清除受影响的渲染结果
+	if cache, shared := rendercache.Open(rendercache.DefaultTTL); shared {
+		cache.Attach(store)
+	}
+
+	// 协作事件总线，设置COLLAB_REDIS_ADDR后组件变更会推送到页面服务的协作连接
+	bus, _ := collab.Open()
+	service.SetEventBus(bus)
+
+	// 创建Gin引擎，只信任TRUSTED_PROXIES中的代理转发的客户端IP
 	r := gin.Default()
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatalf("无效的TRUSTED_PROXIES: %v", err)
+	}
 
 	// 注册中间件
 	r.Use(middleware.CORS())
@@ -51,6 +74,15 @@ func main() {
 			instanceGroup.DELETE("/:id", handlers.DeleteComponent)
 			instanceGroup.PUT("/reorder", handlers.ReorderComponents)
 		}
+
+		// 全局区块中的组件，修改会体现在所有引用该全局区块的页面
+		globalGroup := componentGroup.Group("/sites/:siteId/global-sections/:sectionId/components")
+		{
+			globalGroup.POST("", handlers.AddComponent)
+			globalGroup.PUT("/:id", handlers.UpdateComponent)
+			globalGroup.DELETE("/:id", handlers.DeleteComponent)
+			globalGroup.PUT("/reorder", handlers.ReorderComponents)
+		}
 	}
 
 	// 获取服务端口
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"log"
+	"time"
+	"wz-backend-go/internal/pkg/collab"
+)
+
+// 协作事件总线，由main在启动时设置
+var events collab.Bus
+
+// SetEventBus 设置协作事件总线
+func SetEventBus(bus collab.Bus) {
+	events = bus
+}
+
+// PublishEvent 发布协作事件，发布失败只记录日志，不影响已经完成的修改
+func PublishEvent(event collab.Event) {
+	if events == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if err := events.Publish(event); err != nil {
+		log.Printf("发布协作事件失败: %v", err)
+	}
+}
@@ -2,324 +2,219 @@ package service
 
 import (
 	"errors"
-	"fmt"
+	"wz-backend-go/internal/pkg/componentdef"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/models"
 )
 
-// 模拟组件分类数据
-var componentCategories = []models.ComponentCategory{
-	{
-		ID:   "basic",
-		Name: "基础组件",
-		Components: []models.ComponentDefinition{
-			{
-				Type:        "text",
-				Name:        "文本",
-				Icon:        "text",
-				Description: "文本内容块",
-				DefaultSettings: map[string]interface{}{
-					"textAlign": "left",
-					"fontSize":  "16px",
-				},
-			},
-			{
-				Type:        "heading",
-				Name:        "标题",
-				Icon:        "heading",
-				Description: "标题文本",
-				DefaultSettings: map[string]interface{}{
-					"level":     "h2",
-					"textAlign": "left",
-				},
-			},
-			{
-				Type:        "button",
-				Name:        "按钮",
-				Icon:        "button",
-				Description: "可点击的按钮",
-				DefaultSettings: map[string]interface{}{
-					"style":   "filled",
-					"size":    "medium",
-					"rounded": true,
-				},
-			},
-			{
-				Type:        "divider",
-				Name:        "分隔线",
-				Icon:        "divider",
-				Description: "水平分隔线",
-				DefaultSettings: map[string]interface{}{
-					"style": "solid",
-					"width": "100%",
-				},
-			},
-		},
-	},
-	{
-		ID:   "layout",
-		Name: "布局组件",
-		Components: []models.ComponentDefinition{
-			{
-				Type:        "container",
-				Name:        "容器",
-				Icon:        "container",
-				Description: "内容容器",
-				DefaultSettings: map[string]interface{}{
-					"width":  "100%",
-					"height": "auto",
-				},
-			},
-			{
-				Type:        "row",
-				Name:        "行",
-				Icon:        "row",
-				Description: "水平行",
-				DefaultSettings: map[string]interface{}{
-					"gutter": 16,
-				},
-			},
-			{
-				Type:        "column",
-				Name:        "列",
-				Icon:        "column",
-				Description: "垂直列",
-				DefaultSettings: map[string]interface{}{
-					"span": 12,
-				},
-			},
-			{
-				Type:        "card",
-				Name:        "卡片",
-				Icon:        "card",
-				Description: "卡片容器",
-				DefaultSettings: map[string]interface{}{
-					"shadow":  "medium",
-					"padding": 16,
-				},
-			},
-		},
-	},
-	{
-		ID:   "media",
-		Name: "媒体组件",
-		Components: []models.ComponentDefinition{
-			{
-				Type:        "image",
-				Name:        "图片",
-				Icon:        "image",
-				Description: "图片展示",
-				DefaultSettings: map[string]interface{}{
-					"width":     "100%",
-					"height":    "auto",
-					"objectFit": "cover",
-				},
-			},
-			{
-				Type:        "video",
-				Name:        "视频",
-				Icon:        "video",
-				Description: "视频播放器",
-				DefaultSettings: map[string]interface{}{
-					"autoplay": false,
-					"controls": true,
-					"loop":     false,
-				},
-			},
-			{
-				Type:        "carousel",
-				Name:        "轮播图",
-				Icon:        "carousel",
-				Description: "图片轮播",
-				DefaultSettings: map[string]interface{}{
-					"autoplay":   true,
-					"interval":   3000,
-					"indicators": true,
-					"arrows":     true,
-				},
-			},
-		},
-	},
+// 站点构建器的共享存储，由main在启动时设置
+var store *builder.Store
+
+// SetStore 设置数据存储
+func SetStore(s *builder.Store) {
+	store = s
 }
 
-// 模拟组件数据
-var components = map[string][]models.Component{
-	"section1": {
-		{
-			ID:        "comp1",
-			SectionID: "section1",
-			Type:      "heading",
-			Name:      "网站标题",
-			Settings: map[string]interface{}{
-				"level":     "h1",
-				"textAlign": "center",
-			},
-			Content: map[string]interface{}{
-				"text": "企业官网",
-			},
-			Style: map[string]interface{}{
-				"marginBottom": "20px",
-			},
-			SortOrder: 0,
-		},
-		{
-			ID:        "comp2",
-			SectionID: "section1",
-			Type:      "text",
-			Name:      "欢迎文本",
-			Settings: map[string]interface{}{
-				"textAlign": "center",
-				"fontSize":  "18px",
-			},
-			Content: map[string]interface{}{
-				"text": "欢迎访问我们的企业官网",
-			},
-			Style: map[string]interface{}{
-				"color": "#666",
-			},
-			SortOrder: 1,
-		},
-	},
-	"section2": {
-		{
-			ID:        "comp3",
-			SectionID: "section2",
-			Type:      "image",
-			Name:      "宣传图片",
-			Settings: map[string]interface{}{
-				"width":     "100%",
-				"objectFit": "cover",
-			},
-			Content: map[string]interface{}{
-				"src": "/img/banner.jpg",
-				"alt": "企业宣传图",
-			},
-			Style: map[string]interface{}{
-				"borderRadius": "8px",
-			},
-			SortOrder: 0,
-		},
-	},
+// 检查站点访问权限
+func CheckSiteAccess(siteID string, tenantID string) bool {
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return false
+	}
+	return site.TenantID == tenantID
 }
 
-// 模拟站点权限检查
-var siteTenants = map[string]string{
-	"1": "tenant_456",
+// checkSectionPath 检查区块属于该站点的页面，防止越权操作其他站点的区块。
+// pageID为空时区块是站点的全局区块；引用全局区块的页面区块没有自己的组件
+func checkSectionPath(siteID string, pageID string, sectionID string) error {
+	if pageID == "" {
+		if _, err := store.Sections.Get(builder.GlobalSectionsPageID(siteID), sectionID); err != nil {
+			return errors.New("全局区块不存在")
+		}
+		return nil
+	}
+
+	if _, err := store.Pages.Get(siteID, pageID); err != nil {
+		return errors.New("页面不存在")
+	}
+	section, err := store.Sections.Get(pageID, sectionID)
+	if err != nil {
+		return errors.New("区块不存在")
+	}
+	if section.GlobalSectionID != "" {
+		return errors.New("该区块引用了全局区块，请在全局区块中编辑组件或先解除引用")
+	}
+	return nil
 }
 
-// 检查站点访问权限
-func CheckSiteAccess(siteID string, tenantID string) bool {
-	if tenant, exists := siteTenants[siteID]; exists {
-		return tenant == tenantID
+// notifySectionChanged 发布组件变更事件，全局区块的组件影响站点的所有页面
+func notifySectionChanged(siteID string, pageID string) {
+	if pageID == "" {
+		store.NotifySiteChanged(siteID)
+		return
 	}
-	return false
+	store.NotifyPageChanged(siteID, pageID)
 }
 
 // ListComponentCategories 获取组件分类列表
 func ListComponentCategories() ([]models.ComponentCategory, error) {
-	return componentCategories, nil
+	return componentdef.Categories(), nil
 }
 
 // GetComponentDefinition 获取组件定义
 func GetComponentDefinition(componentType string) (models.ComponentDefinition, error) {
-	for _, category := range componentCategories {
-		for _, definition := range category.Components {
-			if definition.Type == componentType {
-				return definition, nil
-			}
-		}
+	return componentdef.Get(componentType)
+}
+
+// ValidateComponent 按组件定义的Schema校验设置、内容、样式和各语言的翻译，
+// 校验失败时返回包含所有字段错误的jsonschema.ValidationError
+func ValidateComponent(component models.Component) error {
+	return componentdef.Validate(component)
+}
+
+// mergeDefaults 用默认值补全未填写的字段，value不是对象时原样返回
+func mergeDefaults(defaults interface{}, value interface{}) interface{} {
+	defaultMap, ok := defaults.(map[string]interface{})
+	if !ok || len(defaultMap) == 0 {
+		return value
+	}
+	if value == nil {
+		value = map[string]interface{}{}
+	}
+	valueMap, ok := value.(map[string]interface{})
+	if !ok {
+		return value
 	}
 
-	return models.ComponentDefinition{}, errors.New("组件类型不存在")
+	merged := make(map[string]interface{}, len(defaultMap)+len(valueMap))
+	for key, item := range defaultMap {
+		merged[key] = item
+	}
+	for key, item := range valueMap {
+		merged[key] = item
+	}
+	return merged
 }
 
 // AddComponent 添加组件到区块
-func AddComponent(siteID string, pageID string, sectionID string, component models.Component) (models.Component, error) {
-	// 生成一个简单的ID
-	component.ID = fmt.Sprintf("comp%d", len(components[sectionID])+1)
+func AddComponent(siteID string, pageID string, sectionID string, component models.Component, editor builder.Editor) (models.Component, error) {
+	if err := checkSectionPath(siteID, pageID, sectionID); err != nil {
+		return models.Component{}, err
+	}
 
-	// 设置排序顺序
-	if sectionComponents, exists := components[sectionID]; exists {
-		component.SortOrder = len(sectionComponents)
-	} else {
-		component.SortOrder = 0
-		components[sectionID] = []models.Component{}
+	// 未填写的设置和内容使用组件定义的默认值
+	if definition, err := GetComponentDefinition(component.Type); err == nil {
+		component.Settings = mergeDefaults(definition.DefaultSettings, component.Settings)
+		component.Content = mergeDefaults(definition.DefaultContent, component.Content)
+	}
+	if err := ValidateComponent(component); err != nil {
+		return models.Component{}, err
 	}
 
-	// 添加到组件集合
-	components[sectionID] = append(components[sectionID], component)
+	// ID和排序顺序由存储分配
+	component.ID = ""
+	component.SectionID = sectionID
+	if err := store.Components.Create(&component); err != nil {
+		return models.Component{}, err
+	}
 
+	recordComponentEdit(siteID, pageID, sectionID, editor, builder.EditCreate, component.ID, nil, &models.EditState{Component: &component})
+	notifySectionChanged(siteID, pageID)
 	return component, nil
 }
 
 // UpdateComponent 更新组件
-func UpdateComponent(siteID string, pageID string, sectionID string, component models.Component) (models.Component, error) {
-	if sectionComponents, exists := components[sectionID]; exists {
-		for i, c := range sectionComponents {
-			if c.ID == component.ID {
-				// 保持一些不应该被客户端更新的字段
-				component.SortOrder = c.SortOrder
+func UpdateComponent(siteID string, pageID string, sectionID string, component models.Component, editor builder.Editor) (models.Component, error) {
+	if err := checkSectionPath(siteID, pageID, sectionID); err != nil {
+		return models.Component{}, err
+	}
 
-				sectionComponents[i] = component
-				components[sectionID] = sectionComponents
+	existing, err := store.Components.Get(sectionID, component.ID)
+	if err != nil {
+		return models.Component{}, errors.New("组件不存在")
+	}
+	// 未提交类型时沿用原组件的类型
+	if component.Type == "" {
+		component.Type = existing.Type
+	}
+	if err := ValidateComponent(component); err != nil {
+		return models.Component{}, err
+	}
 
-				return component, nil
-			}
+	component.SectionID = sectionID
+	if err := store.Components.Update(&component); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.Component{}, errors.New("组件不存在")
 		}
-		return models.Component{}, errors.New("组件不存在")
+		return models.Component{}, err
 	}
 
-	return models.Component{}, errors.New("区块没有组件")
+	recordComponentEdit(siteID, pageID, sectionID, editor, builder.EditUpdate, component.ID, &models.EditState{Component: &existing}, &models.EditState{Component: &component})
+	notifySectionChanged(siteID, pageID)
+	return component, nil
 }
 
-// DeleteComponent 删除组件
-func DeleteComponent(siteID string, pageID string, sectionID string, componentID string) error {
-	if sectionComponents, exists := components[sectionID]; exists {
-		for i, component := range sectionComponents {
-			if component.ID == componentID {
-				// 从切片中删除
-				components[sectionID] = append(sectionComponents[:i], sectionComponents[i+1:]...)
-
-				// 重新调整排序
-				for j := i; j < len(components[sectionID]); j++ {
-					components[sectionID][j].SortOrder = j
-				}
+// DeleteComponent 删除组件，页面上的组件删除前的内容保存在编辑历史中，可以在保留期内恢复
+func DeleteComponent(siteID string, pageID string, sectionID string, componentID string, editor builder.Editor) error {
+	if err := checkSectionPath(siteID, pageID, sectionID); err != nil {
+		return err
+	}
 
-				return nil
-			}
-		}
+	existing, err := store.Components.Get(sectionID, componentID)
+	if err != nil {
 		return errors.New("组件不存在")
 	}
+	if err := store.Components.Delete(sectionID, componentID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return errors.New("组件不存在")
+		}
+		return err
+	}
 
-	return errors.New("区块没有组件")
+	recordComponentEdit(siteID, pageID, sectionID, editor, builder.EditDelete, componentID, &models.EditState{Component: &existing}, nil)
+	notifySectionChanged(siteID, pageID)
+	return nil
 }
 
 // ReorderComponents 重新排序组件
-func ReorderComponents(siteID string, pageID string, sectionID string, componentOrder []string) error {
-	if sectionComponents, exists := components[sectionID]; exists {
-		if len(componentOrder) != len(sectionComponents) {
-			return errors.New("组件数量不匹配")
-		}
+func ReorderComponents(siteID string, pageID string, sectionID string, componentOrder []string, editor builder.Editor) error {
+	if err := checkSectionPath(siteID, pageID, sectionID); err != nil {
+		return err
+	}
 
-		// 创建新的排序组件切片
-		newOrder := make([]models.Component, len(sectionComponents))
-		for i, componentID := range componentOrder {
-			found := false
-			for _, component := range sectionComponents {
-				if component.ID == componentID {
-					component.SortOrder = i
-					newOrder[i] = component
-					found = true
-					break
-				}
-			}
-			if !found {
-				return errors.New("无效的组件ID")
-			}
+	components, err := store.Components.ListBySection(sectionID)
+	if err != nil {
+		return err
+	}
+	previous := make([]string, len(components))
+	for i, component := range components {
+		previous[i] = component.ID
+	}
+	if err := store.Components.Reorder(sectionID, componentOrder); err != nil {
+		if errors.Is(err, builder.ErrInvalidOrder) {
+			return errors.New("组件数量不匹配或包含无效的组件ID")
 		}
-
-		components[sectionID] = newOrder
-		return nil
+		return err
 	}
 
-	return errors.New("区块没有组件")
+	recordComponentEdit(siteID, pageID, sectionID, editor, builder.EditReorder, "", &models.EditState{Order: previous}, &models.EditState{Order: componentOrder})
+	notifySectionChanged(siteID, pageID)
+	return nil
+}
+
+// recordComponentEdit 把页面上组件的修改记录到页面的编辑历史，全局区块的组件不属于某个页面，不记录
+func recordComponentEdit(siteID string, pageID string, sectionID string, editor builder.Editor, action string, componentID string, before *models.EditState, after *models.EditState) {
+	if pageID == "" {
+		return
+	}
+	store.RecordEdit(editor, models.EditOperation{
+		SiteID:     siteID,
+		PageID:     pageID,
+		Action:     action,
+		EntityType: builder.EditEntityComponent,
+		EntityID:   componentID,
+		ParentID:   sectionID,
+		Before:     before,
+		After:      after,
+	})
 }
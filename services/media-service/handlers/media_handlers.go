@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"wz-backend-go/internal/pkg/media"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/services/media-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadBody 上传请求的最大大小，为文件大小加上multipart的开销
+const maxUploadBody = service.MaxUploadSize + 1<<20
+
+// assetCacheControl 文件ID对应的内容不会改变，访问地址可以长期缓存
+const assetCacheControl = "public, max-age=31536000, immutable"
+
+// assetSecurityPolicy 文件按上传的内容返回，禁止执行脚本，避免上传的HTML或SVG在媒体服务的域名下运行
+const assetSecurityPolicy = "default-src 'none'; img-src 'self'; media-src 'self'; style-src 'unsafe-inline'; sandbox"
+
+// UploadAsset 上传文件到媒体库，表单字段为file；租户已有相同的文件时返回已有文件和200
+func UploadAsset(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	uploadedBy, _ := userID.(string)
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadBody)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请上传文件"})
+		return
+	}
+	if header.Size > service.MaxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": service.ErrFileTooLarge.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	asset, created, err := service.UploadAsset(c.Request.Context(), tenantID.(string), uploadedBy, header.Filename, data)
+	if err != nil {
+		switch err {
+		case service.ErrEmptyFile:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case service.ErrFileTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if created {
+		c.JSON(http.StatusCreated, asset)
+		return
+	}
+	c.JSON(http.StatusOK, asset)
+}
+
+// ListAssets 列出租户的文件，可以按类型（如image/）、上传者和文件名过滤
+func ListAssets(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	assets, err := service.ListAssets(tenantID.(string), builder.MediaFilter{
+		ContentType: c.Query("type"),
+		UploadedBy:  c.Query("uploadedBy"),
+		Search:      c.Query("search"),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, assets)
+}
+
+// GetAsset 获取文件信息
+func GetAsset(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	asset, err := service.GetAsset(tenantID.(string), c.Param("assetId"))
+	if err != nil {
+		respondAssetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, asset)
+}
+
+// GetAssetReferences 列出引用文件的站点、页面和组件
+func GetAssetReferences(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	references, err := service.FindAssetReferences(tenantID.(string), c.Param("assetId"))
+	if err != nil {
+		respondAssetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, references)
+}
+
+// DeleteAsset 删除文件，文件仍被引用时返回409和引用位置
+func DeleteAsset(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	if err := service.DeleteAsset(c.Request.Context(), tenantID.(string), c.Param("assetId")); err != nil {
+		respondAssetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "文件已删除"})
+}
+
+// ServeAsset 公开访问原始文件
+func ServeAsset(c *gin.Context) {
+	asset, reader, err := service.OpenAsset(c.Request.Context(), c.Param("assetId"))
+	if err != nil {
+		respondAssetError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	headers := assetHeaders(`"` + asset.Hash + `"`)
+	if !inlineType(asset.ContentType) {
+		headers["Content-Disposition"] = mime.FormatMediaType("attachment", map[string]string{"filename": asset.FileName})
+	}
+	if notModified(c, headers["ETag"]) {
+		return
+	}
+	c.DataFromReader(http.StatusOK, asset.Size, asset.ContentType, reader, headers)
+}
+
+// ServeVariant 公开访问图片的缩放版本，如/media/:assetId/w640.webp，第一次访问时生成
+func ServeVariant(c *gin.Context) {
+	assetID := c.Param("assetId")
+	variant, reader, err := service.OpenVariant(c.Request.Context(), assetID, c.Param("variant"))
+	if err != nil {
+		respondAssetError(c, err)
+		return
+	}
+	defer reader.Close()
+
+	headers := assetHeaders(`"` + assetID + "-" + variant.Name + `"`)
+	if notModified(c, headers["ETag"]) {
+		return
+	}
+	c.DataFromReader(http.StatusOK, variant.Size, service.VariantContentType(variant.Format), reader, headers)
+}
+
+// respondAssetError 按错误类型返回响应
+func respondAssetError(c *gin.Context, err error) {
+	var inUse *service.AssetInUseError
+	switch {
+	case errors.As(err, &inUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "references": inUse.References})
+	case errors.Is(err, service.ErrAssetNotFound), errors.Is(err, media.ErrInvalidVariant),
+		errors.Is(err, media.ErrNotResizable), errors.Is(err, media.ErrWebPUnavailable):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrUnsupportedImage):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// assetHeaders 公开访问文件的响应头
+func assetHeaders(etag string) map[string]string {
+	return map[string]string{
+		"Cache-Control":           assetCacheControl,
+		"ETag":                    etag,
+		"X-Content-Type-Options":  "nosniff",
+		"Content-Security-Policy": assetSecurityPolicy,
+	}
+}
+
+// notModified 请求的ETag与文件相同时返回304
+func notModified(c *gin.Context, etag string) bool {
+	if c.GetHeader("If-None-Match") != etag {
+		return false
+	}
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", assetCacheControl)
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// inlineType 可以在浏览器中直接显示的文件类型，其他类型作为附件下载
+func inlineType(contentType string) bool {
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/pdf", "text/plain"} {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
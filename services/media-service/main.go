@@ -0,0 +1,115 @@
+package main
+
+import (
+	"log"
+	"net"
+	"os"
+	"wz-backend-go/api/rpc/file"
+	"wz-backend-go/internal/pkg/media"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/middleware"
+	"wz-backend-go/services/media-service/handlers"
+	"wz-backend-go/services/media-service/service"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	// 设置日志
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	// 设置Gin模式
+	ginMode := os.Getenv("GIN_MODE")
+	if ginMode == "" {
+		ginMode = "debug"
+	}
+	gin.SetMode(ginMode)
+
+	// 初始化数据存储
+	store, err := builder.OpenStore()
+	if err != nil {
+		log.Fatalf("初始化数据存储失败: %v", err)
+	}
+	service.SetStore(store)
+
+	// 文件保存在本地目录，多个实例需要共用同一目录
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "data/media"
+	}
+	storage, err := media.NewLocalStorage(mediaDir)
+	if err != nil {
+		log.Fatalf("初始化媒体存储失败: %v", err)
+	}
+	service.SetStorage(storage)
+
+	// 文件访问地址的前缀，通过网关或CDN访问时设置为对外的地址
+	if baseURL := os.Getenv("MEDIA_BASE_URL"); baseURL != "" {
+		service.SetBaseURL(baseURL)
+	}
+
+	// 设置了cwebp命令时提供WebP版本，渲染服务需要使用相同的配置
+	if cwebp := os.Getenv("MEDIA_WEBP_ENCODER"); cwebp != "" {
+		service.SetWebPEncoder(media.NewCommandEncoder(cwebp))
+	}
+
+	// 设置了MEDIA_GRPC_PORT时同时提供文件RPC服务
+	if grpcPort := os.Getenv("MEDIA_GRPC_PORT"); grpcPort != "" {
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Fatalf("监听文件RPC端口失败: %v", err)
+		}
+		server := grpc.NewServer(grpc.MaxRecvMsgSize(service.MaxUploadSize + 1<<20))
+		file.RegisterFileServer(server, service.NewFileServer())
+		go func() {
+			log.Printf("文件RPC服务启动在端口 %s...\n", grpcPort)
+			if err := server.Serve(listener); err != nil {
+				log.Fatalf("启动文件RPC服务失败: %v", err)
+			}
+		}()
+	}
+
+	// 创建Gin引擎，只信任TRUSTED_PROXIES中的代理转发的客户端IP
+	r := gin.Default()
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatalf("无效的TRUSTED_PROXIES: %v", err)
+	}
+
+	// 注册中间件
+	r.Use(middleware.CORS())
+
+	// 健康检查
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"status": "ok",
+		})
+	})
+
+	// 公开路由 - 页面中的图片和文件地址
+	r.GET("/media/:assetId", handlers.ServeAsset)
+	r.GET("/media/:assetId/:variant", handlers.ServeVariant)
+
+	// 需要认证的路由
+	authGroup := r.Group("/api/v1/media")
+	authGroup.Use(middleware.Auth())
+	{
+		authGroup.GET("", handlers.ListAssets)
+		authGroup.POST("", handlers.UploadAsset)
+		authGroup.GET("/:assetId", handlers.GetAsset)
+		authGroup.GET("/:assetId/references", handlers.GetAssetReferences)
+		authGroup.DELETE("/:assetId", handlers.DeleteAsset)
+	}
+
+	// 获取服务端口
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8085"
+	}
+
+	// 启动服务
+	log.Printf("媒体服务启动在端口 %s...\n", port)
+	if err := r.Run(":" + port); err != nil {
+		log.Fatalf("启动服务失败: %v", err)
+	}
+}
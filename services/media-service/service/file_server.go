@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"wz-backend-go/api/rpc/file"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// 文件RPC请求的元数据，由调用方填写当前租户和用户
+const (
+	metadataTenantID = "x-tenant-id"
+	metadataUserID   = "x-user-id"
+)
+
+// 文件RPC的分页大小
+const (
+	defaultFilePageSize = 20
+	maxFilePageSize     = 100
+)
+
+// FileServer 基于媒体库实现的文件RPC服务，Upload上传的文件与媒体库共用去重和引用检查
+type FileServer struct {
+	file.UnimplementedFileServer
+}
+
+// NewFileServer 创建文件RPC服务
+func NewFileServer() *FileServer {
+	return &FileServer{}
+}
+
+// Upload 上传文件，fileType只用于生成文件名的扩展名，文件类型按内容识别
+func (s *FileServer) Upload(ctx context.Context, in *file.UploadRequest) (*file.UploadResponse, error) {
+	tenantID, userID := callerFromContext(ctx)
+	if tenantID == "" {
+		return &file.UploadResponse{Code: http.StatusUnauthorized, Msg: "缺少租户信息"}, nil
+	}
+	asset, _, err := UploadAsset(ctx, tenantID, userID, uploadFileName(in.GetFileType()), in.GetFile())
+	if err != nil {
+		return &file.UploadResponse{Code: fileErrorCode(err), Msg: err.Error()}, nil
+	}
+	return &file.UploadResponse{
+		Code:     0,
+		Msg:      "success",
+		FileId:   asset.ID,
+		FileUrl:  asset.URL,
+		FileSize: asset.Size,
+	}, nil
+}
+
+// GetFile 获取文件信息
+func (s *FileServer) GetFile(ctx context.Context, in *file.GetFileRequest) (*file.GetFileResponse, error) {
+	tenantID, _ := callerFromContext(ctx)
+	if tenantID == "" {
+		return &file.GetFileResponse{Code: http.StatusUnauthorized, Msg: "缺少租户信息"}, nil
+	}
+	asset, err := GetAsset(tenantID, in.GetFileId())
+	if err != nil {
+		return &file.GetFileResponse{Code: fileErrorCode(err), Msg: err.Error()}, nil
+	}
+	return &file.GetFileResponse{Code: 0, Msg: "success", Data: fileInfo(asset)}, nil
+}
+
+// DeleteFile 删除文件，文件仍被站点引用时返回409
+func (s *FileServer) DeleteFile(ctx context.Context, in *file.DeleteFileRequest) (*file.DeleteFileResponse, error) {
+	tenantID, _ := callerFromContext(ctx)
+	if tenantID == "" {
+		return &file.DeleteFileResponse{Code: http.StatusUnauthorized, Msg: "缺少租户信息"}, nil
+	}
+	if err := DeleteAsset(ctx, tenantID, in.GetFileId()); err != nil {
+		return &file.DeleteFileResponse{Code: fileErrorCode(err), Msg: err.Error()}, nil
+	}
+	return &file.DeleteFileResponse{Code: 0, Msg: "success"}, nil
+}
+
+// ListFiles 按上传时间倒序列出租户的文件，pageToken为上一页返回的偏移量；
+// userId不为0时只列出该用户上传的文件，fileType可以是完整类型或类型前缀（如image/）
+func (s *FileServer) ListFiles(ctx context.Context, in *file.ListFilesRequest) (*file.ListFilesResponse, error) {
+	tenantID, _ := callerFromContext(ctx)
+	if tenantID == "" {
+		return &file.ListFilesResponse{Code: http.StatusUnauthorized, Msg: "缺少租户信息"}, nil
+	}
+	offset := 0
+	if token := in.GetPageToken(); token != "" {
+		value, err := strconv.Atoi(token)
+		if err != nil || value < 0 {
+			return &file.ListFilesResponse{Code: http.StatusBadRequest, Msg: "无效的分页标记"}, nil
+		}
+		offset = value
+	}
+	pageSize := int(in.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultFilePageSize
+	}
+	if pageSize > maxFilePageSize {
+		pageSize = maxFilePageSize
+	}
+
+	filter := builder.MediaFilter{ContentType: in.GetFileType()}
+	if in.GetUserId() != 0 {
+		filter.UploadedBy = strconv.FormatInt(in.GetUserId(), 10)
+	}
+	assets, err := ListAssets(tenantID, filter)
+	if err != nil {
+		return &file.ListFilesResponse{Code: http.StatusInternalServerError, Msg: err.Error()}, nil
+	}
+
+	response := &file.ListFilesResponse{Code: 0, Msg: "success", Items: []*file.FileInfo{}}
+	if offset < len(assets) {
+		end := offset + pageSize
+		if end > len(assets) {
+			end = len(assets)
+		}
+		for _, asset := range assets[offset:end] {
+			response.Items = append(response.Items, fileInfo(asset))
+		}
+		if end < len(assets) {
+			response.HasMore = true
+			response.PageToken = strconv.Itoa(end)
+		}
+	}
+	return response, nil
+}
+
+// callerFromContext 从请求元数据中读取租户和用户
+func callerFromContext(ctx context.Context) (tenantID string, userID string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ""
+	}
+	if values := md.Get(metadataTenantID); len(values) > 0 {
+		tenantID = values[0]
+	}
+	if values := md.Get(metadataUserID); len(values) > 0 {
+		userID = values[0]
+	}
+	return tenantID, userID
+}
+
+// uploadFileName 按请求中的文件类型生成文件名，类型可以是MIME类型或扩展名
+func uploadFileName(fileType string) string {
+	fileType = strings.TrimSpace(fileType)
+	if fileType == "" {
+		return "file"
+	}
+	if strings.Contains(fileType, "/") {
+		if extensions, err := mime.ExtensionsByType(fileType); err == nil && len(extensions) > 0 {
+			return "file" + extensions[0]
+		}
+		return "file"
+	}
+	return "file." + strings.TrimPrefix(fileType, ".")
+}
+
+// fileInfo 转换为RPC的文件信息
+func fileInfo(asset models.MediaAsset) *file.FileInfo {
+	return &file.FileInfo{
+		FileId:    asset.ID,
+		FileUrl:   asset.URL,
+		FileType:  asset.ContentType,
+		FileSize:  asset.Size,
+		CreatedAt: asset.CreatedAt.Unix(),
+	}
+}
+
+// fileErrorCode 与HTTP接口相同的错误码
+func fileErrorCode(err error) int64 {
+	var inUse *AssetInUseError
+	switch {
+	case errors.As(err, &inUse):
+		return http.StatusConflict
+	case errors.Is(err, ErrAssetNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrEmptyFile):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrFileTooLarge):
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"wz-backend-go/internal/pkg/media"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// MaxUploadSize 单个文件的最大大小
+const MaxUploadSize = 20 << 20
+
+var (
+	store       *builder.Store
+	storage     media.Storage
+	webpEncoder media.Encoder
+	baseURL     = "/media"
+)
+
+// SetStore 设置数据存储
+func SetStore(s *builder.Store) {
+	store = s
+}
+
+// SetStorage 设置保存文件的存储驱动
+func SetStorage(s media.Storage) {
+	storage = s
+}
+
+// SetWebPEncoder 设置生成WebP版本使用的编码器，为空时不提供WebP版本
+func SetWebPEncoder(encoder media.Encoder) {
+	webpEncoder = encoder
+}
+
+// SetBaseURL 设置文件访问地址的前缀，应指向本服务的/media路由
+func SetBaseURL(url string) {
+	baseURL = url
+}
+
+// 媒体库的错误
+var (
+	ErrAssetNotFound = errors.New("文件不存在")
+	ErrEmptyFile     = errors.New("文件为空")
+	ErrFileTooLarge  = fmt.Errorf("文件不能超过%dMB", MaxUploadSize>>20)
+)
+
+// AssetInUseError 文件仍被站点引用，不能删除
+type AssetInUseError struct {
+	References []models.MediaReference
+}
+
+func (e *AssetInUseError) Error() string {
+	return fmt.Sprintf("文件正在被%d处引用，不能删除", len(e.References))
+}
+
+// UploadAsset 上传文件到租户的媒体库。文件类型和图片尺寸按内容识别；
+// 租户已有内容相同的文件时直接返回已有的文件，created为false
+func UploadAsset(ctx context.Context, tenantID string, userID string, fileName string, data []byte) (asset models.MediaAsset, created bool, err error) {
+	if len(data) == 0 {
+		return models.MediaAsset{}, false, ErrEmptyFile
+	}
+	if len(data) > MaxUploadSize {
+		return models.MediaAsset{}, false, ErrFileTooLarge
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if existing, err := store.Media.GetByHash(tenantID, hash); err == nil {
+		return withURL(existing), false, nil
+	} else if err != builder.ErrNotFound {
+		return models.MediaAsset{}, false, err
+	}
+
+	info := media.Probe(data)
+	key := media.OriginalKey(tenantID, hash)
+	if err := storage.Put(ctx, key, data, info.ContentType); err != nil {
+		return models.MediaAsset{}, false, fmt.Errorf("保存文件失败: %w", err)
+	}
+
+	now := time.Now()
+	asset = models.MediaAsset{
+		TenantID:    tenantID,
+		Hash:        hash,
+		FileName:    cleanFileName(fileName),
+		ContentType: info.ContentType,
+		Size:        int64(len(data)),
+		Width:       info.Width,
+		Height:      info.Height,
+		StorageKey:  key,
+		Variants:    []models.MediaVariant{},
+		UploadedBy:  userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := store.Media.Create(&asset); err != nil {
+		// 同时上传了相同的文件，存储中的文件相同，使用先保存的记录
+		if errors.Is(err, builder.ErrDuplicateMedia) {
+			if existing, err := store.Media.GetByHash(tenantID, hash); err == nil {
+				return withURL(existing), false, nil
+			}
+		}
+		return models.MediaAsset{}, false, err
+	}
+	return withURL(asset), true, nil
+}
+
+// ListAssets 列出租户的文件
+func ListAssets(tenantID string, filter builder.MediaFilter) ([]models.MediaAsset, error) {
+	assets, err := store.Media.List(tenantID, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]models.MediaAsset, 0, len(assets))
+	for _, asset := range assets {
+		result = append(result, withURL(asset))
+	}
+	return result, nil
+}
+
+// GetAsset 获取租户的文件
+func GetAsset(tenantID string, assetID string) (models.MediaAsset, error) {
+	asset, err := store.Media.Get(tenantID, assetID)
+	if err != nil {
+		if err == builder.ErrNotFound {
+			return models.MediaAsset{}, ErrAssetNotFound
+		}
+		return models.MediaAsset{}, err
+	}
+	return withURL(asset), nil
+}
+
+// FindAssetReferences 查找租户站点中引用文件的位置，包括站点草稿和线上版本
+func FindAssetReferences(tenantID string, assetID string) ([]models.MediaReference, error) {
+	if _, err := GetAsset(tenantID, assetID); err != nil {
+		return nil, err
+	}
+	sites, err := store.Sites.List(builder.SiteFilter{TenantID: tenantID})
+	if err != nil {
+		return nil, err
+	}
+
+	references := []models.MediaReference{}
+	for _, site := range sites {
+		tree, err := store.LoadSiteTree(site.ID)
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, media.FindReferences(tree, assetID, false)...)
+
+		version, err := store.Versions.Latest(site.ID)
+		if err == builder.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		references = append(references, media.FindReferences(version.Snapshot, assetID, true)...)
+	}
+	return references, nil
+}
+
+// DeleteAsset 删除文件及其缩放版本，文件仍被站点草稿或线上版本引用时返回AssetInUseError
+func DeleteAsset(ctx context.Context, tenantID string, assetID string) error {
+	unlock := assetLocks.Lock(assetID)
+	defer unlock()
+
+	references, err := FindAssetReferences(tenantID, assetID)
+	if err != nil {
+		return err
+	}
+	if len(references) > 0 {
+		return &AssetInUseError{References: references}
+	}
+
+	asset, err := store.Media.Get(tenantID, assetID)
+	if err != nil {
+		return ErrAssetNotFound
+	}
+	if err := store.Media.Delete(tenantID, assetID); err != nil {
+		return err
+	}
+	// 记录已删除，存储中的文件删除失败只会留下无法访问的文件
+	for _, variant := range asset.Variants {
+		_ = storage.Delete(ctx, media.VariantKey(asset.TenantID, asset.Hash, variant.Name))
+	}
+	return storage.Delete(ctx, asset.StorageKey)
+}
+
+// OpenAsset 打开原始文件，用于公开访问
+func OpenAsset(ctx context.Context, assetID string) (models.MediaAsset, io.ReadCloser, error) {
+	asset, err := store.Media.GetByID(assetID)
+	if err != nil {
+		return models.MediaAsset{}, nil, ErrAssetNotFound
+	}
+	reader, err := storage.Open(ctx, asset.StorageKey)
+	if err != nil {
+		if err == media.ErrNotFound {
+			return models.MediaAsset{}, nil, ErrAssetNotFound
+		}
+		return models.MediaAsset{}, nil, err
+	}
+	return asset, reader, nil
+}
+
+// OpenVariant 打开图片的缩放版本，第一次访问时生成。只生成比原图窄的版本，
+// 格式为与原图对应的格式或配置了编码器时的webp
+func OpenVariant(ctx context.Context, assetID string, name string) (models.MediaVariant, io.ReadCloser, error) {
+	width, format, err := media.ParseVariantName(name)
+	if err != nil {
+		return models.MediaVariant{}, nil, err
+	}
+	asset, err := store.Media.GetByID(assetID)
+	if err != nil {
+		return models.MediaVariant{}, nil, ErrAssetNotFound
+	}
+	if !media.Resizable(asset.ContentType, asset.Width, asset.Height) {
+		return models.MediaVariant{}, nil, media.ErrNotResizable
+	}
+	if width >= asset.Width || (format != media.SourceFormat(asset.ContentType) && format != media.FormatWebP) {
+		return models.MediaVariant{}, nil, media.ErrInvalidVariant
+	}
+	if format == media.FormatWebP && webpEncoder == nil {
+		return models.MediaVariant{}, nil, media.ErrWebPUnavailable
+	}
+
+	if variant, reader, ok := openStoredVariant(ctx, asset, name); ok {
+		return variant, reader, nil
+	}
+
+	// 同一文件的版本依次生成，避免重复生成和同时修改版本列表
+	unlock := assetLocks.Lock(asset.ID)
+	defer unlock()
+	asset, err = store.Media.GetByID(assetID)
+	if err != nil {
+		return models.MediaVariant{}, nil, ErrAssetNotFound
+	}
+	if variant, reader, ok := openStoredVariant(ctx, asset, name); ok {
+		return variant, reader, nil
+	}
+
+	original, err := readAll(ctx, asset.StorageKey)
+	if err != nil {
+		return models.MediaVariant{}, nil, err
+	}
+	variant, data, err := media.GenerateVariant(ctx, original, width, format, webpEncoder)
+	if err != nil {
+		return models.MediaVariant{}, nil, err
+	}
+	if err := storage.Put(ctx, media.VariantKey(asset.TenantID, asset.Hash, name), data, VariantContentType(format)); err != nil {
+		return models.MediaVariant{}, nil, fmt.Errorf("保存图片版本失败: %w", err)
+	}
+
+	asset.Variants = replaceVariant(asset.Variants, variant)
+	asset.UpdatedAt = time.Now()
+	if err := store.Media.Update(&asset); err != nil {
+		return models.MediaVariant{}, nil, err
+	}
+	return variant, io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// openStoredVariant 打开已生成的版本，版本不在记录中或存储中的文件已丢失时返回false
+func openStoredVariant(ctx context.Context, asset models.MediaAsset, name string) (models.MediaVariant, io.ReadCloser, bool) {
+	for _, variant := range asset.Variants {
+		if variant.Name != name {
+			continue
+		}
+		reader, err := storage.Open(ctx, media.VariantKey(asset.TenantID, asset.Hash, name))
+		if err != nil {
+			return models.MediaVariant{}, nil, false
+		}
+		return variant, reader, true
+	}
+	return models.MediaVariant{}, nil, false
+}
+
+// readAll 读取存储中的文件
+func readAll(ctx context.Context, key string) ([]byte, error) {
+	reader, err := storage.Open(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// replaceVariant 添加版本，已有同名版本时替换
+func replaceVariant(variants []models.MediaVariant, variant models.MediaVariant) []models.MediaVariant {
+	result := make([]models.MediaVariant, 0, len(variants)+1)
+	for _, existing := range variants {
+		if existing.Name != variant.Name {
+			result = append(result, existing)
+		}
+	}
+	return append(result, variant)
+}
+
+// VariantContentType 缩放版本的文件类型
+func VariantContentType(format string) string {
+	switch format {
+	case media.FormatJPEG:
+		return media.TypeJPEG
+	case media.FormatWebP:
+		return media.TypeWebP
+	}
+	return media.TypePNG
+}
+
+// withURL 填写文件的访问地址
+func withURL(asset models.MediaAsset) models.MediaAsset {
+	asset.URL = media.AssetURL(baseURL, asset.ID)
+	if asset.Variants == nil {
+		asset.Variants = []models.MediaVariant{}
+	}
+	return asset
+}
+
+// cleanFileName 只保留文件名部分，去掉客户端传来的目录
+func cleanFileName(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "file"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
+
+// assetLocks 按文件ID加锁，同一文件的版本生成和删除依次执行
+var assetLocks = &keyedMutex{locks: map[string]*keyedLock{}}
+
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	waiters int
+}
+
+// Lock 锁定key，返回解锁函数；没有协程等待时释放该key的锁
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.waiters++
+	k.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		k.mu.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
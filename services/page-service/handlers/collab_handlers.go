@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"wz-backend-go/internal/pkg/collab"
+	"wz-backend-go/internal/pkg/globalsection"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/services/page-service/service"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// CollabSocket 建立站点的实时协作连接，推送页面、区块和组件的变更以及编辑者的在线状态。
+// 客户端发送{"type":"presence","pageId":"...","sectionId":"..."}告知正在编辑的位置
+func CollabSocket(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	server := websocket.Server{
+		Handler: func(conn *websocket.Conn) {
+			service.ServeCollaboration(conn, siteID, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// ListEditors 获取站点当前在线的编辑者
+func ListEditors(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	c.JSON(http.StatusOK, service.ListEditors(siteID))
+}
+
+// publishChange 以当前用户的身份发布协作事件
+func publishChange(c *gin.Context, event collab.Event) {
+	event.SiteID = c.Param("siteId")
+	event.UserID = c.GetString("user_id")
+	service.PublishEvent(event)
+}
+
+// respondUpdateError 返回写入失败的响应，修订号冲突时返回409及最新内容，
+// 全局区块仍被引用时返回409及引用位置，区块配置校验失败时返回400及字段错误
+func respondUpdateError(c *gin.Context, err error) {
+	var conflict *builder.ConflictError
+	if errors.As(err, &conflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   conflict.Error(),
+			"current": conflict.Current,
+		})
+		return
+	}
+
+	var inUse *service.GlobalSectionInUseError
+	var validationErr *jsonschema.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "区块配置校验失败",
+			"fields": validationErr.Errors,
+		})
+	case errors.As(err, &inUse):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  inUse.Error(),
+			"usages": inUse.Usages,
+		})
+	case errors.Is(err, globalsection.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, globalsection.ErrCycle), errors.Is(err, service.ErrSectionIsReference), errors.Is(err, service.ErrInvalidVisibility):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
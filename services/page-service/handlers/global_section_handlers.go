@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"net/http"
+	"wz-backend-go/internal/pkg/collab"
+	"wz-backend-go/models"
+	"wz-backend-go/services/page-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListGlobalSections 获取站点的全局区块
+func ListGlobalSections(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	sections, err := service.ListGlobalSections(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sections)
+}
+
+// AddGlobalSection 添加全局区块
+func AddGlobalSection(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	var section models.Section
+	if err := c.ShouldBindJSON(&section); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	addedSection, err := service.AddGlobalSection(siteID, section)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	publishChange(c, collab.Event{
+		Type:      collab.EventGlobalSectionCreated,
+		SectionID: addedSection.ID,
+		Revision:  addedSection.Revision,
+		Data:      addedSection,
+	})
+	c.JSON(http.StatusCreated, addedSection)
+}
+
+// UpdateGlobalSection 更新全局区块
+func UpdateGlobalSection(c *gin.Context) {
+	siteID := c.Param("siteId")
+	sectionID := c.Param("id")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	var section models.Section
+	if err := c.ShouldBindJSON(&section); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	section.ID = sectionID
+
+	updatedSection, err := service.UpdateGlobalSection(siteID, section)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	publishChange(c, collab.Event{
+		Type:      collab.EventGlobalSectionUpdated,
+		SectionID: updatedSection.ID,
+		Revision:  updatedSection.Revision,
+		Data:      updatedSection,
+	})
+	c.JSON(http.StatusOK, updatedSection)
+}
+
+// DeleteGlobalSection 删除全局区块，仍被引用时返回409及引用位置
+func DeleteGlobalSection(c *gin.Context) {
+	siteID := c.Param("siteId")
+	sectionID := c.Param("id")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	if err := service.DeleteGlobalSection(siteID, sectionID); err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	publishChange(c, collab.Event{Type: collab.EventGlobalSectionDeleted, SectionID: sectionID})
+	c.JSON(http.StatusOK, gin.H{"message": "全局区块已删除"})
+}
+
+// ReorderGlobalSections 重新排序全局区块
+func ReorderGlobalSections(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	var sectionOrder []string
+	if err := c.ShouldBindJSON(&sectionOrder); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := service.ReorderGlobalSections(siteID, sectionOrder); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	publishChange(c, collab.Event{Type: collab.EventGlobalSectionsReordered, Data: sectionOrder})
+	c.JSON(http.StatusOK, gin.H{"message": "全局区块顺序已更新"})
+}
+
+// GetGlobalSectionUsage 获取引用全局区块的页面和位置
+func GetGlobalSectionUsage(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	usages, err := service.GetGlobalSectionUsage(siteID, c.Param("id"))
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, usages)
+}
@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"wz-backend-go/internal/pkg/collab"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+	"wz-backend-go/services/page-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListEditHistory 获取页面的编辑历史，可以按会话、操作类型、对象类型和状态过滤
+func ListEditHistory(c *gin.Context) {
+	siteID := c.Param("siteId")
+	pageID := c.Param("pageId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	filter := builder.EditHistoryFilter{
+		SessionID:  c.Query("sessionId"),
+		Action:     c.Query("action"),
+		EntityType: c.Query("entityType"),
+		Status:     c.Query("status"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的limit参数"})
+			return
+		}
+		filter.Limit = value
+	}
+
+	operations, err := service.ListEditHistory(siteID, pageID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, operations)
+}
+
+// UndoEdit 撤销当前编辑器会话在页面上的最近一次操作
+func UndoEdit(c *gin.Context) {
+	siteID := c.Param("siteId")
+	pageID := c.Param("pageId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	operation, err := service.UndoEdit(siteID, pageID, currentEditor(c))
+	if err != nil {
+		respondHistoryError(c, err)
+		return
+	}
+
+	publishEditState(c, operation, operation.Before)
+	c.JSON(http.StatusOK, operation)
+}
+
+// RedoEdit 重做当前编辑器会话在页面上最近撤销的操作
+func RedoEdit(c *gin.Context) {
+	siteID := c.Param("siteId")
+	pageID := c.Param("pageId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	operation, err := service.RedoEdit(siteID, pageID, currentEditor(c))
+	if err != nil {
+		respondHistoryError(c, err)
+		return
+	}
+
+	publishEditState(c, operation, operation.After)
+	c.JSON(http.StatusOK, operation)
+}
+
+// RestoreDeleted 从编辑历史的删除操作中恢复区块或组件
+func RestoreDeleted(c *gin.Context) {
+	siteID := c.Param("siteId")
+	pageID := c.Param("pageId")
+	operationID := c.Param("id")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	state, err := service.RestoreDeleted(siteID, pageID, operationID, currentEditor(c))
+	if err != nil {
+		respondHistoryError(c, err)
+		return
+	}
+
+	operation := models.EditOperation{PageID: pageID, Action: builder.EditCreate}
+	if state.Section != nil {
+		operation.EntityType = builder.EditEntitySection
+	} else {
+		operation.EntityType = builder.EditEntityComponent
+	}
+	publishEditState(c, operation, state)
+	c.JSON(http.StatusCreated, state)
+}
+
+// currentEditor 当前用户和请求头中的编辑器会话
+func currentEditor(c *gin.Context) builder.Editor {
+	return builder.Editor{
+		UserID:    c.GetString("user_id"),
+		SessionID: c.GetHeader(builder.EditorSessionHeader),
+	}
+}
+
+// publishEditState 撤销、重做或恢复后按操作对象改后的状态发布协作事件，
+// 其他编辑者收到的事件与直接修改时相同
+func publishEditState(c *gin.Context, operation models.EditOperation, state *models.EditState) {
+	event := collab.Event{PageID: operation.PageID}
+	switch {
+	case operation.Action == builder.EditReorder && operation.EntityType == builder.EditEntitySection:
+		event.Type = collab.EventSectionsReordered
+		event.Data = state.Order
+	case operation.Action == builder.EditReorder:
+		event.Type = collab.EventComponentsReordered
+		event.SectionID = operation.ParentID
+		event.Data = state.Order
+	case operation.EntityType == builder.EditEntitySection && state == nil:
+		event.Type = collab.EventSectionDeleted
+		event.SectionID = operation.EntityID
+	case operation.EntityType == builder.EditEntitySection:
+		event.Type = collab.EventSectionUpdated
+		if operation.Action != builder.EditUpdate {
+			event.Type = collab.EventSectionCreated
+		}
+		event.SectionID = state.Section.ID
+		event.Revision = state.Section.Revision
+		event.Data = state.Section
+	case state == nil:
+		event.Type = collab.EventComponentDeleted
+		event.SectionID = operation.ParentID
+		event.ComponentID = operation.EntityID
+	default:
+		event.Type = collab.EventComponentUpdated
+		if operation.Action != builder.EditUpdate {
+			event.Type = collab.EventComponentCreated
+		}
+		event.SectionID = state.Component.SectionID
+		event.ComponentID = state.Component.ID
+		event.Revision = state.Component.Revision
+		event.Data = state.Component
+	}
+	publishChange(c, event)
+}
+
+// respondHistoryError 返回撤销、重做和恢复失败的响应
+func respondHistoryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrEditorSessionRequired), errors.Is(err, service.ErrNotRestorable):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrOperationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNothingToUndo), errors.Is(err, service.ErrNothingToRedo),
+		errors.Is(err, service.ErrEditConflict), errors.Is(err, service.ErrAlreadyRestored):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		respondUpdateError(c, err)
+	}
+}
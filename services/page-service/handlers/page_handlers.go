@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
+	"wz-backend-go/internal/pkg/collab"
 	"wz-backend-go/models"
 	"wz-backend-go/services/page-service/service"
 
@@ -36,7 +38,7 @@ func ListPages(c *gin.Context) {
 // GetPage 获取单个页面
 func GetPage(c *gin.Context) {
 	siteID := c.Param("siteId")
-	pageID := c.Param("id")
+	pageID := c.Param("pageId")
 	tenantID, exists := c.Get("tenant_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
@@ -93,18 +95,28 @@ func CreatePage(c *gin.Context) {
 	}
 
 	createdPage, err := service.CreatePage(page)
+	if errors.Is(err, service.ErrInvalidVisibility) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	publishChange(c, collab.Event{
+		Type:     collab.EventPageCreated,
+		PageID:   createdPage.ID,
+		Revision: createdPage.Revision,
+		Data:     createdPage,
+	})
 	c.JSON(http.StatusCreated, createdPage)
 }
 
 // UpdatePage 更新页面
 func UpdatePage(c *gin.Context) {
 	siteID := c.Param("siteId")
-	pageID := c.Param("id")
+	pageID := c.Param("pageId")
 	tenantID, exists := c.Get("tenant_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
@@ -128,7 +140,13 @@ func UpdatePage(c *gin.Context) {
 	page.SiteID = siteID
 	page.UpdatedAt = time.Now()
 
-	// 处理首页设置
+	updatedPage, err := service.UpdatePage(page)
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	// 处理首页设置，在页面更新成功后进行，避免修订号冲突时误改其他页面
 	if page.IsHomepage {
 		if err := service.UnsetOtherHomepages(siteID, pageID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -136,19 +154,19 @@ func UpdatePage(c *gin.Context) {
 		}
 	}
 
-	updatedPage, err := service.UpdatePage(page)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
+	publishChange(c, collab.Event{
+		Type:     collab.EventPageUpdated,
+		PageID:   updatedPage.ID,
+		Revision: updatedPage.Revision,
+		Data:     updatedPage,
+	})
 	c.JSON(http.StatusOK, updatedPage)
 }
 
 // DeletePage 删除页面
 func DeletePage(c *gin.Context) {
 	siteID := c.Param("siteId")
-	pageID := c.Param("id")
+	pageID := c.Param("pageId")
 	tenantID, exists := c.Get("tenant_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
@@ -178,13 +196,14 @@ func DeletePage(c *gin.Context) {
 		return
 	}
 
+	publishChange(c, collab.Event{Type: collab.EventPageDeleted, PageID: pageID})
 	c.JSON(http.StatusOK, gin.H{"message": "页面已删除"})
 }
 
 // SetHomepage 设置页面为首页
 func SetHomepage(c *gin.Context) {
 	siteID := c.Param("siteId")
-	pageID := c.Param("id")
+	pageID := c.Param("pageId")
 	tenantID, exists := c.Get("tenant_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
@@ -210,6 +229,12 @@ func SetHomepage(c *gin.Context) {
 		return
 	}
 
+	publishChange(c, collab.Event{
+		Type:     collab.EventPageUpdated,
+		PageID:   updatedPage.ID,
+		Revision: updatedPage.Revision,
+		Data:     updatedPage,
+	})
 	c.JSON(http.StatusOK, updatedPage)
 }
 
@@ -239,5 +264,6 @@ func ReorderPages(c *gin.Context) {
 		return
 	}
 
+	publishChange(c, collab.Event{Type: collab.EventPagesReordered, Data: pageOrder})
 	c.JSON(http.StatusOK, gin.H{"message": "页面顺序已更新"})
 }
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"wz-backend-go/internal/pkg/collab"
 	"wz-backend-go/models"
 	"wz-backend-go/services/page-service/service"
 
@@ -58,13 +59,20 @@ func AddSection(c *gin.Context) {
 	// 设置区块属性
 	section.PageID = pageID
 
-	// 添加区块
-	addedSection, err := service.AddSection(siteID, pageID, section)
+	// 添加区块，请求体只包含globalSectionId时添加对全局区块的引用
+	addedSection, err := service.AddSection(siteID, pageID, section, currentEditor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondUpdateError(c, err)
 		return
 	}
 
+	publishChange(c, collab.Event{
+		Type:      collab.EventSectionCreated,
+		PageID:    pageID,
+		SectionID: addedSection.ID,
+		Revision:  addedSection.Revision,
+		Data:      addedSection,
+	})
 	c.JSON(http.StatusCreated, addedSection)
 }
 
@@ -95,15 +103,22 @@ func UpdateSection(c *gin.Context) {
 	section.ID = sectionID
 	section.PageID = pageID
 
-	updatedSection, err := service.UpdateSection(siteID, pageID, section)
+	updatedSection, err := service.UpdateSection(siteID, pageID, section, currentEditor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondUpdateError(c, err)
 		return
 	}
 
 	// 同时更新页面的更新时间
 	service.UpdatePageTimestamp(siteID, pageID)
 
+	publishChange(c, collab.Event{
+		Type:      collab.EventSectionUpdated,
+		PageID:    pageID,
+		SectionID: updatedSection.ID,
+		Revision:  updatedSection.Revision,
+		Data:      updatedSection,
+	})
 	c.JSON(http.StatusOK, updatedSection)
 }
 
@@ -125,7 +140,7 @@ func DeleteSection(c *gin.Context) {
 	}
 
 	// 删除区块
-	if err := service.DeleteSection(siteID, pageID, sectionID); err != nil {
+	if err := service.DeleteSection(siteID, pageID, sectionID, currentEditor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -133,6 +148,7 @@ func DeleteSection(c *gin.Context) {
 	// 同时更新页面的更新时间
 	service.UpdatePageTimestamp(siteID, pageID)
 
+	publishChange(c, collab.Event{Type: collab.EventSectionDeleted, PageID: pageID, SectionID: sectionID})
 	c.JSON(http.StatusOK, gin.H{"message": "区块已删除"})
 }
 
@@ -159,7 +175,7 @@ func ReorderSections(c *gin.Context) {
 	}
 
 	// 重新排序区块
-	if err := service.ReorderSections(siteID, pageID, sectionOrder); err != nil {
+	if err := service.ReorderSections(siteID, pageID, sectionOrder, currentEditor(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -167,5 +183,39 @@ func ReorderSections(c *gin.Context) {
 	// 同时更新页面的更新时间
 	service.UpdatePageTimestamp(siteID, pageID)
 
+	publishChange(c, collab.Event{Type: collab.EventSectionsReordered, PageID: pageID, Data: sectionOrder})
 	c.JSON(http.StatusOK, gin.H{"message": "区块顺序已更新"})
 }
+
+// DetachSection 将引用全局区块的区块转换为页面自己的副本
+func DetachSection(c *gin.Context) {
+	siteID := c.Param("siteId")
+	pageID := c.Param("pageId")
+	sectionID := c.Param("id")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	detachedSection, err := service.DetachSection(siteID, pageID, sectionID, currentEditor(c))
+	if err != nil {
+		respondUpdateError(c, err)
+		return
+	}
+
+	publishChange(c, collab.Event{
+		Type:      collab.EventSectionDetached,
+		PageID:    pageID,
+		SectionID: detachedSection.ID,
+		Revision:  detachedSection.Revision,
+		Data:      detachedSection,
+	})
+	c.JSON(http.StatusOK, detachedSection)
+}
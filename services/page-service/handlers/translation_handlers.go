@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"wz-backend-go/services/page-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListMissingTranslations 列出站点在指定语言下缺少翻译的字段
+func ListMissingTranslations(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	locale := c.Query("locale")
+	if locale == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少locale参数"})
+		return
+	}
+
+	locale, missing, err := service.ListMissingTranslations(siteID, locale)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"locale":  locale,
+		"missing": missing,
+		"total":   len(missing),
+	})
+}
@@ -3,8 +3,13 @@ package main
 import (
 	"log"
 	"os"
+	"time"
+	"wz-backend-go/internal/pkg/collab"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/middleware"
 	"wz-backend-go/services/page-service/handlers"
+	"wz-backend-go/services/page-service/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,8 +25,30 @@ func main() {
 	}
 	gin.SetMode(ginMode)
 
-	// 创建Gin引擎
+	// 初始化数据存储
+	store, err := builder.OpenStore()
+	if err != nil {
+		log.Fatalf("初始化数据存储失败: %v", err)
+	}
+	service.SetStore(store)
+
+	// 渲染缓存在服务之间共享时，编辑数据后直接清除受影响的渲染结果
+	if cache, shared := rendercache.Open(rendercache.DefaultTTL); shared {
+		cache.Attach(store)
+	}
+
+	// 协作事件总线，设置COLLAB_REDIS_ADDR后可以接收组件服务发布的组件变更
+	bus, _ := collab.Open()
+	service.SetCollaboration(bus)
+
+	// 定期清理超过保留期的编辑历史
+	service.StartEditHistoryCleanup(time.Hour)
+
+	// 创建Gin引擎，只信任TRUSTED_PROXIES中的代理转发的客户端IP
 	r := gin.Default()
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatalf("无效的TRUSTED_PROXIES: %v", err)
+	}
 
 	// 注册中间件
 	r.Use(middleware.CORS())
@@ -41,11 +68,11 @@ func main() {
 	pageGroup := apiGroup.Group("/sites/:siteId/pages")
 	{
 		pageGroup.GET("", handlers.ListPages)
-		pageGroup.GET("/:id", handlers.GetPage)
+		pageGroup.GET("/:pageId", handlers.GetPage)
 		pageGroup.POST("", handlers.CreatePage)
-		pageGroup.PUT("/:id", handlers.UpdatePage)
-		pageGroup.DELETE("/:id", handlers.DeletePage)
-		pageGroup.PUT("/:id/homepage", handlers.SetHomepage)
+		pageGroup.PUT("/:pageId", handlers.UpdatePage)
+		pageGroup.DELETE("/:pageId", handlers.DeletePage)
+		pageGroup.PUT("/:pageId/homepage", handlers.SetHomepage)
 		pageGroup.PUT("/reorder", handlers.ReorderPages)
 	}
 
@@ -57,8 +84,36 @@ func main() {
 		sectionGroup.PUT("/:id", handlers.UpdateSection)
 		sectionGroup.DELETE("/:id", handlers.DeleteSection)
 		sectionGroup.PUT("/reorder", handlers.ReorderSections)
+		sectionGroup.POST("/:id/detach", handlers.DetachSection)
 	}
 
+	// 全局区块相关路由
+	globalSectionGroup := apiGroup.Group("/sites/:siteId/global-sections")
+	{
+		globalSectionGroup.GET("", handlers.ListGlobalSections)
+		globalSectionGroup.POST("", handlers.AddGlobalSection)
+		globalSectionGroup.PUT("/:id", handlers.UpdateGlobalSection)
+		globalSectionGroup.DELETE("/:id", handlers.DeleteGlobalSection)
+		globalSectionGroup.GET("/:id/usage", handlers.GetGlobalSectionUsage)
+		globalSectionGroup.PUT("/reorder", handlers.ReorderGlobalSections)
+	}
+
+	// 编辑历史，撤销和重做按请求头X-Editor-Session中的编辑器会话进行
+	historyGroup := apiGroup.Group("/sites/:siteId/pages/:pageId/history")
+	{
+		historyGroup.GET("", handlers.ListEditHistory)
+		historyGroup.POST("/undo", handlers.UndoEdit)
+		historyGroup.POST("/redo", handlers.RedoEdit)
+		historyGroup.POST("/:id/restore", handlers.RestoreDeleted)
+	}
+
+	// 实时协作
+	apiGroup.GET("/sites/:siteId/collab", handlers.CollabSocket)
+	apiGroup.GET("/sites/:siteId/editors", handlers.ListEditors)
+
+	// 多语言翻译
+	apiGroup.GET("/sites/:siteId/translations/missing", handlers.ListMissingTranslations)
+
 	// 获取服务端口
 	port := os.Getenv("PORT")
 	if port == "" {
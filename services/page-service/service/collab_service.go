@@ -0,0 +1,47 @@
+package service
+
+import (
+	"log"
+	"time"
+	"wz-backend-go/internal/pkg/collab"
+
+	"golang.org/x/net/websocket"
+)
+
+// 协作事件总线和本服务持有的编辑器连接，由main在启动时设置
+var (
+	events collab.Bus
+	hub    *collab.Hub
+)
+
+// SetCollaboration 设置协作事件总线，并创建向编辑器推送事件的连接中心
+func SetCollaboration(bus collab.Bus) {
+	events = bus
+	hub = collab.NewHub(bus)
+}
+
+// PublishEvent 发布协作事件，发布失败只记录日志，不影响已经完成的修改
+func PublishEvent(event collab.Event) {
+	if events == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if err := events.Publish(event); err != nil {
+		log.Printf("发布协作事件失败: %v", err)
+	}
+}
+
+// ServeCollaboration 处理站点的编辑器连接，阻塞直到连接断开
+func ServeCollaboration(conn *websocket.Conn, siteID string, userID string) {
+	hub.Serve(conn, siteID, userID)
+}
+
+// ListEditors 获取站点当前在线的编辑者
+func ListEditors(siteID string) []collab.Presence {
+	if hub == nil {
+		return []collab.Presence{}
+	}
+	return hub.Presence(siteID)
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"errors"
+	"wz-backend-go/internal/pkg/globalsection"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+var (
+	// ErrSectionIsReference 引用全局区块的页面区块不能直接修改内容
+	ErrSectionIsReference = errors.New("该区块引用了全局区块，请编辑全局区块或先解除引用")
+	// ErrGlobalSectionInUse 全局区块仍被页面、其他全局区块或站点页头页脚引用
+	ErrGlobalSectionInUse = errors.New("全局区块正在被使用，请先解除引用")
+)
+
+// GlobalSectionUsage 全局区块被引用的位置
+type GlobalSectionUsage struct {
+	PageID    string `json:"pageId,omitempty"`    // 引用所在的页面，全局区块之间的引用为空
+	PageName  string `json:"pageName,omitempty"`  // 页面名称
+	SectionID string `json:"sectionId,omitempty"` // 引用区块的ID
+	Slot      string `json:"slot,omitempty"`      // header或footer，表示作为站点的页头或页脚
+}
+
+// GlobalSectionInUseError 全局区块仍被引用，Usages为引用的位置
+type GlobalSectionInUseError struct {
+	Usages []GlobalSectionUsage
+}
+
+func (e *GlobalSectionInUseError) Error() string {
+	return ErrGlobalSectionInUse.Error()
+}
+
+func (e *GlobalSectionInUseError) Unwrap() error {
+	return ErrGlobalSectionInUse
+}
+
+// ListGlobalSections 获取站点的全局区块及其组件
+func ListGlobalSections(siteID string) ([]models.Section, error) {
+	sections, err := store.LoadGlobalSections(siteID)
+	if err != nil {
+		return nil, err
+	}
+	if sections == nil {
+		return []models.Section{}, nil
+	}
+	return sections, nil
+}
+
+// AddGlobalSection 添加全局区块，全局区块可以引用另一个全局区块，但不能形成循环
+func AddGlobalSection(siteID string, section models.Section) (models.Section, error) {
+	if section.GlobalSectionID != "" {
+		globals, err := store.LoadGlobalSections(siteID)
+		if err != nil {
+			return models.Section{}, err
+		}
+		if err := globalsection.CheckReference(globals, "", section.GlobalSectionID); err != nil {
+			return models.Section{}, err
+		}
+	}
+
+	if err := ValidateSectionResponsive(section); err != nil {
+		return models.Section{}, err
+	}
+
+	// ID和排序顺序由存储分配
+	section.ID = ""
+	section.PageID = builder.GlobalSectionsPageID(siteID)
+	section.Components = nil
+	if err := store.Sections.Create(&section); err != nil {
+		return models.Section{}, err
+	}
+
+	// 全局区块可能出现在站点的任何页面
+	store.NotifySiteChanged(siteID)
+	section.Components = []models.Component{}
+	return section, nil
+}
+
+// UpdateGlobalSection 更新全局区块，修改会体现在所有引用它的页面
+func UpdateGlobalSection(siteID string, section models.Section) (models.Section, error) {
+	if section.GlobalSectionID != "" {
+		globals, err := store.LoadGlobalSections(siteID)
+		if err != nil {
+			return models.Section{}, err
+		}
+		if err := globalsection.CheckReference(globals, section.ID, section.GlobalSectionID); err != nil {
+			return models.Section{}, err
+		}
+	}
+
+	if err := ValidateSectionResponsive(section); err != nil {
+		return models.Section{}, err
+	}
+
+	section.PageID = builder.GlobalSectionsPageID(siteID)
+	section.Components = nil
+	if err := store.Sections.Update(&section); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.Section{}, globalsection.ErrNotFound
+		}
+		return models.Section{}, err
+	}
+
+	store.NotifySiteChanged(siteID)
+	return section, nil
+}
+
+// DeleteGlobalSection 删除未被引用的全局区块及其组件
+func DeleteGlobalSection(siteID string, sectionID string) error {
+	usages, err := GetGlobalSectionUsage(siteID, sectionID)
+	if err != nil {
+		return err
+	}
+	if len(usages) > 0 {
+		return &GlobalSectionInUseError{Usages: usages}
+	}
+
+	if err := store.DeleteSectionTree(builder.GlobalSectionsPageID(siteID), sectionID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return globalsection.ErrNotFound
+		}
+		return err
+	}
+
+	store.NotifySiteChanged(siteID)
+	return nil
+}
+
+// ReorderGlobalSections 重新排序全局区块
+func ReorderGlobalSections(siteID string, sectionOrder []string) error {
+	if err := store.Sections.Reorder(builder.GlobalSectionsPageID(siteID), sectionOrder); err != nil {
+		if errors.Is(err, builder.ErrInvalidOrder) {
+			return errors.New("区块数量不匹配或包含无效的区块ID")
+		}
+		return err
+	}
+	return nil
+}
+
+// GetGlobalSectionUsage 列出引用全局区块的页面区块、其他全局区块和站点页头页脚
+func GetGlobalSectionUsage(siteID string, sectionID string) ([]GlobalSectionUsage, error) {
+	globalPageID := builder.GlobalSectionsPageID(siteID)
+	if _, err := store.Sections.Get(globalPageID, sectionID); err != nil {
+		return nil, globalsection.ErrNotFound
+	}
+
+	usages := []GlobalSectionUsage{}
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return nil, err
+	}
+	if site.HeaderSectionID == sectionID {
+		usages = append(usages, GlobalSectionUsage{Slot: "header"})
+	}
+	if site.FooterSectionID == sectionID {
+		usages = append(usages, GlobalSectionUsage{Slot: "footer"})
+	}
+
+	globals, err := store.Sections.ListByPage(globalPageID)
+	if err != nil {
+		return nil, err
+	}
+	for _, section := range globalsection.Referencing(globals, sectionID) {
+		usages = append(usages, GlobalSectionUsage{SectionID: section.ID})
+	}
+
+	pages, err := store.Pages.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+	for _, page := range pages {
+		sections, err := store.Sections.ListByPage(page.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, section := range globalsection.Referencing(sections, sectionID) {
+			usages = append(usages, GlobalSectionUsage{PageID: page.ID, PageName: page.Name, SectionID: section.ID})
+		}
+	}
+	return usages, nil
+}
+
+// DetachSection 将页面中引用全局区块的区块转换为本页面的独立副本，复制全局区块当前的内容和组件，
+// 之后对全局区块的修改不再影响该区块
+func DetachSection(siteID string, pageID string, sectionID string, editor builder.Editor) (models.Section, error) {
+	if _, err := GetPage(siteID, pageID); err != nil {
+		return models.Section{}, err
+	}
+	reference, err := store.Sections.Get(pageID, sectionID)
+	if err != nil {
+		return models.Section{}, errors.New("区块不存在")
+	}
+	if reference.GlobalSectionID == "" {
+		return models.Section{}, errors.New("区块没有引用全局区块")
+	}
+
+	globals, err := store.LoadGlobalSections(siteID)
+	if err != nil {
+		return models.Section{}, err
+	}
+	resolved, err := globalsection.ResolveSection(globals, reference)
+	if err != nil {
+		return models.Section{}, err
+	}
+	// 复制一份，避免副本与全局区块共用设置和翻译
+	detached, err := builder.CloneSection(resolved)
+	if err != nil {
+		return models.Section{}, err
+	}
+
+	components := detached.Components
+	detached.GlobalSectionID = ""
+	detached.Components = nil
+	if err := store.Sections.Update(&detached); err != nil {
+		return models.Section{}, err
+	}
+	for i := range components {
+		component := &components[i]
+		component.ID = ""
+		component.SectionID = detached.ID
+		if err := store.Components.Create(component); err != nil {
+			return models.Section{}, err
+		}
+	}
+	detached.Components = components
+	if detached.Components == nil {
+		detached.Components = []models.Component{}
+	}
+
+	reference.Components = []models.Component{}
+	recordSectionEdit(siteID, pageID, editor, builder.EditUpdate, sectionID, &models.EditState{Section: &reference}, &models.EditState{Section: &detached})
+
+	UpdatePageTimestamp(siteID, pageID)
+	store.NotifyPageChanged(siteID, pageID)
+	return detached, nil
+}
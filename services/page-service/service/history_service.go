@@ -0,0 +1,538 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// maxHistoryLimit 一次最多返回的编辑历史数量
+const maxHistoryLimit = 200
+
+var (
+	// ErrEditorSessionRequired 撤销和重做需要编辑器会话
+	ErrEditorSessionRequired = errors.New("缺少编辑器会话")
+	// ErrNothingToUndo 会话中没有可以撤销的操作
+	ErrNothingToUndo = errors.New("没有可以撤销的操作")
+	// ErrNothingToRedo 会话中没有可以重做的操作
+	ErrNothingToRedo = errors.New("没有可以重做的操作")
+	// ErrEditConflict 操作之后内容又被修改，撤销或重做会覆盖其他修改
+	ErrEditConflict = errors.New("内容已被其他修改改变，无法撤销或重做")
+	// ErrOperationNotFound 编辑历史中没有该操作或已超过保留期
+	ErrOperationNotFound = errors.New("操作不存在或已过期")
+	// ErrNotRestorable 只有删除操作可以恢复
+	ErrNotRestorable = errors.New("只能恢复已删除的区块或组件")
+	// ErrAlreadyRestored 被删除的区块或组件已经恢复
+	ErrAlreadyRestored = errors.New("区块或组件已存在，无需恢复")
+)
+
+// ListEditHistory 按时间倒序获取页面的编辑历史
+func ListEditHistory(siteID string, pageID string, filter builder.EditHistoryFilter) ([]models.EditOperation, error) {
+	if _, err := GetPage(siteID, pageID); err != nil {
+		return nil, err
+	}
+
+	filter.PageID = pageID
+	if filter.Limit <= 0 || filter.Limit > maxHistoryLimit {
+		filter.Limit = maxHistoryLimit
+	}
+	operations, err := store.EditHistory.List(siteID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.EditOperation{}
+	for _, operation := range operations {
+		if !historyExpired(operation) {
+			result = append(result, operation)
+		}
+	}
+	return result, nil
+}
+
+// UndoEdit 撤销编辑器会话在页面上最近一次的操作，返回撤销的操作，
+// 操作的Before更新为撤销后的实际状态
+func UndoEdit(siteID string, pageID string, editor builder.Editor) (models.EditOperation, error) {
+	operation, err := nextSessionOperation(siteID, pageID, editor, builder.EditApplied)
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.EditOperation{}, ErrNothingToUndo
+		}
+		return models.EditOperation{}, err
+	}
+
+	// 恢复内容和更新操作状态在同一事务中，中途失败时不会留下只恢复了一部分的区块
+	err = store.Transaction(func(tx *builder.Store) error {
+		state, err := applyEditState(tx, operation, operation.After, operation.Before)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		operation.Before = state
+		operation.Status = builder.EditUndone
+		operation.UndoneBy = editor.UserID
+		operation.UndoneAt = &now
+		return tx.EditHistory.Update(&operation)
+	})
+	if err != nil {
+		return models.EditOperation{}, err
+	}
+
+	UpdatePageTimestamp(siteID, pageID)
+	store.NotifyPageChanged(siteID, pageID)
+	return operation, nil
+}
+
+// RedoEdit 重做编辑器会话在页面上最近撤销的操作，返回重做的操作，
+// 操作的After更新为重做后的实际状态
+func RedoEdit(siteID string, pageID string, editor builder.Editor) (models.EditOperation, error) {
+	operation, err := nextSessionOperation(siteID, pageID, editor, builder.EditUndone)
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.EditOperation{}, ErrNothingToRedo
+		}
+		return models.EditOperation{}, err
+	}
+
+	err = store.Transaction(func(tx *builder.Store) error {
+		state, err := applyEditState(tx, operation, operation.Before, operation.After)
+		if err != nil {
+			return err
+		}
+		operation.After = state
+		operation.Status = builder.EditApplied
+		operation.UndoneBy = ""
+		operation.UndoneAt = nil
+		return tx.EditHistory.Update(&operation)
+	})
+	if err != nil {
+		return models.EditOperation{}, err
+	}
+
+	UpdatePageTimestamp(siteID, pageID)
+	store.NotifyPageChanged(siteID, pageID)
+	return operation, nil
+}
+
+// nextSessionOperation 查找会话中下一个可以撤销或重做的操作。
+// 撤销取最近一次生效的操作；已撤销的操作总是会话最后的若干个，重做取其中最早的一个
+func nextSessionOperation(siteID string, pageID string, editor builder.Editor, status string) (models.EditOperation, error) {
+	if editor.SessionID == "" {
+		return models.EditOperation{}, ErrEditorSessionRequired
+	}
+	if _, err := GetPage(siteID, pageID); err != nil {
+		return models.EditOperation{}, err
+	}
+
+	filter := builder.EditHistoryFilter{PageID: pageID, SessionID: editor.SessionID, Status: status}
+	if status == builder.EditApplied {
+		filter.Limit = 1
+	}
+	operations, err := store.EditHistory.List(siteID, filter)
+	if err != nil {
+		return models.EditOperation{}, err
+	}
+	if len(operations) == 0 {
+		return models.EditOperation{}, builder.ErrNotFound
+	}
+	operation := operations[len(operations)-1]
+	if historyExpired(operation) {
+		return models.EditOperation{}, builder.ErrNotFound
+	}
+	return operation, nil
+}
+
+// RestoreDeleted 从编辑历史中恢复被删除的区块或组件，恢复到删除前的位置，
+// 恢复作为新的创建操作记录在当前会话中，可以撤销
+func RestoreDeleted(siteID string, pageID string, operationID string, editor builder.Editor) (*models.EditState, error) {
+	if _, err := GetPage(siteID, pageID); err != nil {
+		return nil, err
+	}
+	operation, err := store.EditHistory.Get(siteID, operationID)
+	if err != nil || operation.PageID != pageID || historyExpired(operation) {
+		return nil, ErrOperationNotFound
+	}
+	if operation.Action != builder.EditDelete || operation.Before == nil {
+		return nil, ErrNotRestorable
+	}
+
+	var state *models.EditState
+	err = store.Transaction(func(tx *builder.Store) error {
+		if _, exists, err := loadEditTarget(tx, operation); err != nil {
+			return err
+		} else if exists {
+			return ErrAlreadyRestored
+		}
+		if err := checkEditParent(tx, pageID, operation); err != nil {
+			return err
+		}
+		var err error
+		if state, err = writeEditTarget(tx, operation, nil, operation.Before); err != nil {
+			return err
+		}
+
+		tx.RecordEdit(editor, models.EditOperation{
+			SiteID:     siteID,
+			PageID:     pageID,
+			Action:     builder.EditCreate,
+			EntityType: operation.EntityType,
+			EntityID:   operation.EntityID,
+			ParentID:   operation.ParentID,
+			After:      state,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	UpdatePageTimestamp(siteID, pageID)
+	store.NotifyPageChanged(siteID, pageID)
+	return state, nil
+}
+
+// StartEditHistoryCleanup 在后台按interval删除超过保留期的编辑历史
+func StartEditHistoryCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if deleted, err := store.EditHistory.DeleteBefore(time.Now().Add(-builder.EditHistoryRetention)); err != nil {
+				log.Printf("清理编辑历史失败: %v", err)
+			} else if deleted > 0 {
+				log.Printf("清理了%d条过期的编辑历史", deleted)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// historyExpired 操作是否已超过保留期，清理任务删除之前也不能再使用
+func historyExpired(operation models.EditOperation) bool {
+	return time.Since(operation.CreatedAt) > builder.EditHistoryRetention
+}
+
+// applyEditState 检查操作对象当前的状态与expected一致后把它改为target，返回改后的实际状态。
+// 不一致说明操作之后又有其他修改，返回ErrEditConflict，避免覆盖其他人的修改。
+// 读写都通过事务中的tx进行，以下的辅助函数相同
+func applyEditState(tx *builder.Store, operation models.EditOperation, expected *models.EditState, target *models.EditState) (*models.EditState, error) {
+	if operation.Action == builder.EditReorder {
+		return applyEditOrder(tx, operation, expected, target)
+	}
+
+	current, exists, err := loadEditTarget(tx, operation)
+	if err != nil {
+		return nil, err
+	}
+	if exists != (expected != nil) || (exists && !sameEditState(current, expected)) {
+		return nil, ErrEditConflict
+	}
+	if target != nil {
+		if err := checkEditParent(tx, operation.PageID, operation); err != nil {
+			return nil, err
+		}
+	}
+	return writeEditTarget(tx, operation, current, target)
+}
+
+// applyEditOrder 撤销或重做排序操作，同级的区块或组件有增减时返回ErrEditConflict
+func applyEditOrder(tx *builder.Store, operation models.EditOperation, expected *models.EditState, target *models.EditState) (*models.EditState, error) {
+	order, err := currentOrder(tx, operation)
+	if err != nil {
+		return nil, err
+	}
+	if !sameOrder(order, expected.Order) {
+		return nil, ErrEditConflict
+	}
+	if operation.EntityType == builder.EditEntitySection {
+		err = tx.Sections.Reorder(operation.ParentID, target.Order)
+	} else {
+		err = tx.Components.Reorder(operation.ParentID, target.Order)
+	}
+	if err != nil {
+		if errors.Is(err, builder.ErrInvalidOrder) {
+			return nil, ErrEditConflict
+		}
+		return nil, err
+	}
+	return &models.EditState{Order: target.Order}, nil
+}
+
+// currentOrder 获取排序操作对象当前的ID顺序
+func currentOrder(tx *builder.Store, operation models.EditOperation) ([]string, error) {
+	var order []string
+	if operation.EntityType == builder.EditEntitySection {
+		sections, err := tx.Sections.ListByPage(operation.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, section := range sections {
+			order = append(order, section.ID)
+		}
+		return order, nil
+	}
+	components, err := tx.Components.ListBySection(operation.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	for _, component := range components {
+		order = append(order, component.ID)
+	}
+	return order, nil
+}
+
+// loadEditTarget 加载操作对象当前的状态，区块包含其组件
+func loadEditTarget(tx *builder.Store, operation models.EditOperation) (*models.EditState, bool, error) {
+	if operation.EntityType == builder.EditEntitySection {
+		section, err := tx.LoadSectionTree(operation.ParentID, operation.EntityID)
+		if errors.Is(err, builder.ErrNotFound) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return &models.EditState{Section: &section}, true, nil
+	}
+	component, err := tx.Components.Get(operation.ParentID, operation.EntityID)
+	if errors.Is(err, builder.ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &models.EditState{Component: &component}, true, nil
+}
+
+// checkEditParent 检查组件所在的区块仍在页面上且不是全局区块的引用，区块已删除时需要先恢复区块
+func checkEditParent(tx *builder.Store, pageID string, operation models.EditOperation) error {
+	if operation.EntityType != builder.EditEntityComponent {
+		return nil
+	}
+	section, err := tx.Sections.Get(pageID, operation.ParentID)
+	if err != nil || section.GlobalSectionID != "" {
+		return ErrEditConflict
+	}
+	return nil
+}
+
+// writeEditTarget 把操作对象从current改为target，current为空时按原ID创建并放回原来的位置，target为空时删除
+func writeEditTarget(tx *builder.Store, operation models.EditOperation, current *models.EditState, target *models.EditState) (*models.EditState, error) {
+	if operation.EntityType == builder.EditEntitySection {
+		var currentSection, targetSection *models.Section
+		if current != nil {
+			currentSection = current.Section
+		}
+		if target != nil {
+			targetSection = target.Section
+		}
+		return writeSection(tx, operation.ParentID, currentSection, targetSection)
+	}
+
+	var currentComponent, targetComponent *models.Component
+	if current != nil {
+		currentComponent = current.Component
+	}
+	if target != nil {
+		targetComponent = target.Component
+	}
+	component, err := writeComponent(tx, operation.ParentID, currentComponent, targetComponent)
+	if err != nil || component == nil {
+		return nil, err
+	}
+	return &models.EditState{Component: component}, nil
+}
+
+// writeSection 把区块及其组件从current改为target
+func writeSection(tx *builder.Store, pageID string, current *models.Section, target *models.Section) (*models.EditState, error) {
+	if target == nil {
+		return nil, tx.DeleteSectionTree(pageID, current.ID)
+	}
+
+	section := *target
+	section.PageID = pageID
+	section.Components = nil
+	var existing []models.Component
+	if current == nil {
+		if err := tx.Sections.Create(&section); err != nil {
+			return nil, err
+		}
+		if err := moveToPosition(tx, pageID, section.ID, target.SortOrder, true); err != nil {
+			return nil, err
+		}
+	} else {
+		section.Revision = current.Revision
+		if err := tx.Sections.Update(&section); err != nil {
+			return nil, err
+		}
+		existing = current.Components
+	}
+
+	// 组件按target增删改，最后恢复target中的顺序
+	byID := map[string]models.Component{}
+	for _, component := range existing {
+		byID[component.ID] = component
+	}
+	order := make([]string, 0, len(target.Components))
+	for i := range target.Components {
+		wanted := target.Components[i]
+		var currentComponent *models.Component
+		if component, ok := byID[wanted.ID]; ok {
+			currentComponent = &component
+			delete(byID, wanted.ID)
+		}
+		if _, err := writeComponent(tx, section.ID, currentComponent, &wanted); err != nil {
+			return nil, err
+		}
+		order = append(order, wanted.ID)
+	}
+	for _, component := range byID {
+		if err := tx.Components.Delete(section.ID, component.ID); err != nil {
+			return nil, err
+		}
+	}
+	if len(order) > 0 {
+		if err := tx.Components.Reorder(section.ID, order); err != nil {
+			return nil, err
+		}
+	}
+
+	written, err := tx.LoadSectionTree(pageID, section.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.EditState{Section: &written}, nil
+}
+
+// writeComponent 把组件从current改为target，内容相同时不更新，避免无谓地增加修订号
+func writeComponent(tx *builder.Store, sectionID string, current *models.Component, target *models.Component) (*models.Component, error) {
+	if target == nil {
+		return nil, tx.Components.Delete(sectionID, current.ID)
+	}
+
+	component := *target
+	component.SectionID = sectionID
+	if current == nil {
+		if err := tx.Components.Create(&component); err != nil {
+			return nil, err
+		}
+		if err := moveToPosition(tx, sectionID, component.ID, target.SortOrder, false); err != nil {
+			return nil, err
+		}
+		created, err := tx.Components.Get(sectionID, component.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	if sameComponentContent(*current, component) {
+		return current, nil
+	}
+	component.Revision = current.Revision
+	if err := tx.Components.Update(&component); err != nil {
+		return nil, err
+	}
+	return &component, nil
+}
+
+// moveToPosition 把新建在末尾的区块或组件移动到position，position超出范围时留在末尾
+func moveToPosition(tx *builder.Store, parentID string, id string, position int, section bool) error {
+	var order []string
+	if section {
+		sections, err := tx.Sections.ListByPage(parentID)
+		if err != nil {
+			return err
+		}
+		for _, item := range sections {
+			if item.ID != id {
+				order = append(order, item.ID)
+			}
+		}
+	} else {
+		components, err := tx.Components.ListBySection(parentID)
+		if err != nil {
+			return err
+		}
+		for _, item := range components {
+			if item.ID != id {
+				order = append(order, item.ID)
+			}
+		}
+	}
+	if position < 0 || position >= len(order) {
+		return nil
+	}
+	order = append(order[:position], append([]string{id}, order[position:]...)...)
+	if section {
+		return tx.Sections.Reorder(parentID, order)
+	}
+	return tx.Components.Reorder(parentID, order)
+}
+
+// sameEditState 比较当前状态与记录的状态：区块比较自身内容和组件集合，组件比较内容。
+// 不比较修订号，恢复删除的区块和组件时修订号会重新开始；组件顺序也不参与比较，组件排序是单独的操作
+func sameEditState(current *models.EditState, recorded *models.EditState) bool {
+	if current.Section != nil && recorded.Section != nil {
+		if !sameSectionContent(*current.Section, *recorded.Section) || len(current.Section.Components) != len(recorded.Section.Components) {
+			return false
+		}
+		components := map[string]models.Component{}
+		for _, component := range recorded.Section.Components {
+			components[component.ID] = component
+		}
+		for _, component := range current.Section.Components {
+			if recordedComponent, ok := components[component.ID]; !ok || !sameComponentContent(component, recordedComponent) {
+				return false
+			}
+		}
+		return true
+	}
+	if current.Component != nil && recorded.Component != nil {
+		return sameComponentContent(*current.Component, *recorded.Component)
+	}
+	return false
+}
+
+// sameSectionContent 比较区块自身的内容，不比较组件、位置和修订号
+func sameSectionContent(a models.Section, b models.Section) bool {
+	a.Components, b.Components = nil, nil
+	a.SortOrder, b.SortOrder = 0, 0
+	a.Revision, b.Revision = 0, 0
+	a.PageID, b.PageID = "", ""
+	return sameJSON(a, b)
+}
+
+// sameComponentContent 比较组件的内容，不比较位置和修订号
+func sameComponentContent(a models.Component, b models.Component) bool {
+	a.SortOrder, b.SortOrder = 0, 0
+	a.Revision, b.Revision = 0, 0
+	a.SectionID, b.SectionID = "", ""
+	return sameJSON(a, b)
+}
+
+// sameJSON 按JSON比较，设置和内容是解码后的任意结构
+func sameJSON(a interface{}, b interface{}) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return string(left) == string(right)
+}
+
+func sameOrder(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// useMemoryStore 测试期间使用写入了演示数据的内存存储
+func useMemoryStore(t *testing.T) {
+	t.Helper()
+	memory := builder.NewMemoryStore()
+	if err := builder.SeedDemoData(memory); err != nil {
+		t.Fatalf("写入演示数据失败: %v", err)
+	}
+	previous := store
+	SetStore(memory)
+	t.Cleanup(func() { SetStore(previous) })
+}
+
+var testEditor = builder.Editor{UserID: "user-1", SessionID: "session-1"}
+
+// lastOperation 获取页面最近的一次操作
+func lastOperation(t *testing.T, pageID string) models.EditOperation {
+	t.Helper()
+	operations, err := ListEditHistory("1", pageID, builder.EditHistoryFilter{})
+	if err != nil || len(operations) == 0 {
+		t.Fatalf("获取编辑历史失败: %v %d", err, len(operations))
+	}
+	return operations[0]
+}
+
+func sectionIDs(t *testing.T, pageID string) []string {
+	t.Helper()
+	sections, err := store.Sections.ListByPage(pageID)
+	if err != nil {
+		t.Fatalf("获取区块失败: %v", err)
+	}
+	var ids []string
+	for _, section := range sections {
+		ids = append(ids, section.ID)
+	}
+	return ids
+}
+
+func TestUndoRedoSectionUpdate(t *testing.T) {
+	useMemoryStore(t)
+	section, err := store.Sections.Get("page1", "section1")
+	if err != nil {
+		t.Fatalf("获取区块失败: %v", err)
+	}
+	section.Title = "新的标题"
+	if _, err := UpdateSection("1", "page1", section, testEditor); err != nil {
+		t.Fatalf("修改区块失败: %v", err)
+	}
+
+	undone, err := UndoEdit("1", "page1", testEditor)
+	if err != nil {
+		t.Fatalf("撤销失败: %v", err)
+	}
+	if undone.Status != builder.EditUndone || undone.UndoneBy != testEditor.UserID {
+		t.Fatalf("撤销后的操作状态错误: %+v", undone)
+	}
+	if current, _ := store.Sections.Get("page1", "section1"); current.Title != "页面头部" {
+		t.Fatalf("撤销后标题应恢复: %s", current.Title)
+	}
+	if _, err := UndoEdit("1", "page1", testEditor); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("没有可以撤销的操作: %v", err)
+	}
+
+	if _, err := RedoEdit("1", "page1", testEditor); err != nil {
+		t.Fatalf("重做失败: %v", err)
+	}
+	if current, _ := store.Sections.Get("page1", "section1"); current.Title != "新的标题" {
+		t.Fatalf("重做后标题应为修改后的值: %s", current.Title)
+	}
+	if _, err := RedoEdit("1", "page1", testEditor); !errors.Is(err, ErrNothingToRedo) {
+		t.Fatalf("没有可以重做的操作: %v", err)
+	}
+}
+
+func TestUndoConflictsWithLaterEdits(t *testing.T) {
+	useMemoryStore(t)
+	section, _ := store.Sections.Get("page1", "section1")
+	section.Title = "会话中的修改"
+	updated, err := UpdateSection("1", "page1", section, testEditor)
+	if err != nil {
+		t.Fatalf("修改区块失败: %v", err)
+	}
+
+	// 其他人之后又修改了区块
+	updated.Title = "其他人的修改"
+	if _, err := UpdateSection("1", "page1", updated, builder.Editor{UserID: "user-2", SessionID: "session-2"}); err != nil {
+		t.Fatalf("修改区块失败: %v", err)
+	}
+	if _, err := UndoEdit("1", "page1", testEditor); !errors.Is(err, ErrEditConflict) {
+		t.Fatalf("撤销会覆盖其他修改时应返回冲突: %v", err)
+	}
+	if current, _ := store.Sections.Get("page1", "section1"); current.Title != "其他人的修改" {
+		t.Fatalf("冲突时不能修改区块: %s", current.Title)
+	}
+}
+
+func TestRestoreDeletedSection(t *testing.T) {
+	useMemoryStore(t)
+	before, err := store.LoadSectionTree("page1", "section1")
+	if err != nil {
+		t.Fatalf("获取区块失败: %v", err)
+	}
+	order := sectionIDs(t, "page1")
+	if err := DeleteSection("1", "page1", "section1", testEditor); err != nil {
+		t.Fatalf("删除区块失败: %v", err)
+	}
+	deleted := lastOperation(t, "page1")
+
+	state, err := RestoreDeleted("1", "page1", deleted.ID, testEditor)
+	if err != nil {
+		t.Fatalf("恢复区块失败: %v", err)
+	}
+	if len(state.Section.Components) != len(before.Components) {
+		t.Fatalf("恢复的区块应包含原来的组件: %d/%d", len(state.Section.Components), len(before.Components))
+	}
+	if got := sectionIDs(t, "page1"); len(got) != len(order) || got[0] != order[0] {
+		t.Fatalf("区块应恢复到原来的位置: %v %v", got, order)
+	}
+	if _, err := RestoreDeleted("1", "page1", deleted.ID, testEditor); !errors.Is(err, ErrAlreadyRestored) {
+		t.Fatalf("已恢复的区块不能再次恢复: %v", err)
+	}
+
+	// 恢复记录为创建操作，可以撤销
+	if _, err := UndoEdit("1", "page1", testEditor); err != nil {
+		t.Fatalf("撤销恢复失败: %v", err)
+	}
+	if _, err := store.Sections.Get("page1", "section1"); !errors.Is(err, builder.ErrNotFound) {
+		t.Fatalf("撤销恢复后区块应被删除: %v", err)
+	}
+}
+
+func TestExpiredHistory(t *testing.T) {
+	useMemoryStore(t)
+	if err := DeleteSection("1", "page1", "section2", testEditor); err != nil {
+		t.Fatalf("删除区块失败: %v", err)
+	}
+	operation := lastOperation(t, "page1")
+	operation.CreatedAt = time.Now().Add(-builder.EditHistoryRetention - time.Hour)
+	if err := store.EditHistory.Update(&operation); err != nil {
+		t.Fatalf("修改操作时间失败: %v", err)
+	}
+
+	if _, err := UndoEdit("1", "page1", testEditor); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("过期的操作不能撤销: %v", err)
+	}
+	if _, err := RestoreDeleted("1", "page1", operation.ID, testEditor); !errors.Is(err, ErrOperationNotFound) {
+		t.Fatalf("过期的删除不能恢复: %v", err)
+	}
+	operations, err := ListEditHistory("1", "page1", builder.EditHistoryFilter{})
+	if err != nil {
+		t.Fatalf("获取编辑历史失败: %v", err)
+	}
+	if len(operations) != 0 {
+		t.Fatalf("过期的操作不应出现在编辑历史中: %d", len(operations))
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"wz-backend-go/internal/pkg/sitenav"
+
+	"github.com/google/uuid"
+)
+
+// addPageToNavigation 站点开启了导航自动同步时，把新页面添加到导航顶层末尾
+func addPageToNavigation(siteID string, pageID string) error {
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return err
+	}
+	if !site.Navigation.AutoSync {
+		return nil
+	}
+	items, added := sitenav.AppendPage(site.Navigation.Items, uuid.NewString(), pageID)
+	if !added {
+		return nil
+	}
+	site.Navigation.Items = items
+	return store.Sites.Update(&site)
+}
+
+// removePageFromNavigation 站点开启了导航自动同步时，删除指向已删除页面的导航项，其子项移到原来的位置
+func removePageFromNavigation(siteID string, pageID string) error {
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return err
+	}
+	if !site.Navigation.AutoSync {
+		return nil
+	}
+	items, removed := sitenav.RemovePage(site.Navigation.Items, pageID)
+	if removed == 0 {
+		return nil
+	}
+	site.Navigation.Items = items
+	return store.Sites.Update(&site)
+}
@@ -2,202 +2,178 @@ package service
 
 import (
 	"errors"
-	"fmt"
+	"log"
 	"time"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/models"
 )
 
-// 模拟数据
-var pages = map[string][]models.Page{
-	"1": {
-		{
-			ID:          "page1",
-			SiteID:      "1",
-			Name:        "首页",
-			Slug:        "home",
-			Title:       "企业官网首页",
-			Description: "欢迎访问我们的企业官网",
-			Keywords:    []string{"企业", "官网", "首页"},
-			IsHomepage:  true,
-			Layout:      "default",
-			Sections:    []models.Section{},
-			CreatedAt:   time.Now().Add(-24 * time.Hour),
-			UpdatedAt:   time.Now().Add(-12 * time.Hour),
-			SortOrder:   0,
-		},
-		{
-			ID:          "page2",
-			SiteID:      "1",
-			Name:        "关于我们",
-			Slug:        "about",
-			Title:       "关于我们 - 企业官网",
-			Description: "了解我们的企业文化和团队",
-			Keywords:    []string{"关于", "企业文化", "团队"},
-			IsHomepage:  false,
-			Layout:      "default",
-			Sections:    []models.Section{},
-			CreatedAt:   time.Now().Add(-24 * time.Hour),
-			UpdatedAt:   time.Now().Add(-12 * time.Hour),
-			SortOrder:   1,
-		},
-	},
-}
+// 站点构建器的共享存储，由main在启动时设置
+var store *builder.Store
 
-// 模拟站点权限检查
-var siteTenants = map[string]string{
-	"1": "tenant_456",
+// SetStore 设置数据存储
+func SetStore(s *builder.Store) {
+	store = s
 }
 
 // 检查站点访问权限
 func CheckSiteAccess(siteID string, tenantID string) bool {
-	if tenant, exists := siteTenants[siteID]; exists {
-		return tenant == tenantID
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return false
 	}
-	return false
+	return site.TenantID == tenantID
 }
 
 // ListPages 获取站点的所有页面
 func ListPages(siteID string) ([]models.Page, error) {
-	if sitePages, exists := pages[siteID]; exists {
-		return sitePages, nil
+	pages, err := store.Pages.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+	if pages == nil {
+		return []models.Page{}, nil
 	}
-	return []models.Page{}, nil
+	return pages, nil
 }
 
 // GetPage 获取单个页面
 func GetPage(siteID string, pageID string) (models.Page, error) {
-	if sitePages, exists := pages[siteID]; exists {
-		for _, page := range sitePages {
-			if page.ID == pageID {
-				return page, nil
-			}
-		}
+	page, err := store.Pages.Get(siteID, pageID)
+	if err != nil {
+		return models.Page{}, errors.New("页面不存在")
 	}
-	return models.Page{}, errors.New("页面不存在")
+	return page, nil
 }
 
+// ErrInvalidVisibility 页面的可见时间窗口无效
+var ErrInvalidVisibility = errors.New("可见结束时间必须晚于开始时间")
+
 // CreatePage 创建新页面
 func CreatePage(page models.Page) (models.Page, error) {
-	// 生成一个简单的ID
-	page.ID = fmt.Sprintf("page%d", len(pages[page.SiteID])+1)
-
-	// 设置排序顺序
-	if sitePages, exists := pages[page.SiteID]; exists {
-		page.SortOrder = len(sitePages)
-	} else {
-		page.SortOrder = 0
-		pages[page.SiteID] = []models.Page{}
+	if !validVisibility(page) {
+		return models.Page{}, ErrInvalidVisibility
+	}
+	// ID和排序顺序由存储分配
+	page.ID = ""
+	if err := store.Pages.Create(&page); err != nil {
+		return models.Page{}, err
+	}
+	// 导航同步失败不影响页面的创建
+	if err := addPageToNavigation(page.SiteID, page.ID); err != nil {
+		log.Printf("把页面%s添加到站点导航失败: %v", page.ID, err)
 	}
 
-	// 添加到页面集合
-	pages[page.SiteID] = append(pages[page.SiteID], page)
-
+	// 新页面会出现在站点导航中
+	store.NotifySiteChanged(page.SiteID)
 	return page, nil
 }
 
 // UpdatePage 更新页面
 func UpdatePage(page models.Page) (models.Page, error) {
-	if sitePages, exists := pages[page.SiteID]; exists {
-		for i, p := range sitePages {
-			if p.ID == page.ID {
-				// 保留一些不应该被客户端更新的字段
-				page.CreatedAt = p.CreatedAt
-				page.SortOrder = p.SortOrder
-
-				sitePages[i] = page
-				pages[page.SiteID] = sitePages
-				return page, nil
-			}
+	existing, err := store.Pages.Get(page.SiteID, page.ID)
+	if err != nil {
+		return models.Page{}, errors.New("页面不存在")
+	}
+	if !validVisibility(page) {
+		return models.Page{}, ErrInvalidVisibility
+	}
+
+	// 保留一些不应该被客户端更新的字段
+	page.CreatedAt = existing.CreatedAt
+	page.SortOrder = existing.SortOrder
+
+	if err := store.Pages.Update(&page); err != nil {
+		return models.Page{}, err
+	}
+
+	// 旧地址跳转到新地址，重定向失败不影响页面的修改
+	if page.Slug != existing.Slug {
+		if err := recordSlugHistory(page.SiteID, page.ID, existing.Slug, page.Slug); err != nil {
+			log.Printf("记录页面%s的Slug历史失败: %v", page.ID, err)
 		}
 	}
-	return models.Page{}, errors.New("页面不存在")
+
+	// 页面名称和Slug会影响站点导航
+	store.NotifySiteChanged(page.SiteID)
+	return page, nil
+}
+
+// validVisibility 同时设置开始和结束时间时，结束时间必须晚于开始时间
+func validVisibility(page models.Page) bool {
+	return page.VisibleFrom == nil || page.VisibleUntil == nil || page.VisibleUntil.After(*page.VisibleFrom)
 }
 
-// DeletePage 删除页面
+// DeletePage 删除页面及其区块和组件
 func DeletePage(siteID string, pageID string) error {
-	if sitePages, exists := pages[siteID]; exists {
-		for i, page := range sitePages {
-			if page.ID == pageID {
-				// 从切片中删除
-				pages[siteID] = append(sitePages[:i], sitePages[i+1:]...)
-
-				// 重新调整排序
-				for j := i; j < len(pages[siteID]); j++ {
-					pages[siteID][j].SortOrder = j
-				}
-
-				return nil
-			}
+	if err := store.DeletePageTree(siteID, pageID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return errors.New("页面不存在")
 		}
+		return err
+	}
+	if err := removePageFromNavigation(siteID, pageID); err != nil {
+		log.Printf("从站点导航中移除页面%s失败: %v", pageID, err)
 	}
-	return errors.New("页面不存在")
+
+	store.NotifySiteChanged(siteID)
+	return nil
 }
 
 // UnsetOtherHomepages 将其他页面设置为非首页
 func UnsetOtherHomepages(siteID string, exceptPageID ...string) error {
-	if sitePages, exists := pages[siteID]; exists {
-		for i, page := range sitePages {
-			// 如果页面ID不在例外列表中，则设置为非首页
-			isExcepted := false
-			for _, exceptID := range exceptPageID {
-				if page.ID == exceptID {
-					isExcepted = true
-					break
-				}
+	pages, err := store.Pages.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		// 如果页面ID不在例外列表中，则设置为非首页
+		isExcepted := false
+		for _, exceptID := range exceptPageID {
+			if page.ID == exceptID {
+				isExcepted = true
+				break
 			}
+		}
 
-			if !isExcepted && page.IsHomepage {
-				sitePages[i].IsHomepage = false
-				sitePages[i].UpdatedAt = time.Now()
+		if !isExcepted && page.IsHomepage {
+			page.IsHomepage = false
+			page.UpdatedAt = time.Now()
+			if err := store.Pages.Update(&page); err != nil {
+				return err
 			}
 		}
-		pages[siteID] = sitePages
 	}
 	return nil
 }
 
 // SetHomepage 设置页面为首页
 func SetHomepage(siteID string, pageID string) (models.Page, error) {
-	if sitePages, exists := pages[siteID]; exists {
-		for i, page := range sitePages {
-			if page.ID == pageID {
-				sitePages[i].IsHomepage = true
-				sitePages[i].UpdatedAt = time.Now()
-				pages[siteID] = sitePages
-				return sitePages[i], nil
-			}
-		}
+	page, err := store.Pages.Get(siteID, pageID)
+	if err != nil {
+		return models.Page{}, errors.New("页面不存在")
 	}
-	return models.Page{}, errors.New("页面不存在")
+
+	page.IsHomepage = true
+	page.UpdatedAt = time.Now()
+	if err := store.Pages.Update(&page); err != nil {
+		return models.Page{}, err
+	}
+
+	store.NotifySiteChanged(siteID)
+	return page, nil
 }
 
 // ReorderPages 重新排序页面
 func ReorderPages(siteID string, pageOrder []string) error {
-	if sitePages, exists := pages[siteID]; exists {
-		if len(pageOrder) != len(sitePages) {
-			return errors.New("页面数量不匹配")
-		}
-
-		// 创建新的排序页面切片
-		newOrder := make([]models.Page, len(sitePages))
-		for i, pageID := range pageOrder {
-			found := false
-			for _, page := range sitePages {
-				if page.ID == pageID {
-					page.SortOrder = i
-					page.UpdatedAt = time.Now()
-					newOrder[i] = page
-					found = true
-					break
-				}
-			}
-			if !found {
-				return errors.New("无效的页面ID")
-			}
+	if err := store.Pages.Reorder(siteID, pageOrder); err != nil {
+		if errors.Is(err, builder.ErrInvalidOrder) {
+			return errors.New("页面数量不匹配或包含无效的页面ID")
 		}
-
-		pages[siteID] = newOrder
-		return nil
+		return err
 	}
+
+	store.NotifySiteChanged(siteID)
 	return nil
 }
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"time"
+	"wz-backend-go/internal/pkg/redirects"
+	"wz-backend-go/models"
+)
+
+// recordSlugHistory 页面Slug变化后添加从旧地址到新地址的301重定向。
+// 同一页面之前自动生成的重定向改为直接指向新地址，避免多次跳转；改回旧Slug时删除从该地址出发的重定向。
+// 旧地址已有手动添加的规则时以手动规则为准
+func recordSlugHistory(siteID string, pageID string, oldSlug string, newSlug string) error {
+	if oldSlug == "" || newSlug == "" {
+		return nil
+	}
+	oldPath := redirects.NormalizePath(oldSlug)
+	newPath := redirects.NormalizePath(newSlug)
+	now := time.Now()
+
+	rules, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return err
+	}
+	exists := false
+	for _, rule := range rules {
+		if rule.MatchType != redirects.MatchExact {
+			continue
+		}
+		if rule.Source == oldPath {
+			exists = true
+		}
+		if rule.PageID != pageID {
+			continue
+		}
+		if rule.Source == newPath {
+			if err := store.Redirects.Delete(siteID, rule.ID); err != nil {
+				return err
+			}
+			continue
+		}
+		if rule.Target != newPath {
+			rule.Target = newPath
+			rule.UpdatedAt = now
+			if err := store.Redirects.Update(&rule); err != nil {
+				return err
+			}
+		}
+	}
+	if exists {
+		return nil
+	}
+
+	return store.Redirects.Create(&models.SiteRedirect{
+		SiteID:     siteID,
+		Source:     oldPath,
+		Target:     newPath,
+		MatchType:  redirects.MatchExact,
+		StatusCode: 301,
+		PageID:     pageID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	})
+}
@@ -2,175 +2,236 @@ package service
 
 import (
 	"errors"
-	"fmt"
 	"time"
+	"wz-backend-go/internal/pkg/globalsection"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/pkg/responsive"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/models"
 )
 
-// 模拟数据 - 区块
-var sections = map[string][]models.Section{
-	"page1": {
-		{
-			ID:         "section1",
-			PageID:     "page1",
-			Type:       "header",
-			Title:      "页面头部",
-			Settings:   map[string]interface{}{"backgroundColor": "#f5f5f5"},
-			Components: []models.Component{},
-			Style:      map[string]interface{}{"padding": "20px"},
-			SortOrder:  0,
-		},
-		{
-			ID:         "section2",
-			PageID:     "page1",
-			Type:       "content",
-			Title:      "主要内容",
-			Settings:   map[string]interface{}{"columns": 1},
-			Components: []models.Component{},
-			Style:      map[string]interface{}{"margin": "20px 0"},
-			SortOrder:  1,
-		},
-	},
-}
-
-// ListSections 获取页面下的所有区块
+// ListSections 获取页面下的所有区块。引用全局区块的区块返回全局区块的内容和组件，
+// ID和修订号仍是引用区块自身的，引用失效时原样返回便于编辑器提示和删除
 func ListSections(siteID string, pageID string) ([]models.Section, error) {
 	// 先检查页面是否存在
 	if _, err := GetPage(siteID, pageID); err != nil {
 		return nil, err
 	}
 
-	if pageSections, exists := sections[pageID]; exists {
-		return pageSections, nil
+	sections, err := store.Sections.ListByPage(pageID)
+	if err != nil {
+		return nil, err
+	}
+	if sections == nil {
+		return []models.Section{}, nil
 	}
+	return resolveReferences(siteID, sections)
+}
 
-	return []models.Section{}, nil
+// resolveReferences 填充引用区块的全局区块内容，没有引用时不加载全局区块
+func resolveReferences(siteID string, sections []models.Section) ([]models.Section, error) {
+	var globals []models.Section
+	for i, section := range sections {
+		if section.GlobalSectionID == "" {
+			continue
+		}
+		if globals == nil {
+			loaded, err := store.LoadGlobalSections(siteID)
+			if err != nil {
+				return nil, err
+			}
+			globals = loaded
+		}
+		if resolved, err := globalsection.ResolveSection(globals, section); err == nil {
+			sections[i] = resolved
+		}
+	}
+	return sections, nil
 }
 
 // AddSection 添加新区块
-func AddSection(siteID string, pageID string, section models.Section) (models.Section, error) {
+func AddSection(siteID string, pageID string, section models.Section, editor builder.Editor) (models.Section, error) {
 	// 先检查页面是否存在
 	if _, err := GetPage(siteID, pageID); err != nil {
 		return models.Section{}, err
 	}
 
-	// 生成一个简单的ID
-	section.ID = fmt.Sprintf("section%d", len(sections[pageID])+1)
+	// 引用全局区块时区块只保存引用，内容来自全局区块
+	if section.GlobalSectionID != "" {
+		if err := checkPageReference(siteID, pageID, section.GlobalSectionID); err != nil {
+			return models.Section{}, err
+		}
+		section = models.Section{GlobalSectionID: section.GlobalSectionID}
+	}
 
-	// 设置排序顺序
-	if pageSections, exists := sections[pageID]; exists {
-		section.SortOrder = len(pageSections)
-	} else {
-		section.SortOrder = 0
-		sections[pageID] = []models.Section{}
+	if err := ValidateSectionResponsive(section); err != nil {
+		return models.Section{}, err
 	}
 
-	// 添加到区块集合
-	sections[pageID] = append(sections[pageID], section)
+	// ID和排序顺序由存储分配
+	section.ID = ""
+	section.PageID = pageID
+	if err := store.Sections.Create(&section); err != nil {
+		return models.Section{}, err
+	}
 
 	// 更新页面的时间戳
 	UpdatePageTimestamp(siteID, pageID)
 
-	return section, nil
+	created := section
+	created.Components = []models.Component{}
+	recordSectionEdit(siteID, pageID, editor, builder.EditCreate, section.ID, nil, &models.EditState{Section: &created})
+
+	store.NotifyPageChanged(siteID, pageID)
+	resolved, err := resolveReferences(siteID, []models.Section{section})
+	if err != nil {
+		return models.Section{}, err
+	}
+	return resolved[0], nil
+}
+
+// checkPageReference 检查全局区块存在且页面尚未引用，同一页面引用两次时无法按全局区块ID排序
+func checkPageReference(siteID string, pageID string, globalID string) error {
+	if _, err := store.Sections.Get(builder.GlobalSectionsPageID(siteID), globalID); err != nil {
+		return globalsection.ErrNotFound
+	}
+	sections, err := store.Sections.ListByPage(pageID)
+	if err != nil {
+		return err
+	}
+	if len(globalsection.Referencing(sections, globalID)) > 0 {
+		return errors.New("页面已引用该全局区块")
+	}
+	return nil
 }
 
 // UpdateSection 更新区块
-func UpdateSection(siteID string, pageID string, section models.Section) (models.Section, error) {
+func UpdateSection(siteID string, pageID string, section models.Section, editor builder.Editor) (models.Section, error) {
 	// 先检查页面是否存在
 	if _, err := GetPage(siteID, pageID); err != nil {
 		return models.Section{}, err
 	}
 
-	if pageSections, exists := sections[pageID]; exists {
-		for i, s := range pageSections {
-			if s.ID == section.ID {
-				// 保持一些不应该被客户端更新的字段
-				section.SortOrder = s.SortOrder
+	// 引用区块的内容属于全局区块，只能编辑全局区块或先解除引用
+	existing, err := store.LoadSectionTree(pageID, section.ID)
+	if err != nil {
+		return models.Section{}, errors.New("区块不存在")
+	}
+	if existing.GlobalSectionID != "" {
+		return models.Section{}, ErrSectionIsReference
+	}
 
-				pageSections[i] = section
-				sections[pageID] = pageSections
+	if err := ValidateSectionResponsive(section); err != nil {
+		return models.Section{}, err
+	}
 
-				return section, nil
-			}
+	section.PageID = pageID
+	section.GlobalSectionID = ""
+	if err := store.Sections.Update(&section); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.Section{}, errors.New("区块不存在")
 		}
-		return models.Section{}, errors.New("区块不存在")
+		return models.Section{}, err
 	}
 
-	return models.Section{}, errors.New("页面没有区块")
+	updated := section
+	updated.Components = existing.Components
+	recordSectionEdit(siteID, pageID, editor, builder.EditUpdate, section.ID, &models.EditState{Section: &existing}, &models.EditState{Section: &updated})
+
+	store.NotifyPageChanged(siteID, pageID)
+	return section, nil
+}
+
+// ValidateSectionResponsive 校验区块按设备的样式覆盖和隐藏设置，
+// 校验失败时返回包含字段错误的jsonschema.ValidationError
+func ValidateSectionResponsive(section models.Section) error {
+	fieldErrors := responsive.Validate("settings", section.Settings, true)
+	fieldErrors = append(fieldErrors, responsive.Validate("style", section.Style, false)...)
+	if len(fieldErrors) > 0 {
+		return &jsonschema.ValidationError{Errors: fieldErrors}
+	}
+	return nil
 }
 
-// DeleteSection 删除区块
-func DeleteSection(siteID string, pageID string, sectionID string) error {
+// DeleteSection 删除区块及其组件，删除前的区块保存在编辑历史中，可以在保留期内恢复
+func DeleteSection(siteID string, pageID string, sectionID string, editor builder.Editor) error {
 	// 先检查页面是否存在
 	if _, err := GetPage(siteID, pageID); err != nil {
 		return err
 	}
 
-	if pageSections, exists := sections[pageID]; exists {
-		for i, section := range pageSections {
-			if section.ID == sectionID {
-				// 从切片中删除
-				sections[pageID] = append(pageSections[:i], pageSections[i+1:]...)
-
-				// 重新调整排序
-				for j := i; j < len(sections[pageID]); j++ {
-					sections[pageID][j].SortOrder = j
-				}
-
-				return nil
-			}
-		}
+	existing, err := store.LoadSectionTree(pageID, sectionID)
+	if err != nil {
 		return errors.New("区块不存在")
 	}
+	if err := store.DeleteSectionTree(pageID, sectionID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return errors.New("区块不存在")
+		}
+		return err
+	}
+
+	recordSectionEdit(siteID, pageID, editor, builder.EditDelete, sectionID, &models.EditState{Section: &existing}, nil)
 
-	return errors.New("页面没有区块")
+	store.NotifyPageChanged(siteID, pageID)
+	return nil
 }
 
-// ReorderSections 重新排序区块
-func ReorderSections(siteID string, pageID string, sectionOrder []string) error {
+// ReorderSections 重新排序区块，引用区块既可以用自身的ID，也可以用引用的全局区块ID
+func ReorderSections(siteID string, pageID string, sectionOrder []string, editor builder.Editor) error {
 	// 先检查页面是否存在
 	if _, err := GetPage(siteID, pageID); err != nil {
 		return err
 	}
 
-	if pageSections, exists := sections[pageID]; exists {
-		if len(sectionOrder) != len(pageSections) {
-			return errors.New("区块数量不匹配")
+	sections, err := store.Sections.ListByPage(pageID)
+	if err != nil {
+		return err
+	}
+	referenceIDs := map[string]string{}
+	previous := make([]string, len(sections))
+	for i, section := range sections {
+		previous[i] = section.ID
+		if section.GlobalSectionID != "" {
+			referenceIDs[section.GlobalSectionID] = section.ID
 		}
-
-		// 创建新的排序区块切片
-		newOrder := make([]models.Section, len(pageSections))
-		for i, sectionID := range sectionOrder {
-			found := false
-			for _, section := range pageSections {
-				if section.ID == sectionID {
-					section.SortOrder = i
-					newOrder[i] = section
-					found = true
-					break
-				}
-			}
-			if !found {
-				return errors.New("无效的区块ID")
-			}
+	}
+	order := make([]string, len(sectionOrder))
+	for i, id := range sectionOrder {
+		if referenceID, ok := referenceIDs[id]; ok {
+			id = referenceID
 		}
+		order[i] = id
+	}
 
-		sections[pageID] = newOrder
-		return nil
+	if err := store.Sections.Reorder(pageID, order); err != nil {
+		if errors.Is(err, builder.ErrInvalidOrder) {
+			return errors.New("区块数量不匹配或包含无效的区块ID")
+		}
+		return err
 	}
 
-	return errors.New("页面没有区块")
+	recordSectionEdit(siteID, pageID, editor, builder.EditReorder, "", &models.EditState{Order: previous}, &models.EditState{Order: order})
+
+	store.NotifyPageChanged(siteID, pageID)
+	return nil
+}
+
+// recordSectionEdit 记录页面区块的修改
+func recordSectionEdit(siteID string, pageID string, editor builder.Editor, action string, sectionID string, before *models.EditState, after *models.EditState) {
+	store.RecordEdit(editor, models.EditOperation{
+		SiteID:     siteID,
+		PageID:     pageID,
+		Action:     action,
+		EntityType: builder.EditEntitySection,
+		EntityID:   sectionID,
+		ParentID:   pageID,
+		Before:     before,
+		After:      after,
+	})
 }
 
-// UpdatePageTimestamp 更新页面时间戳
+// UpdatePageTimestamp 更新页面时间戳，不改变页面的修订号，避免与编辑页面设置的人冲突
 func UpdatePageTimestamp(siteID string, pageID string) {
-	if sitePages, exists := pages[siteID]; exists {
-		for i, page := range sitePages {
-			if page.ID == pageID {
-				sitePages[i].UpdatedAt = time.Now()
-				pages[siteID] = sitePages
-				break
-			}
-		}
-	}
+	store.Pages.Touch(siteID, pageID, time.Now())
 }
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"errors"
+	"wz-backend-go/internal/pkg/sitelocale"
+)
+
+// ListMissingTranslations 列出站点在指定语言下尚未翻译的字段，同时返回匹配到的站点语言
+func ListMissingTranslations(siteID string, locale string) (string, []sitelocale.MissingTranslation, error) {
+	site, err := store.LoadSiteTree(siteID)
+	if err != nil {
+		return "", nil, errors.New("站点不存在")
+	}
+
+	matched, ok := sitelocale.Match(site, locale)
+	if !ok {
+		return "", nil, errors.New("站点未启用该语言")
+	}
+	if matched == sitelocale.DefaultLocale(site) {
+		return "", nil, errors.New("默认语言无需翻译")
+	}
+
+	missing := sitelocale.FindMissing(site, matched)
+	if missing == nil {
+		return matched, []sitelocale.MissingTranslation{}, nil
+	}
+	return matched, missing, nil
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBeaconBody 统计事件请求体的最大字节数
+const maxBeaconBody = 4 << 10
+
+// RecordBeacon 接收公开页面上报的浏览和点击事件。
+// 页面使用navigator.sendBeacon发送JSON文本，Content-Type为text/plain，因此不检查请求类型
+func RecordBeacon(c *gin.Context) {
+	siteID := c.Param("siteId")
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBeaconBody))
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "请求体过大"})
+		return
+	}
+	var beacon service.Beacon
+	if err := json.Unmarshal(body, &beacon); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": service.ErrInvalidBeacon.Error()})
+		return
+	}
+
+	// 访客IP只采用TRUSTED_PROXIES中的代理转发的X-Forwarded-For，
+	// 其他来源无法通过伪造请求头冒充不同访客刷高独立访客数
+	visitor := service.BeaconVisitor{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Host:      c.Request.Host,
+	}
+	switch err := service.RecordBeacon(siteID, beacon, visitor); err {
+	case nil:
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusNoContent)
+	case service.ErrInvalidBeacon:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrTooManyBeacons:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case service.ErrAnalyticsDisabled, service.ErrSiteNotPublished, service.ErrPageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RenderDataComponent 渲染线上页面中数据组件的指定页，返回包含外层容器的HTML片段，
+// 页面中的分页脚本用它替换组件
+func RenderDataComponent(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的页码"})
+		return
+	}
+	cookie, _ := c.Cookie(localeCookie)
+
+	html, err := service.RenderDataComponent(c.Param("siteId"), c.Param("componentId"), page, c.Query("locale"), cookie, c.GetHeader("Accept-Language"))
+	switch err {
+	case nil:
+	case service.ErrSiteNotPublished, service.ErrDataComponentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Vary", "Accept-Language, Cookie")
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(service.DataCacheTTL()))
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportDir 静态快照的存储目录
+func exportDir() string {
+	if dir := os.Getenv("EXPORT_DIR"); dir != "" {
+		return dir
+	}
+	return "exports"
+}
+
+// DownloadSiteExport 以zip压缩包形式下载站点静态导出
+func DownloadSiteExport(c *gin.Context) {
+	siteID := c.Param("siteId")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 校验站点所有权
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return
+	}
+
+	// 优先导出线上版本，未发布的站点导出当前草稿
+	site, err := service.GetPublishedSite(siteID)
+	if err != nil {
+		site, err = service.GetSiteWithAllPages(siteID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "站点不存在"})
+			return
+		}
+	}
+
+	// 直接将压缩包写入响应
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="site-%s.zip"`, siteID))
+	c.Status(http.StatusOK)
+
+	if _, err := service.ExportSite(site, service.NewZipExportTarget(c.Writer)); err != nil {
+		// 响应头已发送，只能记录日志
+		log.Printf("导出站点%s失败: %v", siteID, err)
+	}
+}
+
+// ExportSiteSnapshot 生成站点静态快照到导出目录，供site-service发布时调用
+func ExportSiteSnapshot(c *gin.Context) {
+	siteID := c.Param("siteId")
+
+	// 静态快照对应线上版本
+	site, err := service.GetPublishedSite(siteID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := service.ExportSiteToDir(site, filepath.Join(exportDir(), site.ID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
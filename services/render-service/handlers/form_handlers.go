@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxFormBody 表单请求体的最大字节数，包含上传的文件
+	maxFormBody = 25 << 20
+	// formMemory 解析multipart时保存在内存中的最大字节数，超出部分写入临时文件
+	formMemory = 8 << 20
+)
+
+// formResultHTML 浏览器直接提交表单后显示的结果页面
+var formResultHTML = template.Must(template.New("form-result").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><meta name="robots" content="noindex"><title>{{ .Title }}</title></head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 80px auto; padding: 0 16px;">
+<h1 style="font-size: 20px;">{{ .Title }}</h1>
+{{ with .Message }}<p>{{ . }}</p>{{ end }}
+{{ with .Errors }}<ul>{{ range . }}<li>{{ .Message }}</li>{{ end }}</ul>{{ end }}
+{{ with .Back }}<p><a href="{{ . }}">返回</a></p>{{ end }}
+</body>
+</html>`))
+
+// SubmitForm 接收访客对线上表单的提交，支持urlencoded和multipart请求。
+// 请求Accept为application/json时返回JSON，否则返回结果页面
+func SubmitForm(c *gin.Context) {
+	siteID := c.Param("siteId")
+	componentID := c.Param("componentId")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxFormBody)
+	if err := c.Request.ParseMultipartForm(formMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		respondForm(c, http.StatusBadRequest, "提交失败", "提交的内容过大或格式错误", nil)
+		return
+	}
+
+	input := service.FormInput{
+		Values:    map[string]string{},
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	for name, values := range c.Request.PostForm {
+		if len(values) > 0 {
+			input.Values[name] = values[0]
+		}
+	}
+	if c.Request.MultipartForm != nil {
+		input.Files = map[string]*multipart.FileHeader{}
+		for name, headers := range c.Request.MultipartForm.File {
+			if len(headers) > 0 && headers[0].Filename != "" {
+				input.Files[name] = headers[0]
+			}
+		}
+	}
+
+	message, err := service.SubmitForm(siteID, componentID, input)
+	if err != nil {
+		var validationErr *jsonschema.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respondForm(c, http.StatusBadRequest, "提交失败", "请检查填写的内容", validationErr.Errors)
+		case errors.Is(err, service.ErrTooManySubmissions):
+			respondForm(c, http.StatusTooManyRequests, "提交失败", err.Error(), nil)
+		case errors.Is(err, service.ErrFormNotFound):
+			respondForm(c, http.StatusNotFound, "提交失败", err.Error(), nil)
+		default:
+			respondForm(c, http.StatusInternalServerError, "提交失败", "服务暂时不可用，请稍后再试", nil)
+		}
+		return
+	}
+
+	respondForm(c, http.StatusOK, "提交成功", message, nil)
+}
+
+// respondForm 按客户端类型返回JSON或结果页面
+func respondForm(c *gin.Context, status int, title string, message string, fieldErrors []jsonschema.FieldError) {
+	if strings.Contains(c.GetHeader("Accept"), "application/json") {
+		body := gin.H{"message": message}
+		if status >= http.StatusBadRequest {
+			body = gin.H{"error": message}
+		}
+		if len(fieldErrors) > 0 {
+			body["fields"] = fieldErrors
+		}
+		c.JSON(status, body)
+		return
+	}
+
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	formResultHTML.Execute(c.Writer, map[string]interface{}{
+		"Title":   title,
+		"Message": message,
+		"Errors":  fieldErrors,
+		"Back":    sameHostReferer(c),
+	})
+}
+
+// sameHostReferer 来源页面与当前请求同域时返回来源地址，用于结果页面的返回链接
+func sameHostReferer(c *gin.Context) string {
+	referer, err := url.Parse(c.Request.Referer())
+	if err != nil || referer.Host != c.Request.Host {
+		return ""
+	}
+	if referer.Scheme != "http" && referer.Scheme != "https" {
+		return ""
+	}
+	return referer.String()
+}
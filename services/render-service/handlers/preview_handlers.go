@@ -9,45 +9,16 @@ import (
 
 // PreviewSite 预览整个站点
 func PreviewSite(c *gin.Context) {
-	siteID := c.Param("siteId")
-	tenantID, exists := c.Get("tenant_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
-		return
-	}
-
-	// 模拟设备类型
-	device := c.DefaultQuery("device", "desktop") // desktop, tablet, mobile
-
-	// 校验站点所有权
-	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
-		return
-	}
-
-	// 获取站点数据
-	site, err := service.GetSiteWithAllPages(siteID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "站点不存在"})
-		return
-	}
-
-	// 生成站点预览HTML
-	htmlContent, err := service.GenerateSitePreview(site, device)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 返回HTML内容
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, htmlContent)
+	previewPage(c, c.Param("siteId"), "")
 }
 
 // PreviewPage 预览单个页面
 func PreviewPage(c *gin.Context) {
-	siteID := c.Param("siteId")
-	pageID := c.Param("pageId")
+	previewPage(c, c.Param("siteId"), c.Param("pageId"))
+}
+
+// previewPage 预览站点草稿，pageID为空时预览首页
+func previewPage(c *gin.Context, siteID string, pageID string) {
 	tenantID, exists := c.Get("tenant_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
@@ -63,21 +34,12 @@ func PreviewPage(c *gin.Context) {
 		return
 	}
 
-	// 获取站点和页面数据
-	site, page, err := service.GetSiteAndPage(siteID, pageID)
+	// 生成预览HTML
+	entry, err := service.RenderPreviewPage(siteID, pageID, c.Query("locale"), device)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 生成页面预览HTML
-	htmlContent, err := service.GeneratePagePreview(site, page, device)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	// 返回HTML内容
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, htmlContent)
+	writeHTML(c, entry, "private, no-cache")
 }
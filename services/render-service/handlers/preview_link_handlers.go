@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+	"wz-backend-go/models"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// previewProofCookie 保存预览密码凭证的Cookie名前缀，后接链接ID
+const previewProofCookie = "preview_auth_"
+
+// previewPasswordHTML 设置了密码的预览链接显示的密码页面
+var previewPasswordHTML = template.Must(template.New("preview-password").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="UTF-8"><meta name="viewport" content="width=device-width, initial-scale=1.0"><meta name="robots" content="noindex"><title>预览需要密码</title></head>
+<body style="font-family: sans-serif; max-width: 360px; margin: 80px auto; padding: 0 16px;">
+<h1 style="font-size: 20px;">预览需要密码</h1>
+{{ with .Error }}<p style="color: #f44336;">{{ . }}</p>{{ end }}
+<form method="post">
+<input type="password" name="password" required autofocus style="width: 100%; padding: 8px; box-sizing: border-box;">
+<button type="submit" style="margin-top: 12px;">查看预览</button>
+</form>
+</body>
+</html>`))
+
+// ViewPreviewLink 通过分享的预览链接查看未发布的草稿或指定版本，不需要登录。
+// 查询参数pageId切换页面（链接绑定页面时忽略），locale和device与编辑器预览相同
+func ViewPreviewLink(c *gin.Context) {
+	link, ok := openPreviewLink(c)
+	if !ok {
+		return
+	}
+	proof, _ := c.Cookie(previewProofCookie + link.ID)
+	if !service.PreviewLinkUnlocked(link, proof) {
+		respondPreviewPassword(c, http.StatusUnauthorized, "")
+		return
+	}
+
+	html, err := service.RenderPreviewLink(link, c.Query("pageId"), c.Query("locale"), c.Query("device"))
+	switch err {
+	case nil:
+	case service.ErrPageNotFound, service.ErrPreviewLinkInvalid:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+}
+
+// UnlockPreviewLink 校验预览密码，正确时写入凭证Cookie并跳回预览页面
+func UnlockPreviewLink(c *gin.Context) {
+	link, ok := openPreviewLink(c)
+	if !ok {
+		return
+	}
+
+	proof, err := service.UnlockPreviewLink(link, c.ClientIP(), c.PostForm("password"))
+	switch err {
+	case nil:
+	case service.ErrTooManyPasswordAttempts:
+		respondPreviewPassword(c, http.StatusTooManyRequests, err.Error())
+		return
+	default:
+		respondPreviewPassword(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	if proof != "" {
+		maxAge := int(time.Until(link.ExpiresAt).Seconds())
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(previewProofCookie+link.ID, proof, maxAge, "/render/preview/", "", c.Request.TLS != nil, true)
+	}
+	c.Redirect(http.StatusSeeOther, c.Request.URL.RequestURI())
+}
+
+// openPreviewLink 校验路径中的令牌并设置预览页面的响应头，链接无效时写入响应并返回false
+func openPreviewLink(c *gin.Context) (models.PreviewToken, bool) {
+	// 预览内容不能被收录或缓存，令牌在地址中，也不能通过Referer泄露
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+
+	link, err := service.OpenPreviewLink(c.Param("token"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return models.PreviewToken{}, false
+	}
+	return link, true
+}
+
+// respondPreviewPassword 返回密码页面
+func respondPreviewPassword(c *gin.Context, status int, message string) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	previewPasswordHTML.Execute(c.Writer, map[string]interface{}{"Error": message})
+}
@@ -2,98 +2,137 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/rendercache"
 	"wz-backend-go/services/render-service/service"
 
 	"github.com/gin-gonic/gin"
 )
 
-// RenderSiteByDomain 根据域名渲染站点首页
+// RenderSiteByDomain 根据请求的Host渲染绑定了该域名的站点页面，未指定路径时渲染首页。
+// 路径可以有任意多段，便于重定向规则匹配迁移前的深层地址；sitemap.xml和robots.txt也在这里分发
 func RenderSiteByDomain(c *gin.Context) {
-	// 从查询参数或请求头获取域名
-	domain := c.Query("domain")
-	if domain == "" {
-		domain = c.Request.Host
-	}
-
-	// 通过域名获取站点信息
-	site, err := service.GetSiteByDomain(domain)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "站点不存在"})
+	switch c.Param("path") {
+	case "/sitemap.xml":
+		SitemapByDomain(c)
 		return
-	}
-
-	// 获取首页
-	homepage, err := service.GetHomePage(site.ID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "首页不存在"})
+	case "/robots.txt":
+		RobotsByDomain(c)
 		return
 	}
 
-	// 生成HTML
-	htmlContent, err := service.GeneratePageHTML(site, homepage)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// 非主域名统一跳转到主域名
+	siteID, ok := resolveHostSite(c)
+	if !ok {
 		return
 	}
 
-	// 返回HTML内容
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, htmlContent)
+	renderPublishedPage(c, siteID, c.Param("path"), "")
 }
 
-// RenderPageBySlug 根据站点ID和页面Slug渲染特定页面
+// RenderPageBySlug 根据站点ID和页面路径渲染特定页面。gin的通配路由不能与同一层级的其他路由共存，
+// 站点地图、robots.txt、数据组件分页和主题样式表也按路径在这里分发
 func RenderPageBySlug(c *gin.Context) {
 	siteID := c.Param("siteId")
-	slug := c.Param("slug")
+	segments := strings.Split(strings.Trim(c.Param("path"), "/"), "/")
+	switch {
+	case len(segments) == 1 && segments[0] == "sitemap.xml":
+		SitemapBySite(c)
+	case len(segments) == 1 && segments[0] == "robots.txt":
+		RobotsBySite(c)
+	case len(segments) == 2 && segments[0] == "components":
+		c.Params = append(c.Params, gin.Param{Key: "componentId", Value: segments[1]})
+		RenderDataComponent(c)
+	case len(segments) == 2 && segments[0] == "theme":
+		c.Params = append(c.Params, gin.Param{Key: "file", Value: segments[1]})
+		GetThemeStylesheet(c)
+	default:
+		renderPublishedPage(c, siteID, c.Param("path"), "/render/sites/"+siteID)
+	}
+}
 
-	// 检查站点状态是否为已发布
-	if !service.IsSitePublished(siteID) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "站点不存在或未发布"})
+// localeCookie 访客选择的语言
+const localeCookie = "site_locale"
+
+// renderPublishedPage 渲染站点线上版本的页面，使用线上版本渲染，编辑中的草稿不影响公开页面。
+// 先用完整路径匹配站点的重定向规则，站内目标加上basePath跳转；没有规则匹配时路径为/:slug或/:locale/:slug，
+// 更深的路径没有对应的页面
+func renderPublishedPage(c *gin.Context, siteID string, pagePath string, basePath string) {
+	pagePath = strings.Trim(pagePath, "/")
+	if redirect, ok := service.ResolveRedirect(siteID, pagePath); ok {
+		location := redirect.Location
+		if strings.HasPrefix(location, "/") {
+			location = basePath + location
+		}
+		// 目标没有查询参数时保留请求的查询参数
+		if query := c.Request.URL.RawQuery; query != "" && !strings.Contains(location, "?") {
+			location += "?" + query
+		}
+		// 规则可能修改，命中次数也需要统计，浏览器每次都要重新请求
+		c.Header("Cache-Control", "no-cache")
+		c.Redirect(redirect.StatusCode, location)
 		return
 	}
+	cookie, _ := c.Cookie(localeCookie)
 
-	// 获取站点信息
-	site, err := service.GetSite(siteID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "站点不存在"})
+	entry, err := service.RenderPublishedPage(siteID, pagePath, cookie, c.GetHeader("Accept-Language"))
+	switch err {
+	case nil:
+	case service.ErrSiteNotPublished, service.ErrPageNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// 根据slug获取页面
-	page, err := service.GetPageBySlug(siteID, slug)
-	if err != nil {
-		// 如果没找到页面，尝试渲染首页
-		if slug == "" || slug == "index" || slug == "home" {
-			homepage, err := service.GetHomePage(siteID)
-			if err != nil {
-				c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
-				return
-			}
-
-			// 生成首页HTML
-			htmlContent, err := service.GeneratePageHTML(site, homepage)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-				return
-			}
+	// 没有语言前缀时内容随语言协商结果变化
+	c.Header("Vary", "Accept-Language, Cookie")
+	writeHTML(c, entry, "public, no-cache")
+}
 
-			c.Header("Content-Type", "text/html; charset=utf-8")
-			c.String(http.StatusOK, htmlContent)
-			return
-		}
+// writeHTML 返回渲染结果，客户端缓存仍然有效时返回304
+func writeHTML(c *gin.Context, entry rendercache.Entry, cacheControl string) {
+	c.Header("ETag", entry.ETag)
+	c.Header("Last-Modified", entry.LastModified.UTC().Format(http.TimeFormat))
+	c.Header("Cache-Control", cacheControl)
 
-		c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
+	if notModified(c.Request, entry) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
-	// 生成页面HTML
-	htmlContent, err := service.GeneratePageHTML(site, page)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.String(http.StatusOK, entry.HTML)
+}
+
+// notModified 检查条件请求头，If-None-Match优先于If-Modified-Since
+func notModified(r *http.Request, entry rendercache.Entry) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || tag == entry.ETag || tag == "W/"+entry.ETag {
+				return true
+			}
+		}
+		return false
 	}
 
-	// 返回HTML内容
-	c.Header("Content-Type", "text/html; charset=utf-8")
-	c.String(http.StatusOK, htmlContent)
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		t, err := http.ParseTime(since)
+		return err == nil && !entry.LastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// requestScheme 获取客户端请求使用的协议，优先使用反向代理设置的X-Forwarded-Proto
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
 }
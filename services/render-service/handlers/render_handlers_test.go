@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wz-backend-go/internal/pkg/redirects"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRenderRouter 注册与main相同的公开渲染路由，使用写入了演示数据和给定重定向规则的内存存储
+func newRenderRouter(t *testing.T, rules ...models.SiteRedirect) *gin.Engine {
+	t.Helper()
+	store := builder.NewMemoryStore()
+	if err := builder.SeedDemoData(store); err != nil {
+		t.Fatalf("写入演示数据失败: %v", err)
+	}
+	for _, rule := range rules {
+		rule.SiteID = "1"
+		if err := redirects.Normalize(&rule); err != nil {
+			t.Fatalf("无效的重定向规则%+v: %v", rule, err)
+		}
+		if err := store.Redirects.Create(&rule); err != nil {
+			t.Fatalf("保存重定向规则失败: %v", err)
+		}
+	}
+	service.SetStore(store)
+	t.Cleanup(func() { service.SetStore(nil) })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	renderGroup := r.Group("/render")
+	renderGroup.GET("/site", RenderSiteByDomain)
+	renderGroup.GET("/site/*path", RenderSiteByDomain)
+	renderGroup.GET("/sites/:siteId/*path", RenderPageBySlug)
+	return r
+}
+
+func serve(r *gin.Engine, host string, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if host != "" {
+		req.Host = host
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRenderRedirectsDeepPaths(t *testing.T) {
+	r := newRenderRouter(t,
+		models.SiteRedirect{Source: "/blog", Target: "/news", MatchType: redirects.MatchPrefix},
+		models.SiteRedirect{Source: "/archive/*/*", Target: "/news/$2?year=$1", MatchType: redirects.MatchWildcard, StatusCode: 302},
+	)
+
+	tests := []struct {
+		name     string
+		host     string
+		path     string
+		status   int
+		location string
+	}{
+		{"前缀规则匹配多段路径", "", "/render/sites/1/blog/2020/05/post", http.StatusMovedPermanently, "/render/sites/1/news/2020/05/post"},
+		{"前缀规则匹配带语言前缀的路径", "", "/render/sites/1/en/blog/x", http.StatusMovedPermanently, "/render/sites/1/en/news/x"},
+		{"通配规则引用多段内容", "", "/render/sites/1/archive/2020/post", http.StatusFound, "/render/sites/1/news/post?year=2020"},
+		{"按域名访问的多段路径", "company.wanzhimarket.com", "/render/site/blog/2020/05/post", http.StatusMovedPermanently, "/news/2020/05/post"},
+		{"按域名访问的通配规则", "company.wanzhimarket.com", "/render/site/archive/2021/x", http.StatusFound, "/news/x?year=2021"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.host, tt.path)
+			if w.Code != tt.status {
+				t.Fatalf("状态码应为%d: %d %s", tt.status, w.Code, w.Body.String())
+			}
+			if location := w.Header().Get("Location"); location != tt.location {
+				t.Fatalf("跳转地址应为%s: %s", tt.location, location)
+			}
+		})
+	}
+}
+
+func TestRenderDeepPathWithoutRuleNotFound(t *testing.T) {
+	r := newRenderRouter(t)
+	if w := serve(r, "", "/render/sites/1/blog/2020/05/post"); w.Code != http.StatusNotFound {
+		t.Fatalf("没有规则匹配的多段路径应返回404: %d", w.Code)
+	}
+}
+
+func TestRenderPageBySlugDispatchesSiteFiles(t *testing.T) {
+	r := newRenderRouter(t)
+	w := serve(r, "", "/render/sites/1/robots.txt")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("robots.txt应由站点路由分发: %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(r, "", "/render/sites/1/theme/theme-missing.css"); !strings.Contains(w.Body.String(), service.ErrThemeStylesheetNotFound.Error()) {
+		t.Fatalf("主题样式表应由站点路由分发: %d %s", w.Code, w.Body.String())
+	}
+	if w := serve(r, "", "/render/sites/1/components/missing"); !strings.Contains(w.Body.String(), service.ErrDataComponentNotFound.Error()) {
+		t.Fatalf("数据组件分页应由站点路由分发: %d %s", w.Code, w.Body.String())
+	}
+}
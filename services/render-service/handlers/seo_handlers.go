@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SitemapBySite 获取站点的sitemap.xml
+func SitemapBySite(c *gin.Context) {
+	writeSitemap(c, c.Param("siteId"))
+}
+
+// RobotsBySite 获取站点的robots.txt
+func RobotsBySite(c *gin.Context) {
+	writeRobotsTxt(c, c.Param("siteId"))
+}
+
+// SitemapByDomain 根据请求的Host获取站点的sitemap.xml
+func SitemapByDomain(c *gin.Context) {
+	if siteID, ok := resolveHostSite(c); ok {
+		writeSitemap(c, siteID)
+	}
+}
+
+// RobotsByDomain 根据请求的Host获取站点的robots.txt
+func RobotsByDomain(c *gin.Context) {
+	if siteID, ok := resolveHostSite(c); ok {
+		writeRobotsTxt(c, siteID)
+	}
+}
+
+// resolveHostSite 解析请求Host对应的站点，非主域名时跳转到主域名并返回false
+func resolveHostSite(c *gin.Context) (string, bool) {
+	siteID, redirectHost, err := service.ResolveSiteHost(c.Request.Host)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "站点不存在"})
+		return "", false
+	}
+	if redirectHost != "" {
+		c.Redirect(http.StatusMovedPermanently, requestScheme(c)+"://"+redirectHost+c.Request.URL.RequestURI())
+		return "", false
+	}
+	return siteID, true
+}
+
+func writeSitemap(c *gin.Context, siteID string) {
+	sitemap, err := service.GetPublishedSitemap(siteID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", sitemap)
+}
+
+func writeRobotsTxt(c *gin.Context, siteID string) {
+	robots, err := service.GetPublishedRobotsTxt(siteID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(robots))
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetThemeStylesheet 获取站点编译后的主题样式表，地址带内容哈希，按不可变资源缓存
+func GetThemeStylesheet(c *gin.Context) {
+	sheet, err := service.GetThemeStylesheet(c.Param("siteId"), c.Param("file"))
+	switch err {
+	case nil:
+	case service.ErrSiteNotPublished, service.ErrThemeStylesheetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", `"`+sheet.Hash+`"`)
+	c.Data(http.StatusOK, "text/css; charset=utf-8", []byte(sheet.CSS))
+}
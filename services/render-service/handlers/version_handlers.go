@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"wz-backend-go/services/render-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSiteVersions 获取站点的发布版本列表
+func ListSiteVersions(c *gin.Context) {
+	siteID := c.Param("siteId")
+	if !checkTenantSiteAccess(c, siteID) {
+		return
+	}
+
+	versions, err := service.ListSiteVersions(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, versions)
+}
+
+// GetSiteVersion 获取指定版本及其快照
+func GetSiteVersion(c *gin.Context) {
+	siteID := c.Param("siteId")
+	if !checkTenantSiteAccess(c, siteID) {
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本号"})
+		return
+	}
+
+	version, err := service.GetSiteVersion(siteID, versionNumber)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// DiffSiteVersions 比较两个版本的差异
+func DiffSiteVersions(c *gin.Context) {
+	siteID := c.Param("siteId")
+	if !checkTenantSiteAccess(c, siteID) {
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的起始版本号"})
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的目标版本号"})
+		return
+	}
+
+	changes, err := service.DiffSiteVersions(siteID, from, to)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":    from,
+		"to":      to,
+		"changes": changes,
+	})
+}
+
+// RollbackSiteVersion 回滚站点到指定版本
+func RollbackSiteVersion(c *gin.Context) {
+	siteID := c.Param("siteId")
+	if !checkTenantSiteAccess(c, siteID) {
+		return
+	}
+
+	versionNumber, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的版本号"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	publishedBy, _ := userID.(string)
+
+	version, err := service.RollbackSiteVersion(siteID, versionNumber, publishedBy)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+// checkTenantSiteAccess 校验当前租户对站点的访问权限，无权限时写入错误响应
+func checkTenantSiteAccess(c *gin.Context, siteID string) bool {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return false
+	}
+
+	if !service.CheckSiteAccess(siteID, tenantID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该站点"})
+		return false
+	}
+	return true
+}
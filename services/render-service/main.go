@@ -3,10 +3,21 @@ package main
 import (
 	"log"
 	"os"
+	"time"
+	"wz-backend-go/api/rpc/content"
+	"wz-backend-go/api/rpc/notification"
+	"wz-backend-go/internal/pkg/datasource"
+	"wz-backend-go/internal/pkg/previewtoken"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/pkg/siteanalytics"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/middleware"
 	"wz-backend-go/services/render-service/handlers"
+	"wz-backend-go/services/render-service/service"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 func main() {
@@ -20,8 +31,77 @@ func main() {
 	}
 	gin.SetMode(ginMode)
 
-	// 创建Gin引擎
+	// 初始化数据存储
+	store, err := builder.OpenStore()
+	if err != nil {
+		log.Fatalf("初始化数据存储失败: %v", err)
+	}
+	service.SetStore(store)
+
+	// 渲染缓存：线上版本按版本号缓存；草稿预览依赖变更事件失效，
+	// 使用数据库但没有共享缓存时其他服务的编辑无法通知到本服务，因此不缓存草稿
+	cache, shared := rendercache.Open(rendercache.DefaultTTL)
+	cache.Attach(store)
+	service.SetRenderCache(cache, shared || os.Getenv("BUILDER_DB_DSN") == "")
+
+	// 预览链接的签名密钥，与站点服务共用
+	service.SetPreviewSecret(previewtoken.LoadSecret())
+
+	// 访问统计：公开页面注入上报脚本，事件定期汇总为按小时和按天的统计
+	if os.Getenv("ANALYTICS_DISABLED") != "true" {
+		service.SetAnalyticsSecret(siteanalytics.LoadSecret())
+		interval := 5 * time.Minute
+		if value := os.Getenv("ANALYTICS_ROLLUP_INTERVAL"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed <= 0 {
+				log.Fatalf("无效的ANALYTICS_ROLLUP_INTERVAL: %s", value)
+			}
+			interval = parsed
+		}
+		service.StartAnalyticsRollup(interval)
+	}
+
+	// 与媒体服务使用相同的配置，媒体服务能生成WebP时图片组件输出WebP版本
+	service.SetMediaWebP(os.Getenv("MEDIA_WEBP_ENCODER") != "")
+
+	// 表单提交：上传文件目录，配置了通知服务地址时向租户设置的接收人发送通知
+	if dir := os.Getenv("FORM_UPLOAD_DIR"); dir != "" {
+		service.SetFormUploadDir(dir)
+	}
+	if addr := os.Getenv("NOTIFICATION_RPC_ADDR"); addr != "" {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("连接通知服务失败: %v", err)
+		}
+		defer conn.Close()
+		service.SetFormNotifier(service.NewRPCFormNotifier(notification.NewNotificationClient(conn)))
+	}
+
+	// 数据组件：配置了内容服务地址时最新文章组件从内容服务取数。
+	// 商品数据源需要model.ProductService的实现，由部署时链接了商品服务的入口注册
+	if addr := os.Getenv("CONTENT_RPC_ADDR"); addr != "" {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Fatalf("连接内容服务失败: %v", err)
+		}
+		defer conn.Close()
+		service.RegisterDataSource(service.DataSourcePosts, datasource.NewPostProvider(content.NewContentClient(conn)))
+	}
+
+	// 静态导出：页面中的表单和数据组件分页请求发送到渲染服务对外的地址，
+	// 以/开头的站内资源（Logo、图片、媒体文件）从站点的访问地址下载后写入导出包
+	if publicURL := os.Getenv("RENDER_PUBLIC_URL"); publicURL != "" {
+		service.SetExportPublicURL(publicURL)
+	}
+	if assetBaseURL := os.Getenv("EXPORT_ASSET_BASE_URL"); assetBaseURL != "" {
+		service.SetExportAssetFetcher(service.NewHTTPAssetFetcher(assetBaseURL))
+	}
+
+	// 创建Gin引擎，只信任TRUSTED_PROXIES中的代理转发的客户端IP
 	r := gin.Default()
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatalf("无效的TRUSTED_PROXIES: %v", err)
+	}
 
 	// 注册中间件
 	r.Use(middleware.CORS())
@@ -46,13 +126,60 @@ func main() {
 		previewGroup.GET("/sites/:siteId/pages/:pageId", handlers.PreviewPage)
 	}
 
+	// 静态导出路由 - 需要认证
+	exportGroup := apiGroup.Group("/export")
+	exportGroup.Use(middleware.Auth())
+	{
+		// 下载站点静态导出压缩包
+		exportGroup.GET("/sites/:siteId", handlers.DownloadSiteExport)
+	}
+
+	// 版本管理路由 - 需要认证
+	versionGroup := apiGroup.Group("/sites/:siteId/versions")
+	versionGroup.Use(middleware.Auth())
+	{
+		versionGroup.GET("", handlers.ListSiteVersions)
+		versionGroup.GET("/diff", handlers.DiffSiteVersions)
+		versionGroup.GET("/:version", handlers.GetSiteVersion)
+		versionGroup.POST("/:version/rollback", handlers.RollbackSiteVersion)
+	}
+
+	// 内部路由 - 仅供内部服务调用。内部路由不做认证，使用单独的监听地址，
+	// 默认只监听本机，部署时设置INTERNAL_ADDR为内网地址，不能通过网关或公网访问
+	internalAddr := os.Getenv("INTERNAL_ADDR")
+	if internalAddr == "" {
+		internalAddr = "127.0.0.1:9084"
+	}
+	internal := gin.Default()
+	internalGroup := internal.Group("/internal")
+	{
+		// 生成站点静态快照
+		internalGroup.POST("/export/sites/:siteId", handlers.ExportSiteSnapshot)
+	}
+	go func() {
+		log.Printf("渲染服务内部接口启动在 %s...\n", internalAddr)
+		if err := internal.Run(internalAddr); err != nil {
+			log.Fatalf("启动内部接口失败: %v", err)
+		}
+	}()
+
 	// 公开访问路由 - 不需要认证
 	renderGroup := r.Group("/render")
 	{
-		// 根据域名渲染站点
+		// 根据请求Host绑定的域名渲染站点，sitemap.xml和robots.txt由同一处理函数分发
 		renderGroup.GET("/site", handlers.RenderSiteByDomain)
-		// 渲染特定站点的页面
-		renderGroup.GET("/sites/:siteId/:slug", handlers.RenderPageBySlug)
+		renderGroup.GET("/site/*path", handlers.RenderSiteByDomain)
+		// 渲染特定站点的页面，第一段可以是语言前缀，如/en/about；路径可以有任意多段，
+		// 先匹配重定向规则。同一层级的sitemap.xml、robots.txt、数据组件分页（components/:componentId）
+		// 和主题样式表（theme/:file，文件名包含内容哈希）由同一处理函数分发
+		renderGroup.GET("/sites/:siteId/*path", handlers.RenderPageBySlug)
+		// 访客提交线上表单
+		renderGroup.POST("/sites/:siteId/forms/:componentId", handlers.SubmitForm)
+		// 访问统计事件上报
+		renderGroup.POST("/sites/:siteId/beacon", handlers.RecordBeacon)
+		// 分享的预览链接，设置了密码时先提交密码
+		renderGroup.GET("/preview/:token", handlers.ViewPreviewLink)
+		renderGroup.POST("/preview/:token", handlers.UnlockPreviewLink)
 	}
 
 	// 获取服务端口
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"time"
+	"wz-backend-go/internal/pkg/siteanalytics"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+const (
+	// analyticsLookback 每次汇总重新计算的时间范围，覆盖上一次汇总之后到达的事件
+	analyticsLookback = 2 * time.Hour
+	// analyticsBackfill 服务启动时重新计算的时间范围，补上停机期间没有汇总的时段
+	analyticsBackfill = 48 * time.Hour
+	// analyticsEventRetention 原始事件的保留时间，汇总结果长期保留
+	analyticsEventRetention = 30 * 24 * time.Hour
+	// maxBeaconIDLength 上报的页面和组件ID的最大长度
+	maxBeaconIDLength = 64
+)
+
+var (
+	// ErrInvalidBeacon 上报的事件无效
+	ErrInvalidBeacon = errors.New("无效的统计事件")
+	// ErrAnalyticsDisabled 没有启用访问统计
+	ErrAnalyticsDisabled = errors.New("访问统计未启用")
+	// ErrTooManyBeacons 同一IP或同一站点上报过于频繁
+	ErrTooManyBeacons = errors.New("上报过于频繁，请稍后再试")
+)
+
+// 每个IP最多连续上报beaconRateBurst次，之后每beaconRateInterval恢复一次，正常访客的浏览和点击不会超过；
+// 每个站点的上限远高于单个IP，限制更换IP批量刷量能写入的事件数
+const (
+	beaconRateBurst        = 60
+	beaconRateInterval     = time.Second
+	siteBeaconRateBurst    = 2000
+	siteBeaconRateInterval = 10 * time.Millisecond
+)
+
+// 计算访客标识的密钥和上报的频率限制，密钥为空时不注入统计脚本，也不接收事件
+var (
+	analyticsSecret   []byte
+	beaconLimiter     = newKeyLimiter(beaconRateInterval, beaconRateBurst)
+	siteBeaconLimiter = newKeyLimiter(siteBeaconRateInterval, siteBeaconRateBurst)
+)
+
+// SetAnalyticsSecret 设置访客标识的密钥并启用访问统计
+func SetAnalyticsSecret(secret []byte) {
+	analyticsSecret = secret
+}
+
+// Beacon 公开页面上报的事件
+type Beacon struct {
+	Type        string `json:"type"` // pageview, click
+	PageID      string `json:"pageId"`
+	ComponentID string `json:"componentId"`
+	Referrer    string `json:"referrer"`
+	Locale      string `json:"locale"`
+}
+
+// BeaconVisitor 上报事件的访客信息，只用于计算访客标识和设备类型，不保存
+type BeaconVisitor struct {
+	IP        string
+	UserAgent string
+	Host      string // 页面所在的域名，来源为同一域名时视为站内跳转
+}
+
+// BeaconURL 站点的事件上报地址，未启用访问统计时为空
+func BeaconURL(siteID string) string {
+	if analyticsSecret == nil {
+		return ""
+	}
+	return "/render/sites/" + siteID + "/beacon"
+}
+
+// RecordBeacon 记录公开页面上报的浏览或点击事件，爬虫的事件直接忽略。
+// 页面必须在站点的线上版本中，只存在于草稿中的页面不接收事件
+func RecordBeacon(siteID string, beacon Beacon, visitor BeaconVisitor) error {
+	if analyticsSecret == nil {
+		return ErrAnalyticsDisabled
+	}
+	if !beaconLimiter.Allow(visitor.IP) {
+		return ErrTooManyBeacons
+	}
+	if beacon.Type != siteanalytics.EventPageview && beacon.Type != siteanalytics.EventClick {
+		return ErrInvalidBeacon
+	}
+	if beacon.PageID == "" || len(beacon.PageID) > maxBeaconIDLength || len(beacon.ComponentID) > maxBeaconIDLength {
+		return ErrInvalidBeacon
+	}
+	if beacon.Type == siteanalytics.EventClick && beacon.ComponentID == "" {
+		return ErrInvalidBeacon
+	}
+
+	device := siteanalytics.DetectDevice(visitor.UserAgent)
+	if device == siteanalytics.DeviceBot {
+		return nil
+	}
+	site, err := store.Sites.Get(siteID)
+	if err != nil || site.Status != "published" {
+		return ErrSiteNotPublished
+	}
+	if !siteBeaconLimiter.Allow(siteID) {
+		return ErrTooManyBeacons
+	}
+	published, err := GetPublishedSite(siteID)
+	if err != nil {
+		return ErrSiteNotPublished
+	}
+	if !hasPublishedPage(published, beacon.PageID) {
+		return ErrPageNotFound
+	}
+
+	now := time.Now()
+	event := models.PageEvent{
+		SiteID:      siteID,
+		PageID:      beacon.PageID,
+		Type:        beacon.Type,
+		VisitorHash: siteanalytics.VisitorHash(analyticsSecret, siteID, visitor.IP, visitor.UserAgent, now),
+		Device:      device,
+		CreatedAt:   now,
+	}
+	if beacon.Type == siteanalytics.EventClick {
+		event.ComponentID = beacon.ComponentID
+	} else {
+		event.Referrer = siteanalytics.ReferrerHost(beacon.Referrer, visitor.Host)
+	}
+	if len(beacon.Locale) <= 16 {
+		event.Locale = beacon.Locale
+	}
+	return store.Analytics.RecordEvent(&event)
+}
+
+// hasPublishedPage 线上版本中是否有该页面
+func hasPublishedPage(site models.Site, pageID string) bool {
+	for _, page := range site.Pages {
+		if page.ID == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// StartAnalyticsRollup 在后台按interval把事件汇总为按小时和按天的统计，并清理过期的原始事件。
+// 汇总按时段整体替换，多个实例同时执行结果相同
+func StartAnalyticsRollup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		lookback := analyticsBackfill
+		for {
+			RollupAnalytics(time.Now(), lookback)
+			lookback = analyticsLookback
+			<-ticker.C
+		}
+	}()
+}
+
+// RollupAnalytics 重新计算[now-lookback, now]内每个小时和这些小时所在日期的汇总
+func RollupAnalytics(now time.Time, lookback time.Duration) {
+	from := siteanalytics.PeriodStart(now.Add(-lookback), builder.GranularityDay)
+	siteIDs, err := store.Analytics.ListEventSites(from, now.Add(time.Second))
+	if err != nil {
+		log.Printf("读取访问事件失败: %v", err)
+		return
+	}
+	for _, siteID := range siteIDs {
+		if err := rollupSite(siteID, from, now, lookback); err != nil {
+			log.Printf("汇总站点%s的访问统计失败: %v", siteID, err)
+		}
+	}
+
+	if deleted, err := store.Analytics.DeleteEventsBefore(now.Add(-analyticsEventRetention)); err != nil {
+		log.Printf("清理访问事件失败: %v", err)
+	} else if deleted > 0 {
+		log.Printf("清理了%d条过期的访问事件", deleted)
+	}
+}
+
+// rollupSite 汇总站点的事件，from为最早需要重新计算的日期
+func rollupSite(siteID string, from time.Time, now time.Time, lookback time.Duration) error {
+	events, err := store.Analytics.ListEvents(siteID, from, now.Add(time.Second))
+	if err != nil {
+		return err
+	}
+	ranges := map[string]time.Time{
+		builder.GranularityHour: siteanalytics.PeriodStart(now.Add(-lookback), builder.GranularityHour),
+		builder.GranularityDay:  from,
+	}
+	for granularity, start := range ranges {
+		rollups := siteanalytics.Rollup(siteID, events, granularity, now)
+		// 没有事件的时段也要替换，重复执行时结果保持一致
+		for period := start; !period.After(now); period = siteanalytics.NextPeriod(period, granularity) {
+			if err := store.Analytics.ReplaceRollups(siteID, granularity, period, rollups[period]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"wz-backend-go/internal/pkg/siteanalytics"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// useAnalytics 测试期间启用访问统计，频率限制使用新的计数
+func useAnalytics(t *testing.T) *builder.Store {
+	t.Helper()
+	memory := useMemoryStore(t)
+	if _, err := memory.PublishSite("1", "tester", ""); err != nil {
+		t.Fatalf("发布站点失败: %v", err)
+	}
+	previousSecret, previousIP, previousSite := analyticsSecret, beaconLimiter, siteBeaconLimiter
+	SetAnalyticsSecret([]byte("test-secret"))
+	beaconLimiter = newKeyLimiter(beaconRateInterval, beaconRateBurst)
+	siteBeaconLimiter = newKeyLimiter(siteBeaconRateInterval, siteBeaconRateBurst)
+	t.Cleanup(func() {
+		analyticsSecret, beaconLimiter, siteBeaconLimiter = previousSecret, previousIP, previousSite
+	})
+	return memory
+}
+
+func pageview(pageID string) Beacon {
+	return Beacon{Type: siteanalytics.EventPageview, PageID: pageID}
+}
+
+var testVisitor = BeaconVisitor{IP: "203.0.113.20", UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64)", Host: "company.wanzhimarket.com"}
+
+func TestRecordBeaconUsesPublishedPages(t *testing.T) {
+	memory := useAnalytics(t)
+
+	// 发布之后新建的草稿页面和从草稿中删除的页面
+	draft := models.Page{ID: "draft-page", SiteID: "1", Title: "草稿", Slug: "draft"}
+	if err := memory.Pages.Create(&draft); err != nil {
+		t.Fatalf("创建页面失败: %v", err)
+	}
+	if err := memory.Pages.Delete("1", "page2"); err != nil {
+		t.Fatalf("删除页面失败: %v", err)
+	}
+
+	if err := RecordBeacon("1", pageview("draft-page"), testVisitor); !errors.Is(err, ErrPageNotFound) {
+		t.Fatalf("只在草稿中的页面不应接收事件: %v", err)
+	}
+	if err := RecordBeacon("1", pageview("page2"), testVisitor); err != nil {
+		t.Fatalf("线上版本中的页面应接收事件: %v", err)
+	}
+	if err := RecordBeacon("1", pageview("missing"), testVisitor); !errors.Is(err, ErrPageNotFound) {
+		t.Fatalf("不存在的页面不应接收事件: %v", err)
+	}
+}
+
+func TestRecordBeaconThrottlesPerIP(t *testing.T) {
+	useAnalytics(t)
+	for i := 0; i < beaconRateBurst; i++ {
+		if err := RecordBeacon("1", pageview("page1"), testVisitor); err != nil {
+			t.Fatalf("第%d次上报失败: %v", i+1, err)
+		}
+	}
+	if err := RecordBeacon("1", pageview("page1"), testVisitor); !errors.Is(err, ErrTooManyBeacons) {
+		t.Fatalf("超过上限后应拒绝: %v", err)
+	}
+
+	other := testVisitor
+	other.IP = "203.0.113.21"
+	if err := RecordBeacon("1", pageview("page1"), other); err != nil {
+		t.Fatalf("其他IP不受影响: %v", err)
+	}
+}
+
+func TestRecordBeaconThrottlesPerSite(t *testing.T) {
+	useAnalytics(t)
+	siteBeaconLimiter = newKeyLimiter(time.Hour, 3)
+	for i := 0; i < 3; i++ {
+		visitor := testVisitor
+		visitor.IP = "198.51.100." + string(rune('1'+i))
+		if err := RecordBeacon("1", pageview("page1"), visitor); err != nil {
+			t.Fatalf("第%d次上报失败: %v", i+1, err)
+		}
+	}
+	visitor := testVisitor
+	visitor.IP = "198.51.100.9"
+	if err := RecordBeacon("1", pageview("page1"), visitor); !errors.Is(err, ErrTooManyBeacons) {
+		t.Fatalf("站点超过上限后更换IP也应拒绝: %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"sync"
+	"wz-backend-go/internal/pkg/responsive"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/models"
+)
+
+// RenderMode 渲染模式
+type RenderMode string
+
+const (
+	// RenderModePreview 编辑器预览，未知组件显示占位符
+	RenderModePreview RenderMode = "preview"
+	// RenderModePublic 公开访问，未知组件直接跳过
+	RenderModePublic RenderMode = "public"
+	// RenderModeExport 静态导出，与公开访问相同，但表单和数据组件的请求发送到渲染服务对外的地址
+	RenderModeExport RenderMode = "export"
+)
+
+// ComponentData 传递给组件渲染器的数据
+type ComponentData struct {
+	SiteID   string
+	ID       string
+	Type     string
+	Name     string
+	Settings map[string]interface{}
+	Content  map[string]interface{}
+	Style    map[string]interface{}
+	Mode     RenderMode
+	Device   string
+	Locale   string
+	// Page 数据组件显示的页码，从1开始
+	Page int
+}
+
+// ComponentRenderer 组件渲染器
+type ComponentRenderer interface {
+	Render(data ComponentData) (template.HTML, error)
+}
+
+// ComponentRendererFunc 以函数形式实现的组件渲染器
+type ComponentRendererFunc func(data ComponentData) (template.HTML, error)
+
+// Render 调用渲染函数
+func (f ComponentRendererFunc) Render(data ComponentData) (template.HTML, error) {
+	return f(data)
+}
+
+// templateRenderer 基于html/template的组件渲染器
+type templateRenderer struct {
+	tmpl *template.Template
+}
+
+// Render 执行组件模板
+func (r *templateRenderer) Render(data ComponentData) (template.HTML, error) {
+	var buffer bytes.Buffer
+	if err := r.tmpl.Execute(&buffer, data); err != nil {
+		return "", err
+	}
+	return template.HTML(buffer.String()), nil
+}
+
+// 组件渲染器注册表
+var (
+	rendererMu sync.RWMutex
+	renderers  = map[string]ComponentRenderer{}
+)
+
+// componentFuncs 组件模板可用的辅助函数
+var componentFuncs = template.FuncMap{
+	"str":     mapString,
+	"bool":    mapBool,
+	"list":    mapList,
+	"default": defaultString,
+}
+
+// RegisterComponentRenderer 注册组件渲染器，同类型重复注册会覆盖之前的渲染器
+func RegisterComponentRenderer(componentType string, renderer ComponentRenderer) {
+	rendererMu.Lock()
+	defer rendererMu.Unlock()
+	renderers[componentType] = renderer
+}
+
+// RegisterComponentTemplate 使用模板文本注册组件渲染器，模板数据为ComponentData
+func RegisterComponentTemplate(componentType string, text string) error {
+	tmpl, err := template.New(componentType).Funcs(componentFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("解析组件模板%s失败: %w", componentType, err)
+	}
+	RegisterComponentRenderer(componentType, &templateRenderer{tmpl: tmpl})
+	return nil
+}
+
+// mustRegisterComponentTemplate 注册内置组件模板，模板错误属于编程错误
+func mustRegisterComponentTemplate(componentType string, text string) {
+	if err := RegisterComponentTemplate(componentType, text); err != nil {
+		panic(err)
+	}
+}
+
+// GetComponentRenderer 获取组件渲染器
+func GetComponentRenderer(componentType string) (ComponentRenderer, bool) {
+	rendererMu.RLock()
+	defer rendererMu.RUnlock()
+	renderer, exists := renderers[componentType]
+	return renderer, exists
+}
+
+// RegisteredComponentTypes 获取已注册的组件类型
+func RegisteredComponentTypes() []string {
+	rendererMu.RLock()
+	defer rendererMu.RUnlock()
+
+	types := make([]string, 0, len(renderers))
+	for componentType := range renderers {
+		types = append(types, componentType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// RenderComponent 渲染站点中的单个组件，输出包含外层容器，locale为页面的语言
+func RenderComponent(siteID string, component models.Component, mode RenderMode, device string, locale string) (template.HTML, error) {
+	return renderComponentPage(siteID, component, mode, device, locale, 1)
+}
+
+// renderComponentPage 渲染组件，数据组件显示第page页
+func renderComponentPage(siteID string, component models.Component, mode RenderMode, device string, locale string, page int) (template.HTML, error) {
+	renderer, exists := GetComponentRenderer(component.Type)
+	if !exists {
+		if mode == RenderModePreview {
+			return renderPlaceholder(component), nil
+		}
+		return "", nil
+	}
+
+	data := ComponentData{
+		SiteID:  siteID,
+		ID:      component.ID,
+		Type:    component.Type,
+		Name:    component.Name,
+		Content: toMap(component.Content),
+		Mode:    mode,
+		Device:  device,
+		Locale:  locale,
+		Page:    page,
+	}
+	settings := toMap(component.Settings)
+	style := toMap(component.Style)
+
+	// 预览时只渲染当前设备生效的设置和样式
+	if mode == RenderModePreview {
+		device = responsive.Normalize(device)
+		if responsive.Hidden(settings, device) {
+			return "", nil
+		}
+		data.Device = device
+		data.Settings = responsive.Resolve(settings, device)
+		data.Style = responsive.Resolve(style, device)
+		inner, err := renderer.Render(data)
+		if err != nil {
+			return "", fmt.Errorf("渲染组件%s失败: %w", component.ID, err)
+		}
+		return wrapComponent(component, inlineStyle(data.Style), inner), nil
+	}
+
+	// 公开页面按设备的覆盖编译为媒体查询，在所有设备上都隐藏的组件不输出
+	if len(responsive.HiddenDevices(settings)) == len(responsive.Devices) {
+		return "", nil
+	}
+	data.Style, _ = responsive.Split(style)
+	inner, variantRules, err := renderResponsive(renderer, data, settings)
+	if err != nil {
+		return "", fmt.Errorf("渲染组件%s失败: %w", component.ID, err)
+	}
+	css := responsiveCSS(component.ID, style, settings, variantRules...)
+	return css + wrapComponent(component, inlineStyle(data.Style), inner), nil
+}
+
+// renderPlaceholder 生成未知组件的预览占位符
+func renderPlaceholder(component models.Component) template.HTML {
+	return template.HTML(fmt.Sprintf(
+		`<div class="component component-placeholder" id="%s" style="border: 1px dashed #f44336; color: #f44336; padding: 12px;">未知组件类型: %s</div>`,
+		template.HTMLEscapeString(component.ID),
+		template.HTMLEscapeString(component.Type),
+	))
+}
+
+// wrapComponent 为组件输出添加外层容器
+func wrapComponent(component models.Component, style template.CSS, inner template.HTML) template.HTML {
+	var builder strings.Builder
+	builder.WriteString(`<div class="component component-`)
+	builder.WriteString(template.HTMLEscapeString(component.Type))
+	builder.WriteString(`" id="`)
+	builder.WriteString(template.HTMLEscapeString(component.ID))
+	builder.WriteString(`"`)
+	if style != "" {
+		builder.WriteString(` style="`)
+		builder.WriteString(template.HTMLEscapeString(string(style)))
+		builder.WriteString(`"`)
+	}
+	builder.WriteString(`>`)
+	builder.WriteString(string(inner))
+	builder.WriteString(`</div>`)
+	return template.HTML(builder.String())
+}
+
+// toMap 将组件的Settings/Content/Style转换为map
+func toMap(value interface{}) map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v
+	case map[string]string:
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			result[key] = val
+		}
+		return result
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// mapString 从map中读取字符串值
+func mapString(values map[string]interface{}, key string) string {
+	value, exists := values[key]
+	if !exists || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// mapBool 从map中读取布尔值
+func mapBool(values map[string]interface{}, key string) bool {
+	switch v := values[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// mapList 从map中读取列表，列表元素统一转换为map
+func mapList(values map[string]interface{}, key string) []map[string]interface{} {
+	items, ok := values[key].([]interface{})
+	if !ok {
+		if typed, ok := values[key].([]map[string]interface{}); ok {
+			return typed
+		}
+		return nil
+	}
+
+	result := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+// defaultString 值为空时使用默认值
+func defaultString(fallback string, value string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// inlineStyle 将组件样式转换为内联CSS，驼峰属性名转换为连字符形式，
+// 按sitetheme.CheckDeclaration检查，不安全的值会被忽略
+func inlineStyle(style map[string]interface{}) template.CSS {
+	if len(style) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(style))
+	for key := range style {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var declarations []string
+	for _, key := range keys {
+		value, ok := style[key].(string)
+		if !ok {
+			// 嵌套结构（如断点覆盖）不属于内联样式
+			if _, isMap := style[key].(map[string]interface{}); isMap {
+				continue
+			}
+			value = fmt.Sprint(style[key])
+		}
+		property := cssPropertyName(key)
+		if err := sitetheme.CheckDeclaration(property, value); err != nil {
+			continue
+		}
+		declarations = append(declarations, property+": "+value)
+	}
+
+	return template.CSS(strings.Join(declarations, "; "))
+}
+
+// cssPropertyName 将驼峰属性名转换为CSS属性名
+func cssPropertyName(key string) string {
+	var builder strings.Builder
+	for i, r := range key {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				builder.WriteByte('-')
+			}
+			builder.WriteRune(r + ('a' - 'A'))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+	"wz-backend-go/internal/pkg/sitetheme"
+)
+
+// 内置组件渲染器，类型与component-service的组件定义保持一致
+func init() {
+	RegisterComponentRenderer("heading", ComponentRendererFunc(renderHeading))
+
+	mustRegisterComponentTemplate("text", `<p style="text-align: {{ default "left" (str .Settings "textAlign") }}; font-size: {{ default "16px" (str .Settings "fontSize") }};{{ with str .Settings "color" }} color: {{ . }};{{ end }}">{{ str .Content "text" }}</p>`)
+
+	mustRegisterComponentTemplate("button", `{{ if str .Content "link" }}<a class="btn btn-{{ default "filled" (str .Settings "style") }}" href="{{ str .Content "link" }}">{{ str .Content "text" }}</a>{{ else }}<button class="btn btn-{{ default "filled" (str .Settings "style") }}">{{ str .Content "text" }}</button>{{ end }}`)
+
+	mustRegisterComponentTemplate("divider", `<hr style="border-style: {{ default "solid" (str .Settings "style") }}; width: {{ default "100%" (str .Settings "width") }};">`)
+
+	// 媒体库中的图片输出缩放版本的srcset，见media.go
+	RegisterComponentRenderer("image", ComponentRendererFunc(renderImage))
+
+	mustRegisterComponentTemplate("video", `<video src="{{ str .Content "src" }}"{{ with str .Content "poster" }} poster="{{ . }}"{{ end }}{{ if bool .Settings "controls" }} controls{{ end }}{{ if bool .Settings "autoplay" }} autoplay muted{{ end }}{{ if bool .Settings "loop" }} loop{{ end }} style="max-width: 100%;"></video>`)
+
+	mustRegisterComponentTemplate("carousel", `<div class="carousel" data-autoplay="{{ bool .Settings "autoplay" }}" data-interval="{{ default "3000" (str .Settings "interval") }}">{{ range list .Content "images" }}<div class="carousel-item"><img src="{{ str . "src" }}" alt="{{ str . "alt" }}" style="max-width: 100%;"></div>{{ end }}</div>`)
+
+	mustRegisterComponentTemplate("gallery", `<div class="gallery" style="display: grid; grid-template-columns: repeat({{ default "3" (str .Settings "columns") }}, 1fr); gap: {{ default "8px" (str .Settings "gap") }};">{{ range list .Content "images" }}<figure><img src="{{ str . "src" }}" alt="{{ str . "alt" }}" style="width: 100%;">{{ with str . "caption" }}<figcaption>{{ . }}</figcaption>{{ end }}</figure>{{ end }}</div>`)
+
+	mustRegisterComponentTemplate("map", `<div class="map">{{ with str .Content "embedUrl" }}<iframe src="{{ . }}" style="border: 0; width: 100%; height: 300px;" loading="lazy"></iframe>{{ end }}{{ with str .Content "address" }}<p class="map-address">{{ . }}</p>{{ end }}</div>`)
+
+	// 布局组件
+	mustRegisterComponentTemplate("container", `<div class="layout-container">{{ str .Content "text" }}</div>`)
+	mustRegisterComponentTemplate("row", `<div class="layout-row">{{ str .Content "text" }}</div>`)
+	mustRegisterComponentTemplate("column", `<div class="layout-column">{{ str .Content "text" }}</div>`)
+	mustRegisterComponentTemplate("card", `<div class="card">{{ with str .Content "title" }}<h3>{{ . }}</h3>{{ end }}{{ with str .Content "text" }}<p>{{ . }}</p>{{ end }}</div>`)
+}
+
+// headingLevels 允许的标题级别
+var headingLevels = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// renderHeading 渲染标题组件，标题级别不合法时使用h2
+func renderHeading(data ComponentData) (template.HTML, error) {
+	level := mapString(data.Settings, "level")
+	if !headingLevels[level] {
+		level = "h2"
+	}
+	var declarations []string
+	if align := mapString(data.Settings, "textAlign"); align != "" && sitetheme.CheckDeclaration("text-align", align) == nil {
+		declarations = append(declarations, "text-align: "+align+";")
+	}
+	if color := mapString(data.Settings, "color"); color != "" && sitetheme.CheckDeclaration("color", color) == nil {
+		declarations = append(declarations, "color: "+color+";")
+	}
+
+	text := template.HTMLEscapeString(mapString(data.Content, "text"))
+	if len(declarations) == 0 {
+		return template.HTML(fmt.Sprintf("<%s>%s</%s>", level, text, level)), nil
+	}
+	style := template.HTMLEscapeString(strings.Join(declarations, " "))
+	return template.HTML(fmt.Sprintf(`<%s style="%s">%s</%s>`, level, style, text, level)), nil
+}
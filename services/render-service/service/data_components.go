@@ -0,0 +1,411 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"wz-backend-go/internal/pkg/datasource"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/models"
+)
+
+// 数据组件使用的数据源名称
+const (
+	DataSourceProducts = "products"
+	DataSourcePosts    = "posts"
+)
+
+// maxDataPage 数据组件允许访问的最大页码，限制缓存的查询数量
+const maxDataPage = 100
+
+// ErrDataComponentNotFound 线上版本中没有该数据组件
+var ErrDataComponentNotFound = errors.New("数据组件不存在")
+
+// dataSources 数据组件的数据源，由main在启动时注册
+var dataSources = datasource.NewRegistry(datasource.DefaultTimeout, datasource.DefaultTTL)
+
+// RegisterDataSource 注册数据源，未注册的数据源对应的组件在线上页面不显示
+func RegisterDataSource(name string, provider datasource.Provider) {
+	dataSources.Register(name, provider)
+}
+
+// dataComponent 数据组件的查询方式和展示模板
+type dataComponent struct {
+	source      string
+	sourceName  string // 提示信息中的数据源名称
+	defaultSize int
+	filters     func(settings map[string]interface{}) map[string]interface{}
+	tmpl        *template.Template
+}
+
+// dataComponents 按组件类型登记的数据组件，类型与component-service的组件定义保持一致
+var dataComponents = map[string]dataComponent{
+	"product-grid": {
+		source:      DataSourceProducts,
+		sourceName:  "商品",
+		defaultSize: 12,
+		filters: func(settings map[string]interface{}) map[string]interface{} {
+			return pickFilters(settings, "companyId", "category", "keyword", "priceMin", "priceMax")
+		},
+		tmpl: mustParseDataTemplate("product-grid", `
+{{- if .Items }}<div class="product-grid-items" style="display: grid; grid-template-columns: repeat({{ .Columns }}, 1fr); gap: 16px;">
+{{- range $item := .Items }}
+<div class="product-card">
+{{- $link := link $.LinkPattern $item }}
+{{- with str $item "image" }}{{ if $link }}<a href="{{ $link }}">{{ end }}<img src="{{ . }}" alt="{{ str $item "name" }}" loading="lazy" style="width: 100%;">{{ if $link }}</a>{{ end }}{{ end }}
+<h4 class="product-name">{{ if $link }}<a href="{{ $link }}">{{ str . "name" }}</a>{{ else }}{{ str . "name" }}{{ end }}</h4>
+{{- if $.ShowPrice }}<p class="product-price">{{ $.Currency }}{{ price . "price" }}</p>{{ end }}
+</div>
+{{- end }}
+</div>{{ end }}`),
+	},
+	"post-list": {
+		source:      DataSourcePosts,
+		sourceName:  "文章",
+		defaultSize: 5,
+		filters: func(settings map[string]interface{}) map[string]interface{} {
+			return pickFilters(settings, "categoryId", "userId")
+		},
+		tmpl: mustParseDataTemplate("post-list", `
+{{- if .Items }}<ul class="post-list-items">
+{{- range .Items }}
+{{- $link := link $.LinkPattern . }}
+<li class="post-item">
+<h4 class="post-title">{{ if $link }}<a href="{{ $link }}">{{ str . "title" }}</a>{{ else }}{{ str . "title" }}{{ end }}</h4>
+{{- if $.ShowDate }}{{ with str . "createdAt" }}<time class="post-date">{{ . }}</time>{{ end }}{{ end }}
+{{- if $.ShowExcerpt }}{{ with str . "excerpt" }}<p class="post-excerpt">{{ . }}</p>{{ end }}{{ end }}
+</li>
+{{- end }}
+</ul>{{ end }}`),
+	},
+}
+
+func init() {
+	for componentType := range dataComponents {
+		RegisterComponentRenderer(componentType, ComponentRendererFunc(renderDataComponent))
+	}
+}
+
+// mustParseDataTemplate 解析数据组件的列表模板，外层的标题、空状态和分页由dataFrameHTML统一输出
+func mustParseDataTemplate(name string, text string) *template.Template {
+	return template.Must(template.New(name).Funcs(template.FuncMap{
+		"str":   mapString,
+		"price": formatPrice,
+		"link":  itemLink,
+	}).Parse(text))
+}
+
+// dataFrameHTML 数据组件的外层结构，分页链接指向组件的分页接口，有脚本时在页面内替换组件，没有脚本时直接打开分页结果
+var dataFrameHTML = template.Must(template.New("data-frame").Parse(`
+{{- with .Title }}<h3 class="data-title">{{ . }}</h3>{{ end }}
+{{- with .Notice }}<div class="data-notice" style="border: 1px dashed #ff9800; color: #ff9800; padding: 8px;">{{ . }}</div>{{ end }}
+{{- .List }}
+{{- if .Empty }}<p class="data-empty">{{ .EmptyText }}</p>{{ end }}
+{{- with .Pager }}
+<nav class="data-pagination">
+{{- if .Disabled }}<span>第{{ .Page }}/{{ .Pages }}页</span>
+{{- else }}
+{{- with .PrevURL }}<a href="{{ . }}" data-component-page="{{ $.ID }}" rel="prev">上一页</a>{{ end }}
+<span>第{{ .Page }}/{{ .Pages }}页</span>
+{{- with .NextURL }}<a href="{{ . }}" data-component-page="{{ $.ID }}" rel="next">下一页</a>{{ end }}
+<script>if(!window.__dataPager){window.__dataPager=true;document.addEventListener("click",function(e){var a=e.target.closest&&e.target.closest("a[data-component-page]");if(!a)return;e.preventDefault();fetch(a.href).then(function(r){if(!r.ok)throw r;return r.text()}).then(function(html){var el=document.getElementById(a.getAttribute("data-component-page"));if(el)el.outerHTML=html}).catch(function(){location.href=a.href})})}</script>
+{{- end }}
+</nav>
+{{- end }}`))
+
+// dataPager 数据组件的分页信息
+type dataPager struct {
+	Page     int
+	Pages    int
+	PrevURL  string
+	NextURL  string
+	Disabled bool // 预览时分页接口只能访问线上版本，静态导出未配置渲染服务对外的地址时无法请求分页，只显示页码
+}
+
+// renderDataComponent 查询数据源并渲染数据组件。数据源未配置、超时或出错时不影响页面的其他部分：
+// 预览模式下显示提示，公开页面显示空状态，未配置数据源时不显示组件内容
+func renderDataComponent(data ComponentData) (template.HTML, error) {
+	component, ok := dataComponents[data.Type]
+	if !ok {
+		return "", fmt.Errorf("未知的数据组件%s", data.Type)
+	}
+
+	pageSize := int(mapNumber(data.Settings, "limit"))
+	if pageSize <= 0 {
+		pageSize = component.defaultSize
+	}
+	page := data.Page
+	if page < 1 {
+		page = 1
+	}
+	query := datasource.Query{
+		Filters:  component.filters(data.Settings),
+		Sort:     mapString(data.Settings, "sort"),
+		Order:    mapString(data.Settings, "order"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+
+	frame := map[string]interface{}{
+		"ID":        data.ID,
+		"Notice":    "",
+		"List":      template.HTML(""),
+		"Empty":     false,
+		"Pager":     (*dataPager)(nil),
+		"Title":     mapString(data.Content, "title"),
+		"EmptyText": defaultString("暂无内容", mapString(data.Content, "emptyText")),
+	}
+	result, err := dataSources.Fetch(component.source, query)
+	switch {
+	case err == datasource.ErrUnknownSource:
+		if data.Mode != RenderModePreview {
+			return "", nil
+		}
+		frame["Notice"] = "数据源未配置：" + component.sourceName + "，线上页面不会显示该组件"
+	case err != nil:
+		log.Printf("站点%s的数据组件%s查询失败: %v", data.SiteID, data.ID, err)
+		if data.Mode == RenderModePreview {
+			frame["Notice"] = component.sourceName + "数据暂时无法加载: " + err.Error()
+		}
+		frame["Empty"] = true
+		frame["EmptyText"] = defaultString("内容暂时无法加载，请稍后再试", mapString(data.Content, "unavailableText"))
+	default:
+		if result.Stale && data.Mode == RenderModePreview {
+			frame["Notice"] = component.sourceName + "数据源暂时不可用，显示的是之前的数据"
+		}
+		list, err := executeDataList(component.tmpl, data, result)
+		if err != nil {
+			return "", err
+		}
+		frame["List"] = list
+		frame["Empty"] = len(result.Items) == 0
+		if mapBool(data.Settings, "pagination") {
+			frame["Pager"] = newDataPager(data, page, result.Pages(pageSize))
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := dataFrameHTML.Execute(&buffer, frame); err != nil {
+		return "", err
+	}
+	return template.HTML(buffer.String()), nil
+}
+
+// executeDataList 执行数据组件的列表模板
+func executeDataList(tmpl *template.Template, data ComponentData, result datasource.Result) (template.HTML, error) {
+	columns := int(mapNumber(data.Settings, "columns"))
+	if columns < 1 || columns > 6 {
+		columns = 4
+	}
+	var buffer bytes.Buffer
+	err := tmpl.Execute(&buffer, map[string]interface{}{
+		"Items":       result.Items,
+		"Columns":     columns,
+		"LinkPattern": mapString(data.Content, "linkPattern"),
+		"Currency":    defaultString("¥", mapString(data.Settings, "currency")),
+		"ShowPrice":   !isFalse(data.Settings, "showPrice"),
+		"ShowDate":    !isFalse(data.Settings, "showDate"),
+		"ShowExcerpt": !isFalse(data.Settings, "showExcerpt"),
+	})
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buffer.String()), nil
+}
+
+// newDataPager 生成分页信息，只有一页时不显示分页
+func newDataPager(data ComponentData, page int, pages int) *dataPager {
+	if pages > maxDataPage {
+		pages = maxDataPage
+	}
+	if pages <= 1 && page <= 1 {
+		return nil
+	}
+	pager := &dataPager{Page: page, Pages: pages, Disabled: data.Mode == RenderModePreview}
+	// 静态导出的页面不在渲染服务上，分页请求发送到渲染服务对外的地址
+	base := ""
+	if data.Mode == RenderModeExport {
+		base = exportPublicURL
+		pager.Disabled = base == ""
+	}
+	if page > 1 {
+		pager.PrevURL = base + DataComponentURL(data.SiteID, data.ID, page-1, data.Locale)
+	}
+	if page < pages {
+		pager.NextURL = base + DataComponentURL(data.SiteID, data.ID, page+1, data.Locale)
+	}
+	return pager
+}
+
+// DataComponentURL 数据组件分页接口的地址
+func DataComponentURL(siteID string, componentID string, page int, locale string) string {
+	values := url.Values{}
+	values.Set("page", strconv.Itoa(page))
+	if locale != "" {
+		values.Set("locale", locale)
+	}
+	return "/render/sites/" + siteID + "/components/" + componentID + "?" + values.Encode()
+}
+
+// RenderDataComponent 渲染线上版本中数据组件的第page页，供分页链接使用。
+// locale为空或未启用时根据Cookie和Accept-Language选择语言
+func RenderDataComponent(siteID string, componentID string, page int, locale string, localeCookie string, acceptLanguage string) (template.HTML, error) {
+	site, err := store.Sites.Get(siteID)
+	if err != nil || site.Status != "published" {
+		return "", ErrSiteNotPublished
+	}
+	published, err := GetPublishedSite(siteID)
+	if err != nil {
+		return "", err
+	}
+
+	_, component, ok := findPublishedComponent(published, componentID)
+	if !ok || !isDataComponent(component.Type) {
+		return "", ErrDataComponentNotFound
+	}
+	if locale = sitelocale.Normalize(locale); !sitelocale.IsEnabled(published, locale) {
+		locale = sitelocale.Negotiate(published, localeCookie, acceptLanguage)
+	}
+	if page < 1 {
+		page = 1
+	} else if page > maxDataPage {
+		page = maxDataPage
+	}
+
+	component = sitelocale.LocalizeComponent(component, locale)
+	return renderComponentPage(siteID, component, RenderModePublic, "", locale, page)
+}
+
+// DataCacheTTL 数据组件查询结果的缓存时间，也是包含数据组件的页面的缓存时间
+func DataCacheTTL() int {
+	return int(dataSources.TTL().Seconds())
+}
+
+// isDataComponent 判断组件是否需要在渲染时查询数据
+func isDataComponent(componentType string) bool {
+	_, ok := dataComponents[componentType]
+	return ok
+}
+
+// usesLiveData 判断页面（包括页头页脚）是否包含数据组件，这类页面的缓存需要随数据过期
+func usesLiveData(site models.Site, page models.Page) bool {
+	sections := resolvePageSections(site, page).Sections
+	for _, slotID := range []string{site.HeaderSectionID, site.FooterSectionID} {
+		if slot := siteSlotSection(site, slotID, ""); slot != nil {
+			sections = append(sections, *slot)
+		}
+	}
+	for _, section := range sections {
+		for _, component := range section.Components {
+			if isDataComponent(component.Type) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findPublishedComponent 在站点树的页面和全局区块中查找组件，组件在全局区块中时返回的页面ID为空
+func findPublishedComponent(site models.Site, componentID string) (string, models.Component, bool) {
+	for _, page := range site.Pages {
+		if component, ok := findSectionComponent(page.Sections, componentID); ok {
+			return page.ID, component, true
+		}
+	}
+	if component, ok := findSectionComponent(site.GlobalSections, componentID); ok {
+		return "", component, true
+	}
+	return "", models.Component{}, false
+}
+
+func findSectionComponent(sections []models.Section, componentID string) (models.Component, bool) {
+	for _, section := range sections {
+		for _, component := range section.Components {
+			if component.ID == componentID {
+				return component, true
+			}
+		}
+	}
+	return models.Component{}, false
+}
+
+// pickFilters 从组件设置中取出已填写的过滤条件
+func pickFilters(settings map[string]interface{}, keys ...string) map[string]interface{} {
+	filters := map[string]interface{}{}
+	for _, key := range keys {
+		switch value := settings[key].(type) {
+		case string:
+			if value = strings.TrimSpace(value); value != "" {
+				filters[key] = value
+			}
+		case nil:
+		default:
+			if number := mapNumber(settings, key); number != 0 {
+				filters[key] = number
+			}
+		}
+	}
+	return filters
+}
+
+// mapNumber 从map中读取数值，无法解析时返回0
+func mapNumber(values map[string]interface{}, key string) float64 {
+	switch v := values[key].(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return 0
+		}
+		return number
+	default:
+		return 0
+	}
+}
+
+// isFalse 设置项明确为false时返回true，未设置视为开启
+func isFalse(values map[string]interface{}, key string) bool {
+	if _, ok := values[key]; !ok {
+		return false
+	}
+	return !mapBool(values, key)
+}
+
+// formatPrice 格式化数据项中的价格
+func formatPrice(item map[string]interface{}, key string) string {
+	return strconv.FormatFloat(mapNumber(item, key), 'f', 2, 64)
+}
+
+// itemLink 用数据项的字段替换链接模板中的{字段名}，如/products/{id}，模板为空时不生成链接
+func itemLink(pattern string, item map[string]interface{}) string {
+	if pattern == "" {
+		return ""
+	}
+	var builder strings.Builder
+	for {
+		start := strings.Index(pattern, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(pattern[start:], "}")
+		if end < 0 {
+			break
+		}
+		builder.WriteString(pattern[:start])
+		builder.WriteString(url.PathEscape(mapString(item, pattern[start+1:start+end])))
+		pattern = pattern[start+end+1:]
+	}
+	builder.WriteString(pattern)
+	return builder.String()
+}
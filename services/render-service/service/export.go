@@ -0,0 +1,413 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/models"
+)
+
+// exportAssetMaxSize 导出时下载的单个站内资源的最大字节数
+const exportAssetMaxSize = 50 << 20
+
+// 静态导出的配置，由main在启动时设置
+var (
+	// exportPublicURL 渲染服务对外的地址，导出页面中的表单和数据组件分页请求发送到该地址
+	exportPublicURL string
+	// exportAssetFetcher 下载站点引用的站内资源，未设置时导出包中只有资源清单
+	exportAssetFetcher ExportAssetFetcher
+)
+
+// SetExportPublicURL 设置渲染服务对外的地址，如https://render.example.com
+func SetExportPublicURL(publicURL string) {
+	exportPublicURL = strings.TrimSuffix(publicURL, "/")
+}
+
+// SetExportAssetFetcher 设置下载站内资源的方式
+func SetExportAssetFetcher(fetcher ExportAssetFetcher) {
+	exportAssetFetcher = fetcher
+}
+
+// ExportAssetFetcher 下载站点引用的站内资源，ref为以/开头的站内地址，如/media/<id>或/img/logo.png
+type ExportAssetFetcher interface {
+	Fetch(ref string) ([]byte, error)
+}
+
+// HTTPAssetFetcher 从站点的访问地址（网关）下载站内资源
+type HTTPAssetFetcher struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewHTTPAssetFetcher 创建从baseURL下载站内资源的下载器
+func NewHTTPAssetFetcher(baseURL string) *HTTPAssetFetcher {
+	return &HTTPAssetFetcher{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Fetch 下载资源，响应不是200或超过exportAssetMaxSize时返回错误
+func (f *HTTPAssetFetcher) Fetch(ref string) ([]byte, error) {
+	resp, err := f.client.Get(f.baseURL + ref)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, exportAssetMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > exportAssetMaxSize {
+		return nil, errors.New("文件过大")
+	}
+	return data, nil
+}
+
+// ExportTarget 静态导出的存储目标
+type ExportTarget interface {
+	// WriteFile 写入文件，name为以/分隔的相对路径
+	WriteFile(name string, data []byte) error
+	// Close 完成导出
+	Close() error
+}
+
+// DirExportTarget 导出到本地目录
+type DirExportTarget struct {
+	root string
+}
+
+// NewDirExportTarget 创建本地目录导出目标
+func NewDirExportTarget(root string) (*DirExportTarget, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &DirExportTarget{root: root}, nil
+}
+
+// WriteFile 写入文件到目录
+func (t *DirExportTarget) WriteFile(name string, data []byte) error {
+	fullPath := filepath.Join(t.root, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, data, 0644)
+}
+
+// Close 目录导出无需额外处理
+func (t *DirExportTarget) Close() error {
+	return nil
+}
+
+// ZipExportTarget 导出为zip压缩包
+type ZipExportTarget struct {
+	writer *zip.Writer
+}
+
+// NewZipExportTarget 创建zip导出目标
+func NewZipExportTarget(w io.Writer) *ZipExportTarget {
+	return &ZipExportTarget{writer: zip.NewWriter(w)}
+}
+
+// WriteFile 写入文件到压缩包
+func (t *ZipExportTarget) WriteFile(name string, data []byte) error {
+	f, err := t.writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+// Close 写入压缩包目录
+func (t *ZipExportTarget) Close() error {
+	return t.writer.Close()
+}
+
+// ExportResult 导出结果。Assets为站点引用的全部资源，其中站内资源下载后写入导出包，
+// 其他站点的资源保持原地址；Warnings记录导出包与线上站点不一致的地方
+type ExportResult struct {
+	SiteID     string    `json:"siteId"`
+	Files      []string  `json:"files"`
+	Assets     []string  `json:"assets"`
+	Warnings   []string  `json:"warnings,omitempty"`
+	ExportedAt time.Time `json:"exportedAt"`
+}
+
+// ExportSite 将站点所有页面渲染为静态HTML，并生成sitemap.xml、robots.txt、主题CSS、站内资源和资源清单。
+// 表单和数据组件分页请求发送到渲染服务对外的地址，未配置时表单不能提交、数据组件不能翻页
+func ExportSite(site models.Site, target ExportTarget) (ExportResult, error) {
+	result := ExportResult{
+		SiteID:     site.ID,
+		ExportedAt: time.Now(),
+	}
+
+	write := func(name string, data []byte) error {
+		if err := target.WriteFile(name, data); err != nil {
+			return fmt.Errorf("写入%s失败: %w", name, err)
+		}
+		result.Files = append(result.Files, name)
+		return nil
+	}
+
+	// 主题样式表使用带内容哈希的文件名，页面引用相对站点根目录的地址
+	sheet := sitetheme.Compile(site.Theme)
+	themeURL := "/assets/" + sheet.FileName()
+
+	// 默认语言的页面在根目录，其他语言的页面在<语言>/目录下
+	defaultLocale := sitelocale.DefaultLocale(site)
+	for _, locale := range sitelocale.Enabled(site) {
+		for _, page := range site.Pages {
+			// 静态文件无法按时间显示或隐藏，只导出导出时可见的页面
+			if !PageVisible(page, result.ExportedAt) {
+				continue
+			}
+			name, err := exportPagePath(page)
+			if err != nil {
+				return result, err
+			}
+			if locale != defaultLocale {
+				name = locale + "/" + name
+			}
+
+			html, err := generatePageHTML(site, page, locale, RenderModeExport, themeURL, "")
+			if err != nil {
+				return result, fmt.Errorf("渲染页面%s失败: %w", page.ID, err)
+			}
+			if err := write(name, []byte(html)); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if err := write("assets/"+sheet.FileName(), []byte(sheet.CSS)); err != nil {
+		return result, err
+	}
+
+	// sitemap协议要求绝对地址，未设置域名的站点不生成，robots.txt中也不引用
+	if site.Domain != "" {
+		sitemap, err := GenerateSitemap(site)
+		if err != nil {
+			return result, err
+		}
+		if err := write("sitemap.xml", sitemap); err != nil {
+			return result, err
+		}
+	} else {
+		result.Warnings = append(result.Warnings, "站点未设置域名，sitemap需要绝对地址，未生成sitemap.xml")
+	}
+	if err := write("robots.txt", []byte(GenerateRobotsTxt(site))); err != nil {
+		return result, err
+	}
+
+	// 站内资源按原路径写入导出包，页面中的地址不用改写；下载失败不影响导出，记录在警告中
+	result.Assets = collectAssetReferences(site)
+	written := map[string]bool{}
+	for _, name := range result.Files {
+		written[name] = true
+	}
+	for _, ref := range result.Assets {
+		if !isSiteAsset(ref) {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean(ref), "/")
+		if written[name] {
+			continue
+		}
+		if exportAssetFetcher == nil {
+			result.Warnings = append(result.Warnings, "未配置站内资源的下载地址，"+ref+"未写入导出包")
+			continue
+		}
+		data, err := exportAssetFetcher.Fetch(ref)
+		if err != nil {
+			log.Printf("站点%s导出时下载资源%s失败: %v", site.ID, ref, err)
+			result.Warnings = append(result.Warnings, fmt.Sprintf("下载资源%s失败: %v", ref, err))
+			continue
+		}
+		if err := write(name, data); err != nil {
+			return result, err
+		}
+		written[name] = true
+	}
+
+	manifest, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	if err := target.WriteFile("assets/manifest.json", manifest); err != nil {
+		return result, fmt.Errorf("写入资源清单失败: %w", err)
+	}
+	result.Files = append(result.Files, "assets/manifest.json")
+
+	return result, target.Close()
+}
+
+// ExportSiteToDir 导出站点到目录，先写入临时目录再替换，避免读到不完整的快照
+func ExportSiteToDir(site models.Site, dir string) (ExportResult, error) {
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return ExportResult{}, err
+	}
+
+	target, err := NewDirExportTarget(tmpDir)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	result, err := ExportSite(site, target)
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return result, err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return result, err
+	}
+	return result, os.Rename(tmpDir, dir)
+}
+
+// exportPagePath 计算页面的导出路径，首页为index.html，其他页面为<slug>/index.html
+func exportPagePath(page models.Page) (string, error) {
+	if page.IsHomepage {
+		return "index.html", nil
+	}
+
+	slug := strings.Trim(page.Slug, "/")
+	if slug == "" {
+		slug = page.ID
+	}
+	cleaned := path.Clean(slug)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") || strings.Contains(cleaned, "\\") {
+		return "", fmt.Errorf("页面%s的slug不合法: %s", page.ID, page.Slug)
+	}
+	return cleaned + "/index.html", nil
+}
+
+// siteBaseURL 站点的绝对地址
+func siteBaseURL(site models.Site) string {
+	if site.Domain == "" {
+		return ""
+	}
+	return "https://" + strings.TrimSuffix(site.Domain, "/")
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+// GenerateSitemap 根据站点的页面列表生成sitemap.xml
+func GenerateSitemap(site models.Site) ([]byte, error) {
+	if site.Domain == "" {
+		return nil, errors.New("站点未设置域名，无法生成sitemap")
+	}
+
+	urlSet := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	now := time.Now()
+	for _, locale := range sitelocale.Enabled(site) {
+		for _, page := range site.Pages {
+			if !PageVisible(page, now) {
+				continue
+			}
+			urlSet.URLs = append(urlSet.URLs, sitemapURL{
+				Loc:     pageURL(site, page, locale),
+				LastMod: pageLastModified(site, page).Format("2006-01-02"),
+			})
+		}
+	}
+
+	output, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), output...), nil
+}
+
+// GenerateRobotsTxt 生成robots.txt
+func GenerateRobotsTxt(site models.Site) string {
+	var builder strings.Builder
+	builder.WriteString("User-agent: *\n")
+	if site.SEO.NoIndex {
+		builder.WriteString("Disallow: /\n")
+		return builder.String()
+	}
+	builder.WriteString("Allow: /\n")
+	if baseURL := siteBaseURL(site); baseURL != "" {
+		builder.WriteString("Sitemap: " + baseURL + "/sitemap.xml\n")
+	}
+	return builder.String()
+}
+
+// isSiteAsset 判断资源地址是否为站内地址（以/开头，不含查询参数），这类资源可以按路径写入导出包
+func isSiteAsset(ref string) bool {
+	if !strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "//") || strings.ContainsAny(ref, "?#\\") {
+		return false
+	}
+	return path.Clean(ref) != "/"
+}
+
+// assetContentKeys 组件内容中引用资源的字段
+var assetContentKeys = []string{"src", "poster", "file"}
+
+// collectAssetReferences 收集站点引用的静态资源地址
+func collectAssetReferences(site models.Site) []string {
+	seen := map[string]bool{}
+	add := func(ref string) {
+		if ref != "" {
+			seen[ref] = true
+		}
+	}
+
+	add(site.Logo)
+	add(site.Favicon)
+	add(site.Thumbnail)
+	// 引用全局区块的页面区块没有自己的组件，全局区块的资源单独收集
+	sections := append([]models.Section{}, site.GlobalSections...)
+	for _, page := range site.Pages {
+		sections = append(sections, page.Sections...)
+	}
+	for _, section := range sections {
+		for _, component := range section.Components {
+			content := toMap(component.Content)
+			for _, key := range assetContentKeys {
+				add(mapString(content, key))
+			}
+			for _, item := range mapList(content, "images") {
+				add(mapString(item, "src"))
+			}
+		}
+	}
+
+	assets := make([]string, 0, len(seen))
+	for ref := range seen {
+		assets = append(assets, ref)
+	}
+	sort.Strings(assets)
+	return assets
+}
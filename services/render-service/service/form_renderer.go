@@ -0,0 +1,72 @@
+package service
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"wz-backend-go/internal/pkg/forms"
+)
+
+func init() {
+	RegisterComponentRenderer("form", ComponentRendererFunc(renderForm))
+}
+
+// formHTML 表单组件模板，文件字段需要multipart提交；蜜罐字段对用户隐藏，机器人填写后提交会被丢弃
+var formHTML = template.Must(template.New("form").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<form class="site-form" method="post" action="{{ .Action }}" enctype="multipart/form-data">
+{{- with .Title }}<h3 class="form-title">{{ . }}</h3>{{ end }}
+{{- with .Description }}<p class="form-description">{{ . }}</p>{{ end }}
+{{- range .Fields }}
+<div class="form-field form-field-{{ .Type }}">
+<label for="{{ $.ID }}-{{ .Name }}">{{ .Label }}{{ if .Required }} *{{ end }}</label>
+{{- if eq .Type "select" }}
+<select id="{{ $.ID }}-{{ .Name }}" name="{{ .Name }}"{{ if .Required }} required{{ end }}>{{ if not .Required }}<option value=""></option>{{ end }}{{ range .Options }}<option value="{{ . }}">{{ . }}</option>{{ end }}</select>
+{{- else if eq .Type "file" }}
+<input type="file" id="{{ $.ID }}-{{ .Name }}" name="{{ .Name }}"{{ with .Accept }} accept="{{ join . "," }}"{{ end }}{{ if .Required }} required{{ end }}>
+{{- else if .Multiline }}
+<textarea id="{{ $.ID }}-{{ .Name }}" name="{{ .Name }}" rows="4"{{ with .Placeholder }} placeholder="{{ . }}"{{ end }}{{ with .MaxLength }} maxlength="{{ . }}"{{ end }}{{ if .Required }} required{{ end }}></textarea>
+{{- else }}
+<input type="{{ if eq .Type "email" }}email{{ else if eq .Type "phone" }}tel{{ else }}text{{ end }}" id="{{ $.ID }}-{{ .Name }}" name="{{ .Name }}"{{ with .Placeholder }} placeholder="{{ . }}"{{ end }}{{ with .MaxLength }} maxlength="{{ . }}"{{ end }}{{ if .Required }} required{{ end }}>
+{{- end }}
+</div>
+{{- end }}
+<div style="position: absolute; left: -10000px;" aria-hidden="true"><input type="text" name="{{ .Honeypot }}" tabindex="-1" autocomplete="off"></div>
+{{- with .Unavailable }}<p class="form-unavailable">{{ . }}</p>{{ end }}
+<button type="submit"{{ if .Preview }} disabled title="预览模式下不能提交"{{ else if .Unavailable }} disabled{{ end }}>{{ .SubmitText }}</button>
+</form>`))
+
+// renderForm 渲染表单组件，提交到渲染服务的公开表单接口。静态导出的页面提交到渲染服务对外的地址，
+// 未配置该地址时表单不能提交，显示提示
+func renderForm(data ComponentData) (template.HTML, error) {
+	fields, err := forms.ParseFields(data.Content)
+	if err != nil {
+		return "", err
+	}
+
+	action, unavailable := FormActionURL(data.SiteID, data.ID), ""
+	if data.Mode == RenderModeExport {
+		if exportPublicURL == "" {
+			action, unavailable = "", "该表单需要在线提交，静态页面中暂不可用"
+		} else {
+			action = exportPublicURL + action
+		}
+	}
+
+	var buffer bytes.Buffer
+	err = formHTML.Execute(&buffer, map[string]interface{}{
+		"ID":          data.ID,
+		"Action":      action,
+		"Title":       mapString(data.Content, "title"),
+		"Description": mapString(data.Content, "description"),
+		"Fields":      fields,
+		"Honeypot":    forms.HoneypotField,
+		"SubmitText":  defaultString("提交", mapString(data.Settings, "submitText")),
+		"Preview":     data.Mode == RenderModePreview,
+		"Unavailable": unavailable,
+	})
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buffer.String()), nil
+}
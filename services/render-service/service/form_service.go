@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"wz-backend-go/api/rpc/notification"
+	"wz-backend-go/internal/pkg/forms"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+var (
+	// ErrFormNotFound 线上版本中没有该表单
+	ErrFormNotFound = errors.New("表单不存在")
+	// ErrTooManySubmissions 同一IP提交过于频繁
+	ErrTooManySubmissions = errors.New("提交过于频繁，请稍后再试")
+)
+
+// 每个IP最多连续提交formRateBurst次，之后每formRateInterval恢复一次
+const (
+	formRateBurst    = 5
+	formRateInterval = 12 * time.Second
+	// formLimiterIdle 超过该时间没有请求的键会被清理
+	formLimiterIdle = 10 * time.Minute
+	// formLimiterSweepSize 记录的键达到该数量时清理不活跃的键
+	formLimiterSweepSize = 1000
+)
+
+// FormInput 访客提交的表单内容
+type FormInput struct {
+	Values    map[string]string
+	Files     map[string]*multipart.FileHeader
+	IP        string
+	UserAgent string
+}
+
+// FormNotifier 发送表单提交通知
+type FormNotifier interface {
+	Notify(ctx context.Context, site models.Site, submission models.FormSubmission, recipients []int64) error
+}
+
+// rpcFormNotifier 通过通知服务的Send接口通知租户配置的接收人
+type rpcFormNotifier struct {
+	client notification.NotificationClient
+}
+
+// NewRPCFormNotifier 创建基于通知服务RPC的通知器
+func NewRPCFormNotifier(client notification.NotificationClient) FormNotifier {
+	return &rpcFormNotifier{client: client}
+}
+
+func (n *rpcFormNotifier) Notify(ctx context.Context, site models.Site, submission models.FormSubmission, recipients []int64) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"siteId":       submission.SiteID,
+		"componentId":  submission.ComponentID,
+		"submissionId": submission.ID,
+		"values":       submission.Values,
+	})
+	if err != nil {
+		return err
+	}
+
+	formName := submission.FormName
+	if formName == "" {
+		formName = "表单"
+	}
+	content := fmt.Sprintf("站点「%s」的%s收到新的提交", site.Name, formName)
+
+	var failed []string
+	for _, userID := range recipients {
+		resp, err := n.client.Send(ctx, &notification.SendRequest{
+			UserId:  userID,
+			Type:    "form_submission",
+			Content: content,
+			Data:    string(data),
+		})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%d: %v", userID, err))
+		} else if resp.Code != 0 {
+			failed = append(failed, fmt.Sprintf("%d: %s", userID, resp.Msg))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("发送通知失败: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// 表单提交的文件目录和通知器，由main在启动时设置
+var (
+	formUploadDir = filepath.Join("uploads", "forms")
+	formNotifier  FormNotifier
+	formLimiter   = newKeyLimiter(formRateInterval, formRateBurst)
+)
+
+// SetFormUploadDir 设置表单文件的保存目录
+func SetFormUploadDir(dir string) {
+	formUploadDir = dir
+}
+
+// SetFormNotifier 设置表单提交通知器，未设置时不发送通知
+func SetFormNotifier(notifier FormNotifier) {
+	formNotifier = notifier
+}
+
+// FormActionURL 表单的提交地址
+func FormActionURL(siteID string, componentID string) string {
+	return "/render/sites/" + siteID + "/forms/" + componentID
+}
+
+// SubmitForm 校验并保存访客对线上表单的提交，返回表单设置的成功提示。
+// 蜜罐字段被填写时直接返回成功，不保存也不通知
+func SubmitForm(siteID string, componentID string, input FormInput) (string, error) {
+	if !formLimiter.Allow(input.IP) {
+		return "", ErrTooManySubmissions
+	}
+
+	site, err := store.GetPublishedSite(siteID)
+	if err != nil {
+		return "", ErrFormNotFound
+	}
+	pageID, component, ok := findFormComponent(site, componentID)
+	if !ok {
+		return "", ErrFormNotFound
+	}
+
+	settings := toMap(component.Settings)
+	successMessage := defaultString("提交成功", mapString(settings, "successMessage"))
+	if input.Values[forms.HoneypotField] != "" {
+		return successMessage, nil
+	}
+
+	fields, err := forms.ParseFields(component.Content)
+	if err != nil {
+		return "", err
+	}
+	uploads := map[string]forms.Upload{}
+	for name, header := range input.Files {
+		uploads[name] = forms.Upload{Name: header.Filename, Size: header.Size}
+	}
+	if fieldErrors := forms.ValidateSubmission(fields, input.Values, uploads); len(fieldErrors) > 0 {
+		return "", &jsonschema.ValidationError{Errors: fieldErrors}
+	}
+
+	submission := models.FormSubmission{
+		ID:          uuid.NewString(),
+		SiteID:      siteID,
+		PageID:      pageID,
+		ComponentID: componentID,
+		FormName:    mapString(toMap(component.Content), "title"),
+		Values:      map[string]string{},
+		IP:          input.IP,
+		UserAgent:   input.UserAgent,
+		CreatedAt:   time.Now(),
+	}
+	if err := saveFormSubmission(&submission, fields, input); err != nil {
+		// 文件都保存在本次提交的目录中，保存失败时删除整个目录，不留下没有提交记录的文件
+		if removeErr := os.RemoveAll(filepath.Join(formUploadDir, submission.SiteID, submission.ID)); removeErr != nil {
+			log.Printf("删除表单提交%s的文件失败: %v", submission.ID, removeErr)
+		}
+		return "", err
+	}
+
+	go notifyFormSubmission(site, submission)
+	return successMessage, nil
+}
+
+// saveFormSubmission 按字段保存提交的值和文件，最后保存提交记录
+func saveFormSubmission(submission *models.FormSubmission, fields []forms.Field, input FormInput) error {
+	for _, field := range fields {
+		if field.Type == forms.FieldFile {
+			header, ok := input.Files[field.Name]
+			if !ok {
+				continue
+			}
+			file, err := saveFormFile(*submission, field.Name, header)
+			if err != nil {
+				return err
+			}
+			submission.Files = append(submission.Files, file)
+			continue
+		}
+		if value := strings.TrimSpace(input.Values[field.Name]); value != "" {
+			submission.Values[field.Name] = value
+		}
+	}
+	return store.FormSubmissions.Create(submission)
+}
+
+// findFormComponent 在站点树中查找表单组件及其所在页面，全局区块中的表单没有页面
+func findFormComponent(site models.Site, componentID string) (string, models.Component, bool) {
+	pageID, component, ok := findPublishedComponent(site, componentID)
+	if !ok || component.Type != "form" {
+		return "", models.Component{}, false
+	}
+	return pageID, component, true
+}
+
+// saveFormFile 将上传的文件保存到 上传目录/站点ID/提交ID/字段名+扩展名
+func saveFormFile(submission models.FormSubmission, field string, header *multipart.FileHeader) (models.FormFile, error) {
+	storedName := filepath.Join(submission.SiteID, submission.ID, field+strings.ToLower(filepath.Ext(header.Filename)))
+	path := filepath.Join(formUploadDir, storedName)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return models.FormFile{}, err
+	}
+
+	src, err := header.Open()
+	if err != nil {
+		return models.FormFile{}, err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return models.FormFile{}, err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return models.FormFile{}, err
+	}
+
+	return models.FormFile{
+		Field:       field,
+		Name:        filepath.Base(header.Filename),
+		Size:        header.Size,
+		ContentType: header.Header.Get("Content-Type"),
+		StoredName:  filepath.ToSlash(storedName),
+	}, nil
+}
+
+// notifyFormSubmission 通知租户配置的接收人，失败只记录日志
+func notifyFormSubmission(site models.Site, submission models.FormSubmission) {
+	if formNotifier == nil {
+		return
+	}
+	settings, err := store.FormSettings.Get(site.TenantID)
+	if err != nil || len(settings.Recipients) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := formNotifier.Notify(ctx, site, submission, settings.Recipients); err != nil {
+		log.Printf("表单提交%s通知失败: %v", submission.ID, err)
+	}
+}
+
+// keyLimiter 按IP或预览链接等键限制表单提交和预览密码尝试的频率，
+// 每个键最多连续允许burst次，之后每interval恢复一次
+type keyLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	limiters map[string]*keyLimiterEntry
+}
+
+type keyLimiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newKeyLimiter(interval time.Duration, burst int) *keyLimiter {
+	return &keyLimiter{interval: interval, burst: burst, limiters: map[string]*keyLimiterEntry{}}
+}
+
+// Allow 判断该键本次请求是否允许
+func (l *keyLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := l.limiters[key]
+	if !ok {
+		if len(l.limiters) >= formLimiterSweepSize {
+			for key, idle := range l.limiters {
+				if now.Sub(idle.lastSeen) > formLimiterIdle {
+					delete(l.limiters, key)
+				}
+			}
+		}
+		entry = &keyLimiterEntry{limiter: rate.NewLimiter(rate.Every(l.interval), l.burst)}
+		l.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.AllowN(now, 1)
+}
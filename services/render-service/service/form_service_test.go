@@ -0,0 +1,135 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// useMemoryStore 测试期间使用写入了演示数据的内存存储
+func useMemoryStore(t *testing.T) *builder.Store {
+	t.Helper()
+	memory := builder.NewMemoryStore()
+	if err := builder.SeedDemoData(memory); err != nil {
+		t.Fatalf("写入演示数据失败: %v", err)
+	}
+	SetStore(memory)
+	t.Cleanup(func() { SetStore(nil) })
+	return memory
+}
+
+// useFormSite 在演示站点中添加包含文件字段的表单并发布，上传目录使用临时目录
+func useFormSite(t *testing.T) *builder.Store {
+	t.Helper()
+	memory := useMemoryStore(t)
+	form := models.Component{
+		ID:        "contact-form",
+		SectionID: "section1",
+		Type:      "form",
+		Content: map[string]interface{}{
+			"title": "联系我们",
+			"fields": []interface{}{
+				map[string]interface{}{"name": "name", "label": "姓名", "type": "text", "required": true},
+				map[string]interface{}{"name": "resume", "label": "简历", "type": "file", "accept": []interface{}{".pdf"}},
+			},
+		},
+	}
+	if err := memory.Components.Create(&form); err != nil {
+		t.Fatalf("添加表单失败: %v", err)
+	}
+	if _, err := memory.PublishSite("1", "tester", ""); err != nil {
+		t.Fatalf("发布站点失败: %v", err)
+	}
+
+	previous := formUploadDir
+	SetFormUploadDir(t.TempDir())
+	t.Cleanup(func() { SetFormUploadDir(previous) })
+	return memory
+}
+
+// uploadedFile 构造multipart上传的文件
+func uploadedFile(t *testing.T, field string, name string, content string) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile(field, name)
+	if err != nil {
+		t.Fatalf("构造上传文件失败: %v", err)
+	}
+	part.Write([]byte(content))
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatalf("解析上传文件失败: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File[field][0]
+}
+
+// uploadedFiles 上传目录中的所有文件
+func uploadedFiles(t *testing.T) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(formUploadDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("读取上传目录失败: %v", err)
+	}
+	return files
+}
+
+// failingSubmissions 保存提交记录总是失败的仓储
+type failingSubmissions struct {
+	builder.FormSubmissionRepository
+}
+
+func (failingSubmissions) Create(*models.FormSubmission) error {
+	return errors.New("写入失败")
+}
+
+func TestSubmitFormSavesFiles(t *testing.T) {
+	memory := useFormSite(t)
+	input := FormInput{
+		Values: map[string]string{"name": "张三"},
+		Files:  map[string]*multipart.FileHeader{"resume": uploadedFile(t, "resume", "resume.pdf", "%PDF-1.4")},
+		IP:     "203.0.113.10",
+	}
+	if _, err := SubmitForm("1", "contact-form", input); err != nil {
+		t.Fatalf("提交表单失败: %v", err)
+	}
+
+	submissions, err := memory.FormSubmissions.ListBySite("1", "contact-form")
+	if err != nil || len(submissions) != 1 {
+		t.Fatalf("应保存一条提交: %v %d", err, len(submissions))
+	}
+	if len(submissions[0].Files) != 1 || len(uploadedFiles(t)) != 1 {
+		t.Fatalf("应保存上传的文件: %+v", submissions[0].Files)
+	}
+}
+
+func TestSubmitFormRemovesFilesWhenSaveFails(t *testing.T) {
+	memory := useFormSite(t)
+	memory.FormSubmissions = failingSubmissions{memory.FormSubmissions}
+
+	input := FormInput{
+		Values: map[string]string{"name": "李四"},
+		Files:  map[string]*multipart.FileHeader{"resume": uploadedFile(t, "resume", "resume.pdf", "%PDF-1.4")},
+		IP:     "203.0.113.11",
+	}
+	if _, err := SubmitForm("1", "contact-form", input); err == nil {
+		t.Fatal("保存提交记录失败时应返回错误")
+	}
+	if files := uploadedFiles(t); len(files) != 0 {
+		t.Fatalf("保存失败时不应留下文件: %v", files)
+	}
+}
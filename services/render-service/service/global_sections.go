@@ -0,0 +1,42 @@
+package service
+
+import (
+	"log"
+	"wz-backend-go/internal/pkg/globalsection"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/models"
+)
+
+// resolvePageSections 用站点的全局区块替换页面中的引用，引用不存在或循环的区块不渲染
+func resolvePageSections(site models.Site, page models.Page) models.Page {
+	sections := make([]models.Section, 0, len(page.Sections))
+	for _, section := range page.Sections {
+		resolved, err := globalsection.ResolveSection(site.GlobalSections, section)
+		if err != nil {
+			log.Printf("站点%s页面%s的区块%s无法解析: %v", site.ID, page.ID, section.ID, err)
+			continue
+		}
+		sections = append(sections, resolved)
+	}
+	page.Sections = sections
+	return page
+}
+
+// siteSlotSection 获取作为页头或页脚的全局区块在指定语言下的内容，未设置或无法解析时返回nil，使用默认页头页脚
+func siteSlotSection(site models.Site, sectionID string, locale string) *models.Section {
+	if sectionID == "" {
+		return nil
+	}
+	section, ok := globalsection.Find(site.GlobalSections, sectionID)
+	if !ok {
+		return nil
+	}
+	target, err := globalsection.Target(site.GlobalSections, section)
+	if err != nil {
+		log.Printf("站点%s的全局区块%s无法解析: %v", site.ID, sectionID, err)
+		return nil
+	}
+	target.ID = section.ID
+	localized := sitelocale.LocalizeSection(target, locale)
+	return &localized
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"wz-backend-go/internal/pkg/media"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/models"
+)
+
+// mediaWebP 媒体服务配置了WebP编码器时为true，图片组件同时输出WebP版本
+var mediaWebP bool
+
+// SetMediaWebP 设置媒体服务是否提供WebP版本
+func SetMediaWebP(enabled bool) {
+	mediaWebP = enabled
+}
+
+// imageTemplate 图片组件模板。图片来自媒体库时输出缩放版本的srcset和原图尺寸，
+// 媒体服务提供WebP时用picture元素优先使用WebP版本
+var imageTemplate = template.Must(template.New("image").Funcs(componentFuncs).Parse(
+	`{{ if .WebPSrcSet }}<picture><source type="image/webp" srcset="{{ .WebPSrcSet }}" sizes="{{ .Sizes }}">{{ end }}` +
+		`<img src="{{ str .Content "src" }}" alt="{{ str .Content "alt" }}"` +
+		`{{ with .SrcSet }} srcset="{{ . }}" sizes="{{ $.Sizes }}"{{ end }}` +
+		`{{ if .Width }} width="{{ .Width }}" height="{{ .Height }}"{{ end }}` +
+		` style="max-width: 100%; width: {{ default "100%" (str .Settings "width") }};{{ if .Width }} height: auto;{{ end }} object-fit: {{ default "cover" (str .Settings "objectFit") }};">` +
+		`{{ if .WebPSrcSet }}</picture>{{ end }}`))
+
+// imageData 图片组件模板的数据
+type imageData struct {
+	ComponentData
+	SrcSet     string
+	WebPSrcSet string
+	Sizes      string
+	Width      int
+	Height     int
+}
+
+// renderImage 渲染图片组件。content.mediaId为媒体库文件ID且src是该文件的地址时，
+// 按媒体服务的缩放版本生成srcset；其他图片按src原样输出。
+// 静态导出时站内地址的图片只把原图写入导出包，不生成srcset
+func renderImage(data ComponentData) (template.HTML, error) {
+	image := imageData{ComponentData: data}
+	src := mapString(data.Content, "src")
+	if asset, ok := imageAsset(data); ok && !(data.Mode == RenderModeExport && isSiteAsset(src)) {
+		image.Width, image.Height = asset.Width, asset.Height
+		if len(media.Widths(asset.Width)) > 0 {
+			image.SrcSet = mediaSrcSet(src, asset, media.SourceFormat(asset.ContentType))
+			if mediaWebP {
+				image.WebPSrcSet = mediaSrcSet(src, asset, media.FormatWebP)
+			}
+			image.Sizes = imageSizes(mapString(data.Settings, "width"))
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := imageTemplate.Execute(&buffer, image); err != nil {
+		return "", err
+	}
+	return template.HTML(buffer.String()), nil
+}
+
+// imageAsset 查找图片组件引用的媒体库文件，文件必须属于站点所在的租户且能生成缩放版本
+func imageAsset(data ComponentData) (models.MediaAsset, bool) {
+	mediaID := mapString(data.Content, "mediaId")
+	src := mapString(data.Content, "src")
+	if mediaID == "" || !strings.HasSuffix(src, "/"+mediaID) || store == nil {
+		return models.MediaAsset{}, false
+	}
+	asset, err := store.Media.GetByID(mediaID)
+	if err != nil || !media.Resizable(asset.ContentType, asset.Width, asset.Height) {
+		return models.MediaAsset{}, false
+	}
+	site, err := store.Sites.Get(data.SiteID)
+	if err != nil || site.TenantID != asset.TenantID {
+		return models.MediaAsset{}, false
+	}
+	return asset, true
+}
+
+// mediaSrcSet 生成srcset：比原图窄的缩放版本，格式与原图相同时再加上原图本身
+func mediaSrcSet(src string, asset models.MediaAsset, format string) string {
+	var candidates []string
+	for _, width := range media.Widths(asset.Width) {
+		candidates = append(candidates, fmt.Sprintf("%s/%s %dw", src, media.VariantName(width, format), width))
+	}
+	if format == media.SourceFormat(asset.ContentType) {
+		candidates = append(candidates, fmt.Sprintf("%s %dw", src, asset.Width))
+	}
+	return strings.Join(candidates, ", ")
+}
+
+// imageSizes 按图片组件的宽度设置生成sizes：像素宽度直接使用，百分比按视口宽度计算，其他情况为整个视口宽度
+func imageSizes(width string) string {
+	width = strings.TrimSpace(width)
+	switch {
+	case strings.HasSuffix(width, "px") && sitetheme.CheckDeclaration("width", width) == nil:
+		return width
+	case strings.HasSuffix(width, "%") && sitetheme.CheckDeclaration("width", width) == nil:
+		return strings.TrimSuffix(width, "%") + "vw"
+	}
+	return "100vw"
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"bytes"
+	"html/template"
+	"wz-backend-go/internal/pkg/sitenav"
+	"wz-backend-go/models"
+)
+
+// navigationTemplate 站点导航模板，三种导航类型的结构相同，展开方式由主题样式表中的.site-nav-<类型>规则决定。
+// 超级菜单的第二级为分组，第三级为分组内的链接；外部链接在新窗口打开
+var navigationTemplate = template.Must(template.New("navigation").Parse(`
+{{- define "link" -}}
+{{ if .Href }}<a href="{{ .Href }}"{{ if .External }} class="nav-external" target="_blank" rel="noopener noreferrer"{{ end }}>{{ template "label" . }}</a>{{ else }}<span class="nav-label">{{ template "label" . }}</span>{{ end }}
+{{- end -}}
+{{- define "label" -}}
+{{ with .Icon }}<i class="nav-icon {{ . }}" aria-hidden="true"></i> {{ end }}{{ .Label }}
+{{- end -}}
+{{- define "item" -}}
+<li class="nav-item{{ if .Children }} has-children{{ end }}">{{ template "link" . }}{{ with .Children }}<ul class="nav-submenu">{{ range . }}{{ template "item" . }}{{ end }}</ul>{{ end }}</li>
+{{- end -}}
+{{- define "mega" -}}
+<li class="nav-item{{ if .Children }} has-children{{ end }}">{{ template "link" . }}{{ with .Children }}<div class="nav-mega">{{ range . }}<div class="nav-mega-column">{{ template "link" . }}{{ with .Children }}<ul>{{ range . }}<li>{{ template "link" . }}</li>{{ end }}</ul>{{ end }}</div>{{ end }}</div>{{ end }}</li>
+{{- end -}}
+<nav class="site-nav site-nav-{{ .Type }}"{{ with .Style }} style="{{ . }}"{{ end }}>
+<ul class="nav-menu">{{ if eq .Type "mega-menu" }}{{ range .Links }}{{ template "mega" . }}{{ end }}{{ else }}{{ range .Links }}{{ template "item" . }}{{ end }}{{ end }}</ul>
+</nav>`))
+
+// renderNavigation 按站点的导航类型渲染导航，pageHref生成指向页面的链接；没有导航项时返回空
+func renderNavigation(site models.Site, pageHref func(models.Page) string) (template.HTML, error) {
+	return executeNavigation(site.Navigation, sitenav.Resolve(site, pageHref))
+}
+
+// renderPageNavigation 站点没有配置导航时为每个页面生成一个导航项，用于预览时在页面之间切换
+func renderPageNavigation(site models.Site, pageHref func(models.Page) string) (template.HTML, error) {
+	if len(site.Navigation.Items) > 0 {
+		return renderNavigation(site, pageHref)
+	}
+	links := make([]sitenav.Link, 0, len(site.Pages))
+	for _, page := range site.Pages {
+		links = append(links, sitenav.Link{ID: page.ID, Label: page.Name, Href: pageHref(page)})
+	}
+	return executeNavigation(site.Navigation, links)
+}
+
+// executeNavigation 执行导航模板，未知的导航类型按横向菜单渲染
+func executeNavigation(navigation models.Navigation, links []sitenav.Link) (template.HTML, error) {
+	if len(links) == 0 {
+		return "", nil
+	}
+	navigationType := navigation.Type
+	if navigationType == "" || !sitenav.ValidType(navigationType) {
+		navigationType = sitenav.TypeHorizontal
+	}
+
+	var buffer bytes.Buffer
+	err := navigationTemplate.Execute(&buffer, map[string]interface{}{
+		"Type":  navigationType,
+		"Style": inlineStyle(toMap(navigation.Style)),
+		"Links": links,
+	})
+	if err != nil {
+		return "", err
+	}
+	return template.HTML(buffer.String()), nil
+}
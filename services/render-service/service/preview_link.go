@@ -0,0 +1,140 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"wz-backend-go/internal/pkg/previewtoken"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrPreviewLinkInvalid 预览链接签名错误、已过期或已被撤销
+	ErrPreviewLinkInvalid = errors.New("预览链接无效、已过期或已被撤销")
+	// ErrPreviewPasswordWrong 预览密码错误
+	ErrPreviewPasswordWrong = errors.New("密码错误")
+	// ErrTooManyPasswordAttempts 同一IP或同一链接尝试密码过于频繁
+	ErrTooManyPasswordAttempts = errors.New("尝试次数过多，请稍后再试")
+)
+
+// 每个链接的密码最多连续尝试previewLinkRateBurst次，之后每previewLinkRateInterval恢复一次。
+// 按IP的限制可以通过更换IP绕过，按链接的限制保证单个链接的密码不会被快速穷举
+const (
+	previewLinkRateBurst    = 20
+	previewLinkRateInterval = time.Minute
+)
+
+// 预览令牌的签名密钥和密码尝试的频率限制，密钥由main在启动时设置，需要与站点服务一致
+var (
+	previewSecret              []byte
+	previewPasswordLimiter     = newKeyLimiter(formRateInterval, formRateBurst)
+	previewLinkPasswordLimiter = newKeyLimiter(previewLinkRateInterval, previewLinkRateBurst)
+)
+
+// SetPreviewSecret 设置预览令牌的签名密钥
+func SetPreviewSecret(secret []byte) {
+	previewSecret = secret
+}
+
+// OpenPreviewLink 校验预览令牌并获取对应的链接记录，令牌内容必须与记录一致
+func OpenPreviewLink(token string) (models.PreviewToken, error) {
+	claims, err := previewtoken.Parse(previewSecret, token)
+	if err != nil {
+		return models.PreviewToken{}, ErrPreviewLinkInvalid
+	}
+	link, err := store.PreviewTokens.Get(claims.SiteID, claims.ID)
+	if err != nil || link.RevokedAt != nil || !time.Now().Before(link.ExpiresAt) {
+		return models.PreviewToken{}, ErrPreviewLinkInvalid
+	}
+	if link.PageID != claims.PageID || link.Version != claims.Version || link.ExpiresAt.Unix() != claims.ExpiresAt {
+		return models.PreviewToken{}, ErrPreviewLinkInvalid
+	}
+	return link, nil
+}
+
+// PreviewLinkUnlocked 判断访客能否查看预览，设置了密码的链接需要Cookie中有密码凭证
+func PreviewLinkUnlocked(link models.PreviewToken, proof string) bool {
+	if link.PasswordHash == "" {
+		return true
+	}
+	return previewtoken.CheckPasswordProof(previewSecret, link.ID, link.PasswordHash, proof)
+}
+
+// UnlockPreviewLink 校验访客输入的预览密码，正确时返回保存在Cookie中的凭证
+func UnlockPreviewLink(link models.PreviewToken, ip string, password string) (string, error) {
+	if link.PasswordHash == "" {
+		return "", nil
+	}
+	if !previewPasswordLimiter.Allow(ip) || !previewLinkPasswordLimiter.Allow(link.ID) {
+		return "", ErrTooManyPasswordAttempts
+	}
+	if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+		return "", ErrPreviewPasswordWrong
+	}
+	return previewtoken.PasswordProof(previewSecret, link.ID, link.PasswordHash), nil
+}
+
+// RenderPreviewLink 渲染预览链接指向的草稿或发布版本。
+// 链接绑定了页面时只能查看该页面，否则通过pageID切换页面，为空时显示首页
+func RenderPreviewLink(link models.PreviewToken, pageID string, locale string, device string) (string, error) {
+	if device != "tablet" && device != "mobile" {
+		device = "desktop"
+	}
+
+	var site models.Site
+	if link.Version > 0 {
+		version, err := store.Versions.Get(link.SiteID, link.Version)
+		if err != nil {
+			return "", ErrPreviewLinkInvalid
+		}
+		site = version.Snapshot
+	} else {
+		tree, err := GetSiteWithAllPages(link.SiteID)
+		if err != nil {
+			return "", ErrPreviewLinkInvalid
+		}
+		site = tree
+	}
+
+	if link.PageID != "" {
+		pageID = link.PageID
+	}
+	var page models.Page
+	var err error
+	if pageID == "" {
+		page, err = GetHomePage(site)
+	} else {
+		page, err = findPage(site, pageID)
+	}
+	if err != nil {
+		return "", ErrPageNotFound
+	}
+
+	if locale = sitelocale.Normalize(locale); !sitelocale.IsEnabled(site, locale) {
+		locale = sitelocale.DefaultLocale(site)
+	}
+
+	banner := "预览：尚未发布的草稿"
+	if link.Version > 0 {
+		banner = fmt.Sprintf("预览：发布版本v%d", link.Version)
+	}
+	banner += "，链接有效期至" + link.ExpiresAt.Format("2006-01-02 15:04")
+
+	return generatePreview(site, page, locale, device, previewOptions{
+		Banner:    banner,
+		PageLinks: link.PageID == "",
+	})
+}
+
+// findPage 按ID查找站点树中的页面
+func findPage(site models.Site, pageID string) (models.Page, error) {
+	for _, page := range site.Pages {
+		if page.ID == pageID {
+			return page, nil
+		}
+	}
+	return models.Page{}, ErrPageNotFound
+}
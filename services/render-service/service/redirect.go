@@ -0,0 +1,144 @@
+package service
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"wz-backend-go/internal/pkg/redirects"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+)
+
+// Redirect 命中的重定向，Location为站内路径或http(s)地址
+type Redirect struct {
+	Location   string
+	StatusCode int
+}
+
+// siteRedirects 站点编译后的重定向规则，以及匹配时需要的站点发布状态和语言设置
+type siteRedirects struct {
+	site    models.Site
+	manual  *redirects.Rules
+	history *redirects.Rules
+	stamp   string
+}
+
+// 编译后的重定向规则按站点缓存在进程内，是否有效由渲染缓存中的标记判断，
+// 规则、站点设置或线上版本变化时标记随渲染缓存一起被清除
+var (
+	redirectMu    sync.RWMutex
+	redirectSites = map[string]siteRedirects{}
+)
+
+// ResolveRedirect 在查找页面之前匹配已发布站点的重定向规则，命中时记录命中次数。
+// 手动添加的规则优先；Slug历史生成的规则只在线上版本中已没有旧地址的页面时生效，页面发布之前旧地址仍然可以访问。
+// 路径带语言前缀且没有规则匹配完整路径时，去掉前缀再匹配，站内目标加回同样的前缀
+func ResolveRedirect(siteID string, pagePath string) (Redirect, bool) {
+	set, err := loadSiteRedirects(siteID)
+	if err != nil {
+		log.Printf("读取站点%s的重定向规则失败: %v", siteID, err)
+		return Redirect{}, false
+	}
+	if set.site.Status != "published" || set.manual.Len()+set.history.Len() == 0 {
+		return Redirect{}, false
+	}
+
+	path := redirects.NormalizePath(pagePath)
+	rule, target, ok := matchLocalized(set.site, set.manual, path)
+	if !ok {
+		rule, target, ok = matchLocalized(set.site, set.history, path)
+		if ok && slugStillPublished(siteID, rule.Source) {
+			ok = false
+		}
+	}
+	if !ok {
+		return Redirect{}, false
+	}
+
+	if err := store.Redirects.RecordHit(siteID, rule.ID, time.Now()); err != nil {
+		log.Printf("记录重定向%s的命中失败: %v", rule.ID, err)
+	}
+	return Redirect{Location: target, StatusCode: rule.StatusCode}, true
+}
+
+// loadSiteRedirects 获取站点编译后的重定向规则。变更事件能通知到本服务时使用进程内缓存，
+// 标记不存在或与缓存的不同时重新读取站点和规则；标记已被其他实例写入时沿用，避免实例之间互相覆盖
+func loadSiteRedirects(siteID string) (siteRedirects, error) {
+	caching := renderCache != nil && cacheDrafts
+	key := rendercache.RedirectsKey(siteID)
+	var stamp string
+	if caching {
+		stamp, _ = renderCache.GetStamp(key)
+		redirectMu.RLock()
+		cached, ok := redirectSites[siteID]
+		redirectMu.RUnlock()
+		if ok && stamp != "" && cached.stamp == stamp {
+			return cached, nil
+		}
+	}
+
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return siteRedirects{}, err
+	}
+	rules, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return siteRedirects{}, err
+	}
+	var manual, history []models.SiteRedirect
+	for _, rule := range rules {
+		if rule.PageID == "" {
+			manual = append(manual, rule)
+		} else {
+			history = append(history, rule)
+		}
+	}
+	set := siteRedirects{
+		site:    models.Site{ID: site.ID, Status: site.Status, DefaultLocale: site.DefaultLocale, Locales: site.Locales},
+		manual:  redirects.Compile(manual),
+		history: redirects.Compile(history),
+	}
+
+	if caching {
+		if stamp == "" {
+			stamp = uuid.NewString()
+			renderCache.SetStamp(key, stamp)
+		}
+		set.stamp = stamp
+		redirectMu.Lock()
+		redirectSites[siteID] = set
+		redirectMu.Unlock()
+	}
+	return set, nil
+}
+
+// matchLocalized 匹配完整路径，不匹配且第一段是站点启用的语言时去掉语言前缀再匹配
+func matchLocalized(site models.Site, rules *redirects.Rules, path string) (models.SiteRedirect, string, bool) {
+	if rule, target, ok := rules.Match(path); ok {
+		return rule, target, true
+	}
+	prefix, rest, found := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	locale := sitelocale.Normalize(prefix)
+	if !found || locale == "" || !sitelocale.IsEnabled(site, locale) {
+		return models.SiteRedirect{}, "", false
+	}
+	rule, target, ok := rules.Match(rest)
+	if ok && strings.HasPrefix(target, "/") {
+		target = "/" + prefix + target
+	}
+	return rule, target, ok
+}
+
+// slugStillPublished 线上版本中是否仍有使用该地址的页面
+func slugStillPublished(siteID string, source string) bool {
+	published, err := GetPublishedSite(siteID)
+	if err != nil {
+		return false
+	}
+	_, err = GetPageBySlug(published, strings.TrimPrefix(source, "/"))
+	return err == nil
+}
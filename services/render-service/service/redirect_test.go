@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"wz-backend-go/internal/pkg/redirects"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// useRedirectStore 测试期间使用写入了演示数据的内存存储和订阅了变更事件的内存渲染缓存
+func useRedirectStore(t *testing.T) *builder.Store {
+	t.Helper()
+	memory := useMemoryStore(t)
+	cache := rendercache.New(rendercache.NewMemoryBackend(), time.Hour)
+	cache.Attach(memory)
+	SetRenderCache(cache, true)
+	t.Cleanup(func() {
+		SetRenderCache(nil, false)
+		redirectMu.Lock()
+		redirectSites = map[string]siteRedirects{}
+		redirectMu.Unlock()
+	})
+	return memory
+}
+
+func addRedirect(t *testing.T, memory *builder.Store, rule models.SiteRedirect) {
+	t.Helper()
+	rule.SiteID = "1"
+	if err := redirects.Normalize(&rule); err != nil {
+		t.Fatalf("无效的重定向规则: %v", err)
+	}
+	if err := memory.Redirects.Create(&rule); err != nil {
+		t.Fatalf("保存重定向规则失败: %v", err)
+	}
+}
+
+func TestResolveRedirectCachesCompiledRules(t *testing.T) {
+	memory := useRedirectStore(t)
+	addRedirect(t, memory, models.SiteRedirect{Source: "/old/*", Target: "/new/$1", MatchType: redirects.MatchWildcard})
+
+	redirect, ok := ResolveRedirect("1", "old/a/b")
+	if !ok || redirect.Location != "/new/a/b" || redirect.StatusCode != 301 {
+		t.Fatalf("通配规则应匹配: %+v %v", redirect, ok)
+	}
+	first := redirectSites["1"].manual
+
+	// 没有变更事件时沿用编译好的规则
+	addRedirect(t, memory, models.SiteRedirect{Source: "/legacy", Target: "/", MatchType: redirects.MatchPrefix})
+	if _, ok := ResolveRedirect("1", "legacy/x"); ok {
+		t.Fatal("没有变更事件时不应重新读取规则")
+	}
+	if redirectSites["1"].manual != first {
+		t.Fatal("规则集应只编译一次")
+	}
+
+	memory.NotifyRedirectsChanged("1")
+	redirect, ok = ResolveRedirect("1", "en/legacy/x")
+	if !ok || redirect.Location != "/en/x" {
+		t.Fatalf("重定向规则变化后应重新编译: %+v %v", redirect, ok)
+	}
+
+	rules, err := memory.Redirects.ListBySite("1")
+	if err != nil {
+		t.Fatalf("读取重定向规则失败: %v", err)
+	}
+	for _, rule := range rules {
+		if rule.Source == "/old/*" && rule.Hits != 1 {
+			t.Fatalf("命中次数应为1: %d", rule.Hits)
+		}
+	}
+}
+
+func TestResolveRedirectInvalidatedBySiteChanges(t *testing.T) {
+	memory := useRedirectStore(t)
+	addRedirect(t, memory, models.SiteRedirect{Source: "/old", Target: "/new"})
+	if _, ok := ResolveRedirect("1", "/old"); !ok {
+		t.Fatal("精确规则应匹配")
+	}
+
+	site, err := memory.Sites.Get("1")
+	if err != nil {
+		t.Fatalf("获取站点失败: %v", err)
+	}
+	site.Status = "draft"
+	if err := memory.Sites.Update(&site); err != nil {
+		t.Fatalf("修改站点失败: %v", err)
+	}
+	memory.Notify(builder.ChangeEvent{SiteID: "1", Scope: builder.ScopePublish})
+	if _, ok := ResolveRedirect("1", "/old"); ok {
+		t.Fatal("站点下线后重定向不应生效")
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/models"
+)
+
+var (
+	// ErrSiteNotPublished 站点不存在或没有线上版本
+	ErrSiteNotPublished = errors.New("站点不存在或未发布")
+	// ErrPageNotFound 线上版本中没有对应的页面
+	ErrPageNotFound = errors.New("页面不存在")
+)
+
+// 渲染结果缓存，由main在启动时设置，未设置时每次请求都重新渲染
+var (
+	renderCache *rendercache.Cache
+	cacheDrafts bool
+)
+
+// SetRenderCache 设置渲染缓存，drafts表示是否缓存草稿预览
+func SetRenderCache(cache *rendercache.Cache, drafts bool) {
+	renderCache = cache
+	cacheDrafts = drafts
+}
+
+// RenderPublishedPage 渲染站点线上版本的页面，结果按站点、版本、页面和语言缓存。
+// pagePath为页面slug，可以带语言前缀（如en/about），只有语言前缀时渲染该语言的首页；
+// 没有语言前缀时根据Cookie和Accept-Language选择语言
+func RenderPublishedPage(siteID string, pagePath string, localeCookie string, acceptLanguage string) (rendercache.Entry, error) {
+	site, err := store.Sites.Get(siteID)
+	if err != nil || site.Status != "published" {
+		return rendercache.Entry{}, ErrSiteNotPublished
+	}
+
+	locale, slug, ok := splitLocalePath(site, pagePath)
+	if !ok {
+		return rendercache.Entry{}, ErrPageNotFound
+	}
+	if locale == "" {
+		locale = sitelocale.Negotiate(site, localeCookie, acceptLanguage)
+	}
+
+	if renderCache != nil {
+		if entry, ok := renderCache.Get(rendercache.PublishedKey(siteID, site.PublishedVersion, slug, locale, "")); ok {
+			return entry, nil
+		}
+	}
+
+	published, err := GetPublishedSite(siteID)
+	if err != nil {
+		return rendercache.Entry{}, err
+	}
+	// 发布后又停用的语言使用默认语言
+	if !sitelocale.IsEnabled(published, locale) {
+		locale = sitelocale.DefaultLocale(published)
+	}
+
+	var page models.Page
+	if slug == "" {
+		page, err = GetHomePage(published)
+	} else {
+		page, err = GetPageBySlug(published, slug)
+		// 没有对应页面时，index和home指向首页
+		if err != nil && (slug == "index" || slug == "home") {
+			page, err = GetHomePage(published)
+		}
+	}
+	// 不在可见时间窗口内的页面按不存在处理
+	now := time.Now()
+	if err != nil || !PageVisible(page, now) {
+		return rendercache.Entry{}, ErrPageNotFound
+	}
+
+	html, err := GeneratePageHTML(published, page, locale)
+	if err != nil {
+		return rendercache.Entry{}, err
+	}
+
+	// 包含数据组件的页面内容随数据变化，修改时间使用渲染时间，缓存随数据一起过期
+	live := usesLiveData(published, page)
+	lastModified := now
+	if published.PublishedAt != nil && !live {
+		lastModified = *published.PublishedAt
+	}
+	entry := rendercache.NewEntry(html, lastModified)
+	if renderCache != nil {
+		var ttl time.Duration
+		if live {
+			ttl = dataSources.TTL()
+		}
+		// 设置了可见结束时间的页面最多缓存到结束时间
+		if page.VisibleUntil != nil {
+			if remaining := page.VisibleUntil.Sub(now); ttl == 0 || remaining < ttl {
+				ttl = remaining
+			}
+		}
+		// 使用实际渲染的版本号，避免并发发布时把新内容写到旧版本的键下
+		setRenderCache(rendercache.PublishedKey(siteID, published.PublishedVersion, slug, locale, ""), entry, ttl)
+	}
+	return entry, nil
+}
+
+// splitLocalePath 拆分页面路径中的语言前缀，前缀必须是站点启用的语言，
+// 单段路径与启用的语言相同时视为该语言的首页
+func splitLocalePath(site models.Site, pagePath string) (locale string, slug string, ok bool) {
+	parts := strings.SplitN(strings.Trim(pagePath, "/"), "/", 2)
+	prefix := sitelocale.Normalize(parts[0])
+	isLocale := prefix != "" && sitelocale.IsEnabled(site, prefix)
+	if len(parts) == 2 {
+		if !isLocale {
+			return "", "", false
+		}
+		return prefix, parts[1], true
+	}
+	if isLocale {
+		return prefix, "", true
+	}
+	return "", parts[0], true
+}
+
+// RenderPreviewPage 渲染站点草稿的预览页面，pageID为空时预览首页
+func RenderPreviewPage(siteID string, pageID string, locale string, device string) (rendercache.Entry, error) {
+	// 设备类型是缓存键的一部分，只接受已知的取值
+	if device != "tablet" && device != "mobile" {
+		device = "desktop"
+	}
+	if pageID == "" {
+		homepageID, err := findHomepageID(siteID)
+		if err != nil {
+			return rendercache.Entry{}, err
+		}
+		pageID = homepageID
+	}
+
+	// 语言也是缓存键的一部分，未启用的语言使用默认语言
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return rendercache.Entry{}, errors.New("站点不存在")
+	}
+	if locale = sitelocale.Normalize(locale); !sitelocale.IsEnabled(site, locale) {
+		locale = sitelocale.DefaultLocale(site)
+	}
+
+	key := rendercache.DraftKey(siteID, pageID, locale, device)
+	if renderCache != nil && cacheDrafts {
+		if entry, ok := renderCache.Get(key); ok {
+			return entry, nil
+		}
+	}
+
+	tree, page, err := GetSiteAndPage(siteID, pageID)
+	if err != nil {
+		return rendercache.Entry{}, err
+	}
+	html, err := GeneratePagePreview(tree, page, locale, device)
+	if err != nil {
+		return rendercache.Entry{}, err
+	}
+
+	entry := rendercache.NewEntry(html, time.Now())
+	if renderCache != nil && cacheDrafts {
+		var ttl time.Duration
+		if usesLiveData(tree, page) {
+			ttl = dataSources.TTL()
+		}
+		setRenderCache(key, entry, ttl)
+	}
+	return entry, nil
+}
+
+// setRenderCache 写入渲染缓存，ttl为0时使用缓存的默认保留时间。
+// 包含数据组件的页面只缓存到查询结果过期，有可见结束时间的页面只缓存到结束时间
+func setRenderCache(key string, entry rendercache.Entry, ttl time.Duration) {
+	if ttl == 0 {
+		renderCache.Set(key, entry)
+		return
+	}
+	if ttl > 0 {
+		renderCache.SetTTL(key, entry, ttl)
+	}
+}
+
+// findHomepageID 获取草稿中首页的ID
+func findHomepageID(siteID string) (string, error) {
+	pages, err := store.Pages.ListBySite(siteID)
+	if err != nil {
+		return "", err
+	}
+	for _, page := range pages {
+		if page.IsHomepage {
+			return page.ID, nil
+		}
+	}
+	return "", errors.New("找不到首页")
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"wz-backend-go/models"
+)
+
+func TestSplitLocalePath(t *testing.T) {
+	site := models.Site{Locales: []string{"zh-CN", "en", "pt-BR"}}
+	tests := []struct {
+		path   string
+		locale string
+		slug   string
+		ok     bool
+	}{
+		{"", "", "", true},
+		{"/", "", "", true},
+		{"about", "", "about", true},
+		{"/about/", "", "about", true},
+		{"en", "en", "", true},
+		{"/en/", "en", "", true},
+		{"en/about", "en", "about", true},
+		{"EN/about", "en", "about", true},
+		{"zh-cn/about", "zh-CN", "about", true},
+		{"pt_br/produtos/lista", "pt-BR", "produtos/lista", true},
+		{"en/docs/guide", "en", "docs/guide", true},
+		{"fr", "", "fr", true},
+		{"fr/about", "", "", false},
+		{"docs/guide", "", "", false},
+	}
+	for _, tt := range tests {
+		locale, slug, ok := splitLocalePath(site, tt.path)
+		if locale != tt.locale || slug != tt.slug || ok != tt.ok {
+			t.Errorf("%q 拆分为%q、%q、%v，期望%q、%q、%v", tt.path, locale, slug, ok, tt.locale, tt.slug, tt.ok)
+		}
+	}
+}
+
+func TestRenderPublishedPageLocale(t *testing.T) {
+	memory := useMemoryStore(t)
+	if _, err := memory.PublishSite("1", "tester", ""); err != nil {
+		t.Fatalf("发布站点失败: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		cookie         string
+		acceptLanguage string
+		lang           string
+		text           string
+	}{
+		{"默认语言", "", "", "", "zh-CN", "企业官网"},
+		{"语言前缀的首页", "en", "", "", "en", "Company Website"},
+		{"语言前缀的页面", "en/home", "", "", "en", "Company Website"},
+		{"路径优先于Cookie", "zh-CN", "en", "en", "zh-CN", "企业官网"},
+		{"Cookie", "home", "en", "zh-CN", "en", "Company Website"},
+		{"Accept-Language", "", "", "en-US,zh;q=0.5", "en", "Company Website"},
+		{"不支持的语言", "", "fr", "de", "zh-CN", "企业官网"},
+	}
+	for _, tt := range tests {
+		entry, err := RenderPublishedPage("1", tt.path, tt.cookie, tt.acceptLanguage)
+		if err != nil {
+			t.Errorf("%s: 渲染失败: %v", tt.name, err)
+			continue
+		}
+		if !strings.Contains(entry.HTML, `<html lang="`+tt.lang+`">`) || !strings.Contains(entry.HTML, tt.text) {
+			t.Errorf("%s: 页面应使用%s并包含%q", tt.name, tt.lang, tt.text)
+		}
+	}
+
+	// 未启用的语言前缀不会被当作页面路径
+	for _, path := range []string{"fr/home", "en/missing"} {
+		if _, err := RenderPublishedPage("1", path, "", ""); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("%s 应当返回ErrPageNotFound，得到%v", path, err)
+		}
+	}
+}
@@ -5,216 +5,71 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"net/url"
 	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/responsive"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/internal/pkg/sitenav"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/models"
 )
 
-// 模拟站点数据
-var sites = []models.Site{
-	{
-		ID:          "1",
-		Name:        "企业展示站点",
-		Description: "适合企业官网使用的模板",
-		Domain:      "company.wanzhimarket.com",
-		Logo:        "/img/logo1.png",
-		Favicon:     "/img/favicon1.ico",
-		TenantID:    "tenant_456",
-		Theme: models.ThemeConfig{
-			PrimaryColor:    "#FF5722",
-			SecondaryColor:  "#2196F3",
-			AccentColor:     "#4CAF50",
-			TextColor:       "#333333",
-			BackgroundColor: "#FFFFFF",
-			FontFamily:      "Arial, sans-serif",
-			HeaderStyle:     "standard",
-			BorderRadius:    "medium",
-			CustomCSS:       "",
-		},
-		Navigation: models.Navigation{
-			Type:  "horizontal",
-			Items: []models.NavigationItem{},
-			Style: map[string]string{},
-		},
-		Status: "published",
-	},
-}
+// 站点构建器的共享存储，由main在启动时设置
+var store *builder.Store
 
-// 模拟页面数据
-var pages = map[string][]models.Page{
-	"1": {
-		{
-			ID:          "page1",
-			SiteID:      "1",
-			Name:        "首页",
-			Slug:        "home",
-			Title:       "企业官网首页",
-			Description: "欢迎访问我们的企业官网",
-			Keywords:    []string{"企业", "官网", "首页"},
-			IsHomepage:  true,
-			Layout:      "default",
-			Sections:    []models.Section{},
-		},
-		{
-			ID:          "page2",
-			SiteID:      "1",
-			Name:        "关于我们",
-			Slug:        "about",
-			Title:       "关于我们 - 企业官网",
-			Description: "了解我们的企业文化和团队",
-			Keywords:    []string{"关于", "企业文化", "团队"},
-			IsHomepage:  false,
-			Layout:      "default",
-			Sections:    []models.Section{},
-		},
-	},
+// SetStore 设置数据存储
+func SetStore(s *builder.Store) {
+	store = s
 }
 
-// 区块数据
-var sections = map[string][]models.Section{
-	"page1": {
-		{
-			ID:         "section1",
-			PageID:     "page1",
-			Type:       "header",
-			Title:      "页面头部",
-			Settings:   map[string]interface{}{"backgroundColor": "#f5f5f5"},
-			Components: []models.Component{},
-			Style:      map[string]interface{}{"padding": "20px"},
-			SortOrder:  0,
-		},
-		{
-			ID:         "section2",
-			PageID:     "page1",
-			Type:       "content",
-			Title:      "主要内容",
-			Settings:   map[string]interface{}{"columns": 1},
-			Components: []models.Component{},
-			Style:      map[string]interface{}{"margin": "20px 0"},
-			SortOrder:  1,
-		},
-	},
-}
+// 页面模板在启动时解析，renderComponent和预览的区块函数在每次渲染时替换为对应模式的实现
+var (
+	previewTemplate = template.Must(template.New("preview").Funcs(templateFuncs).Funcs(sectionFuncs("")).Parse(previewPageHTML))
+	pageTemplate    = template.Must(template.New("page").Funcs(templateFuncs).Funcs(sectionFuncs("")).Parse(publicPageHTML))
+)
 
-// 组件数据
-var components = map[string][]models.Component{
-	"section1": {
-		{
-			ID:        "comp1",
-			SectionID: "section1",
-			Type:      "heading",
-			Name:      "网站标题",
-			Settings: map[string]interface{}{
-				"level":     "h1",
-				"textAlign": "center",
-			},
-			Content: map[string]interface{}{
-				"text": "企业官网",
-			},
-			Style: map[string]interface{}{
-				"marginBottom": "20px",
-			},
-			SortOrder: 0,
-		},
-		{
-			ID:        "comp2",
-			SectionID: "section1",
-			Type:      "text",
-			Name:      "欢迎文本",
-			Settings: map[string]interface{}{
-				"textAlign": "center",
-				"fontSize":  "18px",
-			},
-			Content: map[string]interface{}{
-				"text": "欢迎访问我们的企业官网",
-			},
-			Style: map[string]interface{}{
-				"color": "#666",
-			},
-			SortOrder: 1,
-		},
+// templateFuncs 页面模板使用的函数
+var templateFuncs = template.FuncMap{
+	"join": strings.Join,
+	"renderComponent": func(component models.Component) (template.HTML, error) {
+		return "", nil
 	},
-	"section2": {
-		{
-			ID:        "comp3",
-			SectionID: "section2",
-			Type:      "image",
-			Name:      "宣传图片",
-			Settings: map[string]interface{}{
-				"width":     "100%",
-				"objectFit": "cover",
-			},
-			Content: map[string]interface{}{
-				"src": "/img/banner.jpg",
-				"alt": "企业宣传图",
-			},
-			Style: map[string]interface{}{
-				"borderRadius": "8px",
-			},
-			SortOrder: 0,
-		},
+	"navigation": func() (template.HTML, error) {
+		return "", nil
 	},
 }
 
-// 模拟站点租户权限
-var siteTenants = map[string]string{
-	"1": "tenant_456",
-}
-
 // CheckSiteAccess 检查站点访问权限
 func CheckSiteAccess(siteID string, tenantID string) bool {
-	if tenant, exists := siteTenants[siteID]; exists {
-		return tenant == tenantID
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return false
 	}
-	return false
+	return site.TenantID == tenantID
 }
 
 // GetSiteWithAllPages 获取站点及其所有页面
 func GetSiteWithAllPages(siteID string) (models.Site, error) {
-	for _, site := range sites {
-		if site.ID == siteID {
-			// 找到站点后，加载所有页面
-			if sitePages, exists := pages[siteID]; exists {
-				// 加载页面中的区块和组件
-				for i, page := range sitePages {
-					if pageSections, exists := sections[page.ID]; exists {
-						// 加载每个区块的组件
-						for j, section := range pageSections {
-							if sectionComponents, exists := components[section.ID]; exists {
-								pageSections[j].Components = sectionComponents
-							}
-						}
-						sitePages[i].Sections = pageSections
-					}
-				}
-				site.Pages = sitePages
-			}
-			return site, nil
-		}
+	site, err := store.LoadSiteTree(siteID)
+	if err != nil {
+		return models.Site{}, errors.New("站点不存在")
 	}
-	return models.Site{}, errors.New("站点不存在")
+	return site, nil
 }
 
 // GetSiteAndPage 获取站点和特定页面
 func GetSiteAndPage(siteID string, pageID string) (models.Site, models.Page, error) {
-	// 获取站点
-	site, err := GetSite(siteID)
+	// 获取站点，预览时导航需要站点的全部页面
+	site, err := GetSiteWithAllPages(siteID)
 	if err != nil {
 		return models.Site{}, models.Page{}, err
 	}
 
 	// 获取页面
-	for _, page := range pages[siteID] {
+	for _, page := range site.Pages {
 		if page.ID == pageID {
-			// 加载页面的区块和组件
-			if pageSections, exists := sections[page.ID]; exists {
-				// 加载每个区块的组件
-				for j, section := range pageSections {
-					if sectionComponents, exists := components[section.ID]; exists {
-						pageSections[j].Components = sectionComponents
-					}
-				}
-				page.Sections = pageSections
-			}
 			return site, page, nil
 		}
 	}
@@ -222,31 +77,188 @@ func GetSiteAndPage(siteID string, pageID string) (models.Site, models.Page, err
 	return models.Site{}, models.Page{}, errors.New("页面不存在")
 }
 
-// GenerateSitePreview 生成站点预览HTML
-func GenerateSitePreview(site models.Site, device string) (string, error) {
-	// 找到首页
-	var homepage models.Page
+// previewOptions 预览页面的附加选项
+type previewOptions struct {
+	// Banner 替换预览栏中的设备和页面信息
+	Banner string
+	// PageLinks 导航链接通过?pageId=切换页面，而不是跳到页面内的锚点
+	PageLinks bool
+}
+
+// GeneratePagePreview 生成页面在指定语言下的预览HTML
+func GeneratePagePreview(site models.Site, page models.Page, locale string, device string) (string, error) {
+	return generatePreview(site, page, locale, device, previewOptions{})
+}
+
+// generatePreview 按选项生成预览HTML
+func generatePreview(site models.Site, page models.Page, locale string, device string, options previewOptions) (string, error) {
+	// 准备模板数据，全局区块的引用替换为全局区块的内容，缺少翻译的内容使用默认语言
+	localizedSite := sitelocale.Localize(site, locale)
+	templateData := map[string]interface{}{
+		"Site":      localizedSite,
+		"Page":      sitelocale.LocalizePage(resolvePageSections(site, page), locale),
+		"Header":    siteSlotSection(site, site.HeaderSectionID, locale),
+		"Footer":    siteSlotSection(site, site.FooterSectionID, locale),
+		"Locale":    locale,
+		"Device":    device,
+		"Banner":    options.Banner,
+		"PageLinks": options.PageLinks,
+		// 编译后的主题已经过清理，草稿修改后预览立即生效
+		"ThemeCSS": template.CSS(sitetheme.Compile(site.Theme).CSS),
+	}
+
+	// 组件由注册表中的渲染器渲染，预览模式下未知组件显示占位符，区块和组件使用当前设备生效的样式
+	funcMap := sectionFuncs(responsive.Normalize(device))
+	funcMap["renderComponent"] = func(component models.Component) (template.HTML, error) {
+		return RenderComponent(site.ID, component, RenderModePreview, device, locale)
+	}
+	// 导航中指向页面的链接切换预览的页面或跳到页面锚点，站点没有配置导航时列出所有页面
+	funcMap["navigation"] = func() (template.HTML, error) {
+		return renderPageNavigation(localizedSite, func(page models.Page) string {
+			if options.PageLinks {
+				return "?pageId=" + url.QueryEscape(page.ID) + "&locale=" + url.QueryEscape(locale) + "&device=" + url.QueryEscape(device)
+			}
+			return "#" + page.Slug
+		})
+	}
+
+	// 模板只在启动时解析一次，每次渲染复制后绑定本次的函数
+	tmpl, err := previewTemplate.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(funcMap)
+
+	// 执行模板
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, templateData)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// ResolveSiteHost 根据请求的Host解析已验证域名对应的站点，
+// 访问的不是主域名时返回需要跳转的主域名
+func ResolveSiteHost(host string) (siteID string, redirectHost string, err error) {
+	domain, primary, err := store.ResolveHostname(host)
+	if err != nil {
+		return "", "", errors.New("站点不存在")
+	}
+	if primary.ID != domain.ID {
+		redirectHost = primary.Hostname
+	}
+	return domain.SiteID, redirectHost, nil
+}
+
+// GetSite 获取站点信息
+func GetSite(siteID string) (models.Site, error) {
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return models.Site{}, errors.New("站点不存在")
+	}
+	return site, nil
+}
+
+// GetHomePage 获取站点树中的首页
+func GetHomePage(site models.Site) (models.Page, error) {
+	if len(site.Pages) == 0 {
+		return models.Page{}, errors.New("站点没有页面")
+	}
+
 	for _, page := range site.Pages {
 		if page.IsHomepage {
-			homepage = page
-			break
+			return page, nil
 		}
 	}
 
-	if homepage.ID == "" {
-		return "", errors.New("找不到首页")
+	return models.Page{}, errors.New("找不到首页")
+}
+
+// PageVisible 判断页面在指定时间是否处于可见时间窗口内，未设置窗口的页面始终可见
+func PageVisible(page models.Page, at time.Time) bool {
+	if page.VisibleFrom != nil && at.Before(*page.VisibleFrom) {
+		return false
+	}
+	return page.VisibleUntil == nil || at.Before(*page.VisibleUntil)
+}
+
+// GetPageBySlug 通过slug获取站点树中的页面
+func GetPageBySlug(site models.Site, slug string) (models.Page, error) {
+	if len(site.Pages) == 0 {
+		return models.Page{}, errors.New("站点没有页面")
+	}
+
+	// 规范化slug
+	slug = strings.ToLower(slug)
+
+	for _, page := range site.Pages {
+		if strings.ToLower(page.Slug) == slug {
+			return page, nil
+		}
+	}
+
+	return models.Page{}, fmt.Errorf("找不到slug为%s的页面", slug)
+}
+
+// GeneratePageHTML 生成页面在指定语言下的HTML，主题样式表由渲染服务按内容哈希提供，
+// 启用了访问统计时注入上报浏览和点击的脚本
+func GeneratePageHTML(site models.Site, page models.Page, locale string) (string, error) {
+	return generatePageHTML(site, page, locale, RenderModePublic, ThemeStylesheetURL(site.ID, sitetheme.Compile(site.Theme)), BeaconURL(site.ID))
+}
+
+// generatePageHTML 生成页面HTML，mode为公开访问或静态导出，themeURL为主题样式表的地址，beaconURL为空时不注入统计脚本
+func generatePageHTML(site models.Site, page models.Page, locale string, mode RenderMode, themeURL string, beaconURL string) (string, error) {
+	// 准备模板数据
+	localizedSite := sitelocale.Localize(site, locale)
+
+	// 注册自定义函数，导航中指向页面的链接使用页面当前的Slug
+	funcMap := template.FuncMap{
+		"renderComponent": func(component models.Component) (template.HTML, error) {
+			return RenderComponent(site.ID, component, mode, "", locale)
+		},
+		"navigation": func() (template.HTML, error) {
+			return renderNavigation(localizedSite, func(page models.Page) string {
+				return sitenav.PagePath(localizedSite, page, locale)
+			})
+		},
+	}
+
+	localizedPage := sitelocale.LocalizePage(resolvePageSections(site, page), locale)
+	templateData := map[string]interface{}{
+		"Site":     localizedSite,
+		"Page":     localizedPage,
+		"Header":   siteSlotSection(site, site.HeaderSectionID, locale),
+		"Footer":   siteSlotSection(site, site.FooterSectionID, locale),
+		"Meta":     BuildPageMeta(localizedSite, localizedPage, locale),
+		"ThemeURL": themeURL,
+		"Beacon":   nil,
+	}
+	if beaconURL != "" {
+		templateData["Beacon"] = map[string]string{"URL": beaconURL, "PageID": page.ID, "Locale": locale}
 	}
 
-	// 使用首页生成预览
-	return GeneratePagePreview(site, homepage, device)
+	tmpl, err := pageTemplate.Clone()
+	if err != nil {
+		return "", err
+	}
+	tmpl.Funcs(funcMap)
+
+	// 执行模板
+	var buffer bytes.Buffer
+	err = tmpl.Execute(&buffer, templateData)
+	if err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
 }
 
-// GeneratePagePreview 生成页面预览HTML
-func GeneratePagePreview(site models.Site, page models.Page, device string) (string, error) {
-	// 通用HTML模板
-	htmlTemplate := `
+// previewPageHTML 预览页面模板
+const previewPageHTML = `
 <!DOCTYPE html>
-<html lang="zh-CN">
+<html lang="{{ .Locale }}">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
@@ -256,9 +268,6 @@ func GeneratePagePreview(site models.Site, page models.Page, device string) (str
     <style>
         /* 预览模式样式 */
         body {
-            font-family: {{ .Site.Theme.FontFamily }};
-            color: {{ .Site.Theme.TextColor }};
-            background-color: {{ .Site.Theme.BackgroundColor }};
             margin: 0;
             padding: 0;
         }
@@ -300,297 +309,189 @@ func GeneratePagePreview(site models.Site, page models.Page, device string) (str
             margin-right: auto;
         }
         {{ end }}
-        
-        /* 站点主题样式 */
-        a {
-            color: {{ .Site.Theme.PrimaryColor }};
-        }
-        .btn {
-            background-color: {{ .Site.Theme.PrimaryColor }};
-            color: white;
-            border: none;
-            padding: 8px 16px;
-            border-radius: {{ .Site.Theme.BorderRadius }};
-            cursor: pointer;
-        }
-        .section {
-            margin-bottom: 30px;
-        }
-        /* 自定义CSS */
-        {{ .Site.Theme.CustomCSS }}
+    </style>
+    <style>
+        /* 站点主题 */
+        {{ .ThemeCSS }}
     </style>
 </head>
 <body>
     <div class="preview-bar">
+        {{ with .Banner }}
+        <span>{{ . }}</span>
+        {{ else }}
         <span>预览模式: {{ .Device }}</span>
         <span>页面: {{ .Page.Name }}</span>
+        {{ end }}
     </div>
     
     <div class="content">
         <!-- 导航 -->
+        {{ with .Header }}
+        {{ if sectionVisible . }}
+        <header class="global-section" id="{{ .ID }}" data-global-section="{{ .ID }}"{{ with sectionStyle . }} style="{{ . }}"{{ end }}>
+            {{ range .Components }}
+            {{ renderComponent . }}
+            {{ end }}
+        </header>
+        {{ end }}
+        {{ else }}
         <header>
             <div class="logo">
                 <img src="{{ .Site.Logo }}" alt="{{ .Site.Name }}" style="max-height: 50px;">
             </div>
-            <nav>
-                <ul style="display: flex; list-style: none; gap: 20px;">
-                    {{ range .Site.Pages }}
-                    <li><a href="#{{ .Slug }}">{{ .Name }}</a></li>
-                    {{ end }}
-                </ul>
-            </nav>
+            {{ navigation }}
         </header>
+        {{ end }}
         
         <!-- 页面内容 -->
         {{ range .Page.Sections }}
-        <div class="section" id="{{ .ID }}">
+        {{ if sectionVisible . }}
+        <div class="section" id="{{ .ID }}"{{ with .GlobalSectionID }} data-global-section="{{ . }}"{{ end }}{{ with sectionStyle . }} style="{{ . }}"{{ end }}>
             <h2>{{ .Title }}</h2>
             {{ range .Components }}
-            <div class="component">
-                {{ if eq .Type "heading" }}
-                <{{ .Settings.level }}>{{ .Content.text }}</{{ .Settings.level }}>
-                {{ else if eq .Type "text" }}
-                <p>{{ .Content.text }}</p>
-                {{ else if eq .Type "image" }}
-                <img src="{{ .Content.src }}" alt="{{ .Content.alt }}" style="max-width: 100%;">
-                {{ else if eq .Type "button" }}
-                <button class="btn">{{ .Content.text }}</button>
-                {{ end }}
-            </div>
+            {{ renderComponent . }}
             {{ end }}
         </div>
         {{ end }}
+        {{ end }}
         
         <!-- 页脚 -->
+        {{ with .Footer }}
+        {{ if sectionVisible . }}
+        <footer class="global-section" id="{{ .ID }}" data-global-section="{{ .ID }}"{{ with sectionStyle . }} style="{{ . }}"{{ end }}>
+            {{ range .Components }}
+            {{ renderComponent . }}
+            {{ end }}
+        </footer>
+        {{ end }}
+        {{ else }}
         <footer>
             <p>© {{ .Site.Name }}</p>
         </footer>
+        {{ end }}
     </div>
 </body>
 </html>
 `
 
-	// 准备模板数据
-	templateData := map[string]interface{}{
-		"Site":   site,
-		"Page":   page,
-		"Device": device,
-	}
-
-	// 解析模板
-	tmpl, err := template.New("preview").Parse(htmlTemplate)
-	if err != nil {
-		return "", err
-	}
-
-	// 执行模板
-	var buffer bytes.Buffer
-	err = tmpl.Execute(&buffer, templateData)
-	if err != nil {
-		return "", err
-	}
-
-	return buffer.String(), nil
-}
-
-// GetSiteByDomain 根据域名获取站点
-func GetSiteByDomain(domain string) (models.Site, error) {
-	for _, site := range sites {
-		if site.Domain == domain && site.Status == "published" {
-			return site, nil
-		}
-	}
-	return models.Site{}, errors.New("站点不存在或未发布")
-}
-
-// GetSite 获取站点信息
-func GetSite(siteID string) (models.Site, error) {
-	for _, site := range sites {
-		if site.ID == siteID {
-			return site, nil
-		}
-	}
-	return models.Site{}, errors.New("站点不存在")
-}
-
-// GetHomePage 获取站点首页
-func GetHomePage(siteID string) (models.Page, error) {
-	sitePages, exists := pages[siteID]
-	if !exists {
-		return models.Page{}, errors.New("站点没有页面")
-	}
-
-	for _, page := range sitePages {
-		if page.IsHomepage {
-			// 加载页面区块和组件
-			if pageSections, exists := sections[page.ID]; exists {
-				// 加载每个区块的组件
-				for j, section := range pageSections {
-					if sectionComponents, exists := components[section.ID]; exists {
-						pageSections[j].Components = sectionComponents
-					}
-				}
-				page.Sections = pageSections
-			}
-			return page, nil
-		}
-	}
-
-	return models.Page{}, errors.New("找不到首页")
-}
-
-// GetPageBySlug 通过slug获取页面
-func GetPageBySlug(siteID string, slug string) (models.Page, error) {
-	sitePages, exists := pages[siteID]
-	if !exists {
-		return models.Page{}, errors.New("站点没有页面")
-	}
-
-	// 规范化slug
-	slug = strings.ToLower(slug)
-
-	for _, page := range sitePages {
-		if strings.ToLower(page.Slug) == slug {
-			// 加载页面区块和组件
-			if pageSections, exists := sections[page.ID]; exists {
-				// 加载每个区块的组件
-				for j, section := range pageSections {
-					if sectionComponents, exists := components[section.ID]; exists {
-						pageSections[j].Components = sectionComponents
-					}
-				}
-				page.Sections = pageSections
-			}
-			return page, nil
-		}
-	}
-
-	return models.Page{}, fmt.Errorf("找不到slug为%s的页面", slug)
-}
-
-// IsSitePublished 检查站点是否已发布
-func IsSitePublished(siteID string) bool {
-	for _, site := range sites {
-		if site.ID == siteID && site.Status == "published" {
-			return true
-		}
-	}
-	return false
-}
-
-// GeneratePageHTML 生成页面HTML
-func GeneratePageHTML(site models.Site, page models.Page) (string, error) {
-	// HTML模板 - 这里简化处理，实际中会更复杂
-	htmlTemplate := `
+// publicPageHTML 公开页面模板 - 这里简化处理，实际中会更复杂
+const publicPageHTML = `
 <!DOCTYPE html>
-<html lang="zh-CN">
+<html lang="{{ .Meta.Language }}">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>{{ .Page.Title }}</title>
-    <meta name="description" content="{{ .Page.Description }}">
-    <meta name="keywords" content="{{ join .Page.Keywords ", " }}">
+    <title>{{ .Meta.Title }}</title>
+    <meta name="description" content="{{ .Meta.Description }}">
+    {{ with .Meta.Keywords }}<meta name="keywords" content="{{ join . ", " }}">{{ end }}
+    {{ if .Meta.NoIndex }}<meta name="robots" content="noindex, nofollow">{{ end }}
+    {{ with .Meta.Canonical }}<link rel="canonical" href="{{ . }}">{{ end }}
+    {{ range .Meta.Alternates }}<link rel="alternate" hreflang="{{ .Hreflang }}" href="{{ .Href }}">
+    {{ end }}
+    <meta property="og:type" content="website">
+    <meta property="og:site_name" content="{{ .Meta.SiteName }}">
+    <meta property="og:title" content="{{ .Meta.Title }}">
+    <meta property="og:description" content="{{ .Meta.Description }}">
+    {{ with .Meta.Canonical }}<meta property="og:url" content="{{ . }}">{{ end }}
+    {{ with .Meta.Image }}<meta property="og:image" content="{{ . }}">{{ end }}
+    <meta name="twitter:card" content="{{ .Meta.TwitterCard }}">
+    <meta name="twitter:title" content="{{ .Meta.Title }}">
+    <meta name="twitter:description" content="{{ .Meta.Description }}">
+    {{ with .Meta.Image }}<meta name="twitter:image" content="{{ . }}">{{ end }}
+    <script type="application/ld+json">{{ .Meta.JSONLD }}</script>
     <link rel="icon" href="{{ .Site.Favicon }}" type="image/x-icon">
     <style>
         /* 站点样式 */
         body {
-            font-family: {{ .Site.Theme.FontFamily }};
-            color: {{ .Site.Theme.TextColor }};
-            background-color: {{ .Site.Theme.BackgroundColor }};
             margin: 0;
             padding: 0;
         }
         .container {
             max-width: 1200px;
             margin: 0 auto;
-            padding: 0 20px;
         }
-        a {
-            color: {{ .Site.Theme.PrimaryColor }};
-        }
-        .btn {
-            background-color: {{ .Site.Theme.PrimaryColor }};
-            color: white;
-            border: none;
-            padding: 8px 16px;
-            border-radius: {{ .Site.Theme.BorderRadius }};
-            cursor: pointer;
-        }
-        .section {
-            margin-bottom: 30px;
-        }
-        /* 自定义CSS */
-        {{ .Site.Theme.CustomCSS }}
     </style>
+    <!-- 站点主题，地址包含内容哈希 -->
+    <link rel="stylesheet" href="{{ .ThemeURL }}">
 </head>
 <body>
     <div class="container">
         <!-- 导航 -->
+        {{ with .Header }}
+        {{ if sectionVisible . }}
+        {{ sectionCSS . }}
+        <header class="global-section" id="{{ .ID }}"{{ with sectionStyle . }} style="{{ . }}"{{ end }}>
+            {{ range .Components }}
+            {{ renderComponent . }}
+            {{ end }}
+        </header>
+        {{ end }}
+        {{ else }}
         <header>
             <div class="logo">
                 <img src="{{ .Site.Logo }}" alt="{{ .Site.Name }}" style="max-height: 50px;">
             </div>
-            <nav>
-                <ul style="display: flex; list-style: none; gap: 20px;">
-                    {{ range .Site.Navigation.Items }}
-                    <li><a href="{{ .Link }}">{{ .Label }}</a></li>
-                    {{ end }}
-                </ul>
-            </nav>
+            {{ navigation }}
         </header>
+        {{ end }}
         
         <!-- 页面内容 -->
         {{ range .Page.Sections }}
-        <div class="section" id="{{ .ID }}">
+        {{ if sectionVisible . }}
+        {{ sectionCSS . }}
+        <div class="section" id="{{ .ID }}"{{ with sectionStyle . }} style="{{ . }}"{{ end }}>
             <h2>{{ .Title }}</h2>
             {{ range .Components }}
-            <div class="component">
-                {{ if eq .Type "heading" }}
-                <{{ .Settings.level }}>{{ .Content.text }}</{{ .Settings.level }}>
-                {{ else if eq .Type "text" }}
-                <p>{{ .Content.text }}</p>
-                {{ else if eq .Type "image" }}
-                <img src="{{ .Content.src }}" alt="{{ .Content.alt }}" style="max-width: 100%;">
-                {{ else if eq .Type "button" }}
-                <button class="btn">{{ .Content.text }}</button>
-                {{ end }}
-            </div>
+            {{ renderComponent . }}
             {{ end }}
         </div>
         {{ end }}
+        {{ end }}
         
         <!-- 页脚 -->
+        {{ with .Footer }}
+        {{ if sectionVisible . }}
+        {{ sectionCSS . }}
+        <footer class="global-section" id="{{ .ID }}"{{ with sectionStyle . }} style="{{ . }}"{{ end }}>
+            {{ range .Components }}
+            {{ renderComponent . }}
+            {{ end }}
+        </footer>
+        {{ end }}
+        {{ else }}
         <footer>
             <p>© {{ .Site.Name }}</p>
         </footer>
+        {{ end }}
     </div>
+    {{ with .Beacon }}
+    <!-- 访问统计：上报浏览量和按钮、链接组件的点击，不使用Cookie -->
+    <script>
+    (function () {
+        var url = {{ .URL }}, pageId = {{ .PageID }}, locale = {{ .Locale }};
+        function send(data) {
+            data.pageId = pageId;
+            data.locale = locale;
+            var body = JSON.stringify(data);
+            if (navigator.sendBeacon && navigator.sendBeacon(url, body)) {
+                return;
+            }
+            if (window.fetch) {
+                fetch(url, { method: "POST", body: body, keepalive: true });
+            }
+        }
+        send({ type: "pageview", referrer: document.referrer });
+        document.addEventListener("click", function (event) {
+            var target = event.target.closest && event.target.closest(".component-button a, .component-button button, .component a[href]");
+            var component = target && target.closest(".component");
+            if (component && component.id) {
+                send({ type: "click", componentId: component.id });
+            }
+        }, true);
+    })();
+    </script>
+    {{ end }}
 </body>
 </html>
 `
-
-	// 注册自定义函数
-	funcMap := template.FuncMap{
-		"join": strings.Join,
-	}
-
-	// 准备模板数据
-	templateData := map[string]interface{}{
-		"Site": site,
-		"Page": page,
-	}
-
-	// 解析模板
-	tmpl, err := template.New("page").Funcs(funcMap).Parse(htmlTemplate)
-	if err != nil {
-		return "", err
-	}
-
-	// 执行模板
-	var buffer bytes.Buffer
-	err = tmpl.Execute(&buffer, templateData)
-	if err != nil {
-		return "", err
-	}
-
-	return buffer.String(), nil
-}
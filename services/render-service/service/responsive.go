@@ -0,0 +1,153 @@
+package service
+
+import (
+	"html/template"
+	"regexp"
+	"strings"
+	"wz-backend-go/internal/pkg/responsive"
+	"wz-backend-go/models"
+)
+
+// responsiveIDPattern 可以直接用作CSS选择器的元素ID，其他ID不生成按设备覆盖的样式
+var responsiveIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// responsiveRule 一条按设备生效的样式规则
+type responsiveRule struct {
+	devices      []string
+	selector     string
+	declarations []string
+}
+
+// responsiveCSS 把区块或组件按设备的样式覆盖和隐藏设置编译为媒体查询，
+// 覆盖的样式需要!important才能优先于元素的内联样式
+func responsiveCSS(id string, style map[string]interface{}, settings map[string]interface{}, extra ...responsiveRule) template.HTML {
+	if !responsiveIDPattern.MatchString(id) {
+		return ""
+	}
+	selector := `[id="` + id + `"]`
+
+	var rules []responsiveRule
+	_, overrides := responsive.Split(style)
+	for _, device := range responsive.Devices {
+		declarations := cssDeclarations(overrides[device])
+		if len(declarations) == 0 {
+			continue
+		}
+		for i := range declarations {
+			declarations[i] += " !important"
+		}
+		rules = append(rules, responsiveRule{devices: []string{device}, selector: selector, declarations: declarations})
+	}
+	if hidden := responsive.HiddenDevices(settings); len(hidden) > 0 {
+		rules = append(rules, responsiveRule{devices: hidden, selector: selector, declarations: []string{"display: none !important"}})
+	}
+	for _, rule := range extra {
+		rule.selector = selector + " " + rule.selector
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("<style>")
+	for _, rule := range rules {
+		for _, device := range rule.devices {
+			builder.WriteString("@media ")
+			builder.WriteString(responsive.MediaQuery(device))
+			builder.WriteString(" { ")
+			builder.WriteString(rule.selector)
+			builder.WriteString(" { ")
+			builder.WriteString(strings.Join(rule.declarations, "; "))
+			builder.WriteString(" } }")
+		}
+	}
+	builder.WriteString("</style>")
+	return template.HTML(builder.String())
+}
+
+// cssDeclarations 将样式转换为CSS声明，规则与inlineStyle相同
+func cssDeclarations(style map[string]interface{}) []string {
+	css := string(inlineStyle(style))
+	if css == "" {
+		return nil
+	}
+	return strings.Split(css, "; ")
+}
+
+// otherDevices 不在devices中的设备
+func otherDevices(devices []string) []string {
+	var others []string
+	for _, device := range responsive.Devices {
+		found := false
+		for _, d := range devices {
+			if d == device {
+				found = true
+				break
+			}
+		}
+		if !found {
+			others = append(others, device)
+		}
+	}
+	return others
+}
+
+// sectionFuncs 页面模板中处理区块样式和显示状态的函数。
+// 预览时device为具体设备，直接使用该设备生效的样式；公开页面device为空，按设备的覆盖编译为媒体查询
+func sectionFuncs(device string) template.FuncMap {
+	if device != "" {
+		return template.FuncMap{
+			"sectionVisible": func(section models.Section) bool {
+				return !responsive.Hidden(toMap(section.Settings), device)
+			},
+			"sectionStyle": func(section models.Section) template.CSS {
+				return inlineStyle(responsive.Resolve(toMap(section.Style), device))
+			},
+			"sectionCSS": func(section models.Section) template.HTML {
+				return ""
+			},
+		}
+	}
+	return template.FuncMap{
+		"sectionVisible": func(section models.Section) bool {
+			return len(responsive.HiddenDevices(toMap(section.Settings))) < len(responsive.Devices)
+		},
+		"sectionStyle": func(section models.Section) template.CSS {
+			base, _ := responsive.Split(toMap(section.Style))
+			return inlineStyle(base)
+		},
+		"sectionCSS": func(section models.Section) template.HTML {
+			return responsiveCSS(section.ID, toMap(section.Style), toMap(section.Settings))
+		},
+	}
+}
+
+// renderResponsive 在公开页面渲染组件。设置按设备覆盖时为每组设置相同的设备各渲染一份，
+// 通过媒体查询只显示当前设备对应的一份；ID不能用作选择器时只渲染最宽设备的一份
+func renderResponsive(renderer ComponentRenderer, data ComponentData, settings map[string]interface{}) (template.HTML, []responsiveRule, error) {
+	variants := responsive.Variants(settings)
+	if len(variants) == 1 || !responsiveIDPattern.MatchString(data.ID) {
+		data.Settings = variants[0].Settings
+		inner, err := renderer.Render(data)
+		return inner, nil, err
+	}
+
+	var builder strings.Builder
+	var rules []responsiveRule
+	for _, variant := range variants {
+		data.Settings = variant.Settings
+		inner, err := renderer.Render(data)
+		if err != nil {
+			return "", nil, err
+		}
+		class := "responsive-variant-" + strings.Join(variant.Devices, "-")
+		builder.WriteString(`<div class="responsive-variant ` + class + `">`)
+		builder.WriteString(string(inner))
+		builder.WriteString(`</div>`)
+		if hidden := otherDevices(variant.Devices); len(hidden) > 0 {
+			rules = append(rules, responsiveRule{devices: hidden, selector: "> ." + class, declarations: []string{"display: none"}})
+		}
+	}
+	return template.HTML(builder.String()), rules, nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"encoding/json"
+	"html/template"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/internal/pkg/sitenav"
+	"wz-backend-go/models"
+)
+
+// PageMeta 公开页面head中的SEO信息
+type PageMeta struct {
+	Language    string
+	Title       string
+	Description string
+	Keywords    []string
+	Canonical   string // 站点未绑定域名时为空
+	SiteName    string
+	Image       string
+	TwitterCard string
+	NoIndex     bool
+	Alternates  []AlternateLink
+	JSONLD      template.JS
+}
+
+// AlternateLink 页面其他语言版本的地址
+type AlternateLink struct {
+	Hreflang string
+	Href     string
+}
+
+// BuildPageMeta 根据页面和站点的SEO设置生成页面元信息，页面未设置的字段使用站点的设置。
+// site和page应为已按locale翻译的内容
+func BuildPageMeta(site models.Site, page models.Page, locale string) PageMeta {
+	meta := PageMeta{
+		Language:    locale,
+		Title:       page.Title,
+		Description: page.Description,
+		Keywords:    page.Keywords,
+		Canonical:   pageURL(site, page, locale),
+		SiteName:    site.Name,
+		Image:       absoluteURL(site, siteShareImage(site)),
+		NoIndex:     site.SEO.NoIndex,
+	}
+
+	if meta.Title == "" {
+		if page.IsHomepage && site.SEO.Title != "" {
+			meta.Title = site.SEO.Title
+		} else {
+			meta.Title = page.Name
+		}
+	}
+	if meta.Description == "" {
+		meta.Description = site.SEO.Description
+	}
+	if meta.Description == "" {
+		meta.Description = site.Description
+	}
+	if len(meta.Keywords) == 0 {
+		meta.Keywords = site.SEO.Keywords
+	}
+
+	meta.TwitterCard = "summary"
+	if meta.Image != "" {
+		meta.TwitterCard = "summary_large_image"
+	}
+	meta.JSONLD = buildJSONLD(site, page, locale)
+
+	// 多语言站点列出各语言版本，x-default指向默认语言
+	if locales := sitelocale.Enabled(site); len(locales) > 1 && meta.Canonical != "" {
+		for _, alternate := range locales {
+			meta.Alternates = append(meta.Alternates, AlternateLink{
+				Hreflang: alternate,
+				Href:     pageURL(site, page, alternate),
+			})
+		}
+		meta.Alternates = append(meta.Alternates, AlternateLink{
+			Hreflang: "x-default",
+			Href:     pageURL(site, page, sitelocale.DefaultLocale(site)),
+		})
+	}
+	return meta
+}
+
+// siteShareImage 分享图片，依次使用SEO设置的图片、站点缩略图和Logo
+func siteShareImage(site models.Site) string {
+	for _, image := range []string{site.SEO.Image, site.Thumbnail, site.Logo} {
+		if image != "" {
+			return image
+		}
+	}
+	return ""
+}
+
+// pageURL 页面在指定语言下的规范地址，非默认语言带语言前缀，站点未绑定域名时返回空
+func pageURL(site models.Site, page models.Page, locale string) string {
+	baseURL := siteBaseURL(site)
+	if baseURL == "" {
+		return ""
+	}
+	return baseURL + sitenav.PagePath(site, page, locale)
+}
+
+// absoluteURL 将站内相对地址转为绝对地址，无法转换时原样返回
+func absoluteURL(site models.Site, ref string) string {
+	if ref == "" || !strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "//") {
+		return ref
+	}
+	if baseURL := siteBaseURL(site); baseURL != "" {
+		return baseURL + ref
+	}
+	return ref
+}
+
+// buildJSONLD 生成Organization、WebSite和BreadcrumbList结构化数据
+func buildJSONLD(site models.Site, page models.Page, locale string) template.JS {
+	baseURL := siteBaseURL(site)
+	organization := map[string]interface{}{
+		"@type": "Organization",
+		"name":  site.Name,
+	}
+	website := map[string]interface{}{
+		"@type": "WebSite",
+		"name":  site.Name,
+	}
+	if baseURL != "" {
+		organization["url"] = baseURL + "/"
+		website["url"] = baseURL + "/"
+	}
+	if site.Logo != "" {
+		organization["logo"] = absoluteURL(site, site.Logo)
+	}
+	graph := []interface{}{organization, website}
+
+	// 面包屑需要绝对地址，只在绑定域名后生成
+	if baseURL != "" {
+		home := models.Page{IsHomepage: true}
+		items := []map[string]interface{}{
+			{"@type": "ListItem", "position": 1, "name": site.Name, "item": pageURL(site, home, locale)},
+		}
+		if !page.IsHomepage {
+			items = append(items, map[string]interface{}{
+				"@type": "ListItem", "position": 2, "name": page.Name, "item": pageURL(site, page, locale),
+			})
+		}
+		graph = append(graph, map[string]interface{}{
+			"@type":           "BreadcrumbList",
+			"itemListElement": items,
+		})
+	}
+
+	// json.Marshal会转义<、>和&，可以安全地放在script标签中
+	data, err := json.Marshal(map[string]interface{}{
+		"@context": "https://schema.org",
+		"@graph":   graph,
+	})
+	if err != nil {
+		return ""
+	}
+	return template.JS(data)
+}
+
+// pageLastModified 页面在站点地图中的更新时间
+func pageLastModified(site models.Site, page models.Page) time.Time {
+	if !page.UpdatedAt.IsZero() {
+		return page.UpdatedAt
+	}
+	if site.PublishedAt != nil {
+		return *site.PublishedAt
+	}
+	return time.Now()
+}
+
+// GetPublishedSitemap 根据站点线上版本的页面生成sitemap.xml
+func GetPublishedSitemap(siteID string) ([]byte, error) {
+	site, err := GetPublishedSite(siteID)
+	if err != nil {
+		return nil, err
+	}
+	return GenerateSitemap(site)
+}
+
+// GetPublishedRobotsTxt 生成站点线上版本的robots.txt
+func GetPublishedRobotsTxt(siteID string) (string, error) {
+	site, err := GetPublishedSite(siteID)
+	if err != nil {
+		return "", err
+	}
+	return GenerateRobotsTxt(site), nil
+}
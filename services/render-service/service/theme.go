@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+	"wz-backend-go/internal/pkg/sitetheme"
+)
+
+// ErrThemeStylesheetNotFound 样式表不存在，通常是站点重新发布后主题已经变化
+var ErrThemeStylesheetNotFound = errors.New("主题样式表不存在")
+
+// ThemeStylesheetURL 站点主题样式表的地址，文件名包含内容哈希，可以长期缓存
+func ThemeStylesheetURL(siteID string, sheet sitetheme.Stylesheet) string {
+	return "/render/sites/" + siteID + "/theme/" + sheet.FileName()
+}
+
+// GetThemeStylesheet 获取已发布站点的主题样式表，文件名中的哈希必须与当前发布版本一致
+func GetThemeStylesheet(siteID string, fileName string) (sitetheme.Stylesheet, error) {
+	site, err := store.Sites.Get(siteID)
+	if err != nil || site.Status != "published" {
+		return sitetheme.Stylesheet{}, ErrSiteNotPublished
+	}
+	published, err := GetPublishedSite(siteID)
+	if err != nil {
+		return sitetheme.Stylesheet{}, err
+	}
+
+	sheet := sitetheme.Compile(published.Theme)
+	if sheet.FileName() != fileName {
+		return sitetheme.Stylesheet{}, ErrThemeStylesheetNotFound
+	}
+	return sheet, nil
+}
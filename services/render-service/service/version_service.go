@@ -0,0 +1,263 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// SiteVersionInfo 版本列表项，不包含快照内容
+type SiteVersionInfo struct {
+	ID           string    `json:"id"`
+	SiteID       string    `json:"siteId"`
+	Version      int       `json:"version"`
+	PublishedBy  string    `json:"publishedBy"`
+	Note         string    `json:"note"`
+	RollbackFrom int       `json:"rollbackFrom,omitempty"`
+	PageCount    int       `json:"pageCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// ListSiteVersions 获取站点的发布版本列表，按版本号倒序
+func ListSiteVersions(siteID string) ([]SiteVersionInfo, error) {
+	versions, err := store.Versions.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]SiteVersionInfo, 0, len(versions))
+	for _, version := range versions {
+		result = append(result, SiteVersionInfo{
+			ID:           version.ID,
+			SiteID:       version.SiteID,
+			Version:      version.Version,
+			PublishedBy:  version.PublishedBy,
+			Note:         version.Note,
+			RollbackFrom: version.RollbackFrom,
+			PageCount:    len(version.Snapshot.Pages),
+			CreatedAt:    version.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// GetSiteVersion 获取站点的指定版本
+func GetSiteVersion(siteID string, version int) (models.SiteVersion, error) {
+	v, err := store.Versions.Get(siteID, version)
+	if err != nil {
+		return models.SiteVersion{}, fmt.Errorf("版本%d不存在", version)
+	}
+	return v, nil
+}
+
+// RollbackSiteVersion 回滚到指定版本，回滚会以该版本的快照生成新的发布版本，历史版本保持不变
+func RollbackSiteVersion(siteID string, targetVersion int, publishedBy string) (models.SiteVersion, error) {
+	target, err := GetSiteVersion(siteID, targetVersion)
+	if err != nil {
+		return models.SiteVersion{}, err
+	}
+
+	note := fmt.Sprintf("回滚到版本%d", targetVersion)
+	return store.PublishSnapshot(target.Snapshot, publishedBy, note, targetVersion)
+}
+
+// GetPublishedSite 获取站点线上版本的完整站点树
+func GetPublishedSite(siteID string) (models.Site, error) {
+	site, err := store.GetPublishedSite(siteID)
+	if errors.Is(err, builder.ErrNotFound) {
+		return models.Site{}, ErrSiteNotPublished
+	}
+	return site, err
+}
+
+// DiffSiteVersions 比较两个版本，差异按站点、页面、区块、组件的层级列出
+func DiffSiteVersions(siteID string, fromVersion int, toVersion int) ([]models.SiteVersionChange, error) {
+	from, err := GetSiteVersion(siteID, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	to, err := GetSiteVersion(siteID, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []models.SiteVersionChange{}
+
+	// 站点本身的字段，版本元数据不参与比较
+	ignoreSite := map[string]bool{"pages": true, "globalSections": true, "publishedAt": true, "publishedVersion": true, "updatedAt": true}
+	changes = append(changes, diffFields("site", from.Snapshot, to.Snapshot, ignoreSite)...)
+	changes = append(changes, diffSectionList("globalSections", from.Snapshot.GlobalSections, to.Snapshot.GlobalSections)...)
+
+	fromPages := map[string]models.Page{}
+	for _, page := range from.Snapshot.Pages {
+		fromPages[page.ID] = page
+	}
+	toPages := map[string]models.Page{}
+	for _, page := range to.Snapshot.Pages {
+		toPages[page.ID] = page
+	}
+
+	for _, id := range unionKeys(pageIDs(from.Snapshot.Pages), pageIDs(to.Snapshot.Pages)) {
+		path := fmt.Sprintf("pages[%s]", id)
+		before, inFrom := fromPages[id]
+		after, inTo := toPages[id]
+		switch {
+		case !inTo:
+			changes = append(changes, models.SiteVersionChange{Path: path, Action: "removed", Before: before})
+		case !inFrom:
+			changes = append(changes, models.SiteVersionChange{Path: path, Action: "added", After: after})
+		default:
+			changes = append(changes, diffFields(path, before, after, map[string]bool{"sections": true, "updatedAt": true})...)
+			changes = append(changes, diffSections(path, before.Sections, after.Sections)...)
+		}
+	}
+
+	return changes, nil
+}
+
+// diffSections 比较页面下的区块和组件
+func diffSections(pagePath string, fromSections []models.Section, toSections []models.Section) []models.SiteVersionChange {
+	return diffSectionList(pagePath+".sections", fromSections, toSections)
+}
+
+// diffSectionList 比较区块列表，listPath为列表的路径，如pages[page1].sections或globalSections
+func diffSectionList(listPath string, fromSections []models.Section, toSections []models.Section) []models.SiteVersionChange {
+	var changes []models.SiteVersionChange
+
+	fromMap := map[string]models.Section{}
+	var fromIDs []string
+	for _, section := range fromSections {
+		fromMap[section.ID] = section
+		fromIDs = append(fromIDs, section.ID)
+	}
+	toMap := map[string]models.Section{}
+	var toIDs []string
+	for _, section := range toSections {
+		toMap[section.ID] = section
+		toIDs = append(toIDs, section.ID)
+	}
+
+	for _, id := range unionKeys(fromIDs, toIDs) {
+		path := fmt.Sprintf("%s[%s]", listPath, id)
+		before, inFrom := fromMap[id]
+		after, inTo := toMap[id]
+		switch {
+		case !inTo:
+			changes = append(changes, models.SiteVersionChange{Path: path, Action: "removed", Before: before})
+		case !inFrom:
+			changes = append(changes, models.SiteVersionChange{Path: path, Action: "added", After: after})
+		default:
+			changes = append(changes, diffFields(path, before, after, map[string]bool{"components": true})...)
+			changes = append(changes, diffComponents(path, before.Components, after.Components)...)
+		}
+	}
+
+	return changes
+}
+
+// diffComponents 比较区块下的组件
+func diffComponents(sectionPath string, fromComponents []models.Component, toComponents []models.Component) []models.SiteVersionChange {
+	var changes []models.SiteVersionChange
+
+	fromMap := map[string]models.Component{}
+	var fromIDs []string
+	for _, component := range fromComponents {
+		fromMap[component.ID] = component
+		fromIDs = append(fromIDs, component.ID)
+	}
+	toMap := map[string]models.Component{}
+	var toIDs []string
+	for _, component := range toComponents {
+		toMap[component.ID] = component
+		toIDs = append(toIDs, component.ID)
+	}
+
+	for _, id := range unionKeys(fromIDs, toIDs) {
+		path := fmt.Sprintf("%s.components[%s]", sectionPath, id)
+		before, inFrom := fromMap[id]
+		after, inTo := toMap[id]
+		switch {
+		case !inTo:
+			changes = append(changes, models.SiteVersionChange{Path: path, Action: "removed", Before: before})
+		case !inFrom:
+			changes = append(changes, models.SiteVersionChange{Path: path, Action: "added", After: after})
+		default:
+			changes = append(changes, diffFields(path, before, after, nil)...)
+		}
+	}
+
+	return changes
+}
+
+// diffFields 以JSON字段为单位比较两个对象
+func diffFields(path string, before interface{}, after interface{}, ignore map[string]bool) []models.SiteVersionChange {
+	beforeFields := jsonFields(before)
+	afterFields := jsonFields(after)
+
+	keys := make([]string, 0, len(beforeFields))
+	for key := range beforeFields {
+		keys = append(keys, key)
+	}
+	for key := range afterFields {
+		if _, exists := beforeFields[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []models.SiteVersionChange
+	for _, key := range keys {
+		if ignore[key] {
+			continue
+		}
+		if !reflect.DeepEqual(beforeFields[key], afterFields[key]) {
+			changes = append(changes, models.SiteVersionChange{
+				Path:   path + "." + key,
+				Action: "modified",
+				Before: beforeFields[key],
+				After:  afterFields[key],
+			})
+		}
+	}
+	return changes
+}
+
+// jsonFields 将对象转换为按JSON字段名索引的map
+func jsonFields(value interface{}) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// pageIDs 获取页面ID列表
+func pageIDs(pageList []models.Page) []string {
+	ids := make([]string, 0, len(pageList))
+	for _, page := range pageList {
+		ids = append(ids, page.ID)
+	}
+	return ids
+}
+
+// unionKeys 合并两个ID列表并保持出现顺序
+func unionKeys(first []string, second []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, list := range [][]string{first, second} {
+		for _, id := range list {
+			if !seen[id] {
+				seen[id] = true
+				result = append(result, id)
+			}
+		}
+	}
+	return result
+}
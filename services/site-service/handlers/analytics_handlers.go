@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSiteStatistics 获取站点或页面的访问统计。
+// 查询参数granularity为hour或day（默认）；from、to为日期（2006-01-02，包含当天）或RFC3339时间，
+// 默认按小时查询最近48小时，按天查询最近30天；pageId为空时查询整个站点
+func GetSiteStatistics(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	granularity := c.DefaultQuery("granularity", builder.GranularityDay)
+	now := time.Now()
+	to := now
+	from := now.AddDate(0, 0, -30)
+	if granularity == builder.GranularityHour {
+		from = now.Add(-48 * time.Hour)
+	}
+	if value := c.Query("from"); value != "" {
+		t, err := parseStatisticsTime(value, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from格式错误"})
+			return
+		}
+		from = t
+	}
+	if value := c.Query("to"); value != "" {
+		t, err := parseStatisticsTime(value, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to格式错误"})
+			return
+		}
+		to = t
+	}
+
+	data, err := service.GetSiteStatistics(siteID, c.Query("pageId"), granularity, from, to)
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrInvalidAnalyticsRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"granularity": granularity,
+		"from":        from,
+		"to":          to,
+		"data":        data,
+	})
+}
+
+// parseStatisticsTime 解析日期或RFC3339时间，日期作为结束时间时包含当天
+func parseStatisticsTime(value string, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
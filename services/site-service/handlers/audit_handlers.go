@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditSite 检查站点草稿，返回失效链接、缺少替代文本、标题层级跳跃、颜色对比度不足等问题。
+// 查询参数external=false时不检查外部链接
+func AuditSite(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	checkExternal := true
+	if value := c.Query("external"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的external参数"})
+			return
+		}
+		checkExternal = parsed
+	}
+
+	report, err := service.AuditSite(c.Request.Context(), siteID, checkExternal)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkSiteOwner 检查当前租户是否拥有站点，无权限时写入响应并返回false
+func checkSiteOwner(c *gin.Context, siteID string) bool {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return false
+	}
+
+	owned, err := service.CheckSiteOwnership(siteID, tenantID.(string))
+	if err != nil || !owned {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此站点"})
+		return false
+	}
+	return true
+}
+
+// ListDomains 获取站点绑定的域名
+func ListDomains(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	domains, err := service.ListDomains(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domains)
+}
+
+// AddDomain 为站点添加域名
+func AddDomain(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var req struct {
+		Hostname string `json:"hostname" binding:"required"`
+		Method   string `json:"method"` // dns（默认）或http
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	domain, err := service.AddDomain(siteID, req.Hostname, req.Method)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, domain)
+}
+
+// VerifyDomain 验证域名所有权
+func VerifyDomain(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	domain, err := service.VerifyDomain(siteID, c.Param("domainId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain)
+}
+
+// SetPrimaryDomain 设置站点主域名
+func SetPrimaryDomain(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	domain, err := service.SetPrimaryDomain(siteID, c.Param("domainId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain)
+}
+
+// RemoveDomain 解绑站点域名
+func RemoveDomain(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	if err := service.RemoveDomain(siteID, c.Param("domainId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "域名已解绑"})
+}
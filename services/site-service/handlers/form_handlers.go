@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListFormSubmissions 获取站点的表单提交，可按表单组件过滤
+func ListFormSubmissions(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	submissions, err := service.ListFormSubmissions(siteID, c.Query("componentId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+// ExportFormSubmissions 导出站点的表单提交为CSV文件
+func ExportFormSubmissions(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	data, err := service.ExportFormSubmissions(siteID, c.Query("componentId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("form-submissions-%s-%s.csv", siteID, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// DownloadFormFile 下载表单提交中上传的文件
+func DownloadFormFile(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	file, path, err := service.FormFilePath(siteID, c.Param("submissionId"), c.Param("field"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, file.Name)
+}
+
+// DeleteFormSubmission 删除表单提交
+func DeleteFormSubmission(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	if err := service.DeleteFormSubmission(siteID, c.Param("submissionId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "提交记录已删除"})
+}
+
+// GetFormNotificationSettings 获取当前租户的表单通知接收人
+func GetFormNotificationSettings(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	settings, err := service.GetFormNotificationSettings(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateFormNotificationSettings 设置当前租户的表单通知接收人
+func UpdateFormNotificationSettings(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	var req struct {
+		Recipients []int64 `json:"recipients"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := service.SaveFormNotificationSettings(tenantID.(string), req.Recipients)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"wz-backend-go/internal/pkg/sitenav"
+	"wz-backend-go/models"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// navigationPosition 导航项的目标位置，parentId为空表示顶层，index为空时放到末尾
+type navigationPosition struct {
+	ParentID string `json:"parentId"`
+	Index    *int   `json:"index"`
+}
+
+// position 返回目标位置的序号，未指定时为-1，表示末尾
+func (p navigationPosition) position() int {
+	if p.Index == nil {
+		return -1
+	}
+	return *p.Index
+}
+
+// GetNavigation 获取站点导航
+func GetNavigation(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	navigation, err := service.GetNavigation(siteID)
+	if err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, navigation)
+}
+
+// UpdateNavigation 保存导航设置和完整的导航树
+func UpdateNavigation(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var navigation models.Navigation
+	if err := c.ShouldBindJSON(&navigation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := service.UpdateNavigation(siteID, navigation)
+	if err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// GenerateNavigation 按页面列表重新生成导航
+func GenerateNavigation(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	navigation, err := service.GenerateNavigation(siteID)
+	if err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, navigation)
+}
+
+// CreateNavigationItem 添加导航项，可以同时指定上级导航项和位置
+func CreateNavigationItem(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var req struct {
+		models.NavigationItem
+		navigationPosition
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := service.CreateNavigationItem(siteID, req.ParentID, req.position(), req.NavigationItem)
+	if err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateNavigationItem 修改导航项
+func UpdateNavigationItem(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var item models.NavigationItem
+	if err := c.ShouldBindJSON(&item); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := service.UpdateNavigationItem(siteID, c.Param("itemId"), item)
+	if err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// MoveNavigationItem 移动导航项，移动到其他导航项下即为嵌套
+func MoveNavigationItem(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var req navigationPosition
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	navigation, err := service.MoveNavigationItem(siteID, c.Param("itemId"), req.ParentID, req.position())
+	if err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, navigation)
+}
+
+// DeleteNavigationItem 删除导航项及其子项
+func DeleteNavigationItem(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	if err := service.DeleteNavigationItem(siteID, c.Param("itemId")); err != nil {
+		respondNavigationError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "导航项已删除"})
+}
+
+// respondNavigationError 返回编辑导航失败的响应
+func respondNavigationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sitenav.ErrItemNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, sitenav.ErrInvalidParent), errors.Is(err, sitenav.ErrTooDeep), errors.Is(err, sitenav.ErrLabelRequired),
+		errors.Is(err, sitenav.ErrInvalidType), errors.Is(err, sitenav.ErrExternalPage), errors.Is(err, sitenav.ErrDuplicateItems),
+		errors.Is(err, service.ErrNavigationPageNotFound):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
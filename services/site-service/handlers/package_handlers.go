@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/models"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSitePackageBody 导入的导出包的最大字节数
+const maxSitePackageBody = 20 << 20
+
+// ExportSitePackage 下载站点的导出包，templates=true时携带租户的私有模板
+func ExportSitePackage(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	pkg, err := service.ExportSitePackage(siteID, c.GetString("tenant_id"), c.Query("templates") == "true")
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("site-%s-%s.json", siteID, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// ImportSitePackage 把导出包导入为指定租户的草稿站点，属于管理操作，只通过内部接口提供。
+// 查询参数tenantId为目标租户，dryRun=true时只检查冲突和校验错误，不写入
+func ImportSitePackage(c *gin.Context) {
+	var pkg models.SitePackage
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSitePackageBody)
+	if err := json.NewDecoder(c.Request.Body).Decode(&pkg); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "导出包过大"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "导出包格式错误: " + err.Error()})
+		return
+	}
+
+	dryRun := c.Query("dryRun") == "true"
+	result, err := service.ImportSitePackage(pkg, c.Query("tenantId"), dryRun)
+	var validationErr *jsonschema.ValidationError
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "导出包校验失败",
+			"fields": validationErr.Errors,
+		})
+		return
+	case errors.Is(err, service.ErrUnsupportedPackage):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/models"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRedirectImportSize 导入重定向CSV的最大字节数
+const maxRedirectImportSize = 2 << 20
+
+// ListRedirects 获取站点的重定向规则
+func ListRedirects(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	rules, err := service.ListRedirects(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRedirect 添加重定向规则
+func CreateRedirect(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var rule models.SiteRedirect
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := service.CreateRedirect(siteID, rule)
+	if err != nil {
+		respondRedirectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateRedirect 修改重定向规则
+func UpdateRedirect(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var rule models.SiteRedirect
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := service.UpdateRedirect(siteID, c.Param("redirectId"), rule)
+	if err != nil {
+		respondRedirectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteRedirect 删除重定向规则
+func DeleteRedirect(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	if err := service.DeleteRedirect(siteID, c.Param("redirectId")); err != nil {
+		respondRedirectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "重定向已删除"})
+}
+
+// ExportRedirects 导出站点的重定向规则为CSV文件
+func ExportRedirects(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	data, err := service.ExportRedirects(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("redirects-%s-%s.csv", siteID, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// ImportRedirects 从CSV导入重定向规则，文件通过multipart的file字段或直接作为请求体上传，
+// mode=replace时替换所有手动添加的规则，默认合并
+func ImportRedirects(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	mode := c.DefaultQuery("mode", "merge")
+	if mode != "merge" && mode != "replace" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mode只能是merge或replace"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRedirectImportSize)
+	var reader io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "缺少CSV文件"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	result, err := service.ImportRedirects(siteID, reader, mode == "replace")
+	if err != nil {
+		respondRedirectError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// respondRedirectError 返回重定向操作失败的响应
+func respondRedirectError(c *gin.Context, err error) {
+	var validationErr *jsonschema.ValidationError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &validationErr):
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "重定向规则校验失败",
+			"fields": validationErr.Errors,
+		})
+	case errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "CSV文件过大"})
+	case errors.Is(err, service.ErrRedirectNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDuplicateRedirect):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
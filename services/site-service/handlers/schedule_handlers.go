@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSchedules 获取站点的定时发布任务及执行记录
+func ListSchedules(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	schedules, err := service.ListSchedules(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateSchedule 创建定时发布或下线任务
+func CreateSchedule(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var req struct {
+		PageID string    `json:"pageId"` // 为空时作用于整个站点
+		Action string    `json:"action" binding:"required"`
+		RunAt  time.Time `json:"runAt" binding:"required"`
+		Note   string    `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := service.CreateSchedule(siteID, req.PageID, req.Action, req.RunAt, req.Note, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// CancelSchedule 取消尚未执行的定时任务，任务记录保留
+func CancelSchedule(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	schedule, err := service.CancelSchedule(siteID, c.Param("scheduleId"), c.GetString("user_id"))
+	switch {
+	case err == nil:
+	case errors.Is(err, service.ErrScheduleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrScheduleNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UnpublishSite 立即下线站点
+func UnpublishSite(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	site, err := service.UnpublishSite(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, site)
+}
+
+// PublishPage 立即把单个页面的草稿发布到线上版本
+func PublishPage(c *gin.Context) {
+	changePublishedPage(c, service.PublishPage)
+}
+
+// UnpublishPage 立即从线上版本中移除单个页面
+func UnpublishPage(c *gin.Context) {
+	changePublishedPage(c, service.UnpublishPage)
+}
+
+func changePublishedPage(c *gin.Context, change func(siteID string, pageID string, publishedBy string) (int, error)) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	version, err := change(siteID, c.Param("pageId"), c.GetString("user_id"))
+	switch {
+	case err == nil:
+	case errors.Is(err, builder.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "页面不存在"})
+		return
+	case errors.Is(err, builder.ErrSiteNotPublished), errors.Is(err, builder.ErrHomepageUnpublish):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"version": version})
+}
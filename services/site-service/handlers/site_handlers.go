@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"log"
 	"net/http"
 	"time"
 	"wz-backend-go/models"
@@ -72,7 +74,7 @@ func CreateSite(c *gin.Context) {
 	// 调用服务层创建站点
 	createdSite, err := service.CreateSite(site)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSiteError(c, err)
 		return
 	}
 	
@@ -108,7 +110,7 @@ func UpdateSite(c *gin.Context) {
 	// 调用服务层更新站点
 	updatedSite, err := service.UpdateSite(site)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSiteError(c, err)
 		return
 	}
 	
@@ -156,12 +158,37 @@ func PublishSite(c *gin.Context) {
 		return
 	}
 	
+	// 可选的发布说明
+	var req struct {
+		Note string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	userID, _ := c.Get("user_id")
+	publishedBy, _ := userID.(string)
+	
 	// 调用服务层发布站点
-	publishedSite, err := service.PublishSite(siteID)
+	publishedSite, err := service.PublishSite(siteID, publishedBy, req.Note)
+	var auditErr *service.AuditFailedError
+	if errors.As(err, &auditErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "audit": auditErr.Report})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	
+	// 生成静态快照，导出失败不影响发布结果
+	if service.ExportEnabled() {
+		if err := service.ExportSite(siteID); err != nil {
+			log.Printf("站点%s静态导出失败: %v", siteID, err)
+		}
+	}
+	
 	c.JSON(http.StatusOK, publishedSite)
 } 
\ No newline at end of file
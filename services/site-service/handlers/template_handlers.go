@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"net/http"
+	"wz-backend-go/models"
 	"wz-backend-go/services/site-service/service"
 
 	"github.com/gin-gonic/gin"
@@ -33,3 +34,83 @@ func GetTemplate(c *gin.Context) {
 
 	c.JSON(http.StatusOK, template)
 }
+
+// ListTenantTemplates 获取当前租户可用的模板，包括系统模板和租户保存的模板
+func ListTenantTemplates(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	templates, err := service.ListTenantTemplates(tenantID.(string), c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+// CreateSiteFromTemplate 根据模板创建站点
+func CreateSiteFromTemplate(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	var req struct {
+		TemplateID string `json:"templateId" binding:"required"`
+		models.TemplateOverrides
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	site, err := service.CreateSiteFromTemplate(req.TemplateID, tenantID.(string), req.TemplateOverrides)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, site)
+}
+
+// SaveSiteAsTemplate 将站点保存为模板
+func SaveSiteAsTemplate(c *gin.Context) {
+	siteID := c.Param("id")
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	// 检查站点所有权
+	owned, err := service.CheckSiteOwnership(siteID, tenantID.(string))
+	if err != nil || !owned {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权操作此站点"})
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Thumbnail   string `json:"thumbnail"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	template, err := service.SaveSiteAsTemplate(siteID, tenantID.(string), req.Name, req.Description, req.Thumbnail)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
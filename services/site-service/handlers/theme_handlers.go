@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/services/site-service/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListThemes 获取当前租户可以应用的主题
+func ListThemes(c *gin.Context) {
+	tenantID, exists := c.Get("tenant_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "需要认证"})
+		return
+	}
+
+	themes, err := service.ListThemes(tenantID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, themes)
+}
+
+// GetThemeSchema 获取主题配置的Schema
+func GetThemeSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, sitetheme.Schema)
+}
+
+// ApplyTheme 将后台主题应用到站点
+func ApplyTheme(c *gin.Context) {
+	siteID := c.Param("id")
+	if !checkSiteOwner(c, siteID) {
+		return
+	}
+
+	var req struct {
+		ThemeID int64 `json:"themeId" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	site, err := service.ApplyTheme(siteID, c.GetString("tenant_id"), req.ThemeID)
+	if errors.Is(err, service.ErrThemeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondSiteError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, site)
+}
+
+// respondSiteError 返回写入站点失败的响应，主题等配置校验失败时返回400及字段错误
+func respondSiteError(c *gin.Context, err error) {
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":  "站点配置校验失败",
+			"fields": validationErr.Errors,
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -3,8 +3,14 @@ package main
 import (
 	"log"
 	"os"
+	"time"
+	"wz-backend-go/internal/pkg/previewtoken"
+	"wz-backend-go/internal/pkg/rendercache"
+	"wz-backend-go/internal/pkg/siteaudit"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/middleware"
 	"wz-backend-go/services/site-service/handlers"
+	"wz-backend-go/services/site-service/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,8 +26,65 @@ func main() {
 	}
 	gin.SetMode(ginMode)
 
-	// 创建Gin引擎
+	// 初始化数据存储
+	store, err := builder.OpenStore()
+	if err != nil {
+		log.Fatalf("初始化数据存储失败: %v", err)
+	}
+	service.SetStore(store)
+
+	// 渲染缓存在服务之间共享时，编辑数据后直接清除受影响的渲染结果
+	if cache, shared := rendercache.Open(rendercache.DefaultTTL); shared {
+		cache.Attach(store)
+	}
+
+	// 配置发布时的静态导出，地址为渲染服务的内部接口（渲染服务的INTERNAL_ADDR）
+	if renderServiceURL := os.Getenv("RENDER_SERVICE_URL"); renderServiceURL != "" {
+		service.SetSiteExporter(service.NewRenderServiceClient(renderServiceURL))
+	}
+
+	// 域名验证可以指定DNS服务器，便于在测试环境使用本地桩解析器
+	if resolverAddr := os.Getenv("DOMAIN_DNS_RESOLVER"); resolverAddr != "" {
+		service.SetDomainResolver(service.NewStubResolver(resolverAddr))
+	}
+
+	// 站点检查时用HEAD请求检查外部链接，AUDIT_LINK_CHECK=off时不检查；
+	// PUBLISH_AUDIT=enforce时站点存在错误级别的问题不能发布
+	if os.Getenv("AUDIT_LINK_CHECK") != "off" {
+		service.SetLinkChecker(siteaudit.NewHTTPChecker(10 * time.Second))
+	}
+	switch value := os.Getenv("PUBLISH_AUDIT"); value {
+	case "", "off":
+	case "enforce":
+		service.SetPublishAuditGate(true)
+	default:
+		log.Fatalf("无效的PUBLISH_AUDIT: %s", value)
+	}
+
+	// 表单提交的上传文件目录，与渲染服务共用
+	if dir := os.Getenv("FORM_UPLOAD_DIR"); dir != "" {
+		service.SetFormUploadDir(dir)
+	}
+
+	// 预览链接的签名密钥，与渲染服务共用
+	service.SetPreviewSecret(previewtoken.LoadSecret())
+
+	// 定时发布任务的轮询间隔，多个实例可以同时运行
+	scheduleInterval := 30 * time.Second
+	if value := os.Getenv("SCHEDULE_POLL_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			log.Fatalf("无效的SCHEDULE_POLL_INTERVAL: %s", value)
+		}
+		scheduleInterval = interval
+	}
+	service.StartScheduler(scheduleInterval)
+
+	// 创建Gin引擎，只信任TRUSTED_PROXIES中的代理转发的客户端IP
 	r := gin.Default()
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatalf("无效的TRUSTED_PROXIES: %v", err)
+	}
 
 	// 注册中间件
 	r.Use(middleware.CORS())
@@ -47,10 +110,105 @@ func main() {
 		authGroup.GET("", handlers.ListSites)
 		authGroup.GET("/:id", handlers.GetSite)
 		authGroup.POST("", handlers.CreateSite)
+		authGroup.POST("/from-template", handlers.CreateSiteFromTemplate)
 		authGroup.PUT("/:id", handlers.UpdateSite)
 		authGroup.DELETE("/:id", handlers.DeleteSite)
 		authGroup.PUT("/:id/publish", handlers.PublishSite)
+		authGroup.PUT("/:id/unpublish", handlers.UnpublishSite)
+		authGroup.PUT("/:id/pages/:pageId/publish", handlers.PublishPage)
+		authGroup.PUT("/:id/pages/:pageId/unpublish", handlers.UnpublishPage)
+		authGroup.POST("/:id/save-as-template", handlers.SaveSiteAsTemplate)
+		authGroup.GET("/:id/package", handlers.ExportSitePackage)
+		authGroup.GET("/:id/audit", handlers.AuditSite)
+
+		// 导航
+		authGroup.GET("/:id/navigation", handlers.GetNavigation)
+		authGroup.PUT("/:id/navigation", handlers.UpdateNavigation)
+		authGroup.POST("/:id/navigation/generate", handlers.GenerateNavigation)
+		authGroup.POST("/:id/navigation/items", handlers.CreateNavigationItem)
+		authGroup.PUT("/:id/navigation/items/:itemId", handlers.UpdateNavigationItem)
+		authGroup.PUT("/:id/navigation/items/:itemId/move", handlers.MoveNavigationItem)
+		authGroup.DELETE("/:id/navigation/items/:itemId", handlers.DeleteNavigationItem)
+
+		// 域名管理
+		authGroup.GET("/:id/domains", handlers.ListDomains)
+		authGroup.POST("/:id/domains", handlers.AddDomain)
+		authGroup.POST("/:id/domains/:domainId/verify", handlers.VerifyDomain)
+		authGroup.PUT("/:id/domains/:domainId/primary", handlers.SetPrimaryDomain)
+		authGroup.DELETE("/:id/domains/:domainId", handlers.RemoveDomain)
+
+		// 表单提交
+		authGroup.GET("/:id/form-submissions", handlers.ListFormSubmissions)
+		authGroup.GET("/:id/form-submissions/export", handlers.ExportFormSubmissions)
+		authGroup.GET("/:id/form-submissions/:submissionId/files/:field", handlers.DownloadFormFile)
+		authGroup.DELETE("/:id/form-submissions/:submissionId", handlers.DeleteFormSubmission)
+
+		// 预览链接
+		authGroup.GET("/:id/preview-links", handlers.ListPreviewLinks)
+		authGroup.POST("/:id/preview-links", handlers.CreatePreviewLink)
+		authGroup.DELETE("/:id/preview-links/:tokenId", handlers.RevokePreviewLink)
+
+		// 主题
+		authGroup.POST("/:id/theme/apply", handlers.ApplyTheme)
+
+		// 重定向管理
+		authGroup.GET("/:id/redirects", handlers.ListRedirects)
+		authGroup.POST("/:id/redirects", handlers.CreateRedirect)
+		authGroup.GET("/:id/redirects/export", handlers.ExportRedirects)
+		authGroup.POST("/:id/redirects/import", handlers.ImportRedirects)
+		authGroup.PUT("/:id/redirects/:redirectId", handlers.UpdateRedirect)
+		authGroup.DELETE("/:id/redirects/:redirectId", handlers.DeleteRedirect)
+
+		// 定时发布
+		authGroup.GET("/:id/schedules", handlers.ListSchedules)
+		authGroup.POST("/:id/schedules", handlers.CreateSchedule)
+		authGroup.DELETE("/:id/schedules/:scheduleId", handlers.CancelSchedule)
+
+		// 访问统计
+		authGroup.GET("/:id/analytics", handlers.GetSiteStatistics)
+	}
+
+	// 后台主题库中租户可用的主题
+	themeGroup := apiGroup.Group("/themes")
+	themeGroup.Use(middleware.Auth())
+	{
+		themeGroup.GET("", handlers.ListThemes)
+		themeGroup.GET("/schema", handlers.GetThemeSchema)
+	}
+
+	// 租户的表单通知设置
+	formSettingsGroup := apiGroup.Group("/form-notification-settings")
+	formSettingsGroup.Use(middleware.Auth())
+	{
+		formSettingsGroup.GET("", handlers.GetFormNotificationSettings)
+		formSettingsGroup.PUT("", handlers.UpdateFormNotificationSettings)
+	}
+
+	// 租户可用的模板，包括租户保存的私有模板
+	tenantTemplateGroup := apiGroup.Group("/my-site-templates")
+	tenantTemplateGroup.Use(middleware.Auth())
+	{
+		tenantTemplateGroup.GET("", handlers.ListTenantTemplates)
+	}
+
+	// 内部路由 - 仅供后台管理服务调用，由后台校验管理员权限。内部路由不做认证，
+	// 使用单独的监听地址，默认只监听本机，部署时设置INTERNAL_ADDR为内网地址，不能通过网关或公网访问
+	internalAddr := os.Getenv("INTERNAL_ADDR")
+	if internalAddr == "" {
+		internalAddr = "127.0.0.1:9081"
+	}
+	internal := gin.Default()
+	internalGroup := internal.Group("/internal")
+	{
+		// 导入站点导出包到指定租户
+		internalGroup.POST("/sites/import", handlers.ImportSitePackage)
 	}
+	go func() {
+		log.Printf("站点服务内部接口启动在 %s...\n", internalAddr)
+		if err := internal.Run(internalAddr); err != nil {
+			log.Fatalf("启动内部接口失败: %v", err)
+		}
+	}()
 
 	// 获取服务端口
 	port := os.Getenv("PORT")
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+	"wz-backend-go/internal/domain"
+	"wz-backend-go/internal/pkg/siteanalytics"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// 统计数据关联的项目类型，来源、设备和组件分组使用siteanalytics的维度名称
+const (
+	StatisticsItemSite = "site"
+	StatisticsItemPage = "page"
+)
+
+// 查询访问统计的最大时间范围
+const (
+	maxHourlyRange = 31 * 24 * time.Hour
+	maxDailyRange  = 366 * 24 * time.Hour
+)
+
+// ErrInvalidAnalyticsRange 查询的粒度或时间范围无效
+var ErrInvalidAnalyticsRange = errors.New("统计粒度只能是hour或day，且时间范围不能超过限制")
+
+// GetSiteStatistics 获取站点在[from, to)内按粒度汇总的访问统计，转换为后台统计使用的StatisticsData。
+// pageID为空时返回站点总量、每个页面的总量和站点的来源、设备、组件分组，否则只返回该页面的总量和分组。
+// Type为pv、uv或click；ItemType为site、page或referrer、device、component，ItemKey为对应的ID、域名或设备类型
+func GetSiteStatistics(siteID string, pageID string, granularity string, from time.Time, to time.Time) ([]domain.StatisticsData, error) {
+	maxRange := maxDailyRange
+	switch granularity {
+	case builder.GranularityHour:
+		maxRange = maxHourlyRange
+	case builder.GranularityDay:
+	default:
+		return nil, ErrInvalidAnalyticsRange
+	}
+	if !from.Before(to) || to.Sub(from) > maxRange {
+		return nil, ErrInvalidAnalyticsRange
+	}
+
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return nil, errors.New("站点不存在")
+	}
+	if pageID != "" {
+		if _, err := store.Pages.Get(siteID, pageID); err != nil {
+			return nil, errors.New("页面不存在")
+		}
+	}
+	rollups, err := store.Analytics.ListRollups(siteID, granularity, siteanalytics.PeriodStart(from, granularity), to)
+	if err != nil {
+		return nil, err
+	}
+
+	// 租户ID在站点上保存为字符串，后台统计使用数字ID
+	tenantID, _ := strconv.ParseInt(site.TenantID, 10, 64)
+	data := []domain.StatisticsData{}
+	for _, rollup := range rollups {
+		item, ok := statisticsItem(rollup, pageID)
+		if !ok {
+			continue
+		}
+		item.Date = rollup.PeriodStart
+		item.Type = rollup.Metric
+		item.Value = rollup.Count
+		item.TenantID = tenantID
+		item.CreatedAt = rollup.PeriodStart
+		item.UpdatedAt = rollup.UpdatedAt
+		data = append(data, item)
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Date.Before(data[j].Date)
+	})
+	return data, nil
+}
+
+// statisticsItem 确定汇总对应的统计项目，不属于查询范围的汇总返回false
+func statisticsItem(rollup models.AnalyticsRollup, pageID string) (domain.StatisticsData, bool) {
+	switch {
+	case rollup.Dimension != "":
+		// 分组只返回查询范围本身的
+		if rollup.PageID != pageID {
+			return domain.StatisticsData{}, false
+		}
+		return domain.StatisticsData{ItemType: rollup.Dimension, ItemKey: rollup.Value}, true
+	case rollup.PageID == "":
+		if pageID != "" {
+			return domain.StatisticsData{}, false
+		}
+		return numericItem(StatisticsItemSite, rollup.SiteID), true
+	case pageID == "" || rollup.PageID == pageID:
+		return numericItem(StatisticsItemPage, rollup.PageID), true
+	}
+	return domain.StatisticsData{}, false
+}
+
+// numericItem 站点和页面的ID是数字时同时填写ItemID
+func numericItem(itemType string, key string) domain.StatisticsData {
+	id, _ := strconv.ParseInt(key, 10, 64)
+	return domain.StatisticsData{ItemID: id, ItemType: itemType, ItemKey: key}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"wz-backend-go/internal/pkg/siteaudit"
+	"wz-backend-go/internal/repository/builder"
+)
+
+// auditTimeout 一次站点检查的最长时间，主要用于外部链接检查
+const auditTimeout = 60 * time.Second
+
+// 检查外部链接使用的检查器，为空时不检查外部链接；publishAuditGate为true时发布前必须通过检查
+var (
+	linkChecker      siteaudit.LinkChecker
+	publishAuditGate bool
+)
+
+// SetLinkChecker 设置检查外部链接使用的检查器
+func SetLinkChecker(checker siteaudit.LinkChecker) {
+	linkChecker = checker
+}
+
+// SetPublishAuditGate 设置发布站点前是否必须通过站点检查
+func SetPublishAuditGate(enabled bool) {
+	publishAuditGate = enabled
+}
+
+// AuditFailedError 发布前的站点检查发现了错误级别的问题
+type AuditFailedError struct {
+	Report siteaudit.Report
+}
+
+func (e *AuditFailedError) Error() string {
+	return fmt.Sprintf("站点检查未通过，发现%d个错误", e.Report.Errors)
+}
+
+// AuditSite 检查站点草稿的失效链接和无障碍问题，checkExternal为false时不检查外部链接
+func AuditSite(ctx context.Context, siteID string, checkExternal bool) (siteaudit.Report, error) {
+	tree, err := store.LoadSiteTree(siteID)
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return siteaudit.Report{}, errors.New("站点不存在")
+		}
+		return siteaudit.Report{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, auditTimeout)
+	defer cancel()
+	checker := linkChecker
+	if !checkExternal {
+		checker = nil
+	}
+	return siteaudit.Audit(ctx, tree, checker), nil
+}
+
+// checkPublishAudit 启用了发布检查时检查站点，存在错误时返回AuditFailedError
+func checkPublishAudit(siteID string) error {
+	if !publishAuditGate {
+		return nil
+	}
+	report, err := AuditSite(context.Background(), siteID, true)
+	if err != nil {
+		return err
+	}
+	if !report.Passed() {
+		return &AuditFailedError{Report: report}
+	}
+	return nil
+}
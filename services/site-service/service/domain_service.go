@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+const (
+	// DomainVerifyDNS 通过DNS TXT记录验证域名
+	DomainVerifyDNS = "dns"
+	// DomainVerifyHTTP 通过站点根目录下的验证文件验证域名
+	DomainVerifyHTTP = "http"
+
+	dnsVerificationPrefix  = "_wz-verification."
+	dnsVerificationValue   = "wz-site-verification="
+	httpVerificationPath   = "/.well-known/wz-site-verification.txt"
+	domainVerifyTimeout    = 10 * time.Second
+	httpVerificationMaxLen = 1024
+)
+
+// TXTResolver 查询DNS TXT记录，*net.Resolver实现了该接口
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// ErrChallengeAddressBlocked 验证文件所在的域名解析到了内网、本机等非公网地址
+var ErrChallengeAddressBlocked = errors.New("域名解析到了非公网地址")
+
+// 域名验证使用的DNS解析器和HTTP客户端，可替换为本地桩服务
+var (
+	domainResolver  TXTResolver = net.DefaultResolver
+	challengeClient             = NewChallengeClient()
+)
+
+// NewChallengeClient 创建获取HTTP验证文件的客户端。域名由用户填写，为避免借验证请求访问内网，
+// 客户端只连接公网地址（在DNS解析之后检查，重新绑定也无法绕过），不跟随重定向，也不使用代理
+func NewChallengeClient() *http.Client {
+	dialer := &net.Dialer{Timeout: domainVerifyTimeout, Control: publicAddressOnly}
+	return &http.Client{
+		Timeout: domainVerifyTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: domainVerifyTimeout,
+			DisableKeepAlives:   true,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddressOnly 拒绝连接本机、内网、链路本地、组播和未指定地址
+func publicAddressOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return ErrChallengeAddressBlocked
+	}
+	return nil
+}
+
+// sharedAddressSpace 运营商级NAT使用的100.64.0.0/10，也不属于公网
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// SetDomainResolver 设置验证域名使用的DNS解析器
+func SetDomainResolver(resolver TXTResolver) {
+	domainResolver = resolver
+}
+
+// SetChallengeClient 设置获取HTTP验证文件使用的客户端
+func SetChallengeClient(client *http.Client) {
+	challengeClient = client
+}
+
+// NewStubResolver 创建将所有DNS查询发送到指定地址（如127.0.0.1:5353）的解析器
+func NewStubResolver(addr string) *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// DomainVerificationInstructions 域名验证说明
+type DomainVerificationInstructions struct {
+	Method string `json:"method"`
+	Name   string `json:"name"`  // dns方式为TXT记录名，http方式为验证文件URL
+	Value  string `json:"value"` // TXT记录值或验证文件内容
+}
+
+// DomainWithInstructions 域名记录及其验证说明
+type DomainWithInstructions struct {
+	models.SiteDomain
+	Instructions DomainVerificationInstructions `json:"instructions"`
+}
+
+// ListDomains 获取站点绑定的域名
+func ListDomains(siteID string) ([]DomainWithInstructions, error) {
+	domains, err := store.Domains.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]DomainWithInstructions, 0, len(domains))
+	for _, domain := range domains {
+		result = append(result, withInstructions(domain))
+	}
+	return result, nil
+}
+
+// AddDomain 为站点添加域名，添加后需要完成验证才能访问
+func AddDomain(siteID string, hostname string, method string) (DomainWithInstructions, error) {
+	hostname, err := builder.NormalizeHostname(hostname)
+	if err != nil {
+		return DomainWithInstructions{}, err
+	}
+	if method == "" {
+		method = DomainVerifyDNS
+	}
+	if method != DomainVerifyDNS && method != DomainVerifyHTTP {
+		return DomainWithInstructions{}, errors.New("不支持的验证方式")
+	}
+
+	// 其他站点未完成验证的绑定不阻止新的绑定，已验证的域名不能被占用
+	existing, err := store.Domains.GetByHostname(hostname)
+	if err == nil {
+		if existing.Verified || existing.SiteID == siteID {
+			return DomainWithInstructions{}, builder.ErrDuplicateHostname
+		}
+		if err := store.Domains.Delete(existing.SiteID, existing.ID); err != nil {
+			return DomainWithInstructions{}, err
+		}
+	} else if !errors.Is(err, builder.ErrNotFound) {
+		return DomainWithInstructions{}, err
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		return DomainWithInstructions{}, err
+	}
+	domain := models.SiteDomain{
+		SiteID:             siteID,
+		Hostname:           hostname,
+		VerificationMethod: method,
+		VerificationToken:  token,
+		CreatedAt:          time.Now(),
+	}
+	if err := store.Domains.Create(&domain); err != nil {
+		return DomainWithInstructions{}, err
+	}
+
+	return withInstructions(domain), nil
+}
+
+// VerifyDomain 检查域名的DNS记录或验证文件，通过后标记为已验证；站点没有主域名时自动设为主域名
+func VerifyDomain(siteID string, domainID string) (DomainWithInstructions, error) {
+	domain, err := store.Domains.Get(siteID, domainID)
+	if err != nil {
+		return DomainWithInstructions{}, errors.New("域名不存在")
+	}
+	if domain.Verified {
+		return withInstructions(domain), nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), domainVerifyTimeout)
+	defer cancel()
+	switch domain.VerificationMethod {
+	case DomainVerifyHTTP:
+		err = checkHTTPChallenge(ctx, domain)
+	default:
+		err = checkDNSChallenge(ctx, domain)
+	}
+	if err != nil {
+		return DomainWithInstructions{}, err
+	}
+
+	now := time.Now()
+	domain.Verified = true
+	domain.VerifiedAt = &now
+	if err := store.Domains.Update(&domain); err != nil {
+		return DomainWithInstructions{}, err
+	}
+
+	hasPrimary := false
+	domains, err := store.Domains.ListBySite(siteID)
+	if err != nil {
+		return DomainWithInstructions{}, err
+	}
+	for _, d := range domains {
+		if d.IsPrimary {
+			hasPrimary = true
+			break
+		}
+	}
+	if !hasPrimary {
+		if domain, err = store.SetPrimaryDomain(siteID, domain.ID); err != nil {
+			return DomainWithInstructions{}, err
+		}
+	}
+
+	return withInstructions(domain), nil
+}
+
+// SetPrimaryDomain 设置站点主域名
+func SetPrimaryDomain(siteID string, domainID string) (DomainWithInstructions, error) {
+	domain, err := store.SetPrimaryDomain(siteID, domainID)
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return DomainWithInstructions{}, errors.New("域名不存在")
+		}
+		return DomainWithInstructions{}, err
+	}
+	return withInstructions(domain), nil
+}
+
+// RemoveDomain 解绑站点域名
+func RemoveDomain(siteID string, domainID string) error {
+	if err := store.RemoveDomain(siteID, domainID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return errors.New("域名不存在")
+		}
+		return err
+	}
+	return nil
+}
+
+// checkDNSChallenge 检查_wz-verification.<域名>下的TXT记录
+func checkDNSChallenge(ctx context.Context, domain models.SiteDomain) error {
+	records, err := domainResolver.LookupTXT(ctx, dnsVerificationPrefix+domain.Hostname)
+	if err != nil {
+		return fmt.Errorf("查询TXT记录失败: %v", err)
+	}
+	expected := dnsVerificationValue + domain.VerificationToken
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			return nil
+		}
+	}
+	return errors.New("未找到匹配的TXT记录")
+}
+
+// checkHTTPChallenge 检查域名下验证文件的内容
+func checkHTTPChallenge(ctx context.Context, domain models.SiteDomain) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+domain.Hostname+httpVerificationPath, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := challengeClient.Do(req)
+	if errors.Is(err, ErrChallengeAddressBlocked) {
+		return ErrChallengeAddressBlocked
+	}
+	if err != nil {
+		return fmt.Errorf("获取验证文件失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// 重定向不会被跟随，验证文件必须直接由该域名返回
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("获取验证文件失败: HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpVerificationMaxLen))
+	if err != nil {
+		return fmt.Errorf("读取验证文件失败: %v", err)
+	}
+	if strings.TrimSpace(string(body)) != domain.VerificationToken {
+		return errors.New("验证文件内容不匹配")
+	}
+	return nil
+}
+
+// withInstructions 附加域名的验证说明
+func withInstructions(domain models.SiteDomain) DomainWithInstructions {
+	instructions := DomainVerificationInstructions{Method: domain.VerificationMethod}
+	if domain.VerificationMethod == DomainVerifyHTTP {
+		instructions.Name = "http://" + domain.Hostname + httpVerificationPath
+		instructions.Value = domain.VerificationToken
+	} else {
+		instructions.Name = dnsVerificationPrefix + domain.Hostname
+		instructions.Value = dnsVerificationValue + domain.VerificationToken
+	}
+	return DomainWithInstructions{SiteDomain: domain, Instructions: instructions}
+}
+
+// newVerificationToken 生成随机验证令牌
+func newVerificationToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
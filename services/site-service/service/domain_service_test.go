@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startStubDNS 启动只回答TXT查询的本地DNS桩服务，records为记录名（不带结尾的点）到TXT值的映射
+func startStubDNS(t *testing.T, records map[string][]string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("监听DNS端口失败: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			var parser dnsmessage.Parser
+			header, err := parser.Start(buffer[:n])
+			if err != nil {
+				continue
+			}
+			question, err := parser.Question()
+			if err != nil {
+				continue
+			}
+
+			name := strings.TrimSuffix(question.Name.String(), ".")
+			values, ok := records[name]
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true},
+				Questions: []dnsmessage.Question{question},
+			}
+			if !ok {
+				response.Header.RCode = dnsmessage.RCodeNameError
+			} else if question.Type == dnsmessage.TypeTXT {
+				for _, value := range values {
+					response.Answers = append(response.Answers, dnsmessage.Resource{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET, TTL: 60},
+						Body:   &dnsmessage.TXTResource{TXT: []string{value}},
+					})
+				}
+			}
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// useMemoryStore 测试期间使用写入了演示数据的内存存储
+func useMemoryStore(t *testing.T) {
+	t.Helper()
+	memory := builder.NewMemoryStore()
+	if err := builder.SeedDemoData(memory); err != nil {
+		t.Fatalf("写入演示数据失败: %v", err)
+	}
+	previous := store
+	SetStore(memory)
+	t.Cleanup(func() { SetStore(previous) })
+}
+
+// useDomainResolver 测试期间使用指定的DNS解析器
+func useDomainResolver(t *testing.T, resolver TXTResolver) {
+	t.Helper()
+	previous := domainResolver
+	SetDomainResolver(resolver)
+	t.Cleanup(func() { SetDomainResolver(previous) })
+}
+
+// useChallengeClient 测试期间使用指定的HTTP客户端
+func useChallengeClient(t *testing.T, client *http.Client) {
+	t.Helper()
+	previous := challengeClient
+	SetChallengeClient(client)
+	t.Cleanup(func() { SetChallengeClient(previous) })
+}
+
+// clientDialing 创建与NewChallengeClient相同但所有连接都发往addr的客户端，
+// 用于把验证请求发送到本机的测试服务
+func clientDialing(addr string) *http.Client {
+	client := NewChallengeClient()
+	var dialer net.Dialer
+	client.Transport.(*http.Transport).DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return client
+}
+
+func TestVerifyDomainDNS(t *testing.T) {
+	useMemoryStore(t)
+	domain, err := AddDomain("1", "dns.example.com", DomainVerifyDNS)
+	if err != nil {
+		t.Fatalf("添加域名失败: %v", err)
+	}
+	if domain.Instructions.Name != "_wz-verification.dns.example.com" {
+		t.Fatalf("TXT记录名错误: %s", domain.Instructions.Name)
+	}
+
+	addr := startStubDNS(t, map[string][]string{
+		domain.Instructions.Name:             {"v=spf1 -all", domain.Instructions.Value},
+		"_wz-verification.other.example.com": {dnsVerificationValue + "wrong"},
+	})
+	useDomainResolver(t, NewStubResolver(addr))
+
+	verified, err := VerifyDomain("1", domain.ID)
+	if err != nil {
+		t.Fatalf("验证域名失败: %v", err)
+	}
+	if !verified.Verified || verified.VerifiedAt == nil {
+		t.Fatalf("验证后应为已验证: %+v", verified.SiteDomain)
+	}
+
+	other, err := AddDomain("1", "other.example.com", DomainVerifyDNS)
+	if err != nil {
+		t.Fatalf("添加域名失败: %v", err)
+	}
+	if _, err := VerifyDomain("1", other.ID); err == nil {
+		t.Fatal("TXT记录值不匹配时验证应失败")
+	}
+	missing, err := AddDomain("1", "missing.example.com", DomainVerifyDNS)
+	if err != nil {
+		t.Fatalf("添加域名失败: %v", err)
+	}
+	if _, err := VerifyDomain("1", missing.ID); err == nil {
+		t.Fatal("没有TXT记录时验证应失败")
+	}
+}
+
+func TestVerifyDomainHTTP(t *testing.T) {
+	useMemoryStore(t)
+	domain, err := AddDomain("1", "http.example.com", DomainVerifyHTTP)
+	if err != nil {
+		t.Fatalf("添加域名失败: %v", err)
+	}
+
+	var host string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		if r.URL.Path != httpVerificationPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(domain.VerificationToken + "\n"))
+	}))
+	defer server.Close()
+	useChallengeClient(t, clientDialing(server.Listener.Addr().String()))
+
+	verified, err := VerifyDomain("1", domain.ID)
+	if err != nil {
+		t.Fatalf("验证域名失败: %v", err)
+	}
+	if !verified.Verified {
+		t.Fatal("验证后应为已验证")
+	}
+	if host != "http.example.com" {
+		t.Fatalf("验证请求的Host应为绑定的域名: %s", host)
+	}
+}
+
+func TestVerifyDomainHTTPMismatch(t *testing.T) {
+	useMemoryStore(t)
+	domain, err := AddDomain("1", "http.example.com", DomainVerifyHTTP)
+	if err != nil {
+		t.Fatalf("添加域名失败: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("wrong-token"))
+	}))
+	defer server.Close()
+	useChallengeClient(t, clientDialing(server.Listener.Addr().String()))
+
+	if _, err := VerifyDomain("1", domain.ID); err == nil {
+		t.Fatal("验证文件内容不匹配时验证应失败")
+	}
+	stored, err := store.Domains.Get("1", domain.ID)
+	if err != nil {
+		t.Fatalf("获取域名失败: %v", err)
+	}
+	if stored.Verified {
+		t.Fatal("验证失败时不能标记为已验证")
+	}
+}
+
+func TestVerifyDomainHTTPDoesNotFollowRedirects(t *testing.T) {
+	useMemoryStore(t)
+	domain, err := AddDomain("1", "http.example.com", DomainVerifyHTTP)
+	if err != nil {
+		t.Fatalf("添加域名失败: %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == httpVerificationPath {
+			http.Redirect(w, r, "/token", http.StatusFound)
+			return
+		}
+		w.Write([]byte(domain.VerificationToken))
+	}))
+	defer server.Close()
+	useChallengeClient(t, clientDialing(server.Listener.Addr().String()))
+
+	_, err = VerifyDomain("1", domain.ID)
+	if err == nil || !strings.Contains(err.Error(), "HTTP 302") {
+		t.Fatalf("重定向不应被跟随: %v", err)
+	}
+}
+
+func TestCheckHTTPChallengeRejectsPrivateAddresses(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+	useChallengeClient(t, NewChallengeClient())
+
+	domain := models.SiteDomain{Hostname: server.Listener.Addr().String(), VerificationToken: "token"}
+	err := checkHTTPChallenge(context.Background(), domain)
+	if !errors.Is(err, ErrChallengeAddressBlocked) {
+		t.Fatalf("本机地址应被拒绝: %v", err)
+	}
+	if requested {
+		t.Fatal("被拒绝的地址不应收到请求")
+	}
+}
+
+func TestPublicAddressOnly(t *testing.T) {
+	blocked := []string{
+		"127.0.0.1:80", "10.1.2.3:80", "172.16.0.1:80", "192.168.1.1:80", "169.254.169.254:80",
+		"100.64.0.1:80", "0.0.0.0:80", "224.0.0.1:80", "[::1]:80", "[fe80::1]:80", "[fd00::1]:80",
+		"[::ffff:127.0.0.1]:80", "[::]:80",
+	}
+	for _, address := range blocked {
+		if err := publicAddressOnly("tcp", address, nil); !errors.Is(err, ErrChallengeAddressBlocked) {
+			t.Errorf("%s 应被拒绝: %v", address, err)
+		}
+	}
+	allowed := []string{"93.184.216.34:80", "[2606:2800:220:1:248:1893:25c8:1946]:80"}
+	for _, address := range allowed {
+		if err := publicAddressOnly("tcp", address, nil); err != nil {
+			t.Errorf("%s 应被允许: %v", address, err)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/forms"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// formUploadDir 访客上传文件的保存目录，需要与渲染服务的FORM_UPLOAD_DIR一致
+var formUploadDir = filepath.Join("uploads", "forms")
+
+// SetFormUploadDir 设置表单文件的保存目录
+func SetFormUploadDir(dir string) {
+	formUploadDir = dir
+}
+
+// ListFormSubmissions 获取站点的表单提交，componentID为空时返回所有表单的提交
+func ListFormSubmissions(siteID string, componentID string) ([]models.FormSubmission, error) {
+	return store.FormSubmissions.ListBySite(siteID, componentID)
+}
+
+// DeleteFormSubmission 删除表单提交及其上传的文件
+func DeleteFormSubmission(siteID string, submissionID string) error {
+	if _, err := store.FormSubmissions.Get(siteID, submissionID); err != nil {
+		if err == builder.ErrNotFound {
+			return errors.New("提交记录不存在")
+		}
+		return err
+	}
+	if err := store.FormSubmissions.Delete(siteID, submissionID); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(formUploadDir, siteID, submissionID))
+}
+
+// FormFilePath 获取表单提交中某个字段上传的文件及其保存路径
+func FormFilePath(siteID string, submissionID string, field string) (models.FormFile, string, error) {
+	submission, err := store.FormSubmissions.Get(siteID, submissionID)
+	if err != nil {
+		if err == builder.ErrNotFound {
+			return models.FormFile{}, "", errors.New("提交记录不存在")
+		}
+		return models.FormFile{}, "", err
+	}
+	for _, file := range submission.Files {
+		if file.Field != field {
+			continue
+		}
+		path := filepath.Join(formUploadDir, filepath.FromSlash(file.StoredName))
+		if _, err := os.Stat(path); err != nil {
+			break
+		}
+		return file, path, nil
+	}
+	return models.FormFile{}, "", errors.New("文件不存在")
+}
+
+// ExportFormSubmissions 将表单提交导出为CSV。字段列按草稿中表单的字段顺序并使用字段标题，
+// 表单中已删除的字段排在最后并使用字段名
+func ExportFormSubmissions(siteID string, componentID string) ([]byte, error) {
+	submissions, err := store.FormSubmissions.ListBySite(siteID, componentID)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := store.LoadSiteTree(siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	pageTitles := map[string]string{}
+	var names []string
+	labels := map[string]string{}
+	for _, page := range tree.Pages {
+		pageTitles[page.ID] = page.Title
+		for _, section := range page.Sections {
+			for _, component := range section.Components {
+				if component.Type != "form" || (componentID != "" && component.ID != componentID) {
+					continue
+				}
+				fields, err := forms.ParseFields(component.Content)
+				if err != nil {
+					continue
+				}
+				for _, field := range fields {
+					if _, ok := labels[field.Name]; ok {
+						continue
+					}
+					names = append(names, field.Name)
+					labels[field.Name] = field.Label
+				}
+			}
+		}
+	}
+
+	var extra []string
+	for _, submission := range submissions {
+		for name := range submission.Values {
+			if _, ok := labels[name]; !ok {
+				labels[name] = name
+				extra = append(extra, name)
+			}
+		}
+		for _, file := range submission.Files {
+			if _, ok := labels[file.Field]; !ok {
+				labels[file.Field] = file.Field
+				extra = append(extra, file.Field)
+			}
+		}
+	}
+	sort.Strings(extra)
+	names = append(names, extra...)
+
+	header := []string{"提交时间", "页面", "表单"}
+	for _, name := range names {
+		header = append(header, csvSafe(defaultLabel(labels[name], name)))
+	}
+	header = append(header, "IP")
+
+	var buf bytes.Buffer
+	// 写入BOM，便于Excel识别UTF-8编码
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	for _, submission := range submissions {
+		files := map[string]string{}
+		for _, file := range submission.Files {
+			files[file.Field] = file.Name
+		}
+
+		pageTitle := pageTitles[submission.PageID]
+		if pageTitle == "" {
+			pageTitle = submission.PageID
+		}
+		record := []string{submission.CreatedAt.Format(time.DateTime), csvSafe(pageTitle), csvSafe(submission.FormName)}
+		for _, name := range names {
+			if value, ok := submission.Values[name]; ok {
+				record = append(record, csvSafe(value))
+			} else {
+				record = append(record, csvSafe(files[name]))
+			}
+		}
+		record = append(record, csvSafe(submission.IP))
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// csvSafe 避免访客填写的内容以及表单名、页面标题等单元格在表格软件中被当作公式执行
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func defaultLabel(label string, name string) string {
+	if label == "" {
+		return name
+	}
+	return label
+}
+
+// GetFormNotificationSettings 获取租户的表单通知设置，未设置时返回空的接收人列表
+func GetFormNotificationSettings(tenantID string) (models.FormNotificationSettings, error) {
+	settings, err := store.FormSettings.Get(tenantID)
+	if err == builder.ErrNotFound {
+		return models.FormNotificationSettings{TenantID: tenantID, Recipients: []int64{}}, nil
+	}
+	return settings, err
+}
+
+// SaveFormNotificationSettings 保存租户的表单通知接收人，重复的接收人只保留一个
+func SaveFormNotificationSettings(tenantID string, recipients []int64) (models.FormNotificationSettings, error) {
+	unique := []int64{}
+	seen := map[int64]bool{}
+	for _, userID := range recipients {
+		if userID <= 0 {
+			return models.FormNotificationSettings{}, errors.New("接收人ID无效")
+		}
+		if !seen[userID] {
+			seen[userID] = true
+			unique = append(unique, userID)
+		}
+	}
+
+	settings := models.FormNotificationSettings{
+		TenantID:   tenantID,
+		Recipients: unique,
+		UpdatedAt:  time.Now(),
+	}
+	if err := store.FormSettings.Save(&settings); err != nil {
+		return models.FormNotificationSettings{}, err
+	}
+	return settings, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+	"wz-backend-go/models"
+)
+
+func TestExportFormSubmissionsEscapesEveryCell(t *testing.T) {
+	useMemoryStore(t)
+	submission := &models.FormSubmission{
+		ID:          "submission-1",
+		SiteID:      "1",
+		PageID:      "@page",
+		ComponentID: "form-1",
+		FormName:    "=HYPERLINK(\"http://evil.example\")",
+		Values:      map[string]string{"name": "+1", "-field": "ok"},
+		IP:          "-1",
+		CreatedAt:   time.Now(),
+	}
+	if err := store.FormSubmissions.Create(submission); err != nil {
+		t.Fatalf("保存表单提交失败: %v", err)
+	}
+
+	data, err := ExportFormSubmissions("1", "form-1")
+	if err != nil {
+		t.Fatalf("导出表单提交失败: %v", err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff")))).ReadAll()
+	if err != nil {
+		t.Fatalf("解析CSV失败: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("应导出表头和一行提交: %v", rows)
+	}
+	for _, row := range rows {
+		for _, cell := range row {
+			if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+				t.Errorf("单元格%q可能被当作公式", cell)
+			}
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"errors"
+	"wz-backend-go/internal/pkg/sitenav"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrNavigationPageNotFound 导航项指向的页面不存在
+var ErrNavigationPageNotFound = errors.New("导航项指向的页面不存在")
+
+// GetNavigation 获取站点导航，指向页面的导航项返回页面当前的链接
+func GetNavigation(siteID string) (models.Navigation, error) {
+	site, pages, err := loadNavigationSite(siteID)
+	if err != nil {
+		return models.Navigation{}, err
+	}
+	return navigationView(site, pages), nil
+}
+
+// UpdateNavigation 保存导航类型、样式、自动同步设置和完整的导航树，没有ID的导航项会生成新ID
+func UpdateNavigation(siteID string, navigation models.Navigation) (models.Navigation, error) {
+	site, pages, err := loadNavigationSite(siteID)
+	if err != nil {
+		return models.Navigation{}, err
+	}
+	if !sitenav.ValidType(navigation.Type) {
+		return models.Navigation{}, sitenav.ErrInvalidType
+	}
+	if navigation.Type == "" {
+		navigation.Type = sitenav.TypeHorizontal
+	}
+	if navigation.Items == nil {
+		navigation.Items = []models.NavigationItem{}
+	}
+	sitenav.AssignIDs(navigation.Items, uuid.NewString)
+	if err := sitenav.Validate(navigation.Items); err != nil {
+		return models.Navigation{}, err
+	}
+	if err := checkNavigationPages(navigation.Items, pages); err != nil {
+		return models.Navigation{}, err
+	}
+
+	site.Navigation = navigation
+	if err := saveNavigation(&site); err != nil {
+		return models.Navigation{}, err
+	}
+	return navigationView(site, pages), nil
+}
+
+// CreateNavigationItem 在parentID下第index个位置添加导航项，parentID为空时添加到顶层，index为负数时追加到末尾
+func CreateNavigationItem(siteID string, parentID string, index int, item models.NavigationItem) (models.NavigationItem, error) {
+	site, pages, err := loadNavigationSite(siteID)
+	if err != nil {
+		return models.NavigationItem{}, err
+	}
+	item.ID = uuid.NewString()
+	subtree := []models.NavigationItem{item}
+	sitenav.AssignIDs(subtree, uuid.NewString)
+	if err := sitenav.Validate(subtree); err != nil {
+		return models.NavigationItem{}, err
+	}
+	if err := checkNavigationPages(subtree, pages); err != nil {
+		return models.NavigationItem{}, err
+	}
+	item = subtree[0]
+
+	items, err := sitenav.Insert(site.Navigation.Items, parentID, index, item)
+	if err != nil {
+		return models.NavigationItem{}, err
+	}
+	site.Navigation.Items = items
+	if err := saveNavigation(&site); err != nil {
+		return models.NavigationItem{}, err
+	}
+	created, _, _ := sitenav.Find(navigationView(site, pages).Items, item.ID)
+	return created, nil
+}
+
+// UpdateNavigationItem 修改导航项的名称、链接、指向的页面、图标和外部链接标记，子项和位置不变
+func UpdateNavigationItem(siteID string, itemID string, item models.NavigationItem) (models.NavigationItem, error) {
+	site, pages, err := loadNavigationSite(siteID)
+	if err != nil {
+		return models.NavigationItem{}, err
+	}
+	item.ID = itemID
+	if err := sitenav.NormalizeItem(&item); err != nil {
+		return models.NavigationItem{}, err
+	}
+	if err := checkNavigationPages([]models.NavigationItem{item}, pages); err != nil {
+		return models.NavigationItem{}, err
+	}
+
+	items, ok := sitenav.Update(site.Navigation.Items, item)
+	if !ok {
+		return models.NavigationItem{}, sitenav.ErrItemNotFound
+	}
+	site.Navigation.Items = items
+	if err := saveNavigation(&site); err != nil {
+		return models.NavigationItem{}, err
+	}
+	updated, _, _ := sitenav.Find(navigationView(site, pages).Items, itemID)
+	return updated, nil
+}
+
+// MoveNavigationItem 把导航项连同子项移动到parentID下第index个位置，parentID为空时移动到顶层
+func MoveNavigationItem(siteID string, itemID string, parentID string, index int) (models.Navigation, error) {
+	site, pages, err := loadNavigationSite(siteID)
+	if err != nil {
+		return models.Navigation{}, err
+	}
+	items, err := sitenav.Move(site.Navigation.Items, itemID, parentID, index)
+	if err != nil {
+		return models.Navigation{}, err
+	}
+	site.Navigation.Items = items
+	if err := saveNavigation(&site); err != nil {
+		return models.Navigation{}, err
+	}
+	return navigationView(site, pages), nil
+}
+
+// DeleteNavigationItem 删除导航项及其子项
+func DeleteNavigationItem(siteID string, itemID string) error {
+	site, _, err := loadNavigationSite(siteID)
+	if err != nil {
+		return err
+	}
+	items, _, ok := sitenav.Remove(site.Navigation.Items, itemID)
+	if !ok {
+		return sitenav.ErrItemNotFound
+	}
+	site.Navigation.Items = items
+	return saveNavigation(&site)
+}
+
+// GenerateNavigation 按页面顺序重新生成导航，每个页面一个顶层导航项，原有的导航项被替换
+func GenerateNavigation(siteID string) (models.Navigation, error) {
+	site, pages, err := loadNavigationSite(siteID)
+	if err != nil {
+		return models.Navigation{}, err
+	}
+	site.Navigation.Items = sitenav.FromPages(pages, uuid.NewString)
+	if site.Navigation.Type == "" {
+		site.Navigation.Type = sitenav.TypeHorizontal
+	}
+	if err := saveNavigation(&site); err != nil {
+		return models.Navigation{}, err
+	}
+	return navigationView(site, pages), nil
+}
+
+// loadNavigationSite 加载站点和页面列表
+func loadNavigationSite(siteID string) (models.Site, []models.Page, error) {
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return models.Site{}, nil, errors.New("站点不存在")
+	}
+	pages, err := store.Pages.ListBySite(siteID)
+	if err != nil {
+		return models.Site{}, nil, err
+	}
+	return site, pages, nil
+}
+
+// saveNavigation 保存站点导航，导航出现在站点的所有页面中
+func saveNavigation(site *models.Site) error {
+	if err := store.Sites.Update(site); err != nil {
+		return err
+	}
+	store.NotifySiteChanged(site.ID)
+	return nil
+}
+
+// checkNavigationPages 检查导航项指向的页面都存在
+func checkNavigationPages(items []models.NavigationItem, pages []models.Page) error {
+	for _, item := range items {
+		if item.PageID != "" && !containsPage(pages, item.PageID) {
+			return ErrNavigationPageNotFound
+		}
+		if err := checkNavigationPages(item.Children, pages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// containsPage 判断页面列表中是否有指定页面
+func containsPage(pages []models.Page, pageID string) bool {
+	for _, page := range pages {
+		if page.ID == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// navigationView 返回给编辑器的导航，指向页面的导航项的Link填写页面在默认语言下的当前路径
+func navigationView(site models.Site, pages []models.Page) models.Navigation {
+	site.Pages = pages
+	navigation := site.Navigation
+	navigation.Items = pageLinks(site, navigation.Items)
+	return navigation
+}
+
+// pageLinks 为指向页面的导航项填写页面当前的路径
+func pageLinks(site models.Site, items []models.NavigationItem) []models.NavigationItem {
+	result := make([]models.NavigationItem, len(items))
+	for i, item := range items {
+		if item.PageID != "" {
+			for _, page := range site.Pages {
+				if page.ID == item.PageID {
+					item.Link = sitenav.PagePath(site, page, "")
+					break
+				}
+			}
+		}
+		item.Children = pageLinks(site, item.Children)
+		result[i] = item
+	}
+	return result
+}
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/componentdef"
+	"wz-backend-go/internal/pkg/globalsection"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/pkg/sitelocale"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// 导入冲突的自动处理方式
+const (
+	ConflictRenamed = "renamed" // 使用新的名称或Slug
+	ConflictSkipped = "skipped" // 不导入该项
+	ConflictCleared = "cleared" // 清空对目标环境中不存在的数据的引用
+)
+
+// ErrUnsupportedPackage 导出包的格式或版本不受支持
+var ErrUnsupportedPackage = fmt.Errorf("不支持的导出包，格式需为%s，版本不高于%d", models.SitePackageFormat, models.SitePackageVersion)
+
+// ExportSitePackage 把站点的草稿导出为导出包，includeTemplates为true时携带租户的私有模板。
+// 租户、发布状态和发布版本属于源环境，不包含在导出包中
+func ExportSitePackage(siteID string, tenantID string, includeTemplates bool) (models.SitePackage, error) {
+	tree, err := store.LoadSiteTree(siteID)
+	if err != nil || tree.TenantID != tenantID {
+		return models.SitePackage{}, errors.New("站点不存在")
+	}
+	tree, err = builder.CloneSite(tree)
+	if err != nil {
+		return models.SitePackage{}, err
+	}
+	tree.TenantID = ""
+	tree.Status = ""
+	tree.PublishedAt = nil
+	tree.PublishedVersion = 0
+
+	pkg := models.SitePackage{
+		Format:     models.SitePackageFormat,
+		Version:    models.SitePackageVersion,
+		ExportedAt: time.Now(),
+		Site:       tree,
+	}
+	domains, err := store.Domains.ListBySite(siteID)
+	if err != nil {
+		return models.SitePackage{}, err
+	}
+	for _, domain := range domains {
+		pkg.Domains = append(pkg.Domains, domain.Hostname)
+	}
+
+	if includeTemplates {
+		templates, err := store.Templates.List()
+		if err != nil {
+			return models.SitePackage{}, err
+		}
+		for _, template := range templates {
+			if template.TenantID == tenantID {
+				template.ID = ""
+				template.TenantID = ""
+				pkg.Templates = append(pkg.Templates, template)
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// ImportSitePackage 把导出包导入为tenantID的草稿站点。
+// 页面、区块、组件、全局区块和导航项的ID重新生成，指向原站点的链接和锚点随之重写；
+// 组件按组件定义的Schema校验，任何错误都不做修改并返回jsonschema.ValidationError。
+// 重复的页面Slug、已被使用的域名、同名站点等冲突会自动处理并记录在结果中；dryRun为true时只检查不写入
+func ImportSitePackage(pkg models.SitePackage, tenantID string, dryRun bool) (models.SiteImportResult, error) {
+	if pkg.Format != models.SitePackageFormat || pkg.Version < 1 || pkg.Version > models.SitePackageVersion {
+		return models.SiteImportResult{}, ErrUnsupportedPackage
+	}
+	if tenantID == "" {
+		return models.SiteImportResult{}, errors.New("需要指定目标租户")
+	}
+	if err := validateSitePackage(pkg); err != nil {
+		return models.SiteImportResult{}, err
+	}
+
+	site, err := builder.CloneSite(pkg.Site)
+	if err != nil {
+		return models.SiteImportResult{}, err
+	}
+	result := models.SiteImportResult{DryRun: dryRun, Conflicts: []models.SiteImportConflict{}}
+	conflict := func(kind string, path string, value string, message string, resolution string) {
+		result.Conflicts = append(result.Conflicts, models.SiteImportConflict{
+			Type: kind, Path: path, Value: value, Message: message, Resolution: resolution,
+		})
+	}
+
+	// 站点名称在租户内重复时加上序号
+	existing, err := store.Sites.List(builder.SiteFilter{TenantID: tenantID})
+	if err != nil {
+		return models.SiteImportResult{}, err
+	}
+	names := map[string]bool{}
+	for _, s := range existing {
+		names[s.Name] = true
+	}
+	if names[site.Name] {
+		name := site.Name
+		for n := 2; names[name]; n++ {
+			name = fmt.Sprintf("%s (%d)", site.Name, n)
+		}
+		conflict("name", "site.name", site.Name, "租户已有同名站点", ConflictRenamed)
+		site.Name = name
+	}
+
+	// 域名需要在目标环境重新添加并验证，已被其他站点绑定的域名无法使用
+	sourceHosts := append([]string{site.Domain}, pkg.Domains...)
+	for i, hostname := range pkg.Domains {
+		if _, err := store.Domains.GetByHostname(hostname); err == nil {
+			conflict("domain", fmt.Sprintf("domains[%d]", i), hostname, "域名已绑定到其他站点", ConflictSkipped)
+		}
+	}
+	site.Domain = ""
+	// 已在校验时检查，这里规范化语言代码
+	sitelocale.Validate(&site)
+
+	// 主题库中不存在的主题只保留复制到站点的主题配置
+	if site.ThemeID != 0 {
+		if _, err := store.Themes.Get(site.ThemeID); err != nil {
+			conflict("theme", "site.themeId", fmt.Sprint(site.ThemeID), "主题库中没有该主题，保留站点的主题配置", ConflictCleared)
+			site.ThemeID = 0
+		}
+	}
+
+	// 页面Slug重复时加上序号，只保留一个首页
+	usedSlugs := map[string]bool{}
+	homepage := -1
+	for i := range site.Pages {
+		page := &site.Pages[i]
+		if page.IsHomepage && homepage == -1 {
+			homepage = i
+		} else if page.IsHomepage {
+			conflict("slug", fmt.Sprintf("site.pages[%d].isHomepage", i), page.Slug, "导出包中有多个首页", ConflictCleared)
+			page.IsHomepage = false
+		}
+		slug := strings.ToLower(strings.TrimSpace(page.Slug))
+		if slug == "" {
+			slug = fmt.Sprintf("page-%d", i+1)
+		}
+		if usedSlugs[slug] {
+			base := slug
+			for n := 2; usedSlugs[slug]; n++ {
+				slug = fmt.Sprintf("%s-%d", base, n)
+			}
+			conflict("slug", fmt.Sprintf("site.pages[%d].slug", i), page.Slug, "页面Slug重复", ConflictRenamed)
+		}
+		usedSlugs[slug] = true
+		page.Slug = slug
+	}
+	if homepage == -1 {
+		site.Pages[0].IsHomepage = true
+	}
+
+	// 租户已有同名私有模板时不导入
+	var templates []models.SiteTemplate
+	if len(pkg.Templates) > 0 {
+		all, err := store.Templates.List()
+		if err != nil {
+			return models.SiteImportResult{}, err
+		}
+		templateNames := map[string]bool{}
+		for _, template := range all {
+			if template.TenantID == tenantID {
+				templateNames[template.Name] = true
+			}
+		}
+		for i, template := range pkg.Templates {
+			if templateNames[template.Name] {
+				conflict("template", fmt.Sprintf("templates[%d].name", i), template.Name, "租户已有同名模板", ConflictSkipped)
+				continue
+			}
+			templateNames[template.Name] = true
+			template.ID = ""
+			template.TenantID = tenantID
+			templates = append(templates, template)
+		}
+	}
+
+	result.Pages = len(site.Pages)
+	for _, page := range site.Pages {
+		result.Sections += len(page.Sections)
+	}
+	result.Templates = len(templates)
+	if dryRun {
+		return result, nil
+	}
+
+	now := time.Now()
+	oldSiteID := site.ID
+	oldIDs := collectTreeIDs(site)
+	site.TenantID = tenantID
+	site.Status = "draft"
+	site.PublishedAt = nil
+	site.PublishedVersion = 0
+	site.CreatedAt = now
+	site.UpdatedAt = now
+	site.Navigation.Items = renewNavigationIDs(site.Navigation.Items)
+	for i := range site.Pages {
+		site.Pages[i].CreatedAt = now
+		site.Pages[i].UpdatedAt = now
+	}
+	if err := store.CreateSiteTree(&site); err != nil {
+		return models.SiteImportResult{}, err
+	}
+
+	// 区块和组件的ID已重新生成，按相同顺序建立新旧ID的对应关系
+	anchors := map[string]string{}
+	newIDs := collectTreeIDs(site)
+	for i, id := range oldIDs {
+		if id != "" {
+			anchors[id] = newIDs[i]
+		}
+	}
+	rewriter := linkRewriter{
+		hosts:     sourceHosts,
+		oldPrefix: "/render/sites/" + oldSiteID,
+		newPrefix: "/render/sites/" + site.ID,
+		anchors:   anchors,
+	}
+	if err := rewriteSiteLinks(&site, &rewriter); err != nil {
+		store.DeleteSiteTree(site.ID)
+		return models.SiteImportResult{}, err
+	}
+
+	for i := range templates {
+		if err := store.Templates.Create(&templates[i]); err != nil {
+			store.DeleteSiteTree(site.ID)
+			return models.SiteImportResult{}, err
+		}
+	}
+
+	result.SiteID = site.ID
+	result.Links = rewriter.count
+	return result, nil
+}
+
+// validateSitePackage 校验导出包中的站点、主题、组件、全局区块引用和模板，字段以导出包中的位置标识
+func validateSitePackage(pkg models.SitePackage) error {
+	var fieldErrors []jsonschema.FieldError
+	addErrors := func(prefix string, err error) {
+		var validationErr *jsonschema.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, fieldError := range validationErr.Errors {
+				fieldError.Field = prefix + "." + fieldError.Field
+				fieldErrors = append(fieldErrors, fieldError)
+			}
+		} else if err != nil {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: prefix, Message: err.Error()})
+		}
+	}
+
+	site := pkg.Site
+	if strings.TrimSpace(site.Name) == "" {
+		fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: "site.name", Message: "站点名称不能为空"})
+	}
+	if len(site.Pages) == 0 {
+		fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: "site.pages", Message: "站点至少需要一个页面"})
+	}
+	if err := sitelocale.Validate(&site); err != nil {
+		fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: "site.locales", Message: err.Error()})
+	}
+	var themeErr *jsonschema.ValidationError
+	if errors.As(sitetheme.Validate("site.theme", site.Theme), &themeErr) {
+		fieldErrors = append(fieldErrors, themeErr.Errors...)
+	}
+
+	checkComponents := func(prefix string, components []models.Component) {
+		for k, component := range components {
+			addErrors(fmt.Sprintf("%s.components[%d]", prefix, k), componentdef.Validate(component))
+		}
+	}
+	for i, section := range site.GlobalSections {
+		prefix := fmt.Sprintf("site.globalSections[%d]", i)
+		if section.ID == "" {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: prefix + ".id", Message: "全局区块缺少ID"})
+		}
+		checkComponents(prefix, section.Components)
+	}
+	for _, slot := range []struct{ field, id string }{
+		{"site.headerSectionId", site.HeaderSectionID},
+		{"site.footerSectionId", site.FooterSectionID},
+	} {
+		if _, ok := globalsection.Find(site.GlobalSections, slot.id); slot.id != "" && !ok {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: slot.field, Message: "引用的全局区块不存在"})
+		}
+	}
+	for i, page := range site.Pages {
+		if strings.TrimSpace(page.Name) == "" {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: fmt.Sprintf("site.pages[%d].name", i), Message: "页面名称不能为空"})
+		}
+		for j, section := range page.Sections {
+			prefix := fmt.Sprintf("site.pages[%d].sections[%d]", i, j)
+			if section.GlobalSectionID != "" {
+				if _, ok := globalsection.Find(site.GlobalSections, section.GlobalSectionID); !ok {
+					fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: prefix + ".globalSectionId", Message: "引用的全局区块不存在"})
+				}
+				continue
+			}
+			checkComponents(prefix, section.Components)
+		}
+	}
+
+	for i, template := range pkg.Templates {
+		if strings.TrimSpace(template.Name) == "" {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: fmt.Sprintf("templates[%d].name", i), Message: "模板名称不能为空"})
+		}
+		if _, err := ParseTemplateConfig(template.Config); err != nil {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: fmt.Sprintf("templates[%d].config", i), Message: err.Error()})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &jsonschema.ValidationError{Errors: fieldErrors}
+	}
+	return nil
+}
+
+// collectTreeIDs 按固定顺序列出站点树中页面、区块和组件的ID，用于在重新生成ID后建立对应关系
+func collectTreeIDs(site models.Site) []string {
+	var ids []string
+	collect := func(sections []models.Section) {
+		for _, section := range sections {
+			ids = append(ids, section.ID)
+			for _, component := range section.Components {
+				ids = append(ids, component.ID)
+			}
+		}
+	}
+	collect(site.GlobalSections)
+	for _, page := range site.Pages {
+		ids = append(ids, page.ID)
+		collect(page.Sections)
+	}
+	return ids
+}
+
+// rewriteSiteLinks 重写已保存的站点中组件和导航的链接，只保存有变化的组件
+func rewriteSiteLinks(site *models.Site, rewriter *linkRewriter) error {
+	rewriteSections := func(sections []models.Section) error {
+		for _, section := range sections {
+			for _, component := range section.Components {
+				before := rewriter.count
+				settings := rewriter.rewriteValue(component.Settings)
+				content := rewriter.rewriteValue(component.Content)
+				translations := map[string]map[string]interface{}{}
+				for locale, fields := range component.Translations {
+					translations[locale], _ = rewriter.rewriteValue(fields).(map[string]interface{})
+				}
+				if rewriter.count == before {
+					continue
+				}
+				// 使用存储中的修订号更新
+				stored, err := store.Components.Get(section.ID, component.ID)
+				if err != nil {
+					return err
+				}
+				stored.Settings = settings
+				stored.Content = content
+				if len(component.Translations) > 0 {
+					stored.Translations = translations
+				}
+				if err := store.Components.Update(&stored); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := rewriteSections(site.GlobalSections); err != nil {
+		return err
+	}
+	for _, page := range site.Pages {
+		if err := rewriteSections(page.Sections); err != nil {
+			return err
+		}
+	}
+
+	before := rewriter.count
+	site.Navigation.Items = rewriter.rewriteNavigation(site.Navigation.Items)
+	if rewriter.count == before {
+		return nil
+	}
+	stored, err := store.Sites.Get(site.ID)
+	if err != nil {
+		return err
+	}
+	stored.Navigation = site.Navigation
+	return store.Sites.Update(&stored)
+}
+
+// linkRewriter 把指向原站点的链接改为导入后站点的链接：原域名的绝对地址改为站内路径，
+// 按站点ID访问的渲染地址替换站点ID，指向区块或组件的锚点替换为新ID
+type linkRewriter struct {
+	hosts     []string
+	oldPrefix string
+	newPrefix string
+	anchors   map[string]string
+	count     int
+}
+
+// rewriteValue 重写JSON值中所有看起来是链接的字符串
+func (r *linkRewriter) rewriteValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.rewrite(v)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = r.rewriteValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = r.rewriteValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+func (r *linkRewriter) rewriteNavigation(items []models.NavigationItem) []models.NavigationItem {
+	for i := range items {
+		items[i].Link = r.rewrite(items[i].Link)
+		items[i].Children = r.rewriteNavigation(items[i].Children)
+	}
+	return items
+}
+
+// rewrite 重写单个链接，不是链接或不需要修改时原样返回
+func (r *linkRewriter) rewrite(link string) string {
+	if link == "" || strings.ContainsAny(link, " \t\r\n") {
+		return link
+	}
+	rewritten := link
+	lower := strings.ToLower(link)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		u, err := url.Parse(link)
+		if err != nil || !r.sourceHost(u.Hostname()) {
+			return link
+		}
+		u.Scheme = ""
+		u.Host = ""
+		u.User = nil
+		rewritten = u.String()
+		if !strings.HasPrefix(rewritten, "/") {
+			rewritten = "/" + rewritten
+		}
+	} else if !strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "#") {
+		return link
+	}
+
+	if rest, ok := strings.CutPrefix(rewritten, r.oldPrefix); ok && r.oldPrefix != "/render/sites/" && (rest == "" || strings.ContainsAny(rest[:1], "/?#")) {
+		rewritten = r.newPrefix + rest
+	}
+	if base, fragment, ok := strings.Cut(rewritten, "#"); ok {
+		if id, exists := r.anchors[fragment]; exists {
+			rewritten = base + "#" + id
+		}
+	}
+	if rewritten != link {
+		r.count++
+	}
+	return rewritten
+}
+
+// sourceHost 判断域名是否属于原站点
+func (r *linkRewriter) sourceHost(hostname string) bool {
+	for _, host := range r.hosts {
+		if host != "" && strings.EqualFold(host, hostname) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+	"wz-backend-go/internal/pkg/previewtoken"
+	"wz-backend-go/models"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// defaultPreviewHours 未指定有效期时预览链接的有效小时数
+	defaultPreviewHours = 72
+	// maxPreviewHours 预览链接最长的有效小时数
+	maxPreviewHours = 30 * 24
+	// minPreviewPasswordLength 预览密码的最小长度
+	minPreviewPasswordLength = 4
+)
+
+// 预览链接状态
+const (
+	PreviewLinkActive  = "active"
+	PreviewLinkExpired = "expired"
+	PreviewLinkRevoked = "revoked"
+)
+
+// ErrPreviewLinkNotFound 预览链接不存在
+var ErrPreviewLinkNotFound = errors.New("预览链接不存在")
+
+// previewSecret 预览令牌的签名密钥，由main在启动时设置，渲染服务需要使用相同的密钥
+var previewSecret []byte
+
+// SetPreviewSecret 设置预览令牌的签名密钥
+func SetPreviewSecret(secret []byte) {
+	previewSecret = secret
+}
+
+// PreviewLinkInput 创建预览链接的参数
+type PreviewLinkInput struct {
+	PageID    string `json:"pageId"`    // 为空时可以预览所有页面
+	Version   int    `json:"version"`   // 0表示当前草稿
+	ExpiresIn int    `json:"expiresIn"` // 有效小时数，默认72，最长720
+	Password  string `json:"password"`  // 为空时不需要密码
+	Note      string `json:"note"`
+}
+
+// PreviewLink 预览链接记录及其访问地址
+type PreviewLink struct {
+	models.PreviewToken
+	Token       string `json:"token"`
+	URL         string `json:"url"`
+	HasPassword bool   `json:"hasPassword"`
+	Status      string `json:"status"` // active、expired或revoked
+}
+
+// ListPreviewLinks 获取站点的预览链接，包括已过期和已撤销的链接
+func ListPreviewLinks(siteID string) ([]PreviewLink, error) {
+	tokens, err := store.PreviewTokens.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	links := make([]PreviewLink, 0, len(tokens))
+	for _, token := range tokens {
+		link, err := newPreviewLink(token)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// CreatePreviewLink 为站点的草稿或发布版本创建预览链接
+func CreatePreviewLink(siteID string, createdBy string, input PreviewLinkInput) (PreviewLink, error) {
+	if input.ExpiresIn == 0 {
+		input.ExpiresIn = defaultPreviewHours
+	}
+	if input.ExpiresIn < 0 || input.ExpiresIn > maxPreviewHours {
+		return PreviewLink{}, errors.New("有效期必须在1到720小时之间")
+	}
+	if input.Password != "" && utf8.RuneCountInString(input.Password) < minPreviewPasswordLength {
+		return PreviewLink{}, errors.New("预览密码至少需要4个字符")
+	}
+	if utf8.RuneCountInString(input.Note) > 200 {
+		return PreviewLink{}, errors.New("备注不能超过200个字符")
+	}
+	if err := checkPreviewTarget(siteID, input.PageID, input.Version); err != nil {
+		return PreviewLink{}, err
+	}
+
+	now := time.Now()
+	token := models.PreviewToken{
+		ID:        uuid.NewString(),
+		SiteID:    siteID,
+		PageID:    input.PageID,
+		Version:   input.Version,
+		Note:      input.Note,
+		ExpiresAt: now.Add(time.Duration(input.ExpiresIn) * time.Hour).Truncate(time.Second),
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+	if input.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return PreviewLink{}, err
+		}
+		token.PasswordHash = string(hash)
+	}
+	if err := store.PreviewTokens.Create(&token); err != nil {
+		return PreviewLink{}, err
+	}
+	return newPreviewLink(token)
+}
+
+// RevokePreviewLink 撤销预览链接，撤销后链接立即失效，记录保留在列表中
+func RevokePreviewLink(siteID string, tokenID string) (PreviewLink, error) {
+	token, err := store.PreviewTokens.Get(siteID, tokenID)
+	if err != nil {
+		return PreviewLink{}, ErrPreviewLinkNotFound
+	}
+	if token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+		if err := store.PreviewTokens.Update(&token); err != nil {
+			return PreviewLink{}, err
+		}
+	}
+	return newPreviewLink(token)
+}
+
+// checkPreviewTarget 检查预览的版本和页面存在，指定版本时页面必须在该版本中
+func checkPreviewTarget(siteID string, pageID string, version int) error {
+	if version < 0 {
+		return errors.New("版本号无效")
+	}
+	if version > 0 {
+		snapshot, err := store.Versions.Get(siteID, version)
+		if err != nil {
+			return errors.New("版本不存在")
+		}
+		if pageID == "" {
+			return nil
+		}
+		for _, page := range snapshot.Snapshot.Pages {
+			if page.ID == pageID {
+				return nil
+			}
+		}
+		return errors.New("该版本中没有此页面")
+	}
+	if pageID != "" {
+		if _, err := store.Pages.Get(siteID, pageID); err != nil {
+			return errors.New("页面不存在")
+		}
+	}
+	return nil
+}
+
+// newPreviewLink 签发令牌并生成预览地址
+func newPreviewLink(token models.PreviewToken) (PreviewLink, error) {
+	signed, err := previewtoken.Sign(previewSecret, previewtoken.Claims{
+		ID:        token.ID,
+		SiteID:    token.SiteID,
+		PageID:    token.PageID,
+		Version:   token.Version,
+		ExpiresAt: token.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return PreviewLink{}, err
+	}
+
+	status := PreviewLinkActive
+	switch {
+	case token.RevokedAt != nil:
+		status = PreviewLinkRevoked
+	case !time.Now().Before(token.ExpiresAt):
+		status = PreviewLinkExpired
+	}
+	return PreviewLink{
+		PreviewToken: token,
+		Token:        signed,
+		URL:          "/render/preview/" + signed,
+		HasPassword:  token.PasswordHash != "",
+		Status:       status,
+	}, nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/jsonschema"
+	"wz-backend-go/internal/pkg/redirects"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// maxRedirectsPerSite 每个站点最多的重定向规则数量，包括Slug历史生成的规则
+const maxRedirectsPerSite = 5000
+
+var (
+	// ErrRedirectNotFound 重定向规则不存在
+	ErrRedirectNotFound = errors.New("重定向不存在")
+	// ErrDuplicateRedirect 相同来源和匹配方式的规则已存在
+	ErrDuplicateRedirect = errors.New("相同来源的重定向已存在")
+	// ErrTooManyRedirects 超过站点的规则数量上限
+	ErrTooManyRedirects = fmt.Errorf("每个站点最多%d条重定向", maxRedirectsPerSite)
+)
+
+// redirectCSVHeader 导入导出CSV的列，导入时hits列会被忽略
+var redirectCSVHeader = []string{"source", "target", "type", "status", "hits"}
+
+// RedirectImportResult 导入重定向的结果
+type RedirectImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// ListRedirects 获取站点的重定向规则，按创建顺序排列
+func ListRedirects(siteID string) ([]models.SiteRedirect, error) {
+	rules, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		return []models.SiteRedirect{}, nil
+	}
+	return rules, nil
+}
+
+// CreateRedirect 添加重定向规则
+func CreateRedirect(siteID string, rule models.SiteRedirect) (models.SiteRedirect, error) {
+	if err := redirects.Normalize(&rule); err != nil {
+		return models.SiteRedirect{}, err
+	}
+	rules, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return models.SiteRedirect{}, err
+	}
+	if len(rules) >= maxRedirectsPerSite {
+		return models.SiteRedirect{}, ErrTooManyRedirects
+	}
+	if findRedirect(rules, rule.Source, rule.MatchType, "") != nil {
+		return models.SiteRedirect{}, ErrDuplicateRedirect
+	}
+
+	now := time.Now()
+	rule.ID = ""
+	rule.SiteID = siteID
+	rule.PageID = ""
+	rule.Hits = 0
+	rule.LastHitAt = nil
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := store.Redirects.Create(&rule); err != nil {
+		return models.SiteRedirect{}, err
+	}
+	store.NotifyRedirectsChanged(siteID)
+	return rule, nil
+}
+
+// UpdateRedirect 修改重定向规则，保留命中统计。修改Slug历史生成的规则后，该规则按手动规则处理
+func UpdateRedirect(siteID string, redirectID string, rule models.SiteRedirect) (models.SiteRedirect, error) {
+	existing, err := store.Redirects.Get(siteID, redirectID)
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.SiteRedirect{}, ErrRedirectNotFound
+		}
+		return models.SiteRedirect{}, err
+	}
+	if err := redirects.Normalize(&rule); err != nil {
+		return models.SiteRedirect{}, err
+	}
+	rules, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return models.SiteRedirect{}, err
+	}
+	if findRedirect(rules, rule.Source, rule.MatchType, redirectID) != nil {
+		return models.SiteRedirect{}, ErrDuplicateRedirect
+	}
+
+	existing.Source = rule.Source
+	existing.Target = rule.Target
+	existing.MatchType = rule.MatchType
+	existing.StatusCode = rule.StatusCode
+	existing.PageID = ""
+	existing.UpdatedAt = time.Now()
+	if err := store.Redirects.Update(&existing); err != nil {
+		return models.SiteRedirect{}, err
+	}
+	store.NotifyRedirectsChanged(siteID)
+	return existing, nil
+}
+
+// DeleteRedirect 删除重定向规则
+func DeleteRedirect(siteID string, redirectID string) error {
+	if err := store.Redirects.Delete(siteID, redirectID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return ErrRedirectNotFound
+		}
+		return err
+	}
+	store.NotifyRedirectsChanged(siteID)
+	return nil
+}
+
+// findRedirect 查找来源和匹配方式相同的规则，exceptID为修改中的规则
+func findRedirect(rules []models.SiteRedirect, source string, matchType string, exceptID string) *models.SiteRedirect {
+	for i := range rules {
+		if rules[i].ID != exceptID && rules[i].Source == source && rules[i].MatchType == matchType {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// ExportRedirects 将站点的重定向规则导出为CSV，列为source、target、type、status、hits
+func ExportRedirects(siteID string) ([]byte, error) {
+	rules, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	// 写入BOM，便于Excel识别UTF-8编码
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.Write(redirectCSVHeader); err != nil {
+		return nil, err
+	}
+	for _, rule := range rules {
+		record := []string{rule.Source, rule.Target, rule.MatchType, strconv.Itoa(rule.StatusCode), strconv.FormatInt(rule.Hits, 10)}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ImportRedirects 从CSV导入重定向规则。第一行为source、target等列名时按列名读取，否则按
+// source、target、type、status的顺序读取，type和status可以省略。
+// 任何一行无效时不做修改，返回包含每行错误的jsonschema.ValidationError。
+// replace为true时先删除所有手动添加的规则，否则来源和匹配方式相同的规则会被更新
+func ImportRedirects(siteID string, r io.Reader, replace bool) (RedirectImportResult, error) {
+	imported, err := parseRedirectCSV(r)
+	if err != nil {
+		return RedirectImportResult{}, err
+	}
+	existing, err := store.Redirects.ListBySite(siteID)
+	if err != nil {
+		return RedirectImportResult{}, err
+	}
+
+	var result RedirectImportResult
+	kept := existing
+	if replace {
+		kept = nil
+		for _, rule := range existing {
+			if rule.PageID != "" {
+				kept = append(kept, rule)
+			}
+		}
+	}
+	count := len(kept)
+	for _, rule := range imported {
+		if findRedirect(kept, rule.Source, rule.MatchType, "") == nil {
+			count++
+		}
+	}
+	if count > maxRedirectsPerSite {
+		return RedirectImportResult{}, ErrTooManyRedirects
+	}
+	// 中途失败时已写入的规则也需要生效
+	defer store.NotifyRedirectsChanged(siteID)
+
+	if replace {
+		for _, rule := range existing {
+			if rule.PageID != "" {
+				continue
+			}
+			if err := store.Redirects.Delete(siteID, rule.ID); err != nil {
+				return result, err
+			}
+			result.Deleted++
+		}
+	}
+
+	now := time.Now()
+	for _, rule := range imported {
+		if current := findRedirect(kept, rule.Source, rule.MatchType, ""); current != nil {
+			current.Target = rule.Target
+			current.StatusCode = rule.StatusCode
+			current.PageID = ""
+			current.UpdatedAt = now
+			if err := store.Redirects.Update(current); err != nil {
+				return result, err
+			}
+			result.Updated++
+			continue
+		}
+		rule.SiteID = siteID
+		rule.CreatedAt = now
+		rule.UpdatedAt = now
+		if err := store.Redirects.Create(&rule); err != nil {
+			return result, err
+		}
+		result.Created++
+	}
+	return result, nil
+}
+
+// parseRedirectCSV 解析并校验导入的CSV，字段错误以rows[行号]标识
+func parseRedirectCSV(r io.Reader) ([]models.SiteRedirect, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV格式错误: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("CSV文件为空")
+	}
+
+	columns := map[string]int{"source": 0, "target": 1, "type": 2, "status": 3}
+	first := 0
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+	if strings.EqualFold(strings.TrimSpace(records[0][0]), "source") {
+		columns = map[string]int{}
+		for i, name := range records[0] {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := columns["target"]; !ok {
+			return nil, errors.New("CSV缺少target列")
+		}
+		first = 1
+	}
+	cell := func(record []string, name string) string {
+		index, ok := columns[name]
+		if !ok || index >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[index])
+	}
+
+	var rules []models.SiteRedirect
+	var fieldErrors []jsonschema.FieldError
+	seen := map[string]int{}
+	for i := first; i < len(records); i++ {
+		record := records[i]
+		row := fmt.Sprintf("rows[%d]", i+1)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		rule := models.SiteRedirect{
+			Source:    cell(record, "source"),
+			Target:    cell(record, "target"),
+			MatchType: strings.ToLower(cell(record, "type")),
+		}
+		if status := cell(record, "status"); status != "" {
+			code, err := strconv.Atoi(status)
+			if err != nil {
+				fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: row + ".status", Message: "状态码只能是301或302"})
+				continue
+			}
+			rule.StatusCode = code
+		}
+		if err := redirects.Normalize(&rule); err != nil {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: row, Message: err.Error()})
+			continue
+		}
+		key := rule.MatchType + " " + rule.Source
+		if previous, ok := seen[key]; ok {
+			fieldErrors = append(fieldErrors, jsonschema.FieldError{Field: row, Message: fmt.Sprintf("与第%d行的来源重复", previous)})
+			continue
+		}
+		seen[key] = i + 1
+		rules = append(rules, rule)
+	}
+	if len(fieldErrors) > 0 {
+		return nil, &jsonschema.ValidationError{Errors: fieldErrors}
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("CSV中没有重定向规则")
+	}
+	return rules, nil
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SiteExporter 站点静态导出
+type SiteExporter interface {
+	ExportSite(siteID string) error
+}
+
+// 发布时使用的导出器，未配置时不导出
+var siteExporter SiteExporter
+
+// SetSiteExporter 设置站点导出器
+func SetSiteExporter(exporter SiteExporter) {
+	siteExporter = exporter
+}
+
+// ExportEnabled 是否配置了静态导出
+func ExportEnabled() bool {
+	return siteExporter != nil
+}
+
+// ExportSite 生成站点静态快照
+func ExportSite(siteID string) error {
+	if siteExporter == nil {
+		return nil
+	}
+	return siteExporter.ExportSite(siteID)
+}
+
+// RenderServiceClient 通过render-service的内部接口生成静态快照
+type RenderServiceClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewRenderServiceClient 创建render-service客户端
+func NewRenderServiceClient(baseURL string) *RenderServiceClient {
+	return &RenderServiceClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// ExportSite 请求render-service生成站点快照
+func (r *RenderServiceClient) ExportSite(siteID string) error {
+	resp, err := r.client.Post(fmt.Sprintf("%s/internal/export/sites/%s", r.baseURL, siteID), "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("请求渲染服务失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("渲染服务导出失败，状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
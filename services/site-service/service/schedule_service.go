@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+	"wz-backend-go/internal/repository/builder"
+	"wz-backend-go/models"
+)
+
+// 定时任务的动作
+const (
+	ScheduleActionPublish   = "publish"
+	ScheduleActionUnpublish = "unpublish"
+)
+
+const (
+	// scheduleBatchSize 每次轮询最多执行的任务数
+	scheduleBatchSize = 50
+	// scheduleStaleAfter 执行中的任务超过该时间仍未结束时视为实例中断，标记为失败而不是重新执行，避免重复发布
+	scheduleStaleAfter = 10 * time.Minute
+	// maxScheduleAhead 最远可以提前设置的时间
+	maxScheduleAhead = 366 * 24 * time.Hour
+)
+
+var (
+	// ErrScheduleNotFound 定时任务不存在
+	ErrScheduleNotFound = errors.New("定时任务不存在")
+	// ErrScheduleNotPending 任务已执行或已取消
+	ErrScheduleNotPending = errors.New("定时任务已执行或已取消")
+)
+
+// schedulerInstance 当前服务实例的标识，记录在领取的任务上
+var schedulerInstance = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}()
+
+// ListSchedules 获取站点的定时任务，包括已执行和已取消的任务，按执行时间倒序
+func ListSchedules(siteID string) ([]models.PublishSchedule, error) {
+	schedules, err := store.Schedules.ListBySite(siteID)
+	if err != nil {
+		return nil, err
+	}
+	if schedules == nil {
+		return []models.PublishSchedule{}, nil
+	}
+	return schedules, nil
+}
+
+// CreateSchedule 创建定时任务，pageID为空时作用于整个站点
+func CreateSchedule(siteID string, pageID string, action string, runAt time.Time, note string, createdBy string) (models.PublishSchedule, error) {
+	if action != ScheduleActionPublish && action != ScheduleActionUnpublish {
+		return models.PublishSchedule{}, errors.New("动作只能是publish或unpublish")
+	}
+	now := time.Now()
+	if !runAt.After(now) {
+		return models.PublishSchedule{}, errors.New("执行时间必须晚于当前时间")
+	}
+	if runAt.After(now.Add(maxScheduleAhead)) {
+		return models.PublishSchedule{}, errors.New("执行时间不能超过一年")
+	}
+	if pageID != "" {
+		page, err := store.Pages.Get(siteID, pageID)
+		if err != nil {
+			return models.PublishSchedule{}, errors.New("页面不存在")
+		}
+		if action == ScheduleActionUnpublish && page.IsHomepage {
+			return models.PublishSchedule{}, builder.ErrHomepageUnpublish
+		}
+	}
+
+	schedule := models.PublishSchedule{
+		SiteID:    siteID,
+		PageID:    pageID,
+		Action:    action,
+		RunAt:     runAt,
+		Status:    builder.SchedulePending,
+		Note:      note,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+	}
+	if err := store.Schedules.Create(&schedule); err != nil {
+		return models.PublishSchedule{}, err
+	}
+	return schedule, nil
+}
+
+// CancelSchedule 取消尚未执行的定时任务，记录取消人
+func CancelSchedule(siteID string, scheduleID string, cancelledBy string) (models.PublishSchedule, error) {
+	schedule, err := store.Schedules.Get(siteID, scheduleID)
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.PublishSchedule{}, ErrScheduleNotFound
+		}
+		return models.PublishSchedule{}, err
+	}
+
+	now := time.Now()
+	schedule.Status = builder.ScheduleCancelled
+	schedule.CancelledBy = cancelledBy
+	schedule.CancelledAt = &now
+	ok, err := store.Schedules.Transition(&schedule, builder.SchedulePending)
+	if err != nil {
+		return models.PublishSchedule{}, err
+	}
+	if !ok {
+		return models.PublishSchedule{}, ErrScheduleNotPending
+	}
+	return schedule, nil
+}
+
+// UnpublishSite 立即下线站点，访问站点页面返回404，发布版本保留
+func UnpublishSite(siteID string) (models.Site, error) {
+	if err := store.UnpublishSite(siteID); err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return models.Site{}, errors.New("站点不存在")
+		}
+		return models.Site{}, err
+	}
+	return store.Sites.Get(siteID)
+}
+
+// PublishPage 立即把页面的草稿发布到线上版本，返回新的版本号
+func PublishPage(siteID string, pageID string, publishedBy string) (int, error) {
+	version, err := store.PublishPage(siteID, pageID, publishedBy, "发布页面")
+	return version.Version, err
+}
+
+// UnpublishPage 立即从线上版本中移除页面，返回新的版本号
+func UnpublishPage(siteID string, pageID string, publishedBy string) (int, error) {
+	version, err := store.UnpublishPage(siteID, pageID, publishedBy, "下线页面")
+	return version.Version, err
+}
+
+// StartScheduler 在后台按interval轮询并执行到期的定时任务。
+// 任务保存在数据存储中，服务重启后错过的任务会在下一次轮询时执行；多个实例同时运行时，
+// 每个任务只会被一个实例领取
+func StartScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			RunDueSchedules(time.Now())
+			<-ticker.C
+		}
+	}()
+}
+
+// RunDueSchedules 执行到期的定时任务，并把中断的任务标记为失败
+func RunDueSchedules(now time.Time) {
+	stale, err := store.Schedules.ListRunning(now.Add(-scheduleStaleAfter))
+	if err != nil {
+		log.Printf("读取执行中的定时任务失败: %v", err)
+	}
+	for _, schedule := range stale {
+		finished := now
+		schedule.Status = builder.ScheduleFailed
+		schedule.FinishedAt = &finished
+		schedule.Error = "执行中断，请确认站点状态后重新设置"
+		if _, err := store.Schedules.Transition(&schedule, builder.ScheduleRunning); err != nil {
+			log.Printf("更新定时任务%s失败: %v", schedule.ID, err)
+		}
+	}
+
+	due, err := store.Schedules.ListDue(now, scheduleBatchSize)
+	if err != nil {
+		log.Printf("读取到期的定时任务失败: %v", err)
+		return
+	}
+	for _, schedule := range due {
+		runSchedule(schedule)
+	}
+}
+
+// runSchedule 领取并执行任务，领取失败说明任务已被其他实例执行或已取消
+func runSchedule(schedule models.PublishSchedule) {
+	started := time.Now()
+	schedule.Status = builder.ScheduleRunning
+	schedule.ClaimedBy = schedulerInstance
+	schedule.StartedAt = &started
+	claimed, err := store.Schedules.Transition(&schedule, builder.SchedulePending)
+	if err != nil {
+		log.Printf("领取定时任务%s失败: %v", schedule.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	version, err := executeSchedule(schedule)
+	finished := time.Now()
+	schedule.FinishedAt = &finished
+	schedule.Version = version
+	if err != nil {
+		schedule.Status = builder.ScheduleFailed
+		schedule.Error = err.Error()
+		log.Printf("定时任务%s执行失败: %v", schedule.ID, err)
+	} else {
+		schedule.Status = builder.ScheduleDone
+	}
+	if _, err := store.Schedules.Transition(&schedule, builder.ScheduleRunning); err != nil {
+		log.Printf("更新定时任务%s失败: %v", schedule.ID, err)
+	}
+}
+
+// executeSchedule 执行任务，返回产生的发布版本号
+func executeSchedule(schedule models.PublishSchedule) (int, error) {
+	note := schedule.Note
+	if note == "" && schedule.Action == ScheduleActionPublish {
+		note = "定时发布"
+	} else if note == "" {
+		note = "定时下线"
+	}
+
+	var version models.SiteVersion
+	var err error
+	switch {
+	case schedule.PageID == "" && schedule.Action == ScheduleActionPublish:
+		if err := checkPublishAudit(schedule.SiteID); err != nil {
+			return 0, err
+		}
+		version, err = store.PublishSite(schedule.SiteID, schedule.CreatedBy, note)
+	case schedule.PageID == "" && schedule.Action == ScheduleActionUnpublish:
+		return 0, store.UnpublishSite(schedule.SiteID)
+	case schedule.Action == ScheduleActionPublish:
+		version, err = store.PublishPage(schedule.SiteID, schedule.PageID, schedule.CreatedBy, note)
+	default:
+		version, err = store.UnpublishPage(schedule.SiteID, schedule.PageID, schedule.CreatedBy, note)
+	}
+	if err != nil {
+		if errors.Is(err, builder.ErrNotFound) {
+			return 0, errors.New("站点或页面不存在")
+		}
+		return 0, err
+	}
+
+	// 生成静态快照，导出失败不影响发布结果
+	if ExportEnabled() {
+		if err := ExportSite(schedule.SiteID); err != nil {
+			log.Printf("站点%s静态导出失败: %v", schedule.SiteID, err)
+		}
+	}
+	return version.Version, nil
+}
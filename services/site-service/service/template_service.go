@@ -1,63 +1,308 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/internal/repository/builder"
 	"wz-backend-go/models"
+
+	"github.com/google/uuid"
 )
 
-// 模拟模板数据
-var templates = []models.SiteTemplate{
-	{
-		ID:          "t1",
-		Name:        "企业展示",
-		Thumbnail:   "/img/template1.jpg",
-		Description: "适合企业官网使用的模板",
-		Config:      `{"pages":[{"name":"首页","layout":"default"},{"name":"关于我们","layout":"default"},{"name":"产品服务","layout":"default"},{"name":"联系我们","layout":"default"}]}`,
-	},
-	{
-		ID:          "t2",
-		Name:        "产品展示",
-		Thumbnail:   "/img/template2.jpg",
-		Description: "重点突出产品的模板",
-		Config:      `{"pages":[{"name":"首页","layout":"full-width"},{"name":"产品目录","layout":"sidebar"},{"name":"产品详情","layout":"default"},{"name":"联系我们","layout":"default"}]}`,
-	},
-	{
-		ID:          "t3",
-		Name:        "简约风格",
-		Thumbnail:   "/img/template3.jpg",
-		Description: "简洁大方的设计风格",
-		Config:      `{"pages":[{"name":"首页","layout":"default"},{"name":"博客","layout":"sidebar"},{"name":"作品集","layout":"full-width"},{"name":"关于","layout":"default"}]}`,
-	},
-}
-
-// ListTemplates 获取模板列表
+// ListTemplates 获取系统模板列表
 func ListTemplates(category string) ([]models.SiteTemplate, error) {
-	if category == "" {
-		return templates, nil
+	return listTemplates("", category)
+}
+
+// ListTenantTemplates 获取租户可用的模板，包括系统模板和租户自己保存的模板
+func ListTenantTemplates(tenantID string, category string) ([]models.SiteTemplate, error) {
+	return listTemplates(tenantID, category)
+}
+
+func listTemplates(tenantID string, category string) ([]models.SiteTemplate, error) {
+	templates, err := store.Templates.List()
+	if err != nil {
+		return nil, err
 	}
 
-	var result []models.SiteTemplate
+	result := []models.SiteTemplate{}
 	categoryLower := strings.ToLower(category)
 
 	for _, template := range templates {
+		if !templateVisible(template, tenantID) {
+			continue
+		}
 		// 简单过滤，实际中可能需要在模板中添加分类字段
-		if strings.Contains(strings.ToLower(template.Name), categoryLower) ||
-			strings.Contains(strings.ToLower(template.Description), categoryLower) {
-			result = append(result, template)
+		if category != "" &&
+			!strings.Contains(strings.ToLower(template.Name), categoryLower) &&
+			!strings.Contains(strings.ToLower(template.Description), categoryLower) {
+			continue
 		}
+		result = append(result, template)
 	}
 
 	return result, nil
 }
 
-// GetTemplate 获取模板详情
+// templateVisible 系统模板对所有人可见，租户模板只对所属租户可见
+func templateVisible(template models.SiteTemplate, tenantID string) bool {
+	return template.TenantID == "" || template.TenantID == tenantID
+}
+
+// GetTemplate 获取系统模板详情
 func GetTemplate(templateID string) (models.SiteTemplate, error) {
-	for _, template := range templates {
-		if template.ID == templateID {
-			return template, nil
+	return getTemplate(templateID, "")
+}
+
+func getTemplate(templateID string, tenantID string) (models.SiteTemplate, error) {
+	template, err := store.Templates.Get(templateID)
+	if err != nil || !templateVisible(template, tenantID) {
+		return models.SiteTemplate{}, errors.New("模板不存在")
+	}
+
+	return template, nil
+}
+
+// ParseTemplateConfig 解析并校验模板配置
+func ParseTemplateConfig(config string) (models.SiteTemplateConfig, error) {
+	var parsed models.SiteTemplateConfig
+	if strings.TrimSpace(config) == "" {
+		return parsed, errors.New("模板配置为空")
+	}
+	if err := json.Unmarshal([]byte(config), &parsed); err != nil {
+		return parsed, fmt.Errorf("模板配置格式错误: %v", err)
+	}
+	if len(parsed.Pages) == 0 {
+		return parsed, errors.New("模板至少需要一个页面")
+	}
+
+	for i, page := range parsed.Pages {
+		if page.Name == "" {
+			return parsed, fmt.Errorf("模板第%d个页面缺少名称", i+1)
 		}
+		for j, section := range page.Sections {
+			for k, component := range section.Components {
+				if component.Type == "" {
+					return parsed, fmt.Errorf("模板页面%s第%d个区块的第%d个组件缺少类型", page.Name, j+1, k+1)
+				}
+			}
+		}
+	}
+
+	return parsed, nil
+}
+
+// CreateSiteFromTemplate 根据模板创建草稿站点，复制模板中的页面、区块和组件并应用租户的自定义内容
+func CreateSiteFromTemplate(templateID string, tenantID string, overrides models.TemplateOverrides) (models.Site, error) {
+	template, err := getTemplate(templateID, tenantID)
+	if err != nil {
+		return models.Site{}, err
+	}
+	config, err := ParseTemplateConfig(template.Config)
+	if err != nil {
+		return models.Site{}, err
+	}
+
+	now := time.Now()
+	site := models.Site{
+		Name:        template.Name,
+		Description: template.Description,
+		Thumbnail:   template.Thumbnail,
+		TenantID:    tenantID,
+		Status:      "draft",
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Footer:      config.Footer,
+		Navigation: models.Navigation{
+			Type:  "horizontal",
+			Items: []models.NavigationItem{},
+			Style: map[string]string{},
+		},
+	}
+	if config.Theme != nil {
+		site.Theme = *config.Theme
+	}
+	if config.Navigation != nil {
+		site.Navigation = *config.Navigation
+		site.Navigation.Items = renewNavigationIDs(site.Navigation.Items)
+	}
+	applyTemplateOverrides(&site, overrides)
+	if err := sitetheme.Validate("theme", site.Theme); err != nil {
+		return models.Site{}, err
+	}
+
+	site.Pages = prepareTemplatePages(config.Pages, now)
+	site.GlobalSections = config.GlobalSections
+	site.HeaderSectionID = config.HeaderSectionID
+	site.FooterSectionID = config.FooterSectionID
+	if err := store.CreateSiteTree(&site); err != nil {
+		return models.Site{}, err
+	}
+
+	return site, nil
+}
+
+// applyTemplateOverrides 用租户填写的非空字段覆盖模板默认值
+func applyTemplateOverrides(site *models.Site, overrides models.TemplateOverrides) {
+	if overrides.Name != "" {
+		site.Name = overrides.Name
+	}
+	if overrides.Description != "" {
+		site.Description = overrides.Description
+	}
+	if overrides.Logo != "" {
+		site.Logo = overrides.Logo
+	}
+	if overrides.Favicon != "" {
+		site.Favicon = overrides.Favicon
+	}
+	if overrides.PrimaryColor != "" {
+		site.Theme.PrimaryColor = overrides.PrimaryColor
+	}
+	if overrides.SecondaryColor != "" {
+		site.Theme.SecondaryColor = overrides.SecondaryColor
+	}
+	if overrides.FontFamily != "" {
+		site.Theme.FontFamily = overrides.FontFamily
+	}
+}
+
+// prepareTemplatePages 补全模板页面的Slug和首页标记，保证站点内Slug唯一且只有一个首页
+func prepareTemplatePages(pages []models.Page, now time.Time) []models.Page {
+	// 模板未标记首页时使用第一个页面，标记了多个时只保留第一个
+	homeIndex := 0
+	for i, page := range pages {
+		if page.IsHomepage {
+			homeIndex = i
+			break
+		}
+	}
+
+	usedSlugs := map[string]bool{}
+	for i := range pages {
+		page := &pages[i]
+		page.IsHomepage = i == homeIndex
+
+		slug := page.Slug
+		if slug == "" {
+			if page.IsHomepage {
+				slug = "home"
+			} else {
+				slug = fmt.Sprintf("page-%d", i+1)
+			}
+		}
+		base := slug
+		for n := 2; usedSlugs[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		usedSlugs[slug] = true
+		page.Slug = slug
+
+		if page.Title == "" {
+			page.Title = page.Name
+		}
+		if page.Layout == "" {
+			page.Layout = "default"
+		}
+		page.CreatedAt = now
+		page.UpdatedAt = now
+	}
+	return pages
+}
+
+// renewNavigationIDs 为导航项生成新的ID，避免不同站点共用同一组ID
+func renewNavigationIDs(items []models.NavigationItem) []models.NavigationItem {
+	renewed := make([]models.NavigationItem, len(items))
+	for i, item := range items {
+		item.ID = uuid.NewString()
+		item.Children = renewNavigationIDs(item.Children)
+		renewed[i] = item
+	}
+	return renewed
+}
+
+// SaveSiteAsTemplate 将站点当前的草稿保存为租户私有模板
+func SaveSiteAsTemplate(siteID string, tenantID string, name string, description string, thumbnail string) (models.SiteTemplate, error) {
+	tree, err := store.LoadSiteTree(siteID)
+	if err != nil || tree.TenantID != tenantID {
+		return models.SiteTemplate{}, errors.New("站点不存在")
+	}
+	tree, err = builder.CloneSite(tree)
+	if err != nil {
+		return models.SiteTemplate{}, err
+	}
+
+	// 去掉站点自身的ID和时间等信息，只保留可复用的结构。
+	// 页面保留ID，导航通过ID引用页面，创建站点时会重新生成
+	for i := range tree.Pages {
+		page := &tree.Pages[i]
+		page.SiteID = ""
+		page.SortOrder = 0
+		page.CreatedAt = time.Time{}
+		page.UpdatedAt = time.Time{}
+		for j := range page.Sections {
+			section := &page.Sections[j]
+			section.ID = ""
+			section.PageID = ""
+			section.SortOrder = 0
+			for k := range section.Components {
+				component := &section.Components[k]
+				component.ID = ""
+				component.SectionID = ""
+				component.SortOrder = 0
+			}
+		}
+	}
+
+	// 全局区块保留ID，页面和页头页脚通过ID引用，创建站点时会重新生成
+	for i := range tree.GlobalSections {
+		section := &tree.GlobalSections[i]
+		section.PageID = ""
+		section.SortOrder = 0
+		for k := range section.Components {
+			component := &section.Components[k]
+			component.ID = ""
+			component.SectionID = ""
+			component.SortOrder = 0
+		}
+	}
+
+	config, err := json.Marshal(models.SiteTemplateConfig{
+		Theme:           &tree.Theme,
+		Navigation:      &tree.Navigation,
+		Footer:          tree.Footer,
+		Pages:           tree.Pages,
+		GlobalSections:  tree.GlobalSections,
+		HeaderSectionID: tree.HeaderSectionID,
+		FooterSectionID: tree.FooterSectionID,
+	})
+	if err != nil {
+		return models.SiteTemplate{}, err
+	}
+
+	if name == "" {
+		name = tree.Name
+	}
+	if description == "" {
+		description = tree.Description
+	}
+	if thumbnail == "" {
+		thumbnail = tree.Thumbnail
+	}
+	template := models.SiteTemplate{
+		Name:        name,
+		Thumbnail:   thumbnail,
+		Description: description,
+		Config:      string(config),
+		TenantID:    tenantID,
+	}
+	if err := store.Templates.Create(&template); err != nil {
+		return models.SiteTemplate{}, err
 	}
 
-	return models.SiteTemplate{}, errors.New("模板不存在")
+	return template, nil
 }
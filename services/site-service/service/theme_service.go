@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"wz-backend-go/internal/domain"
+	"wz-backend-go/internal/pkg/sitetheme"
+	"wz-backend-go/models"
+)
+
+// ErrThemeNotFound 主题不存在、未启用或不属于当前租户
+var ErrThemeNotFound = errors.New("主题不存在")
+
+// SiteTheme 后台主题库中可以应用到站点的主题
+type SiteTheme struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Code        string             `json:"code"`
+	Preview     string             `json:"preview"`
+	Description string             `json:"description"`
+	IsDefault   bool               `json:"isDefault"`
+	Config      models.ThemeConfig `json:"config"`
+}
+
+// ListThemes 获取租户可用的主题：系统主题和租户自己的已启用主题，配置无效的主题不会列出
+func ListThemes(tenantID string) ([]SiteTheme, error) {
+	themes, err := store.Themes.ListEnabled()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []SiteTheme{}
+	for _, theme := range themes {
+		if !themeAvailable(theme, tenantID) {
+			continue
+		}
+		config, err := sitetheme.ParseConfig(theme.Config)
+		if err != nil {
+			log.Printf("主题%d的配置无效: %v", theme.ID, err)
+			continue
+		}
+		result = append(result, SiteTheme{
+			ID:          theme.ID,
+			Name:        theme.Name,
+			Code:        theme.Code,
+			Preview:     theme.Preview,
+			Description: theme.Description,
+			IsDefault:   theme.IsDefault == 1,
+			Config:      config,
+		})
+	}
+	return result, nil
+}
+
+// ApplyTheme 把后台主题的配置复制到站点，主题没有自定义CSS时保留站点原有的自定义CSS。
+// 之后修改后台主题不会影响站点，需要重新应用
+func ApplyTheme(siteID string, tenantID string, themeID int64) (models.Site, error) {
+	site, err := store.Sites.Get(siteID)
+	if err != nil {
+		return models.Site{}, errors.New("站点不存在")
+	}
+	theme, err := store.Themes.Get(themeID)
+	if err != nil || theme.Status != 1 || !themeAvailable(theme, tenantID) {
+		return models.Site{}, ErrThemeNotFound
+	}
+	config, err := sitetheme.ParseConfig(theme.Config)
+	if err != nil {
+		return models.Site{}, err
+	}
+
+	if config.CustomCSS == "" {
+		config.CustomCSS = site.Theme.CustomCSS
+	}
+	site.Theme = config
+	site.ThemeID = theme.ID
+	if err := store.Sites.Update(&site); err != nil {
+		return models.Site{}, err
+	}
+
+	store.NotifySiteChanged(site.ID)
+	return site, nil
+}
+
+// themeAvailable 租户ID为0的系统主题对所有租户可用，其他主题只对所属租户可用
+func themeAvailable(theme domain.Theme, tenantID string) bool {
+	return theme.TenantID == 0 || strconv.FormatInt(theme.TenantID, 10) == tenantID
+}